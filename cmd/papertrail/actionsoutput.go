@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// actionsOutput is one step output for GitHub Actions.
+type actionsOutput struct {
+	Name, Value string
+}
+
+// writeActionsOutputs appends outputs to the file at $GITHUB_OUTPUT, which GitHub Actions
+// (and Gitea and Forgejo Actions) turn into step outputs. It does nothing outside Actions.
+// Multi-line values use the heredoc syntax.
+func writeActionsOutputs(outputs ...actionsOutput) error {
+	p := strings.TrimSpace(os.Getenv("GITHUB_OUTPUT"))
+	if p == "" {
+		return nil
+	}
+	var b strings.Builder
+	for _, o := range outputs {
+		if strings.ContainsAny(o.Value, "\r\n") {
+			const delim = "PAPERTRAIL_OUTPUT_EOF"
+			fmt.Fprintf(&b, "%s<<%s\n%s\n%s\n", o.Name, delim, o.Value, delim)
+			continue
+		}
+		fmt.Fprintf(&b, "%s=%s\n", o.Name, o.Value)
+	}
+	f, err := os.OpenFile(p, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("writing GITHUB_OUTPUT: %w", err)
+	}
+	if _, err := f.WriteString(b.String()); err != nil {
+		f.Close()
+		return fmt.Errorf("writing GITHUB_OUTPUT: %w", err)
+	}
+	return f.Close()
+}
+
+// releaseOutputs are the step outputs of a release: its version, bump, fragment count, and
+// release notes path when the notes were written.
+func releaseOutputs(version string, kind bumpKind, fragments int, releaseNotesPath string) []actionsOutput {
+	outputs := []actionsOutput{
+		{"next_version", version},
+		{"bump_kind", kind.String()},
+		{"fragments_count", fmt.Sprint(fragments)},
+	}
+	if releaseNotesPath != "" {
+		outputs = append(outputs, actionsOutput{"release_notes_path", releaseNotesPath})
+	}
+	return outputs
+}
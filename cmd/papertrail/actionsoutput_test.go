@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestActionsOutputs(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{
+		".papertrail.config.yml":     "versioning:\n  rules:\n    feature: minor\n",
+		"CHANGELOG.md":               "# Changelog\n",
+		"changelog.d/20260101_a.yml": "component: CLI\ntype: feature\nsummary: Add a\n",
+		"changelog.d/20260102_b.yml": "component: CLI\ntype: fix\nsummary: Fix b\n",
+	})
+	output := filepath.Join(t.TempDir(), "output")
+	if err := os.WriteFile(output, []byte("earlier=1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GITHUB_OUTPUT", output)
+
+	if _, err := captureStdout(t, func() error {
+		return cmdBump(t.Context(), []string{"--base", "v1.2.3", "--skip-version-check"})
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmdMerge(t.Context(), []string{"--version", "v1.3.0", "--date", "2026-01-03", "--skip-version-check", "--release-notes-out", filepath.Join(dir, "notes.md")}); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "earlier=1\n" +
+		"next_version=v1.3.0\nbump_kind=minor\nfragments_count=2\n" +
+		"next_version=v1.3.0\nbump_kind=minor\nfragments_count=2\nrelease_notes_path=" + filepath.Join(dir, "notes.md") + "\n"
+	if string(b) != want {
+		t.Fatalf("GITHUB_OUTPUT:\n%s\nwant:\n%s", b, want)
+	}
+
+	if err := writeActionsOutputs(actionsOutput{"notes", "line 1\nline 2"}); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(output); string(b) != want+"notes<<PAPERTRAIL_OUTPUT_EOF\nline 1\nline 2\nPAPERTRAIL_OUTPUT_EOF\n" {
+		t.Fatalf("multi-line output:\n%s", b)
+	}
+}
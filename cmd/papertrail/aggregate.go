@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+// aggregateRepo identifies a repository to aggregate. When Version is set, the released
+// CHANGELOG section for that version is used; otherwise pending fragments are collected.
+type aggregateRepo struct {
+	Name    string
+	Version string
+}
+
+type aggregateOptions struct {
+	Ref          string
+	FragmentsDir string
+	Changelog    string
+}
+
+// repoList is a repeatable --repo flag.
+type repoList []aggregateRepo
+
+func (r *repoList) String() string {
+	var out []string
+	for _, x := range *r {
+		out = append(out, x.Name)
+	}
+	return strings.Join(out, ",")
+}
+
+func (r *repoList) Set(v string) error {
+	repo, err := parseAggregateRepo(v)
+	if err != nil {
+		return err
+	}
+	*r = append(*r, repo)
+	return nil
+}
+
+func parseAggregateRepo(s string) (aggregateRepo, error) {
+	s = strings.TrimSpace(s)
+	name, version, _ := strings.Cut(s, "@")
+	parts := strings.Split(name, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return aggregateRepo{}, fmt.Errorf("invalid --repo %q (expected owner/name or owner/name@vX.Y.Z)", s)
+	}
+	if version != "" {
+		if _, err := parseSemver(version); err != nil {
+			return aggregateRepo{}, fmt.Errorf("invalid --repo %q: version %q: %v", s, version, err)
+		}
+	}
+	return aggregateRepo{Name: name, Version: version}, nil
+}
+
+func cmdAggregate(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("aggregate", flag.ContinueOnError)
+	fs.SetOutput(ioDiscard{})
+
+	var repos repoList
+	fs.Var(&repos, "repo", "repository owner/name[@vX.Y.Z] (repeatable)")
+	version := fs.String("version", "", "platform version like v5.0.0 (required)")
+	date := fs.String("date", "", "release date YYYY-MM-DD (default: today UTC, or SOURCE_DATE_EPOCH)")
+	ref := fs.String("ref", "", "git ref to read pending fragments from (default: each repo's default branch)")
+	fragmentsDir := fs.String("fragments", "changelog.d", "fragments directory in each repo")
+	changelogPath := fs.String("changelog", "CHANGELOG.md", "changelog path in each repo")
+	out := fs.String("out", "", "write the combined document to this path (default: stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *version == "" {
+		return fmt.Errorf("--version is required (e.g. v5.0.0)")
+	}
+	if _, err := parseSemver(*version); err != nil {
+		return fmt.Errorf("invalid --version %q: %v (expected vMAJOR.MINOR.PATCH)", *version, err)
+	}
+	if len(repos) == 0 {
+		return fmt.Errorf("at least one --repo is required")
+	}
+
+	releaseDate := *date
+	if releaseDate == "" {
+		now, err := buildTime()
+		if err != nil {
+			return err
+		}
+		releaseDate = now.Format("2006-01-02")
+	} else if !looksLikeDate(releaseDate) {
+		return fmt.Errorf("invalid --date %q (expected YYYY-MM-DD)", releaseDate)
+	}
+
+	doc, err := renderAggregate(ctx, newGitHubClient(), *version, releaseDate, repos, aggregateOptions{
+		Ref:          *ref,
+		FragmentsDir: strings.Trim(*fragmentsDir, "/"),
+		Changelog:    *changelogPath,
+	})
+	if err != nil {
+		return err
+	}
+	if *out != "" {
+		return os.WriteFile(*out, doc, 0644)
+	}
+	_, _ = os.Stdout.Write(doc)
+	return nil
+}
+
+// renderAggregate builds a combined release document with one section per repository, in
+// the order the repositories were given.
+func renderAggregate(ctx context.Context, c *githubClient, version, date string, repos []aggregateRepo, opts aggregateOptions) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "## %s (%s)\n\n", version, date)
+
+	for _, r := range repos {
+		if r.Version != "" {
+			body, err := fetchReleasedSection(ctx, c, r, opts)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Fprintf(&buf, "### %s %s\n\n", r.Name, r.Version)
+			if body == "" {
+				buf.WriteString("_No changes recorded._\n\n")
+				continue
+			}
+			buf.WriteString(demoteHeadings(body))
+			buf.WriteString("\n")
+			continue
+		}
+
+		items, manifest, err := fetchPendingItems(ctx, c, r, opts)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&buf, "### %s\n\n", r.Name)
+		if len(items) == 0 {
+			buf.WriteString("_No pending changes._\n\n")
+			continue
+		}
+		writeComponentGroups(&buf, groupItems(items, manifest), "####", manifest.Changelog.Style)
+	}
+	return buf.Bytes(), nil
+}
+
+func fetchReleasedSection(ctx context.Context, c *githubClient, r aggregateRepo, opts aggregateOptions) (string, error) {
+	b, err := c.getFile(ctx, r.Name, opts.Changelog, r.Version)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", r.Name, err)
+	}
+	body, ok := papertrail.ExtractSection(string(b), r.Version)
+	if !ok {
+		return "", fmt.Errorf("%s: %s has no section for %s", r.Name, opts.Changelog, r.Version)
+	}
+	return body, nil
+}
+
+// fetchPendingItems reads and validates the unarchived fragments of a repository using that
+// repository's own manifest (if it has one).
+func fetchPendingItems(ctx context.Context, c *githubClient, r aggregateRepo, opts aggregateOptions) ([]item, releaseManifest, error) {
+	manifest, err := fetchManifest(ctx, c, r.Name, opts.Ref)
+	if err != nil {
+		return nil, releaseManifest{}, err
+	}
+
+	entries, err := c.listDir(ctx, r.Name, opts.FragmentsDir, opts.Ref)
+	if errors.Is(err, errGitHubNotFound) {
+		return nil, manifest, nil
+	}
+	if err != nil {
+		return nil, releaseManifest{}, fmt.Errorf("%s: %w", r.Name, err)
+	}
+
+	var items []item
+	for _, e := range entries {
+		if e.Type != "file" {
+			continue
+		}
+		if !manifest.IsFragmentFile(e.Name) {
+			continue
+		}
+		b, err := c.getFile(ctx, r.Name, e.Path, opts.Ref)
+		if err != nil {
+			return nil, releaseManifest{}, fmt.Errorf("%s: %w", r.Name, err)
+		}
+		fragments, err := papertrail.ParseFragmentFile(e.Name, b, manifest)
+		if err != nil {
+			return nil, releaseManifest{}, fmt.Errorf("%s: invalid fragment %s: %w", r.Name, e.Path, err)
+		}
+		for _, f := range fragments {
+			items = append(items, item{Path: e.Path, Frag: f})
+		}
+	}
+	return items, manifest, nil
+}
+
+func fetchManifest(ctx context.Context, c *githubClient, repo, ref string) (releaseManifest, error) {
+	for _, cand := range []string{".papertrail.config.yml", "papertrail.config.yml"} {
+		b, err := c.getFile(ctx, repo, cand, ref)
+		if errors.Is(err, errGitHubNotFound) {
+			continue
+		}
+		if err != nil {
+			return releaseManifest{}, fmt.Errorf("%s: %w", repo, err)
+		}
+		m, err := papertrail.ParseManifest(b)
+		if err != nil {
+			return releaseManifest{}, fmt.Errorf("%s: %s: %w", repo, cand, err)
+		}
+		return m, nil
+	}
+	return releaseManifest{}, nil
+}
+
+// demoteHeadings nests a released section one heading level deeper so it fits under a
+// per-repository heading.
+func demoteHeadings(body string) string {
+	lines := strings.SplitAfter(body, "\n")
+	for i, l := range lines {
+		if strings.HasPrefix(l, "#") {
+			lines[i] = "#" + l
+		}
+	}
+	return strings.Join(lines, "")
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRenderAggregate(t *testing.T) {
+	t.Parallel()
+
+	files := map[string]string{
+		"/repos/org/a/contents/.papertrail.config.yml": "changelog:\n  components: [Server, CLI]\n",
+		"/repos/org/a/contents/changelog.d/1.yml":      "component: CLI\ntype: feature\nsummary: Add a flag\n",
+		"/repos/org/a/contents/changelog.d/2.yml":      "component: Server\ntype: fix\nsummary: Fix a crash\n",
+		"/repos/org/b/contents/CHANGELOG.md":           "# Changelog\n\n## v1.2.3 (2025-01-02)\n\n### API\n\n- **fix**: Fix b.\n\n## v1.2.2 (2025-01-01)\n",
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/repos/org/a/contents/changelog.d" {
+			_, _ = w.Write([]byte(`[
+				{"name":"2.yml","path":"changelog.d/2.yml","type":"file"},
+				{"name":"1.yml","path":"changelog.d/1.yml","type":"file"},
+				{"name":"archived","path":"changelog.d/archived","type":"dir"}
+			]`))
+			return
+		}
+		if r.URL.Path == "/repos/org/b/contents/CHANGELOG.md" && r.URL.Query().Get("ref") != "v1.2.3" {
+			http.NotFound(w, r)
+			return
+		}
+		body, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	c := &githubClient{baseURL: srv.URL, http: srv.Client()}
+	repos := []aggregateRepo{{Name: "org/a"}, {Name: "org/b", Version: "v1.2.3"}}
+	got, err := renderAggregate(t.Context(), c, "v5.0.0", "2025-02-01", repos, aggregateOptions{FragmentsDir: "changelog.d", Changelog: "CHANGELOG.md"})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	want := strings.Join([]string{
+		"## v5.0.0 (2025-02-01)",
+		"",
+		"### org/a",
+		"",
+		"#### Server",
+		"",
+		"- **fix**: Fix a crash.",
+		"",
+		"#### CLI",
+		"",
+		"- **feature**: Add a flag.",
+		"",
+		"### org/b v1.2.3",
+		"",
+		"#### API",
+		"",
+		"- **fix**: Fix b.",
+		"",
+		"",
+	}, "\n")
+	if string(got) != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestParseAggregateRepo(t *testing.T) {
+	t.Parallel()
+
+	r, err := parseAggregateRepo("org/a@v1.0.0")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if r.Name != "org/a" || r.Version != "v1.0.0" {
+		t.Fatalf("got %+v", r)
+	}
+	for _, bad := range []string{"org", "org/", "org/a/b", "org/a@1.0"} {
+		if _, err := parseAggregateRepo(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+// actionsAnnotationsDefault reports whether check prints annotations without --annotations:
+// under GitHub Actions, which sets GITHUB_ACTIONS.
+func actionsAnnotationsDefault() bool {
+	return os.Getenv("GITHUB_ACTIONS") == "true"
+}
+
+// writeAnnotations prints a GitHub Actions error or warning command per issue, so check
+// failures show inline on the files of a pull request. Issues are placed on the line the YAML
+// parser failed at or the line of the issue's field.
+func writeAnnotations(w io.Writer, results []checkResult) {
+	var lines []string
+	for _, res := range results {
+		for _, is := range res.Issues {
+			level := "error"
+			if is.Severity == papertrail.SeverityWarning {
+				level = "warning"
+			}
+			props := []string{}
+			if res.Path != "" {
+				line, col := res.issueLine(is)
+				props = append(props,
+					"file="+escapeAnnotationProperty(res.Path),
+					fmt.Sprintf("line=%d", line),
+					fmt.Sprintf("col=%d", col))
+			}
+			props = append(props, "title="+escapeAnnotationProperty("papertrail "+is.Rule))
+			lines = append(lines, fmt.Sprintf("::%s %s::%s", level, strings.Join(props, ","), escapeAnnotationData(is.Message)))
+		}
+	}
+	sort.Strings(lines)
+	for _, l := range lines {
+		fmt.Fprintln(w, l)
+	}
+}
+
+// issueLine returns the one-based line and column of an issue in the result's file: where
+// the YAML parser failed or the value of the issue's field. Markdown fragments and changesets
+// keep their YAML in front matter, after the opening "---".
+func (res checkResult) issueLine(is papertrail.Issue) (line, col int) {
+	name := res.Path
+	if name == "" {
+		name = res.Name
+	}
+	src, offset := res.Source, 0
+	if papertrail.FragmentFormat(name) == papertrail.FormatMarkdown {
+		front, _, err := papertrail.SplitFrontMatter(res.Source)
+		if err != nil {
+			return 1, 1
+		}
+		src, offset = front, 1
+	}
+	line, col = issuePosition(is, fragmentValueNodes(string(src)))
+	return line + offset + 1, col + 1
+}
+
+func escapeAnnotationData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+func escapeAnnotationProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCmdCheck_Annotations(t *testing.T) {
+	initGitRepo(t, map[string]string{
+		".papertrail.config.yml":   "types:\n  order: [fix]\nfragments:\n  formats: [yaml, markdown]\n",
+		"changelog.d/ok.yml":       "component: CLI\ntype: fix\nsummary: Fine.\n",
+		"changelog.d/bad_type.yml": "component: CLI\ntype: chore\nsummary: Tidy up.\n",
+		"changelog.d/bad_yaml.yml": "component: CLI\ntype: fix\nsummary: [unclosed\n",
+		"changelog.d/bad_front.md": "---\ncomponent: CLI\ntype: chore\nsummary: Tidy.\n---\n\nDetails.\n",
+	})
+	t.Setenv("GITHUB_ACTIONS", "true")
+
+	out, err := captureStdout(t, func() error { return cmdCheck(t.Context(), nil) })
+	if err == nil {
+		t.Fatalf("expected check to fail")
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	want := []string{
+		"::error file=changelog.d/bad_front.md,line=3,col=7,title=papertrail unknown_type::",
+		"::error file=changelog.d/bad_type.yml,line=2,col=7,title=papertrail unknown_type::",
+		"::error file=changelog.d/bad_yaml.yml,line=2,",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("annotations:\n%s", out)
+	}
+	for i, w := range want {
+		if !strings.HasPrefix(lines[i], w) {
+			t.Fatalf("annotation %d = %q, want prefix %q", i, lines[i], w)
+		}
+	}
+
+	out, _ = captureStdout(t, func() error { return cmdCheck(t.Context(), []string{"--annotations=false"}) })
+	if out != "" {
+		t.Fatalf("--annotations=false printed:\n%s", out)
+	}
+	if err := cmdCheck(t.Context(), []string{"--annotations", "--format", "json"}); err == nil || !strings.Contains(err.Error(), "--annotations") {
+		t.Fatalf("expected --annotations with --format json to fail, got %v", err)
+	}
+}
+
+func TestEscapeAnnotation(t *testing.T) {
+	t.Parallel()
+
+	if got := escapeAnnotationData("50% done\nnext: line"); got != "50%25 done%0Anext: line" {
+		t.Fatalf("escapeAnnotationData = %q", got)
+	}
+	if got := escapeAnnotationProperty("a:b,c"); got != "a%3Ab%2Cc" {
+		t.Fatalf("escapeAnnotationProperty = %q", got)
+	}
+}
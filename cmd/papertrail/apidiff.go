@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// goAPI maps each exported Go identifier to a description of its declaration. Keys are
+// "<package dir>:<Name>" for top-level identifiers and "<package dir>:<Type>.<Member>" for
+// exported fields, methods, and interface methods.
+type goAPI map[string]string
+
+// interfaceMethodPrefix marks interface methods, which unlike other members break
+// implementations when added.
+const interfaceMethodPrefix = "interface method "
+
+// loadGoAPI collects the exported API of every importable package in fsys: package main,
+// tests, and internal, testdata, vendor, and hidden directories are skipped.
+func loadGoAPI(fsys fs.FS) (goAPI, error) {
+	api := goAPI{}
+	fset := token.NewFileSet()
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if p != "." && (name == "internal" || name == "testdata" || name == "vendor" ||
+				strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			return nil
+		}
+		src, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		f, err := parser.ParseFile(fset, p, src, parser.SkipObjectResolution)
+		if err != nil {
+			return fmt.Errorf("parsing %s: %w", p, err)
+		}
+		if f.Name.Name != "main" {
+			addFileAPI(api, path.Dir(p), f)
+		}
+		return nil
+	})
+	return api, err
+}
+
+func addFileAPI(api goAPI, dir string, f *ast.File) {
+	key := func(ident string) string { return dir + ":" + ident }
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if !d.Name.IsExported() {
+				continue
+			}
+			if d.Recv == nil {
+				api[key(d.Name.Name)] = "func" + funcSignature(d.Type)
+				continue
+			}
+			recv := d.Recv.List[0].Type
+			ptr := ""
+			if star, ok := recv.(*ast.StarExpr); ok {
+				recv, ptr = star.X, "*"
+			}
+			switch r := recv.(type) {
+			case *ast.IndexExpr:
+				recv = r.X
+			case *ast.IndexListExpr:
+				recv = r.X
+			}
+			if id, ok := recv.(*ast.Ident); ok && id.IsExported() {
+				api[key(id.Name+"."+d.Name.Name)] = "method (" + ptr + id.Name + ") func" + funcSignature(d.Type)
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					if s.Name.IsExported() {
+						addTypeAPI(api, key, s)
+					}
+				case *ast.ValueSpec:
+					for _, n := range s.Names {
+						if !n.IsExported() {
+							continue
+						}
+						desc := strings.ToLower(d.Tok.String())
+						if s.Type != nil {
+							desc += " " + types.ExprString(s.Type)
+						}
+						api[key(n.Name)] = desc
+					}
+				}
+			}
+		}
+	}
+}
+
+func addTypeAPI(api goAPI, key func(string) string, s *ast.TypeSpec) {
+	name := s.Name.Name
+	tparams := ""
+	if s.TypeParams != nil {
+		tparams = "[" + fieldTypes(s.TypeParams) + "]"
+	}
+	switch t := s.Type.(type) {
+	case *ast.StructType:
+		api[key(name)] = "struct" + tparams
+		for _, f := range t.Fields.List {
+			typ := types.ExprString(f.Type)
+			if len(f.Names) == 0 {
+				// Embedded field: its name is the type name.
+				emb := strings.TrimPrefix(typ, "*")
+				if i := strings.LastIndex(emb, "."); i >= 0 {
+					emb = emb[i+1:]
+				}
+				if i := strings.Index(emb, "["); i >= 0 {
+					emb = emb[:i]
+				}
+				if ast.IsExported(emb) {
+					api[key(name+"."+emb)] = "embedded " + typ
+				}
+				continue
+			}
+			for _, n := range f.Names {
+				if n.IsExported() {
+					api[key(name+"."+n.Name)] = "field " + typ
+				}
+			}
+		}
+	case *ast.InterfaceType:
+		api[key(name)] = "interface" + tparams
+		for _, m := range t.Methods.List {
+			if len(m.Names) == 0 {
+				api[key(name+"."+types.ExprString(m.Type))] = interfaceMethodPrefix + "(embedded)"
+				continue
+			}
+			ft, _ := m.Type.(*ast.FuncType)
+			for _, n := range m.Names {
+				if ft != nil {
+					api[key(name+"."+n.Name)] = interfaceMethodPrefix + "func" + funcSignature(ft)
+				}
+			}
+		}
+	default:
+		desc := "type" + tparams + " "
+		if s.Assign.IsValid() {
+			desc += "= "
+		}
+		api[key(name)] = desc + types.ExprString(s.Type)
+	}
+}
+
+// funcSignature renders parameter and result types without names, since renaming a
+// parameter is not an API change.
+func funcSignature(ft *ast.FuncType) string {
+	sig := ""
+	if ft.TypeParams != nil {
+		sig += "[" + fieldTypes(ft.TypeParams) + "]"
+	}
+	sig += "(" + fieldTypes(ft.Params) + ")"
+	if ft.Results != nil && len(ft.Results.List) > 0 {
+		sig += " (" + fieldTypes(ft.Results) + ")"
+	}
+	return sig
+}
+
+func fieldTypes(fl *ast.FieldList) string {
+	var parts []string
+	for _, f := range fl.List {
+		n := len(f.Names)
+		if n == 0 {
+			n = 1
+		}
+		for i := 0; i < n; i++ {
+			parts = append(parts, types.ExprString(f.Type))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// incompatibleAPIChanges lists changes from old to new that can break importers: removed or
+// changed identifiers, and methods added to existing interfaces. Members of a removed type
+// are not listed separately.
+func incompatibleAPIChanges(old, new goAPI) []string {
+	var changes []string
+	for k, was := range old {
+		now, ok := new[k]
+		switch {
+		case !ok:
+			if parent, _, isMember := cutMember(k); isMember {
+				if _, parentKept := new[parent]; !parentKept {
+					continue
+				}
+			}
+			changes = append(changes, "removed "+displayAPIKey(k))
+		case now != was:
+			changes = append(changes, fmt.Sprintf("changed %s: %s → %s", displayAPIKey(k), was, now))
+		}
+	}
+	for k, now := range new {
+		if _, existed := old[k]; existed || !strings.HasPrefix(now, interfaceMethodPrefix) {
+			continue
+		}
+		if parent, _, _ := cutMember(k); old[parent] != "" {
+			changes = append(changes, "added "+displayAPIKey(k)+" to an existing interface")
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
+
+// cutMember splits "<dir>:T.M" into "<dir>:T" and "M".
+func cutMember(k string) (parent, member string, ok bool) {
+	colon := strings.LastIndex(k, ":")
+	i := strings.Index(k[colon+1:], ".")
+	if i < 0 {
+		return k, "", false
+	}
+	i += colon + 1
+	return k[:i], k[i+1:], true
+}
+
+func displayAPIKey(k string) string {
+	dir, ident, _ := strings.Cut(k, ":")
+	if dir == "." {
+		return ident
+	}
+	return dir + "." + ident
+}
+
+// apiBreaksSince compares the exported Go API at the merge base of baseRef and HEAD with
+// HEAD, both read from git objects.
+func apiBreaksSince(ctx context.Context, baseRef string) ([]string, error) {
+	base, err := runGit(ctx, "merge-base", baseRef, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	old, err := loadGoAPI(gitFS{ctx: ctx, ref: base})
+	if err != nil {
+		return nil, fmt.Errorf("loading Go API at %s: %w", baseRef, err)
+	}
+	cur, err := loadGoAPI(gitFS{ctx: ctx, ref: "HEAD"})
+	if err != nil {
+		return nil, fmt.Errorf("loading Go API at HEAD: %w", err)
+	}
+	return incompatibleAPIChanges(old, cur), nil
+}
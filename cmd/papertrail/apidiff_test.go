@@ -0,0 +1,80 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIncompatibleAPIChanges(t *testing.T) {
+	t.Parallel()
+
+	old := newMemFS(map[string]string{
+		"go.mod": "module example.com/lib\n",
+		"lib.go": `package lib
+
+import "io"
+
+type Client struct {
+	Name    string
+	Timeout int
+	io.Reader
+	secret  string
+}
+
+type Store interface {
+	Get(key string) ([]byte, error)
+}
+
+type Gone struct{ A int }
+
+const Version = "1"
+
+func New(name string, opts ...int) *Client { return nil }
+func (c *Client) Do(b []byte) error        { return nil }
+`,
+		"internal/x/x.go":  "package x\n\nfunc Hidden() {}\n",
+		"cmd/tool/main.go": "package main\n\nfunc Exported() {}\n",
+	})
+	cur := newMemFS(map[string]string{
+		"go.mod": "module example.com/lib\n",
+		"lib.go": `package lib
+
+import "io"
+
+type Client struct {
+	Name    string
+	Timeout int64
+	io.Reader
+	Added   bool
+}
+
+type Store interface {
+	Get(k string) ([]byte, error)
+	Put(key string, v []byte) error
+}
+
+const Version = "2"
+
+func New(n string, o ...int) *Client { return nil }
+`,
+	})
+
+	oldAPI, err := loadGoAPI(old)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	curAPI, err := loadGoAPI(cur)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	got := incompatibleAPIChanges(oldAPI, curAPI)
+	want := []string{
+		"added Store.Put to an existing interface",
+		"changed Client.Timeout: field int → field int64",
+		"removed Client.Do",
+		"removed Gone",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
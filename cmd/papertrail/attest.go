@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+)
+
+// attestationPayloadType identifies the statement format inside an attestation envelope.
+const attestationPayloadType = "application/vnd.papertrail.release+json; version=1"
+
+// releaseStatement is what merge attests to: the release notes and the exact fragment set.
+type releaseStatement struct {
+	Version            string             `json:"version"`
+	ReleaseNotesSHA256 string             `json:"release_notes_sha256"`
+	Fragments          []attestedFragment `json:"fragments"`
+}
+
+// attestedFragment records a fragment by its archived path (or source name for fragments
+// from manifest sources, which merge does not archive).
+type attestedFragment struct {
+	Path     string `json:"path"`
+	SHA256   string `json:"sha256"`
+	External bool   `json:"external,omitempty"`
+}
+
+// attestationEnvelope carries a signed statement. The signature covers
+// PayloadType + "\n" + Payload, so the payload type cannot be swapped.
+type attestationEnvelope struct {
+	PayloadType string `json:"payload_type"`
+	Payload     []byte `json:"payload"`
+	KeyID       string `json:"key_id"`
+	Signature   []byte `json:"signature"`
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// newReleaseStatement hashes the release notes and fragments. Local fragment paths are
+// recorded where merge archives them, so the statement can be verified after the release.
+func newReleaseStatement(version string, releaseNotes []byte, archiveDir string, files []fragmentFile) (releaseStatement, error) {
+	st := releaseStatement{Version: version, ReleaseNotesSHA256: sha256Hex(releaseNotes), Fragments: []attestedFragment{}}
+	for _, ff := range files {
+		b, err := ff.read()
+		if err != nil {
+			return releaseStatement{}, err
+		}
+		af := attestedFragment{Path: path.Join(archiveDir, version, path.Base(ff.Path)), SHA256: sha256Hex(b)}
+		if ff.External {
+			af = attestedFragment{Path: ff.Name, SHA256: sha256Hex(b), External: true}
+		}
+		st.Fragments = append(st.Fragments, af)
+	}
+	return st, nil
+}
+
+// keyID is a short fingerprint of a public key, so verifiers can tell which key signed.
+func keyID(pub ed25519.PublicKey) string {
+	return sha256Hex(pub)[:16]
+}
+
+func attestationMessage(payloadType string, payload []byte) []byte {
+	return append([]byte(payloadType+"\n"), payload...)
+}
+
+func signReleaseStatement(st releaseStatement, key ed25519.PrivateKey) ([]byte, error) {
+	payload, err := json.Marshal(st)
+	if err != nil {
+		return nil, err
+	}
+	env := attestationEnvelope{
+		PayloadType: attestationPayloadType,
+		Payload:     payload,
+		KeyID:       keyID(key.Public().(ed25519.PublicKey)),
+		Signature:   ed25519.Sign(key, attestationMessage(attestationPayloadType, payload)),
+	}
+	b, err := json.MarshalIndent(env, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// verifyReleaseStatement checks the envelope signature and returns the signed statement.
+func verifyReleaseStatement(envelope []byte, pub ed25519.PublicKey) (releaseStatement, error) {
+	var env attestationEnvelope
+	if err := json.Unmarshal(envelope, &env); err != nil {
+		return releaseStatement{}, fmt.Errorf("invalid attestation: %w", err)
+	}
+	if env.PayloadType != attestationPayloadType {
+		return releaseStatement{}, fmt.Errorf("unsupported attestation payload type %q", env.PayloadType)
+	}
+	if !ed25519.Verify(pub, attestationMessage(env.PayloadType, env.Payload), env.Signature) {
+		return releaseStatement{}, fmt.Errorf("attestation signature does not verify with key %s (signed by %s)", keyID(pub), env.KeyID)
+	}
+	var st releaseStatement
+	if err := json.Unmarshal(env.Payload, &st); err != nil {
+		return releaseStatement{}, fmt.Errorf("invalid attestation payload: %w", err)
+	}
+	return st, nil
+}
+
+// loadSigningKey reads an Ed25519 private key in PKCS#8 PEM form, as written by
+// `openssl genpkey -algorithm ed25519`.
+func loadSigningKey(fsys fs.FS, name string) (ed25519.PrivateKey, error) {
+	der, err := readPEM(fsys, name, "PRIVATE KEY")
+	if err != nil {
+		return nil, err
+	}
+	k, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signing key %s: %w", name, err)
+	}
+	priv, ok := k.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("invalid signing key %s: not an Ed25519 key", name)
+	}
+	return priv, nil
+}
+
+// loadVerifyKey reads an Ed25519 public key in PKIX PEM form, as written by
+// `openssl pkey -pubout`.
+func loadVerifyKey(fsys fs.FS, name string) (ed25519.PublicKey, error) {
+	der, err := readPEM(fsys, name, "PUBLIC KEY")
+	if err != nil {
+		return nil, err
+	}
+	k, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key %s: %w", name, err)
+	}
+	pub, ok := k.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("invalid public key %s: not an Ed25519 key", name)
+	}
+	return pub, nil
+}
+
+func readPEM(fsys fs.FS, name, blockType string) ([]byte, error) {
+	b, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(b)
+	if block == nil || block.Type != blockType {
+		return nil, fmt.Errorf("%s: expected a PEM %q block", name, blockType)
+	}
+	return block.Bytes, nil
+}
+
+func cmdVerifyAttestation(_ context.Context, args []string) error {
+	fs := flag.NewFlagSet("verify-attestation", flag.ContinueOnError)
+	fs.SetOutput(ioDiscard{})
+	keyPath := fs.String("key", "", "Ed25519 public key (PEM) (required)")
+	attestationPath := fs.String("attestation", "", "attestation written by merge --attestation-out (required)")
+	releaseNotes := fs.String("release-notes", "", "also check these release notes against the attested digest")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *keyPath == "" || *attestationPath == "" {
+		return fmt.Errorf("--key and --attestation are required")
+	}
+
+	fsys := hostFS{}
+	pub, err := loadVerifyKey(fsys, *keyPath)
+	if err != nil {
+		return err
+	}
+	env, err := readFile(fsys, *attestationPath)
+	if err != nil {
+		return err
+	}
+	st, err := verifyReleaseStatement(env, pub)
+	if err != nil {
+		return err
+	}
+	problems, checked := checkReleaseStatement(fsys, st, *releaseNotes)
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "\n"))
+	}
+	_, _ = fmt.Fprintf(os.Stdout, "verified %s: signature by %s, %d fragment(s) checked\n", st.Version, keyID(pub), checked)
+	return nil
+}
+
+// checkReleaseStatement compares the attested digests with files on fsys. Fragments from
+// manifest sources are not stored in the repository and are not checked.
+func checkReleaseStatement(fsys fs.FS, st releaseStatement, releaseNotesPath string) (problems []string, checked int) {
+	if releaseNotesPath != "" {
+		b, err := fs.ReadFile(fsys, releaseNotesPath)
+		switch {
+		case err != nil:
+			problems = append(problems, err.Error())
+		case sha256Hex(b) != st.ReleaseNotesSHA256:
+			problems = append(problems, fmt.Sprintf("%s: digest does not match the attested release notes", releaseNotesPath))
+		}
+	}
+	for _, f := range st.Fragments {
+		if f.External {
+			continue
+		}
+		b, err := fs.ReadFile(fsys, f.Path)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		if sha256Hex(b) != f.SHA256 {
+			problems = append(problems, fmt.Sprintf("%s: digest does not match the attestation", f.Path))
+			continue
+		}
+		checked++
+	}
+	return problems, checked
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"strings"
+	"testing"
+)
+
+func TestReleaseAttestation(t *testing.T) {
+	t.Parallel()
+
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privDER, _ := x509.MarshalPKCS8PrivateKey(priv)
+	pubDER, _ := x509.MarshalPKIXPublicKey(pub)
+	fsys := newMemFS(map[string]string{
+		"key.pem":           string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER})),
+		"pub.pem":           string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})),
+		"CHANGELOG.md":      "# Changelog\n",
+		"changelog.d/a.yml": "component: CLI\ntype: fix\nsummary: Fix a\n",
+	})
+
+	files, cleanup, err := discoverFragments(t.Context(), fsys, "changelog.d", releaseManifest{}, false)
+	defer cleanup()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	notes := []byte("## v0.2.0\n\n- **fix**: Fix a.\n")
+	st, err := newReleaseStatement("v0.2.0", notes, "changelog.d/archived", files)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	key, err := loadSigningKey(fsys, "key.pem")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	env, err := signReleaseStatement(st, key)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Archive the fragment the way merge does, then verify against the archived copy.
+	err = writeRelease(fsys, releaseOutput{
+		Version: "v0.2.0", ChangelogPath: "CHANGELOG.md", ArchiveDir: "changelog.d/archived",
+		ReleaseNotesOut: "notes.md", ReleaseNotes: notes, AttestationOut: "attestation.json", Attestation: env,
+		Items: []item{{Path: "changelog.d/a.yml"}},
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	verifyKey, err := loadVerifyKey(fsys, "pub.pem")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	written, _ := readFile(fsys, "attestation.json")
+	got, err := verifyReleaseStatement(written, verifyKey)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if problems, checked := checkReleaseStatement(fsys, got, "notes.md"); len(problems) > 0 || checked != 1 {
+		t.Fatalf("problems=%v checked=%d", problems, checked)
+	}
+
+	_ = fsys.WriteFile("changelog.d/archived/v0.2.0/a.yml", []byte("component: CLI\ntype: fix\nsummary: Fix b\n"), 0644)
+	if problems, _ := checkReleaseStatement(fsys, got, ""); len(problems) != 1 || !strings.Contains(problems[0], "a.yml: digest does not match") {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+
+	var tampered attestationEnvelope
+	_ = json.Unmarshal(env, &tampered)
+	tampered.Payload = []byte(strings.Replace(string(tampered.Payload), "v0.2.0", "v0.3.0", 1))
+	b, _ := json.Marshal(tampered)
+	if _, err := verifyReleaseStatement(b, verifyKey); err == nil || !strings.Contains(err.Error(), "does not verify") {
+		t.Fatalf("expected signature failure, got %v", err)
+	}
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// gitCommit is a commit with the files it changed (against its first parent for merges).
+type gitCommit struct {
+	Hash    string
+	Subject string
+	Body    string
+	Files   []string
+}
+
+// Short is the commit's abbreviated hash.
+func (c gitCommit) Short() string {
+	if len(c.Hash) > 7 {
+		return c.Hash[:7]
+	}
+	return c.Hash
+}
+
+// firstParentCommits lists the commits in revRange (e.g. v1.2.0..HEAD) along the first
+// parent, oldest first, so each merged pull request is one merge commit rather than the
+// commits it merged.
+func firstParentCommits(ctx context.Context, revRange string) ([]gitCommit, error) {
+	return logCommits(ctx, revRange, "--first-parent", "--diff-merges=first-parent")
+}
+
+// logCommits lists the commits in revRange, oldest first, selected by the git log options.
+func logCommits(ctx context.Context, revRange string, options ...string) ([]gitCommit, error) {
+	args := append([]string{"log"}, options...)
+	args = append(args, "--reverse", "--name-only", "--format=%x1e%H%x1f%s%x1f%b%x1f", revRange)
+	out, err := runCmdRaw(ctx, "git", args...)
+	if err != nil {
+		return nil, fmt.Errorf("git log %s: %w", revRange, err)
+	}
+	var commits []gitCommit
+	for _, rec := range strings.Split(string(out), "\x1e") {
+		fields := strings.SplitN(rec, "\x1f", 4)
+		if len(fields) < 4 {
+			continue
+		}
+		c := gitCommit{Hash: fields[0], Subject: fields[1], Body: strings.TrimSpace(fields[2])}
+		for _, f := range strings.Split(fields[3], "\n") {
+			if f = strings.TrimSpace(f); f != "" {
+				c.Files = append(c.Files, f)
+			}
+		}
+		commits = append(commits, c)
+	}
+	return commits, nil
+}
+
+// conventionalCommit is a parsed "type(scope)!: description" subject.
+type conventionalCommit struct {
+	Type        string
+	Scope       string
+	Breaking    bool
+	Description string
+}
+
+var (
+	conventionalSubjectRE = regexp.MustCompile(`^([A-Za-z][A-Za-z-]*)(?:\(([^)]*)\))?(!)?: *(.+)$`)
+	mergePullRequestRE    = regexp.MustCompile(`^Merge pull request #([0-9]+) from \S+`)
+	squashPullRequestRE   = regexp.MustCompile(`\s*\(#([0-9]+)\)$`)
+)
+
+// parseConventionalCommit parses a conventional commit subject; body is searched for a
+// BREAKING CHANGE footer.
+func parseConventionalCommit(subject, body string) (conventionalCommit, bool) {
+	m := conventionalSubjectRE.FindStringSubmatch(strings.TrimSpace(subject))
+	if m == nil {
+		return conventionalCommit{}, false
+	}
+	cc := conventionalCommit{Type: strings.ToLower(m[1]), Scope: strings.TrimSpace(m[2]), Breaking: m[3] == "!", Description: strings.TrimSpace(m[4])}
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(line, "BREAKING CHANGE:") || strings.HasPrefix(line, "BREAKING-CHANGE:") {
+			cc.Breaking = true
+		}
+	}
+	return cc, true
+}
+
+// commitChange returns the change a commit describes and its pull request number, if any:
+// a GitHub merge commit's pull request title (the first line of its body), or the subject
+// without the "(#123)" squash merges append.
+func commitChange(c gitCommit) (title, pr string) {
+	if m := mergePullRequestRE.FindStringSubmatch(c.Subject); m != nil {
+		title, _, _ = strings.Cut(c.Body, "\n")
+		return strings.TrimSpace(title), m[1]
+	}
+	if m := squashPullRequestRE.FindStringSubmatchIndex(c.Subject); m != nil {
+		return c.Subject[:m[0]], c.Subject[m[2]:m[3]]
+	}
+	return c.Subject, ""
+}
+
+// defaultCommitTypes maps conventional commit types to fragment types when neither the
+// flags, import.types, nor commit_message.types do; types mapped to "" are skipped.
+var defaultCommitTypes = map[string]string{
+	"feat":     "feature",
+	"fix":      "fix",
+	"perf":     "fix",
+	"revert":   "fix",
+	"docs":     "docs",
+	"refactor": "refactor",
+	"breaking": "breaking",
+	"build":    "",
+	"chore":    "",
+	"ci":       "",
+	"style":    "",
+	"test":     "",
+}
+
+// commitTypes builds the conventional commit type → fragment type table: the defaults, then
+// commit_message.types read in reverse, then import.types and --type from=to flags (see
+// importTypes).
+func commitTypes(manifest releaseManifest, flags []string) (map[string]string, error) {
+	types := map[string]string{}
+	for k, v := range defaultCommitTypes {
+		types[k] = v
+	}
+	for fragType, commitType := range manifest.CommitMessage.Types {
+		types[strings.ToLower(strings.TrimSpace(commitType))] = strings.TrimSpace(fragType)
+	}
+	overrides, err := importTypes(manifest, flags)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range overrides {
+		types[k] = v
+	}
+	return types, nil
+}
+
+// leadingVerbTypes guess the type of a change that is not a conventional commit from the
+// first word of its title.
+var leadingVerbTypes = map[string]string{
+	"fix": "fix", "fixes": "fix", "fixed": "fix", "resolve": "fix", "resolves": "fix",
+	"add": "feat", "adds": "feat", "added": "feat", "introduce": "feat", "support": "feat",
+}
+
+// commitFragment turns a commit into a draft fragment: the type from its conventional commit
+// type (or, failing that, its leading verb) mapped through types, the component from a scope
+// naming a configured component, else from the changed files (see ComponentForPaths), else
+// component, the summary from the description, and the pull request as a ref. reason
+// explains why a commit yields no fragment.
+func commitFragment(c gitCommit, types map[string]string, component string, manifest releaseManifest) (f fragment, reason string) {
+	title, pr := commitChange(c)
+	if title == "" {
+		return fragment{}, "no title"
+	}
+	cc, ok := parseConventionalCommit(title, c.Body)
+	if !ok {
+		word, _, _ := strings.Cut(title, " ")
+		t, known := leadingVerbTypes[strings.ToLower(word)]
+		if !known {
+			return fragment{}, "not a conventional commit"
+		}
+		cc = conventionalCommit{Type: t, Description: title}
+	}
+	t, mapped := types[cc.Type]
+	if !mapped {
+		t = cc.Type
+	}
+	if cc.Breaking {
+		if bt := types["breaking"]; bt != "" && typeAllowed(manifest, bt) {
+			t = bt
+		}
+	}
+	if t == "" {
+		return fragment{}, fmt.Sprintf("%s commits are skipped", cc.Type)
+	}
+	if !typeAllowed(manifest, t) {
+		return fragment{}, fmt.Sprintf("type %q is not in types.order (map it with --type %s=<type>)", t, cc.Type)
+	}
+	f = fragment{Type: manifest.CanonicalType(t), Summary: upperFirst(cc.Description)}
+	if cc.Scope != "" {
+		for _, name := range append(manifest.ComponentOrder(), componentNames(manifest)...) {
+			if strings.EqualFold(name, cc.Scope) {
+				f.Component = name
+				break
+			}
+		}
+	}
+	if f.Component == "" {
+		f.Component, _ = manifest.ComponentForPaths(c.Files)
+	}
+	if f.Component == "" {
+		f.Component = component
+	}
+	if f.Component == "" {
+		return fragment{}, "no component (use --component or changelog.component_paths)"
+	}
+	if pr != "" {
+		f.Refs = []string{"#" + pr}
+	}
+	return f, ""
+}
+
+// typeAllowed reports whether t is a fragment type the manifest accepts.
+func typeAllowed(manifest releaseManifest, t string) bool {
+	return manifest.TypeAllowed(manifest.CanonicalType(t))
+}
+
+// componentNames returns the independently versioned components, which need not be listed
+// in changelog.components.
+func componentNames(manifest releaseManifest) []string {
+	var names []string
+	for name := range manifest.Versioning.Components {
+		names = append(names, name)
+	}
+	return names
+}
+
+func upperFirst(s string) string {
+	r, n := utf8.DecodeRuneInString(s)
+	if n == 0 {
+		return s
+	}
+	return string(unicode.ToUpper(r)) + s[n:]
+}
+
+// cmdBackfill writes draft fragments for the changes merged since a release, for teams
+// adopting papertrail mid-project. Commits that already added a fragment are skipped.
+func cmdBackfill(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("backfill", flag.ContinueOnError)
+	fs.SetOutput(ioDiscard{})
+	since := fs.String("since", "", "release tag or commit to start after, like v1.2.0 (required)")
+	fragmentsDir := fs.String("fragments", "changelog.d", "fragments directory to write")
+	manifestPath := fs.String("manifest", "", "optional release config YAML path")
+	component := fs.String("component", "", "component for commits whose scope does not name one")
+	var typeFlags stringList
+	fs.Var(&typeFlags, "type", "map a conventional commit type to a fragment type, as from=to; an empty to skips it (repeatable)")
+	dryRun := fs.Bool("dry-run", false, "print the fragments that would be written without writing")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if strings.TrimSpace(*since) == "" {
+		return fmt.Errorf("--since is required (e.g. v1.2.0)")
+	}
+	manifest, err := loadManifestDefault(*manifestPath)
+	if err != nil {
+		return err
+	}
+	types, err := commitTypes(manifest, typeFlags)
+	if err != nil {
+		return err
+	}
+	commits, err := firstParentCommits(ctx, *since+"..HEAD")
+	if err != nil {
+		return err
+	}
+
+	var entries []importedFragment
+	for _, c := range commits {
+		if touchesFragments(c, *fragmentsDir, manifest) {
+			continue
+		}
+		f, reason := commitFragment(c, types, strings.TrimSpace(*component), manifest)
+		if reason != "" {
+			fmt.Fprintf(os.Stderr, "skipped %s %s: %s\n", c.Short(), c.Subject, reason)
+			continue
+		}
+		entries = append(entries, importedFragment{Source: c.Short(), Name: f.Summary, Frag: f})
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("no commits since %s to backfill", *since)
+	}
+	if err := writeImportedFragments(entries, *fragmentsDir, manifest, *dryRun, false); err != nil {
+		return err
+	}
+	if !*dryRun {
+		fmt.Fprintf(os.Stderr, "wrote %d draft fragment(s); review them before committing\n", len(entries))
+	}
+	return nil
+}
+
+// touchesFragments reports whether c added or changed a fragment under fragmentsDir.
+func touchesFragments(c gitCommit, fragmentsDir string, manifest releaseManifest) bool {
+	for _, f := range c.Files {
+		if isFragmentPath(f, fragmentsDir, manifest) {
+			return true
+		}
+	}
+	return false
+}
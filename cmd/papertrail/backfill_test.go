@@ -0,0 +1,90 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+func TestCommitFragment(t *testing.T) {
+	t.Parallel()
+
+	manifest, err := papertrail.ParseManifest([]byte("types:\n  order: [breaking, feature, fix]\nchangelog:\n  components: [CLI, API]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	types, err := commitTypes(manifest, []string{"perf="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		commit gitCommit
+		want   fragment
+		reason string
+	}{
+		{commit: gitCommit{Subject: "feat(api): add the endpoint (#12)"}, want: fragment{Component: "API", Type: "FEATURE", Summary: "Add the endpoint", Refs: []string{"#12"}}},
+		{commit: gitCommit{Subject: "Merge pull request #7 from me/branch", Body: "fix: handle empty input\n\nDetails."}, want: fragment{Component: "CLI", Type: "FIX", Summary: "Handle empty input", Refs: []string{"#7"}}},
+		{commit: gitCommit{Subject: "refactor!: drop the old flag"}, want: fragment{Component: "CLI", Type: "BREAKING", Summary: "Drop the old flag"}},
+		{commit: gitCommit{Subject: "feat: new output", Body: "BREAKING CHANGE: the format changed"}, want: fragment{Component: "CLI", Type: "BREAKING", Summary: "New output"}},
+		{commit: gitCommit{Subject: "Fix the crash on start"}, want: fragment{Component: "CLI", Type: "FIX", Summary: "Fix the crash on start"}},
+		{commit: gitCommit{Subject: "chore: bump deps"}, reason: "chore commits are skipped"},
+		{commit: gitCommit{Subject: "perf: faster parsing"}, reason: "perf commits are skipped"},
+		{commit: gitCommit{Subject: "docs: clarify usage"}, reason: `type "docs" is not in types.order`},
+		{commit: gitCommit{Subject: "Update README"}, reason: "not a conventional commit"},
+	} {
+		f, reason := commitFragment(tc.commit, types, "CLI", manifest)
+		if tc.reason != "" {
+			if !strings.Contains(reason, tc.reason) {
+				t.Errorf("%s: reason %q, want %q", tc.commit.Subject, reason, tc.reason)
+			}
+			continue
+		}
+		if reason != "" || f.Component != tc.want.Component || f.Type != tc.want.Type || f.Summary != tc.want.Summary || strings.Join(f.Refs, ",") != strings.Join(tc.want.Refs, ",") {
+			t.Errorf("%s: got %+v (%s), want %+v", tc.commit.Subject, f, reason, tc.want)
+		}
+	}
+}
+
+func TestCmdBackfill(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{"README.md": "x\n"})
+	gitIn(t, dir, "tag", "v0.1.0")
+	commit := func(msg string, files ...string) {
+		t.Helper()
+		for _, f := range files {
+			p := filepath.Join(dir, f)
+			if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(p, []byte(msg+"\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		gitIn(t, dir, "add", "-A")
+		gitIn(t, dir, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", msg)
+	}
+	commit("feat: add the thing (#3)", "thing.go")
+	commit("fix: covered already", "fix.go", "changelog.d/20260101_fix.yml")
+	commit("ci: tweak the workflow", ".github/ci.yml")
+	t.Setenv("SOURCE_DATE_EPOCH", "1790000000") // 2026-09-21
+
+	out, err := captureStdout(t, func() error {
+		return cmdBackfill(t.Context(), []string{"--since", "v0.1.0", "--component", "CLI"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, " -> changelog.d/20260921_add_the_thing.yml") || strings.Contains(out, "covered") {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "changelog.d/20260921_add_the_thing.yml"))
+	if err != nil || string(b) != "component: CLI\ntype: FEATURE\nsummary: Add the thing\nrefs:\n  - '#3'\n" {
+		t.Fatalf("unexpected fragment %q (%v)", b, err)
+	}
+
+	if err := cmdBackfill(t.Context(), []string{"--since", "HEAD"}); err == nil || !strings.Contains(err.Error(), "no commits since HEAD") {
+		t.Fatalf("expected nothing to backfill, got %v", err)
+	}
+}
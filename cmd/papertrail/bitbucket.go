@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// bitbucketLabelRE matches a "[label]" tag in a pull request's title or description.
+// Bitbucket pull requests have no labels, so these stand in for them.
+var bitbucketLabelRE = regexp.MustCompile(`\[([A-Za-z0-9][A-Za-z0-9 ._:/-]*)\]`)
+
+// bitbucketPullRequest fetches the pull request of a Bitbucket Pipelines pull request build
+// (BITBUCKET_PR_ID in BITBUCKET_REPO_FULL_NAME) from the Bitbucket Cloud API: its title,
+// "[label]" tags, destination commit, and changed files (from the diffstat).
+//
+// BITBUCKET_API_URL overrides the API endpoint. BITBUCKET_ACCESS_TOKEN is sent as a bearer
+// token, or BITBUCKET_USERNAME and BITBUCKET_APP_PASSWORD as basic auth.
+func bitbucketPullRequest(ctx context.Context) (pullRequest, error) {
+	repo := strings.TrimSpace(os.Getenv("BITBUCKET_REPO_FULL_NAME"))
+	number, err := strconv.Atoi(strings.TrimSpace(os.Getenv("BITBUCKET_PR_ID")))
+	if err != nil || repo == "" {
+		return pullRequest{}, fmt.Errorf("BITBUCKET_PR_ID and BITBUCKET_REPO_FULL_NAME are required on Bitbucket")
+	}
+	base := strings.TrimSpace(os.Getenv("BITBUCKET_API_URL"))
+	if base == "" {
+		base = "https://api.bitbucket.org/2.0"
+	}
+	prURL := fmt.Sprintf("%s/repositories/%s/pullrequests/%d", strings.TrimRight(base, "/"), repo, number)
+
+	var meta struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		Destination struct {
+			Commit struct {
+				Hash string `json:"hash"`
+			} `json:"commit"`
+		} `json:"destination"`
+	}
+	if err := bitbucketGet(ctx, prURL, &meta); err != nil {
+		return pullRequest{}, err
+	}
+	pr := pullRequest{Repo: repo, Number: number, Title: meta.Title, Body: meta.Description, Base: meta.Destination.Commit.Hash, Files: []string{}}
+	for _, m := range bitbucketLabelRE.FindAllStringSubmatch(meta.Title+"\n"+meta.Description, -1) {
+		pr.Labels = append(pr.Labels, strings.TrimSpace(m[1]))
+	}
+	pr.Labels = sortedUnique(pr.Labels)
+
+	for next := prURL + "/diffstat"; next != ""; {
+		var page struct {
+			Values []struct {
+				Old *struct {
+					Path string `json:"path"`
+				} `json:"old"`
+				New *struct {
+					Path string `json:"path"`
+				} `json:"new"`
+			} `json:"values"`
+			Next string `json:"next"`
+		}
+		if err := bitbucketGet(ctx, next, &page); err != nil {
+			return pullRequest{}, err
+		}
+		for _, v := range page.Values {
+			switch {
+			case v.New != nil:
+				pr.Files = append(pr.Files, v.New.Path)
+			case v.Old != nil:
+				pr.Files = append(pr.Files, v.Old.Path)
+			}
+		}
+		next = page.Next
+	}
+	return pr, nil
+}
+
+// bitbucketGet fetches a Bitbucket API URL and decodes the JSON response into v.
+func bitbucketGet(ctx context.Context, url string, v any) error {
+	if isOffline(ctx) {
+		return fmt.Errorf("Bitbucket API GET %s: %w", url, errOffline)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if token := strings.TrimSpace(os.Getenv("BITBUCKET_ACCESS_TOKEN")); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else if user := strings.TrimSpace(os.Getenv("BITBUCKET_USERNAME")); user != "" {
+		req.SetBasicAuth(user, os.Getenv("BITBUCKET_APP_PASSWORD"))
+	}
+	resp, err := (&http.Client{Timeout: 30 * time.Second}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("Bitbucket API GET %s: %s: %s", url, resp.Status, strings.TrimSpace(string(b)))
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("invalid Bitbucket API response for %s: %w", url, err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCmdPRFragment_Bitbucket(t *testing.T) {
+	initGitRepo(t, map[string]string{
+		"main.go":             "package main\n",
+		"changelog.d/new.yml": "component: CLI\ntype: feature\nsummary: Support Bitbucket.\n",
+	})
+
+	title := "Support Bitbucket"
+	var diffstat string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.RequestURI() {
+		case "/repositories/ws/repo/pullrequests/5":
+			_, _ = w.Write([]byte(`{"title": "` + title + `", "description": "Closes #3.\n\n[ui]", "destination": {"commit": {"hash": "abc"}}}`))
+		case "/repositories/ws/repo/pullrequests/5/diffstat":
+			_, _ = w.Write([]byte(`{"values": [{"old": {"path": "old.go"}, "new": null}], "next": "http://` + r.Host + `/repositories/ws/repo/pullrequests/5/diffstat?page=2"}`))
+		case "/repositories/ws/repo/pullrequests/5/diffstat?page=2":
+			_, _ = w.Write([]byte(diffstat))
+		default:
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("GITHUB_EVENT_PATH", "")
+	t.Setenv("BITBUCKET_PR_ID", "5")
+	t.Setenv("BITBUCKET_REPO_FULL_NAME", "ws/repo")
+	t.Setenv("BITBUCKET_API_URL", srv.URL)
+	t.Setenv("BITBUCKET_ACCESS_TOKEN", "tok")
+
+	diffstat = `{"values": [{"old": null, "new": {"path": "main.go"}}]}`
+	pr, err := currentPullRequest(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr.Title != title || pr.Base != "abc" || strings.Join(pr.Labels, ",") != "ui" || strings.Join(pr.Files, ",") != "old.go,main.go" {
+		t.Fatalf("pull request: %+v", pr)
+	}
+	if err := cmdPRFragment(t.Context(), nil); err == nil || !strings.Contains(err.Error(), "No changelog fragment") {
+		t.Fatalf("expected a missing fragment error, got %v", err)
+	}
+
+	// "[label]" tags in the title stand in for labels.
+	title = "[no-changelog] Support Bitbucket"
+	if err := cmdPRFragment(t.Context(), nil); err != nil {
+		t.Fatalf("opt-out tag: %v", err)
+	}
+
+	title = "Support Bitbucket"
+	diffstat = `{"values": [{"old": null, "new": {"path": "changelog.d/new.yml"}}]}`
+	if err := cmdPRFragment(t.Context(), nil); err != nil {
+		t.Fatalf("with a fragment: %v", err)
+	}
+}
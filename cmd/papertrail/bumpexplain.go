@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// bumpReason is the bump one pending fragment calls for and the versioning rule behind it
+// (empty when no rule applies and it falls back to patch).
+type bumpReason struct {
+	Bump      string `json:"bump"`
+	Path      string `json:"path"`
+	Component string `json:"component"`
+	Type      string `json:"type"`
+	Rule      string `json:"rule,omitempty"`
+}
+
+// bumpExplainReport is `bump --format json --explain` output.
+type bumpExplainReport struct {
+	bumpReport
+	// Degraded is the bump the fragments called for when versioning.zero_major_policy
+	// lowered it.
+	Degraded string       `json:"degraded_from,omitempty"`
+	Explain  []bumpReason `json:"explain"`
+}
+
+// explainBump lists why each item bumps what it does, highest bump first and otherwise in
+// item order.
+func explainBump(items []item, manifest releaseManifest) []bumpReason {
+	reasons := []bumpReason{}
+	for _, k := range []bumpKind{bumpMajor, bumpMinor, bumpPatch} {
+		for _, it := range items {
+			b, rule, ok := manifest.BumpRule(it.Frag)
+			if !ok {
+				b = bumpPatch
+			}
+			if b != k {
+				continue
+			}
+			reasons = append(reasons, bumpReason{Bump: b.String(), Path: it.Path, Component: it.Frag.Component, Type: it.Frag.Type, Rule: rule})
+		}
+	}
+	return reasons
+}
+
+// writeBumpExplanation prints the reasons grouped by bump level, then the decision: kind,
+// or pending lowered to kind under versioning.zero_major_policy.
+func writeBumpExplanation(w io.Writer, reasons []bumpReason, pending, kind bumpKind) {
+	level := ""
+	for _, r := range reasons {
+		if r.Bump != level {
+			level = r.Bump
+			fmt.Fprintf(w, "%s:\n", level)
+		}
+		rule := r.Rule
+		if rule == "" {
+			rule = "no rule, patch by default"
+		}
+		fmt.Fprintf(w, "  %s (%s in %s): %s\n", r.Path, r.Type, r.Component, rule)
+	}
+	switch {
+	case len(reasons) == 0:
+		fmt.Fprintf(w, "bump: %s (no pending fragments)\n", kind)
+	case pending != kind:
+		fmt.Fprintf(w, "bump: %s (%s lowered by versioning.zero_major_policy)\n", kind, pending)
+	default:
+		fmt.Fprintf(w, "bump: %s\n", kind)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+func TestExplainBump(t *testing.T) {
+	manifest, err := papertrail.ParseManifest([]byte(`versioning:
+  rules:
+    breaking: major
+    feature: minor
+  component_rules:
+    GitHub Actions:
+      breaking: minor
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := []item{
+		{Path: "changelog.d/a.yml", Frag: fragment{Component: "CLI", Type: "FIX", Summary: "Fix a"}},
+		{Path: "changelog.d/b.yml", Frag: fragment{Component: "GitHub Actions", Type: "BREAKING", Summary: "Drop b"}},
+		{Path: "changelog.d/c.yml", Frag: fragment{Component: "CLI", Type: "BREAKING", Summary: "Drop c"}},
+	}
+	reasons := explainBump(items, manifest)
+	want := []bumpReason{
+		{Bump: "major", Path: "changelog.d/c.yml", Component: "CLI", Type: "BREAKING", Rule: `versioning.rules["BREAKING"]`},
+		{Bump: "minor", Path: "changelog.d/b.yml", Component: "GitHub Actions", Type: "BREAKING", Rule: `versioning.component_rules["GitHub Actions"]["BREAKING"]`},
+		{Bump: "patch", Path: "changelog.d/a.yml", Component: "CLI", Type: "FIX"},
+	}
+	if len(reasons) != len(want) {
+		t.Fatalf("got %+v, want %+v", reasons, want)
+	}
+	for i := range want {
+		if reasons[i] != want[i] {
+			t.Fatalf("reason %d: got %+v, want %+v", i, reasons[i], want[i])
+		}
+	}
+
+	var b strings.Builder
+	writeBumpExplanation(&b, reasons, bumpMajor, bumpMajor)
+	wantText := `major:
+  changelog.d/c.yml (BREAKING in CLI): versioning.rules["BREAKING"]
+minor:
+  changelog.d/b.yml (BREAKING in GitHub Actions): versioning.component_rules["GitHub Actions"]["BREAKING"]
+patch:
+  changelog.d/a.yml (FIX in CLI): no rule, patch by default
+bump: major
+`
+	if b.String() != wantText {
+		t.Fatalf("got:\n%s\nwant:\n%s", b.String(), wantText)
+	}
+	b.Reset()
+	writeBumpExplanation(&b, reasons[:1], bumpMajor, bumpMinor)
+	if !strings.HasSuffix(b.String(), "bump: minor (major lowered by versioning.zero_major_policy)\n") {
+		t.Fatalf("degraded bump: got %q", b.String())
+	}
+}
+
+func TestCmdBump_ExplainJSON(t *testing.T) {
+	initGitRepo(t, map[string]string{
+		".papertrail.config.yml":     "versioning:\n  zero_major_policy: true\n  rules:\n    breaking: major\n",
+		"changelog.d/20260101_a.yml": "component: CLI\ntype: breaking\nsummary: Drop a\n",
+	})
+	out, err := captureStdout(t, func() error {
+		return cmdBump(t.Context(), []string{"--base", "v0.2.0", "--skip-version-check", "--explain", "--format", "json"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report bumpExplainReport
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if report.Next != "v0.3.0" || report.Bump != "minor" || report.Degraded != "major" || len(report.Explain) != 1 ||
+		report.Explain[0].Path != "changelog.d/20260101_a.yml" || report.Explain[0].Rule != `versioning.rules["BREAKING"]` {
+		t.Fatalf("got %+v\n%s", report, out)
+	}
+}
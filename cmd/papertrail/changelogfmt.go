@@ -0,0 +1,280 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+// cmdFmt re-renders every release section of the changelog through the current
+// changelog.style, so a style change applies to history as well as new releases. Sections
+// (or trailing parts of sections) that are not in the generated shape are kept verbatim.
+func cmdFmt(_ context.Context, args []string) error {
+	fs := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	fs.SetOutput(ioDiscard{})
+	changelogPath := fs.String("changelog", "CHANGELOG.md", "changelog path")
+	manifestPath := fs.String("manifest", "", "optional release config YAML path")
+	check := fs.Bool("check", false, "print a diff and fail if the changelog is not formatted, without writing it")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	manifest, err := loadManifestDefault(*manifestPath)
+	if err != nil {
+		return err
+	}
+	if manifest.Changelog.Templates.Release != "" {
+		// Template output cannot be parsed back into entries to re-render.
+		return fmt.Errorf("fmt formats the built-in layout; changelog.templates.release is set")
+	}
+	fsys := hostFS{}
+	b, err := readFile(fsys, *changelogPath)
+	if err != nil {
+		return err
+	}
+	formatted, err := formatChangelog(string(b), manifest)
+	if err != nil {
+		return fmt.Errorf("formatting %s: %w", *changelogPath, err)
+	}
+	if formatted == string(b) {
+		return nil
+	}
+	if *check {
+		fmt.Fprint(os.Stdout, unifiedDiff(*changelogPath, string(b), formatted))
+		return fmt.Errorf("%s is not formatted; run papertrail fmt", *changelogPath)
+	}
+	return fsys.WriteFile(*changelogPath, []byte(formatted), 0644)
+}
+
+// formatChangelog re-renders each versioned section; the preamble is kept as written.
+// Sections grouped by type (changelog.group_by: type) cannot be read back, so they are too.
+func formatChangelog(doc string, manifest releaseManifest) (string, error) {
+	if manifest.GroupByType() {
+		return doc, nil
+	}
+	preamble, sections := splitChangelog(doc)
+	var out strings.Builder
+	out.WriteString(preamble)
+	for _, s := range sections {
+		model, rest, ok := parseReleaseSection(s.Body, manifest)
+		if !ok {
+			out.WriteString(s.Body)
+			continue
+		}
+		model.Style = manifest.Changelog.Style
+		b, err := papertrail.MarkdownRenderer{}.Render(model)
+		if err != nil {
+			return "", fmt.Errorf("rendering the %s section: %w", model.Version, err)
+		}
+		out.Write(b)
+		if rest != "" {
+			out.WriteString(rest + "\n\n")
+		}
+	}
+	// The file keeps its trailing newlines: merge leaves a blank line after the last section.
+	end := doc[len(strings.TrimRight(doc, "\n")):]
+	if end == "" {
+		end = "\n"
+	}
+	return strings.TrimRight(out.String(), "\n") + end, nil
+}
+
+var (
+	releaseHeadingRE = regexp.MustCompile(`^## (v\S+)(?: \(([^)]*)\))?\s*$`)
+	boldEntryRE      = regexp.MustCompile(`^\*\*([^*]+)\*\*: (.*)$`)
+	plainEntryRE     = regexp.MustCompile(`^([a-z][a-z0-9 _-]*): (.*)$`)
+)
+
+// parseReleaseSection reads a generated release section back into a model: the heading,
+// then "###" component groups of list entries, after a Highlights group and before a
+// Deprecations group if any. Parsing stops at the first group that is not
+// in that shape (e.g. a dependency changes subsection); it and everything after it is
+// returned as rest, to be kept verbatim. ok is false when nothing could be parsed.
+func parseReleaseSection(body string, manifest releaseManifest) (m papertrail.ReleaseModel, rest string, ok bool) {
+	lines := strings.Split(strings.TrimRight(body, "\n"), "\n")
+	h := releaseHeadingRE.FindStringSubmatch(lines[0])
+	if h == nil {
+		return papertrail.ReleaseModel{}, "", false
+	}
+	if _, err := parseSemver(h[1]); err != nil {
+		return papertrail.ReleaseModel{}, "", false
+	}
+	m.Version, m.Date = h[1], h[2]
+
+	i := 1
+	for i < len(lines) && strings.TrimSpace(lines[i]) == "" {
+		i++
+	}
+	var parsed []componentGroup
+	var highlights, deprecations []item
+	for i < len(lines) && deprecations == nil {
+		groups, next, ok := parseComponentGroups(lines, i, manifest)
+		if !ok {
+			rest = strings.TrimRight(strings.Join(lines[i:], "\n"), "\n")
+			break
+		}
+		if len(parsed) == 0 && highlights == nil && len(groups) == 1 && groups[0].Name == papertrail.HighlightsHeading {
+			highlights = groups[0].Items
+		} else if len(parsed) > 0 && len(groups) == 1 && groups[0].Name == papertrail.DeprecationsHeading {
+			deprecations = groups[0].Items
+		} else {
+			parsed = append(parsed, groups...)
+		}
+		i = next
+	}
+	if len(parsed) == 0 && rest != "" {
+		return papertrail.ReleaseModel{}, "", false
+	}
+	if deprecations != nil && i < len(lines) {
+		rest = strings.TrimRight(strings.Join(lines[i:], "\n"), "\n")
+	}
+	markHighlights(parsed, highlights)
+	markDeprecations(parsed, deprecations)
+	m.Groups = libraryGroups(parsed)
+	return m, rest, true
+}
+
+// markHighlights flags the entries a Highlights group lists, so re-rendering keeps it.
+// Highlights are labeled with their component where entries have a type label.
+func markHighlights(groups []componentGroup, highlights []item) {
+	for _, h := range highlights {
+		for gi := range groups {
+			for ii := range groups[gi].Items {
+				f := &groups[gi].Items[ii].Frag
+				if f.Summary == h.Frag.Summary && (h.Frag.Type == "" || strings.EqualFold(h.Frag.Type, f.Component)) {
+					f.Highlight = true
+				}
+			}
+		}
+	}
+}
+
+// markDeprecations restores the removal versions a Deprecations group lists, so
+// re-rendering keeps them. Entries are matched as in markHighlights.
+func markDeprecations(groups []componentGroup, deprecations []item) {
+	for _, d := range deprecations {
+		summary, removal := papertrail.ParseDeprecationSummary(d.Frag.Summary)
+		if removal == "" {
+			continue
+		}
+		for gi := range groups {
+			for ii := range groups[gi].Items {
+				f := &groups[gi].Items[ii].Frag
+				if f.Summary == summary && (d.Frag.Type == "" || strings.EqualFold(d.Frag.Type, f.Component)) {
+					f.RemovalVersion = removal
+				}
+			}
+		}
+	}
+}
+
+// parseComponentGroups parses a "### <component>" group starting at lines[i] and the
+// "#### <child>" groups of its nested components ("<component>/<child>") after it, returning
+// the index after them.
+func parseComponentGroups(lines []string, i int, manifest releaseManifest) ([]componentGroup, int, bool) {
+	g, i, ok := parseComponentGroup(lines, i, "### ", "", manifest)
+	if !ok {
+		return nil, 0, false
+	}
+	var groups []componentGroup
+	if len(g.Items) > 0 {
+		groups = append(groups, g)
+	}
+	for i < len(lines) && strings.HasPrefix(lines[i], "#### ") {
+		child, next, ok := parseComponentGroup(lines, i, "#### ", g.Name, manifest)
+		if !ok || len(child.Items) == 0 {
+			return nil, 0, false
+		}
+		groups = append(groups, child)
+		i = next
+	}
+	return groups, i, len(groups) > 0
+}
+
+// parseComponentGroup parses a component heading with the given prefix and its entries
+// starting at lines[i], returning the index after the group and its trailing blank lines.
+// Under a parent, the heading names a nested component. The group may have no entries.
+// Trailing ref links are read back as refs, and indented paragraphs under an entry as its
+// details.
+func parseComponentGroup(lines []string, i int, prefix, parent string, manifest releaseManifest) (componentGroup, int, bool) {
+	name, ok := strings.CutPrefix(lines[i], prefix)
+	if !ok || strings.TrimSpace(name) == "" {
+		return componentGroup{}, 0, false
+	}
+	g := componentGroup{Name: strings.TrimSpace(name)}
+	if parent != "" {
+		g.Name = parent + papertrail.ComponentSeparator + g.Name
+	}
+	i++
+	// blanks counts the blank lines since the last entry or details line; indented lines
+	// after one belong to the entry's details rather than its (wrapped) summary.
+	blanks := 0
+	for i < len(lines) && !strings.HasPrefix(lines[i], "#") {
+		line := lines[i]
+		indented := line != "" && (line[0] == ' ' || line[0] == '\t')
+		switch {
+		case strings.TrimSpace(line) == "":
+			blanks++
+			i++
+			continue
+		case strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* "):
+			f, ok := parseEntry(line[2:], manifest)
+			if !ok {
+				return componentGroup{}, 0, false
+			}
+			f.Component = g.Name
+			g.Items = append(g.Items, item{Frag: f})
+		case indented && len(g.Items) > 0 && (blanks > 0 || g.Items[len(g.Items)-1].Frag.Details != ""):
+			// The details body, indented under its entry after a blank line.
+			last := &g.Items[len(g.Items)-1].Frag
+			l := strings.TrimPrefix(line, "  ")
+			if last.Details == "" {
+				last.Details = l
+			} else {
+				last.Details += strings.Repeat("\n", blanks+1) + l
+			}
+		case indented && len(g.Items) > 0 && !mdListItemRE.MatchString(strings.TrimSpace(line)):
+			// A wrapped entry's continuation line; nested lists are not in the generated shape.
+			last := &g.Items[len(g.Items)-1].Frag
+			last.Summary += " " + strings.TrimSpace(line)
+		default:
+			return componentGroup{}, 0, false
+		}
+		blanks = 0
+		i++
+	}
+	for ii := range g.Items {
+		f := &g.Items[ii].Frag
+		f.Summary, f.Refs = papertrail.SplitRefLinks(f.Summary, manifest.Refs.Patterns)
+	}
+	return g, i, true
+}
+
+// parseEntry reads "**type**: summary", "type: summary" (plain labels must be lowercase and,
+// when types.order is set, a known type), or a bare summary. Labels from types.display are
+// read back as their types.
+func parseEntry(s string, manifest releaseManifest) (fragment, bool) {
+	s = strings.TrimSpace(s)
+	for t, label := range manifest.Types.Display {
+		for _, prefix := range []string{"**" + label + "**: ", label + ": "} {
+			if summary, ok := strings.CutPrefix(s, prefix); ok {
+				return fragment{Type: t, Summary: summary}, true
+			}
+		}
+	}
+	if m := boldEntryRE.FindStringSubmatch(s); m != nil {
+		return fragment{Type: manifest.LabelType(m[1]), Summary: m[2]}, true
+	}
+	if m := plainEntryRE.FindStringSubmatch(s); m != nil {
+		order := manifest.TypeOrder()
+		if len(order) == 0 || contains(order, manifest.CanonicalType(m[1])) {
+			return fragment{Type: m[1], Summary: m[2]}, true
+		}
+	}
+	return fragment{Summary: s}, s != ""
+}
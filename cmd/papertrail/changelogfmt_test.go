@@ -0,0 +1,218 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+const fmtTestChangelog = "# Changelog\n\nIntro.\n\n" +
+	"## v1.1.0 (2026-02-01)\n\n" +
+	"### CLI\n\n" +
+	"- **feature**: Add a very long entry that the style should wrap at forty columns.\n" +
+	"- Entry without a type.\n\n" +
+	"### Dependency changes\n\n" +
+	"**Added**\n\n" +
+	"- `example.com/x` v1.0.0\n\n" +
+	"## v1.0.0\n\n" +
+	"### CLI\n\n" +
+	"* fix: Fix a.\n\n" +
+	"## 2025-12-01\n\n" +
+	"- dated entry, kept as written\n"
+
+func TestFormatChangelog(t *testing.T) {
+	t.Parallel()
+
+	m, err := papertrail.ParseManifest([]byte("changelog:\n  style:\n    bullet: \"*\"\n    type_label: plain\n    wrap: 40\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# Changelog\n\nIntro.\n\n" +
+		"## v1.1.0 (2026-02-01)\n\n" +
+		"### CLI\n\n" +
+		"* feature: Add a very long entry that\n" +
+		"  the style should wrap at forty\n" +
+		"  columns.\n" +
+		"* Entry without a type.\n\n" +
+		"### Dependency changes\n\n" +
+		"**Added**\n\n" +
+		"- `example.com/x` v1.0.0\n\n" +
+		"## v1.0.0\n\n" +
+		"### CLI\n\n" +
+		"* fix: Fix a.\n\n" +
+		"## 2025-12-01\n\n" +
+		"- dated entry, kept as written\n"
+	got := mustFormatChangelog(t, fmtTestChangelog, m)
+	if got != want {
+		t.Fatalf("got:\n%s\n%s", got, unifiedDiff("CHANGELOG.md", want, got))
+	}
+	if again := mustFormatChangelog(t, got, m); again != got {
+		t.Fatalf("not idempotent:\n%s", unifiedDiff("CHANGELOG.md", got, again))
+	}
+
+	// Back to the default style recovers the original generated sections.
+	if back := mustFormatChangelog(t, got, releaseManifest{}); !strings.Contains(back, "- **feature**: Add a very long entry that the style should wrap at forty columns.\n") ||
+		!strings.Contains(back, "- **fix**: Fix a.\n") {
+		t.Fatalf("default style:\n%s", back)
+	}
+}
+
+func TestCmdFmt_Check(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile("CHANGELOG.md", []byte(fmtTestChangelog), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmdFmt(t.Context(), []string{"--check"}); err == nil || !strings.Contains(err.Error(), "not formatted") {
+		t.Fatalf("expected --check to fail, got %v", err)
+	}
+	if err := cmdFmt(t.Context(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmdFmt(t.Context(), []string{"--check"}); err != nil {
+		t.Fatalf("formatted changelog fails --check: %v", err)
+	}
+}
+
+func TestCmdFmt_AfterMergeWithRefLinks(t *testing.T) {
+	initGitRepo(t, map[string]string{
+		".papertrail.config.yml":     "refs:\n  patterns:\n    - pattern: '#(\\d+)'\n      url: https://github.com/org/repo/pull/{1}\n    - pattern: 'JIRA-\\d+'\n",
+		"CHANGELOG.md":               "# Changelog\n",
+		"changelog.d/20260101_a.yml": "component: CLI\ntype: feature\nsummary: Add a\nrefs: ['#12', JIRA-3]\n",
+		"changelog.d/20260102_b.yml": "component: CLI\ntype: fix\nsummary: Fix b (for real)\n",
+	})
+	if err := cmdMerge(t.Context(), []string{"--version", "v1.0.0", "--date", "2026-01-03", "--skip-version-check"}); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile("CHANGELOG.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "- **feature**: Add a. ([#12](https://github.com/org/repo/pull/12))\n") {
+		t.Fatalf("merged changelog:\n%s", b)
+	}
+	if err := cmdFmt(t.Context(), []string{"--check"}); err != nil {
+		t.Fatalf("merged changelog fails fmt --check: %v", err)
+	}
+}
+
+func TestCmdFmt_AfterMergeWithDetails(t *testing.T) {
+	initGitRepo(t, map[string]string{
+		"CHANGELOG.md":               "# Changelog\n",
+		"changelog.d/20260101_a.yml": "component: CLI\ntype: breaking\nsummary: Drop --legacy\ndetails: |\n  Scripts that passed it should remove it:\n\n  - `merge --legacy` is now `merge`\n",
+		"changelog.d/20260102_b.yml": "component: CLI\ntype: fix\nsummary: Fix b\n",
+	})
+	if err := cmdMerge(t.Context(), []string{"--version", "v1.0.0", "--date", "2026-01-03", "--skip-version-check"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmdFmt(t.Context(), []string{"--check"}); err != nil {
+		t.Fatalf("merged changelog fails fmt --check: %v", err)
+	}
+
+	b, err := os.ReadFile("CHANGELOG.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := papertrail.ParseManifest([]byte("changelog:\n  style:\n    bullet: \"*\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "* **breaking**: Drop --legacy.\n\n  Scripts that passed it should remove it:\n\n  - `merge --legacy` is now `merge`\n* **fix**: Fix b.\n"
+	if got := mustFormatChangelog(t, string(b), m); !strings.Contains(got, want) {
+		t.Fatalf("got:\n%s\nwant it to contain:\n%s", got, want)
+	}
+}
+
+func TestFormatChangelog_Flat(t *testing.T) {
+	t.Parallel()
+
+	m, err := papertrail.ParseManifest([]byte("changelog:\n  layout: flat\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := "# Changelog\n\n## v1.1.0 (2026-02-01)\n\n- **fix**: Fix b.\n\n## v1.0.0 (2026-01-01)\n\n### CLI\n\n- **fix**: Fix a.\n"
+	want := "# Changelog\n\n## v1.1.0 (2026-02-01)\n\n- **fix**: Fix b.\n\n## v1.0.0 (2026-01-01)\n\n- **fix**: Fix a.\n"
+	if got := mustFormatChangelog(t, doc, m); got != want {
+		t.Fatalf("got:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestFormatChangelog_TypeDisplay(t *testing.T) {
+	t.Parallel()
+
+	m, err := papertrail.ParseManifest([]byte("types:\n  order: [feature, fix]\n  display:\n    feature: \"✨ Feature\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := "## v1.0.0 (2026-01-01)\n\n### CLI\n\n- fix: Fix a.\n- ✨ Feature: Add b.\n"
+	want := "## v1.0.0 (2026-01-01)\n\n### CLI\n\n- **fix**: Fix a.\n- **✨ Feature**: Add b.\n"
+	if got := mustFormatChangelog(t, doc, m); got != want {
+		t.Fatalf("got:\n%q\nwant:\n%q", got, want)
+	}
+	if problems := changelogProblems(want, m); len(problems) != 0 {
+		t.Fatalf("unexpected problems: %q", problems)
+	}
+}
+
+func TestFormatChangelog_NestedComponents(t *testing.T) {
+	t.Parallel()
+
+	m, err := papertrail.ParseManifest([]byte("changelog:\n  components: [Server/Auth]\n  strict_components: true\n  style:\n    bullet: \"*\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := "## v1.0.0 (2026-01-01)\n\n### Server\n\n#### Auth\n\n- **fix**: Fix login.\n"
+	want := "## v1.0.0 (2026-01-01)\n\n### Server\n\n#### Auth\n\n* **fix**: Fix login.\n"
+	if got := mustFormatChangelog(t, doc, m); got != want {
+		t.Fatalf("got:\n%q\nwant:\n%q", got, want)
+	}
+	if problems := changelogProblems(want, m); len(problems) != 0 {
+		t.Fatalf("unexpected problems: %q", problems)
+	}
+}
+
+func TestFormatChangelog_Highlights(t *testing.T) {
+	t.Parallel()
+
+	m, err := papertrail.ParseManifest([]byte("changelog:\n  style:\n    bullet: \"*\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := "## v1.0.0 (2026-01-01)\n\n### Highlights\n\n- **CLI**: Add themes.\n\n### CLI\n\n- **feature**: Add themes.\n- **fix**: Fix flag.\n"
+	want := "## v1.0.0 (2026-01-01)\n\n### Highlights\n\n* **CLI**: Add themes.\n\n### CLI\n\n* **feature**: Add themes.\n* **fix**: Fix flag.\n"
+	if got := mustFormatChangelog(t, doc, m); got != want {
+		t.Fatalf("got:\n%q\nwant:\n%q", got, want)
+	}
+	if problems := changelogProblems(want, m); len(problems) != 0 {
+		t.Fatalf("unexpected problems: %q", problems)
+	}
+}
+
+func TestFormatChangelog_Deprecations(t *testing.T) {
+	t.Parallel()
+
+	m, err := papertrail.ParseManifest([]byte("changelog:\n  style:\n    bullet: \"*\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := "## v1.0.0 (2026-01-01)\n\n### CLI\n\n- **deprecation**: Deprecate --legacy.\n- **fix**: Fix flag.\n\n" +
+		"### Deprecations\n\n- **CLI**: Deprecate --legacy (removal in v2.0.0).\n\n### Contributors\n\n- @octocat\n"
+	want := "## v1.0.0 (2026-01-01)\n\n### CLI\n\n* **deprecation**: Deprecate --legacy.\n* **fix**: Fix flag.\n\n" +
+		"### Deprecations\n\n* **CLI**: Deprecate --legacy (removal in v2.0.0).\n\n### Contributors\n\n- @octocat\n"
+	if got := mustFormatChangelog(t, doc, m); got != want {
+		t.Fatalf("got:\n%q\nwant:\n%q", got, want)
+	}
+	if problems := changelogProblems(want, m); len(problems) != 0 {
+		t.Fatalf("unexpected problems: %q", problems)
+	}
+}
+
+func mustFormatChangelog(t *testing.T, doc string, manifest releaseManifest) string {
+	t.Helper()
+	got, err := formatChangelog(doc, manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+func cmdCommitMessage(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("commit-message", flag.ContinueOnError)
+	fs.SetOutput(ioDiscard{})
+	baseRef := fs.String("base-ref", "", "base ref to diff against to find the PR's fragments, e.g. origin/main")
+	fragmentsDir := fs.String("fragments", "changelog.d", "fragments directory")
+	manifestPath := fs.String("manifest", "", "optional release config YAML path")
+	out := fs.String("out", "", "write the message to this path (default: stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	manifest, err := loadManifestDefault(*manifestPath)
+	if err != nil {
+		return err
+	}
+
+	files := fs.Args()
+	if len(files) == 0 {
+		if strings.TrimSpace(*baseRef) == "" {
+			return fmt.Errorf("commit-message requires --base-ref or explicit fragment paths")
+		}
+		files, err = changedFragmentFiles(ctx, *baseRef, *fragmentsDir, manifest)
+		if err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			return fmt.Errorf("no fragments changed since %s under %q", *baseRef, *fragmentsDir)
+		}
+	}
+
+	items := make([]item, 0, len(files))
+	for _, p := range files {
+		its, err := readItems(fragmentFile{FS: hostFS{}, Path: p, Name: p}, manifest)
+		if err != nil {
+			return err
+		}
+		items = append(items, its...)
+	}
+
+	msg := renderCommitMessage(items, manifest)
+	if *out != "" {
+		return os.WriteFile(*out, msg, 0644)
+	}
+	_, _ = os.Stdout.Write(msg)
+	return nil
+}
+
+// changedFragmentFiles returns the fragment files added or modified since baseRef that still
+// exist in the working tree.
+func changedFragmentFiles(ctx context.Context, baseRef, fragmentsDir string, manifest releaseManifest) ([]string, error) {
+	changed, err := gitChangedFiles(ctx, baseRef)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, f := range changed {
+		if !isFragmentPath(f, fragmentsDir, manifest) || strings.Contains(f, "/archived/") {
+			continue
+		}
+		if _, err := os.Stat(f); err != nil {
+			continue
+		}
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// renderCommitMessage produces a conventional-commit squash message. The highest-bump
+// fragment becomes the header; remaining fragments are listed in the body, and refs become
+// footers. Fragments whose type bumps major are marked breaking.
+func renderCommitMessage(items []item, manifest releaseManifest) []byte {
+	rows := sortedItems(items, manifest)
+	sort.SliceStable(rows, func(i, j int) bool {
+		return commitBump(rows[i].Frag, manifest) > commitBump(rows[j].Frag, manifest)
+	})
+
+	var buf bytes.Buffer
+	buf.WriteString(commitSubject(rows[0].Frag, manifest))
+	buf.WriteString("\n")
+
+	if len(rows) > 1 {
+		buf.WriteString("\n")
+		for _, r := range rows[1:] {
+			fmt.Fprintf(&buf, "- %s\n", commitSubject(r.Frag, manifest))
+		}
+	}
+
+	var footers []string
+	seenRef := map[string]bool{}
+	for _, r := range rows {
+		if commitBump(r.Frag, manifest) == bumpMajor {
+			footers = append(footers, "BREAKING CHANGE: "+ensurePeriod(r.Frag.Summary))
+		}
+	}
+	for _, r := range rows {
+		for _, ref := range r.Frag.Refs {
+			if ref == "" || seenRef[ref] {
+				continue
+			}
+			seenRef[ref] = true
+			footers = append(footers, "Refs: "+ref)
+		}
+	}
+	if len(footers) > 0 {
+		buf.WriteString("\n")
+		for _, f := range footers {
+			buf.WriteString(f + "\n")
+		}
+	}
+	return buf.Bytes()
+}
+
+func commitSubject(f fragment, manifest releaseManifest) string {
+	bang := ""
+	if commitBump(f, manifest) == bumpMajor {
+		bang = "!"
+	}
+	summary := strings.TrimRight(strings.TrimSpace(f.Summary), ".")
+	return fmt.Sprintf("%s(%s)%s: %s", commitType(f.Type, manifest), commitScope(f.Component), bang, summary)
+}
+
+func commitBump(f fragment, manifest releaseManifest) bumpKind {
+	bt, _ := manifest.BumpForFragment(f)
+	return bt
+}
+
+// commitType maps a canonical fragment type to a conventional-commit type using
+// `commit_message.types`; unmapped types are lowercased with spaces replaced by dashes.
+func commitType(t string, manifest releaseManifest) string {
+	if ct, ok := manifest.CommitMessage.Types[t]; ok {
+		return strings.TrimSpace(ct)
+	}
+	return strings.ReplaceAll(displayType(t), " ", "-")
+}
+
+func commitScope(component string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(component)), " ", "-")
+}
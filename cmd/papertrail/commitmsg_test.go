@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestRenderCommitMessage(t *testing.T) {
+	t.Parallel()
+
+	var m releaseManifest
+	m.Versioning.Rules = map[string]string{"BREAKING": "major", "FEATURE": "minor", "*": "patch"}
+	m.CommitMessage.Types = map[string]string{"FEATURE": "feat"}
+
+	items := []item{
+		{Path: "changelog.d/a.yml", Frag: fragment{Component: "CLI", Type: "FIX", Summary: "Fix output."}},
+		{Path: "changelog.d/b.yml", Frag: fragment{Component: "GitHub Actions", Type: "FEATURE", Summary: "Add input", Refs: []string{"#12"}}},
+		{Path: "changelog.d/c.yml", Frag: fragment{Component: "CLI", Type: "BREAKING", Summary: "Drop --foo", Refs: []string{"#12", "#13"}}},
+	}
+
+	got := string(renderCommitMessage(items, m))
+	want := "breaking(cli)!: Drop --foo\n" +
+		"\n" +
+		"- feat(github-actions): Add input\n" +
+		"- fix(cli): Fix output\n" +
+		"\n" +
+		"BREAKING CHANGE: Drop --foo.\n" +
+		"Refs: #12\n" +
+		"Refs: #13\n"
+	if got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// skippedCommitPrefixes mark commit messages git or the user generates for history
+// editing and merges, which are not held to the title rules.
+var skippedCommitPrefixes = []string{"fixup! ", "squash! ", "amend! ", "Merge "}
+
+// cmdCommitMsg is the commit-msg hook: the commit message's subject must be a conventional
+// commit with a known type that follows pr_policy.title_validation, the same rules
+// pr-fragment applies to pull request titles.
+func cmdCommitMsg(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("commit-msg", flag.ContinueOnError)
+	fs.SetOutput(ioDiscard{})
+	manifestPath := fs.String("manifest", "", "optional release config YAML path")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("commit-msg requires exactly one commit message file (e.g. .git/COMMIT_EDITMSG)")
+	}
+	b, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	manifest, err := loadManifestDefault(*manifestPath)
+	if err != nil {
+		return err
+	}
+	return checkCommitSubject(messageSubject(string(b)), manifest)
+}
+
+// messageSubject returns the first line of a commit message that is neither blank nor a "#"
+// comment, as git keeps it.
+func messageSubject(msg string) string {
+	for _, line := range strings.Split(strings.ReplaceAll(msg, "\r\n", "\n"), "\n") {
+		if t := strings.TrimSpace(line); t != "" && !strings.HasPrefix(t, "#") {
+			return t
+		}
+	}
+	return ""
+}
+
+// checkCommitSubject applies the conventional commit types (commit_message.types,
+// import.types, and pr_policy.title_types) and pr_policy.title_validation to a commit
+// subject. Empty subjects are left to git, which aborts the commit.
+func checkCommitSubject(subject string, manifest releaseManifest) error {
+	if subject == "" {
+		return nil
+	}
+	for _, p := range skippedCommitPrefixes {
+		if strings.HasPrefix(subject, p) {
+			return nil
+		}
+	}
+	types, err := commitTypes(manifest, nil)
+	if err != nil {
+		return err
+	}
+	for t := range manifest.PRPolicy.TitleTypes.Types {
+		types[t] = ""
+	}
+	var problems []string
+	cc, ok := parseConventionalCommit(subject, "")
+	if !ok {
+		problems = append(problems, "not a conventional commit (expected type(scope): description)")
+	} else {
+		if _, ok := types[cc.Type]; !ok {
+			known := make([]string, 0, len(types))
+			for t := range types {
+				known = append(known, t)
+			}
+			sort.Strings(known)
+			problems = append(problems, fmt.Sprintf("type %q is not known (expected one of %s)", cc.Type, strings.Join(known, ", ")))
+		}
+		problems = append(problems, titleProblems(cc, subject, manifest)...)
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	msg := fmt.Sprintf("❌ The commit message %q does not follow the commit rules:\n  - %s", subject, strings.Join(problems, "\n  - "))
+	if suggested, ok := suggestPRTitle(subject, manifest); ok {
+		msg += "\n💡 Suggested: " + suggested
+	}
+	return errors.New(msg)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+func TestCheckCommitSubject(t *testing.T) {
+	t.Parallel()
+
+	manifest, err := papertrail.ParseManifest([]byte(`changelog:
+  components: [CLI]
+pr_policy:
+  title_types:
+    types:
+      perf: [fix]
+  title_validation:
+    allowed_scopes: [api]
+    component_scopes: true
+    scope_aliases:
+      rest: api
+    no_trailing_period: true
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, subject := range []string{
+		"feat(api): add x",
+		"fix(rest): alias for api",
+		"fix(cli): component scope",
+		"chore: tidy up",
+		"perf: title_types type",
+		"fixup! feat(web): anything goes",
+		"squash! whatever",
+		"Merge branch 'main' into topic",
+		"",
+	} {
+		if err := checkCommitSubject(subject, manifest); err != nil {
+			t.Fatalf("%q: %v", subject, err)
+		}
+	}
+	for subject, want := range map[string]string{
+		"Add x":              "not a conventional commit",
+		"wip: add x":         `type "wip" is not known`,
+		"feat(web): add x":   `scope "web" is not allowed`,
+		"fix(api): fix x.":   "description ends with a period",
+		"feature: add x":     "💡 Suggested: feat: add x",
+		"Revert \"feat: x\"": "not a conventional commit",
+	} {
+		err := checkCommitSubject(subject, manifest)
+		if err == nil || !strings.Contains(err.Error(), want) || !strings.Contains(err.Error(), "commit message") {
+			t.Fatalf("%q: expected %q, got %v", subject, want, err)
+		}
+	}
+}
+
+func TestMessageSubject(t *testing.T) {
+	t.Parallel()
+
+	msg := "# Please enter the commit message\n\n  fix: handle x  \r\n\nBody.\n# comment\n"
+	if got := messageSubject(msg); got != "fix: handle x" {
+		t.Fatalf("messageSubject = %q", got)
+	}
+	if got := messageSubject("# only comments\n\n"); got != "" {
+		t.Fatalf("messageSubject of comments = %q", got)
+	}
+}
+
+func TestCmdCommitMsg(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{
+		".papertrail.config.yml": "pr_policy:\n  title_validation:\n    subject_case: lower\n",
+	})
+	p := filepath.Join(dir, ".git", "COMMIT_EDITMSG")
+	if err := os.WriteFile(p, []byte("fix: handle x\n\n# Lines starting with '#' are ignored.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmdCommitMsg(t.Context(), []string{p}); err != nil {
+		t.Fatalf("valid message: %v", err)
+	}
+	if err := os.WriteFile(p, []byte("fix: Handle x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmdCommitMsg(t.Context(), []string{p}); err == nil || !strings.Contains(err.Error(), "lowercase") {
+		t.Fatalf("expected a subject case error, got %v", err)
+	}
+	if err := cmdCommitMsg(t.Context(), nil); err == nil || !strings.Contains(err.Error(), "exactly one") {
+		t.Fatalf("expected a usage error, got %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+// knownRelease is the latest released version and where it was found.
+type knownRelease struct {
+	Version semver
+	Source  string
+}
+
+// latestRelease returns the highest of the latest git tag with tagPrefix and the top
+// versioned section of the changelog. Either source may be absent (no git repository, no
+// tags, no changelog); ok is false when neither yields a version.
+func latestRelease(ctx context.Context, fsys fs.FS, changelogPath, tagPrefix string) (rel knownRelease, ok bool, err error) {
+	var candidates []knownRelease
+	if v, tag, found, err := latestTagVersion(ctx, tagPrefix); err != nil {
+		return knownRelease{}, false, err
+	} else if found {
+		candidates = append(candidates, knownRelease{Version: v, Source: "git tag " + tag})
+	}
+
+	b, err := fs.ReadFile(fsys, changelogPath)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return knownRelease{}, false, err
+	default:
+		if v, found := topChangelogVersion(string(b)); found {
+			candidates = append(candidates, knownRelease{Version: v, Source: changelogPath})
+		}
+	}
+
+	for _, c := range candidates {
+		if !ok || c.Version.Compare(rel.Version) > 0 {
+			rel, ok = c, true
+		}
+	}
+	return rel, ok, nil
+}
+
+// latestTagVersion returns the highest SemVer tag starting with prefix (e.g. "v" or
+// "api/v") by precedence, and the tag's name. Tags that are not valid versions are ignored,
+// and so is running outside a git work tree.
+func latestTagVersion(ctx context.Context, prefix string) (latest semver, tag string, found bool, err error) {
+	if _, err := runGit(ctx, "rev-parse", "--is-inside-work-tree"); err != nil {
+		return semver{}, "", false, nil
+	}
+	out, err := runGit(ctx, "tag", "--list", prefix+"*")
+	if err != nil {
+		return semver{}, "", false, err
+	}
+	for _, line := range strings.Split(out, "\n") {
+		name := strings.TrimSpace(line)
+		v, ok := tagVersion(name, prefix)
+		if ok && (!found || v.Compare(latest) > 0) {
+			latest, tag, found = v, name, true
+		}
+	}
+	return latest, tag, found, nil
+}
+
+// tagVersion parses the version in a tag named prefix + version, where the version's
+// leading "v" may be part of the prefix ("v", "api/v") or follow it ("api/").
+func tagVersion(tag, prefix string) (semver, bool) {
+	rest, ok := strings.CutPrefix(tag, prefix)
+	if !ok || rest == "" {
+		return semver{}, false
+	}
+	if !strings.HasPrefix(rest, "v") {
+		rest = "v" + rest
+	}
+	v, err := parseSemver(rest)
+	return v, err == nil
+}
+
+// topChangelogVersion returns the version of the first "## vX.Y.Z" (or "## [vX.Y.Z]")
+// section.
+func topChangelogVersion(changelog string) (semver, bool) {
+	for _, line := range strings.Split(changelog, "\n") {
+		version, _, ok := papertrail.ParseReleaseHeading(strings.TrimSpace(line))
+		if !ok {
+			continue
+		}
+		if v, err := parseSemver(version); err == nil {
+			return v, true
+		}
+	}
+	return semver{}, false
+}
+
+// checkBumpBase verifies that base is the latest known release, so the computed version
+// follows on from it.
+func checkBumpBase(base semver, latest knownRelease) error {
+	if base.Compare(latest.Version) != 0 {
+		return fmt.Errorf("--base %s does not match the latest release %s (from %s); pass --skip-version-check to bump from another release", base, latest.Version, latest.Source)
+	}
+	return nil
+}
+
+// checkNextVersion verifies that next is newer than the latest known release and does not
+// skip a version: its release must be the patch, minor, or major successor of latest.
+func checkNextVersion(next semver, latest knownRelease) error {
+	if next.Compare(latest.Version) <= 0 {
+		return fmt.Errorf("--version %s is not newer than the latest release %s (from %s); pass --skip-version-check to release it anyway", next, latest.Version, latest.Source)
+	}
+	core := semver{Major: next.Major, Minor: next.Minor, Patch: next.Patch}
+	var want []string
+	for _, k := range []bumpKind{bumpPatch, bumpMinor, bumpMajor} {
+		s := latest.Version.Bump(k)
+		if s.Compare(core) == 0 {
+			return nil
+		}
+		if len(want) == 0 || want[len(want)-1] != s.String() {
+			want = append(want, s.String())
+		}
+	}
+	return fmt.Errorf("--version %s skips ahead of the latest release %s (from %s); expected one of %s, or pass --skip-version-check", next, latest.Version, latest.Source, strings.Join(want, ", "))
+}
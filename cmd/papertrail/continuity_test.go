@@ -0,0 +1,186 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestLatestRelease(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{
+		"CHANGELOG.md": "# Changelog\n\n## Components\n\n## v1.2.0 (2025-01-02)\n\n## v1.1.0 (2025-01-01)\n",
+	})
+	for _, tag := range []string{"v1.1.0", "v1.10.0-rc.1", "v1.9.0", "not-a-version", "v01.2.3"} {
+		cmd := exec.Command("git", "tag", tag)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git tag %s: %v\n%s", tag, err, out)
+		}
+	}
+
+	rel, ok, err := latestRelease(t.Context(), hostFS{}, "CHANGELOG.md", "v")
+	if err != nil || !ok {
+		t.Fatalf("ok=%v err=%v", ok, err)
+	}
+	if rel.Version.String() != "v1.10.0-rc.1" || rel.Source != "git tag v1.10.0-rc.1" {
+		t.Fatalf("unexpected release: %+v", rel)
+	}
+
+	// Without tags the changelog's top section is used; with neither, there is no baseline.
+	fsys := newMemFS(map[string]string{"CHANGELOG.md": "## v0.3.0\n"})
+	t.Chdir(t.TempDir())
+	rel, ok, err = latestRelease(t.Context(), fsys, "CHANGELOG.md", "v")
+	if err != nil || !ok || rel.Version.String() != "v0.3.0" || rel.Source != "CHANGELOG.md" {
+		t.Fatalf("unexpected release: %+v ok=%v err=%v", rel, ok, err)
+	}
+	if _, ok, err := latestRelease(t.Context(), fsys, "missing.md", "v"); ok || err != nil {
+		t.Fatalf("expected no baseline, got ok=%v err=%v", ok, err)
+	}
+}
+
+func TestCheckNextVersion(t *testing.T) {
+	t.Parallel()
+
+	latest := knownRelease{Version: mustSemver(t, "v1.2.3"), Source: "CHANGELOG.md"}
+	for _, v := range []string{"v1.2.4", "v1.3.0", "v2.0.0", "v1.3.0-rc.1", "v2.0.0+build.1"} {
+		if err := checkNextVersion(mustSemver(t, v), latest); err != nil {
+			t.Fatalf("%s: %v", v, err)
+		}
+	}
+	err := checkNextVersion(mustSemver(t, "v1.2.3"), latest)
+	if err == nil || !strings.Contains(err.Error(), "not newer than the latest release v1.2.3 (from CHANGELOG.md)") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = checkNextVersion(mustSemver(t, "v1.5.0"), latest)
+	if err == nil || !strings.Contains(err.Error(), "expected one of v1.2.4, v1.3.0, v2.0.0") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A prerelease may be followed by the next prerelease or by its release.
+	rc := knownRelease{Version: mustSemver(t, "v1.3.0-rc.1"), Source: "git tag v1.3.0-rc.1"}
+	for _, v := range []string{"v1.3.0-rc.2", "v1.3.0"} {
+		if err := checkNextVersion(mustSemver(t, v), rc); err != nil {
+			t.Fatalf("%s: %v", v, err)
+		}
+	}
+
+	if err := checkBumpBase(mustSemver(t, "v1.2.2"), latest); err == nil {
+		t.Fatalf("expected base mismatch error")
+	}
+}
+
+func mustSemver(t *testing.T, s string) semver {
+	t.Helper()
+	v, err := parseSemver(s)
+	if err != nil {
+		t.Fatalf("parseSemver(%q): %v", s, err)
+	}
+	return v
+}
+
+func TestCmdBump_BaseAuto(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{
+		".papertrail.config.yml":     "versioning:\n  rules:\n    feature: minor\n",
+		"changelog.d/20260101_a.yml": "component: CLI\ntype: feature\nsummary: Add a\n",
+	})
+	bump := func(args ...string) (string, error) {
+		return captureStdout(t, func() error { return cmdBump(t.Context(), args) })
+	}
+	if _, err := bump(); err == nil || !strings.Contains(err.Error(), "no git tag matching v<version>") {
+		t.Fatalf("expected a missing tag error, got %v", err)
+	}
+
+	for _, tag := range []string{"v1.1.0", "v1.2.0", "api/v0.3.0", "api/v0.10.0", "api/latest"} {
+		cmd := exec.Command("git", "tag", tag)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git tag %s: %v\n%s", tag, err, out)
+		}
+	}
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{nil, "v1.3.0\n"},
+		{[]string{"--base", "auto"}, "v1.3.0\n"},
+		{[]string{"--tag-prefix", "api/v"}, "v0.11.0\n"},
+		{[]string{"--tag-prefix", "api/"}, "v0.11.0\n"},
+	} {
+		got, err := bump(tc.args...)
+		if err != nil || got != tc.want {
+			t.Fatalf("bump %q: got %q, %v; want %q", tc.args, got, err, tc.want)
+		}
+	}
+	if _, err := bump("--base", "v1.1.0"); err == nil || !strings.Contains(err.Error(), "git tag v1.2.0") {
+		t.Fatalf("expected the version check to fail, got %v", err)
+	}
+}
+
+func TestReleaseCandidates(t *testing.T) {
+	dir := setupCutRepo(t)
+
+	out, err := captureStdout(t, func() error { return cmdBump(t.Context(), []string{"--prerelease", "rc"}) })
+	if err != nil || out != "v1.3.0-rc.1\n" {
+		t.Fatalf("first candidate: got %q, %v", out, err)
+	}
+	if err := cmdMerge(t.Context(), []string{"--version", "v1.3.0-rc.1", "--date", "2026-02-01"}); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile("CHANGELOG.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "## v1.3.0-rc.1 (2026-02-01)\n") {
+		t.Fatalf("missing candidate section:\n%s", b)
+	}
+	gitIn(t, dir, "tag", "v1.3.0-rc.1")
+
+	if err := os.WriteFile("changelog.d/20260202.yml", []byte("component: CLI\ntype: fix\nsummary: Fix rc\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out, err = captureStdout(t, func() error { return cmdBump(t.Context(), []string{"--prerelease", "rc"}) })
+	if err != nil || out != "v1.3.0-rc.2\n" {
+		t.Fatalf("next candidate: got %q, %v", out, err)
+	}
+	out, err = captureStdout(t, func() error { return cmdBump(t.Context(), nil) })
+	if err != nil || out != "v1.3.0\n" {
+		t.Fatalf("final release: got %q, %v", out, err)
+	}
+}
+
+func TestBuildMetadata(t *testing.T) {
+	setupCutRepo(t)
+
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--base", "v1.2.0+nightly.44", "--skip-version-check"}, "v1.3.0\n"},
+		{[]string{"--base", "v1.2.0+nightly.44", "--skip-version-check", "--keep-build"}, "v1.3.0+nightly.44\n"},
+		{[]string{"--build", "nightly.45"}, "v1.3.0+nightly.45\n"},
+		{[]string{"--prerelease", "rc", "--build", "sha.0a1b2c"}, "v1.3.0-rc.1+sha.0a1b2c\n"},
+	} {
+		out, err := captureStdout(t, func() error { return cmdBump(t.Context(), tc.args) })
+		if err != nil || out != tc.want {
+			t.Fatalf("bump %q: got %q, %v; want %q", tc.args, out, err, tc.want)
+		}
+	}
+	if err := cmdBump(t.Context(), []string{"--build", "a..b"}); err == nil || !strings.Contains(err.Error(), "--build") {
+		t.Fatalf("expected an invalid --build error, got %v", err)
+	}
+
+	if err := cmdMerge(t.Context(), []string{"--version", "v1.3.0+nightly.45", "--date", "2026-02-01"}); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile("CHANGELOG.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "## v1.3.0+nightly.45 (2026-02-01)\n") {
+		t.Fatalf("missing nightly section:\n%s", b)
+	}
+	if _, err := os.Stat("changelog.d/archived/v1.3.0+nightly.45"); err != nil {
+		t.Fatalf("fragments not archived under the full version: %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+// writeContributors renders the "Contributors" subsection of release notes: one bullet per
+// author, with GitHub @-handles linked to their profiles on GITHUB_SERVER_URL (default
+// https://github.com). Nothing is written without authors.
+func writeContributors(buf *bytes.Buffer, authors []string) {
+	if len(authors) == 0 {
+		return
+	}
+	server := strings.TrimRight(strings.TrimSpace(os.Getenv("GITHUB_SERVER_URL")), "/")
+	if server == "" {
+		server = "https://github.com"
+	}
+	buf.WriteString("### Contributors\n\n")
+	for _, a := range authors {
+		if papertrail.IsGitHubHandle(a) {
+			fmt.Fprintf(buf, "- [%s](%s/%s)\n", a, server, strings.TrimPrefix(a, "@"))
+			continue
+		}
+		fmt.Fprintf(buf, "- %s\n", a)
+	}
+	buf.WriteString("\n")
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderReleaseSection_Contributors(t *testing.T) {
+	t.Setenv("GITHUB_SERVER_URL", "https://github.example.com/")
+
+	items := []item{
+		{Path: "changelog.d/a.yml", Frag: fragment{Component: "CLI", Type: "FIX", Summary: "Fix a", Authors: []string{"@octocat", "Jane Doe"}}},
+		{Path: "changelog.d/b.yml", Frag: fragment{Component: "CLI", Type: "FIX", Summary: "Fix b", Authors: []string{"@octocat"}}},
+	}
+	section, notes, err := renderReleaseSection("v1.0.0", "2026-01-01", items, releaseManifest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(section), "Contributors") {
+		t.Fatalf("the CHANGELOG section must not list contributors:\n%s", section)
+	}
+	want := "### Contributors\n\n- Jane Doe\n- [@octocat](https://github.example.com/octocat)\n\n"
+	if !strings.HasSuffix(string(notes), want) {
+		t.Fatalf("release notes:\n%s\nwant suffix:\n%s", notes, want)
+	}
+
+	_, notes, _ = renderReleaseSection("v1.0.0", "2026-01-01", items[:0], releaseManifest{})
+	if strings.Contains(string(notes), "Contributors") {
+		t.Fatalf("no authors, no Contributors section:\n%s", notes)
+	}
+}
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// cmdCut runs a whole release in one step: compute the next version, merge fragments into the
+// changelog, write release notes, archive fragments, update version files, commit, tag, push,
+// and create the GitHub Release. Each step after the merge can be skipped, and --dry-run
+// prints the plan and notes without changing anything.
+func cmdCut(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("cut", flag.ContinueOnError)
+	fs.SetOutput(ioDiscard{})
+	version := fs.String("version", "", "release this version instead of the one computed from the latest release and fragments")
+	date := fs.String("date", "", "release date YYYY-MM-DD (default: today in changelog.timezone, or at SOURCE_DATE_EPOCH)")
+	fragmentsDir := fs.String("fragments", "changelog.d", "fragments directory")
+	changelogPath := fs.String("changelog", "CHANGELOG.md", "changelog path")
+	archiveDir := fs.String("archive", "changelog.d/archived", "archive directory")
+	releaseNotesOut := fs.String("release-notes-out", ".papertrail/release-notes.md", "write release notes body to this path")
+	manifestPath := fs.String("manifest", "", "optional release config YAML path")
+	skipVersionCheck := fs.Bool("skip-version-check", false, "do not require --version to directly follow the latest release or to match the Go module major path")
+	var versionFiles stringList
+	fs.Var(&versionFiles, "version-file", "file whose previous version string is replaced with the new one (repeatable)")
+	dryRun := fs.Bool("dry-run", false, "print the plan and release notes without changing anything")
+	noCommit := fs.Bool("no-commit", false, "do not commit the release changes (requires --no-tag)")
+	noTag := fs.Bool("no-tag", false, "do not create the release tag")
+	push := fs.Bool("push", false, "push the release commit and tag")
+	remote := fs.String("remote", "origin", "remote to push to (with --push)")
+	noGitHubRelease := fs.Bool("no-github-release", false, "do not create a GitHub (or Gitea) Release even when a token is set")
+	repo := fs.String("repo", os.Getenv("GITHUB_REPOSITORY"), "GitHub repository owner/name for the release (default: $GITHUB_REPOSITORY)")
+	draft := fs.Bool("draft", false, "create the GitHub Release as a draft")
+	lintOutput := fs.Bool("lint-output", false, "check the generated section and release notes against markdownlint rules before writing")
+	wait := fs.Duration("wait", 0, "wait this long (e.g. 2m) for another merge, unmerge, or cut of the changelog to finish instead of failing")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *noCommit && !*noTag {
+		return fmt.Errorf("--no-commit requires --no-tag: the tag would point at the commit before the release")
+	}
+	if !*dryRun {
+		unlock, err := acquireChangelogLock(ctx, *changelogPath, *wait)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+
+	manifest, err := loadManifestDefault(*manifestPath)
+	if err != nil {
+		return err
+	}
+	_, items, cleanup, err := loadPendingItems(ctx, *fragmentsDir, *archiveDir, manifest)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+	if len(manifest.Versioning.Components) > 0 {
+		// Independently versioned components are released with merge --component.
+		if _, items, err = streamItems(nil, items, manifest, ""); err != nil {
+			return err
+		}
+	}
+
+	latest, hasLatest, err := latestRelease(ctx, hostFS{}, *changelogPath, "v")
+	if err != nil {
+		return err
+	}
+	prev := latest.Version
+	if !hasLatest {
+		prev = semver{}
+	}
+	kind := nextBump(items, manifest, prev)
+	next := prev.Bump(kind)
+	if *version != "" {
+		if next, err = parseSemver(*version); err != nil {
+			return fmt.Errorf("invalid --version %q: %v (expected vMAJOR.MINOR.PATCH)", *version, err)
+		}
+		if hasLatest && !*skipVersionCheck {
+			if err := checkNextVersion(next, latest); err != nil {
+				return err
+			}
+		}
+	}
+	if !*skipVersionCheck {
+		if err := checkGoModuleVersion(hostFS{}, next); err != nil {
+			return fmt.Errorf("%w; pass --skip-version-check to release it anyway", err)
+		}
+	}
+	tag := next.String()
+
+	releaseDate, err := resolveReleaseDate(ctx, *date, "", manifest)
+	if err != nil {
+		return err
+	}
+	section, releaseNotes, err := renderReleaseSection(tag, releaseDate, items, manifest)
+	if err != nil {
+		return err
+	}
+	releaseNotes = trimTrailingNewlines(releaseNotes)
+	if *lintOutput || manifest.Changelog.Style.Markdownlint {
+		if err := lintReleaseOutput(section, releaseNotes); err != nil {
+			return err
+		}
+	}
+
+	gh, err := newForge()
+	if err != nil {
+		return err
+	}
+	createRelease := !*noGitHubRelease && gh.hasToken()
+	if createRelease {
+		switch {
+		case *noTag || !*push:
+			return fmt.Errorf("creating the GitHub Release needs the tag on GitHub: pass --push (and not --no-tag), or --no-github-release")
+		case strings.TrimSpace(*repo) == "":
+			return fmt.Errorf("creating the GitHub Release needs --repo owner/name (or GITHUB_REPOSITORY)")
+		}
+	}
+
+	// Apply version files in memory first so a missing version fails before anything is written.
+	updated := make(map[string][]byte, len(versionFiles))
+	for _, p := range versionFiles {
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		nb, n := replaceVersion(string(b), prev, next)
+		if n == 0 {
+			return fmt.Errorf("%s does not contain the previous version %s", p, prev)
+		}
+		updated[p] = []byte(nb)
+	}
+	if !*noTag {
+		if _, err := runGit(ctx, "rev-parse", "--quiet", "--verify", "refs/tags/"+tag); err == nil {
+			return fmt.Errorf("tag %s already exists", tag)
+		}
+	}
+
+	local := 0
+	for _, it := range items {
+		if !it.External {
+			local++
+		}
+	}
+	steps := []string{
+		fmt.Sprintf("release %s (previous: %s, %d fragment(s))", tag, prevLabel(prev, hasLatest), len(items)),
+		fmt.Sprintf("update %s and write %s", *changelogPath, *releaseNotesOut),
+		fmt.Sprintf("archive %d fragment(s) under %s/%s", local, *archiveDir, tag),
+	}
+	for _, p := range versionFiles {
+		steps = append(steps, fmt.Sprintf("set the version in %s", p))
+	}
+	if !*noCommit {
+		steps = append(steps, fmt.Sprintf("commit %q", releaseCommitMessage(tag)))
+	}
+	if !*noTag {
+		steps = append(steps, "tag "+tag)
+	}
+	if *push && (!*noCommit || !*noTag) {
+		steps = append(steps, "push to "+*remote)
+	}
+	if createRelease {
+		steps = append(steps, "create the GitHub Release in "+*repo)
+	}
+
+	if *dryRun {
+		fmt.Fprintln(os.Stdout, "Dry run; would:")
+		for _, s := range steps {
+			fmt.Fprintln(os.Stdout, "  - "+s)
+		}
+		fmt.Fprintln(os.Stdout)
+		_, _ = os.Stdout.Write(releaseNotes)
+		return nil
+	}
+
+	if *releaseNotesOut != "" {
+		if err := os.MkdirAll(filepath.Dir(*releaseNotesOut), 0755); err != nil {
+			return err
+		}
+	}
+	if err := writeRelease(hostFS{}, releaseOutput{
+		Version:         tag,
+		ChangelogPath:   *changelogPath,
+		ArchiveDir:      *archiveDir,
+		ReleaseNotesOut: *releaseNotesOut,
+		Section:         section,
+		ReleaseNotes:    releaseNotes,
+		Items:           items,
+		Style:           manifest.Changelog.Style,
+		Compare:         changelogCompare(manifest, "v"),
+		VersionFiles:    updated,
+	}); err != nil {
+		return err
+	}
+
+	if !*noCommit {
+		paths := []string{*changelogPath, *fragmentsDir, *archiveDir, *releaseNotesOut}
+		paths = append(paths, versionFiles...)
+		if err := commitPaths(ctx, releaseCommitMessage(tag), paths); err != nil {
+			return err
+		}
+	}
+	if !*noTag {
+		notes, err := sectionReleaseNotes(hostFS{}, *changelogPath, tag)
+		if err != nil {
+			return err
+		}
+		if _, err := runGit(ctx, "tag", "-a", tag, "-m", releaseTagMessage(tag, notes)); err != nil {
+			return err
+		}
+	}
+	if *push {
+		refs := []string{}
+		if !*noCommit {
+			refs = append(refs, "HEAD")
+		}
+		if !*noTag {
+			refs = append(refs, "refs/tags/"+tag)
+		}
+		if len(refs) > 0 {
+			if _, err := runGit(ctx, append([]string{"push", *remote}, refs...)...); err != nil {
+				return err
+			}
+		}
+	}
+	if createRelease {
+		url, err := gh.createRelease(ctx, *repo, githubRelease{TagName: tag, Name: tag, Body: string(releaseNotes), Draft: *draft})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stdout, "created "+url)
+	}
+	fmt.Fprintln(os.Stdout, tag)
+	return writeActionsOutputs(releaseOutputs(tag, kind, len(items), *releaseNotesOut)...)
+}
+
+func prevLabel(prev semver, known bool) string {
+	if !known {
+		return "none"
+	}
+	return prev.String()
+}
+
+func releaseCommitMessage(tag string) string {
+	return "chore(release): " + tag
+}
+
+// commitPaths stages paths (skipping ignored ones) and commits them.
+func commitPaths(ctx context.Context, msg string, paths []string) error {
+	var add []string
+	for _, p := range paths {
+		if p == "" {
+			continue
+		}
+		if _, err := runGit(ctx, "check-ignore", "--quiet", p); err == nil {
+			continue
+		}
+		add = append(add, p)
+	}
+	if _, err := runGit(ctx, append([]string{"add", "--all", "--"}, add...)...); err != nil {
+		return err
+	}
+	_, err := runGit(ctx, "commit", "--quiet", "-m", msg)
+	return err
+}
+
+// replaceVersion replaces whole-version occurrences of prev with next, with or without the
+// leading "v" (the "v" is kept as written). "1.2.3" inside "11.2.3" or "1.2.3-rc.1" is left
+// alone.
+func replaceVersion(s string, prev, next semver) (string, int) {
+	old := strings.TrimPrefix(prev.String(), "v")
+	repl := strings.TrimPrefix(next.String(), "v")
+	var b strings.Builder
+	n := 0
+	for {
+		i := strings.Index(s, old)
+		if i < 0 {
+			b.WriteString(s)
+			return b.String(), n
+		}
+		end := i + len(old)
+		if versionBoundaryBefore(s, i) && versionBoundaryAfter(s, end) {
+			b.WriteString(s[:i])
+			b.WriteString(repl)
+			n++
+		} else {
+			b.WriteString(s[:end])
+		}
+		s = s[end:]
+	}
+}
+
+func versionBoundaryBefore(s string, i int) bool {
+	if i == 0 {
+		return true
+	}
+	c := s[i-1]
+	if c == 'v' {
+		return i == 1 || !isVersionChar(s[i-2])
+	}
+	return !isVersionChar(c)
+}
+
+func versionBoundaryAfter(s string, end int) bool {
+	if end == len(s) {
+		return true
+	}
+	c := s[end]
+	if c == '.' {
+		// A sentence-ending period is fine; "1.2.3.4" is a different version.
+		return end+1 == len(s) || !isDigit(s[end+1])
+	}
+	return !isVersionChar(c) && c != '-' && c != '+'
+}
+
+func isVersionChar(c byte) bool {
+	return isDigit(c) || c == '.' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
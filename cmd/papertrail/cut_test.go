@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReplaceVersion(t *testing.T) {
+	t.Parallel()
+
+	prev, next := mustSemver(t, "v1.2.3"), mustSemver(t, "v1.3.0")
+	for _, tc := range []struct {
+		in, want string
+		n        int
+	}{
+		{"1.2.3\n", "1.3.0\n", 1},
+		{`{"version": "1.2.3"}`, `{"version": "1.3.0"}`, 1},
+		{"const Version = \"v1.2.3\" // see v1.2.3.", "const Version = \"v1.3.0\" // see v1.3.0.", 2},
+		{"11.2.3 1.2.34 1.2.3-rc.1 1.2.3.4 dev1.2.3", "11.2.3 1.2.34 1.2.3-rc.1 1.2.3.4 dev1.2.3", 0},
+	} {
+		got, n := replaceVersion(tc.in, prev, next)
+		if got != tc.want || n != tc.n {
+			t.Fatalf("replaceVersion(%q) = %q, %d; want %q, %d", tc.in, got, n, tc.want, tc.n)
+		}
+	}
+}
+
+// setupCutRepo creates a repository released at v1.2.0 with one pending feature fragment and
+// a git identity for the commits and tags cut makes.
+func setupCutRepo(t *testing.T) string {
+	t.Helper()
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GH_TOKEN", "")
+	for _, k := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(k, "test")
+	}
+	for _, k := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(k, "test@example.com")
+	}
+	dir := initGitRepo(t, map[string]string{
+		".papertrail.config.yml":   "versioning:\n  rules:\n    feature: minor\n",
+		"CHANGELOG.md":             "# Changelog\n\n## v1.2.0 (2026-01-01)\n\n- old\n",
+		"VERSION":                  "1.2.0\n",
+		"changelog.d/20260201.yml": "component: CLI\ntype: feature\nsummary: Add cut\n",
+	})
+	gitIn(t, dir, "tag", "v1.2.0")
+	return dir
+}
+
+func gitOutput(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git %v: %v", args, err)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestCmdCut(t *testing.T) {
+	dir := setupCutRepo(t)
+
+	if err := cmdCut(t.Context(), []string{"--version-file", "VERSION", "--date", "2026-02-01", "--dry-run"}); err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if s := gitOutput(t, dir, "status", "--porcelain"); s != "" {
+		t.Fatalf("dry run changed the work tree:\n%s", s)
+	}
+
+	if err := cmdCut(t.Context(), []string{"--version-file", "VERSION", "--date", "2026-02-01"}); err != nil {
+		t.Fatalf("cut: %v", err)
+	}
+	changelog, _ := os.ReadFile(filepath.Join(dir, "CHANGELOG.md"))
+	if !strings.Contains(string(changelog), "## v1.3.0 (2026-02-01)") {
+		t.Fatalf("changelog:\n%s", changelog)
+	}
+	if v, _ := os.ReadFile(filepath.Join(dir, "VERSION")); string(v) != "1.3.0\n" {
+		t.Fatalf("VERSION = %q", v)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "changelog.d", "archived", "v1.3.0", "20260201.yml")); err != nil {
+		t.Fatalf("fragment not archived: %v", err)
+	}
+	if s := gitOutput(t, dir, "status", "--porcelain"); s != "" {
+		t.Fatalf("release changes not committed:\n%s", s)
+	}
+	if msg := gitOutput(t, dir, "log", "-1", "--format=%s"); msg != "chore(release): v1.3.0" {
+		t.Fatalf("commit message %q", msg)
+	}
+	if tag := gitOutput(t, dir, "describe", "--exact-match", "HEAD"); tag != "v1.3.0" {
+		t.Fatalf("HEAD tag %q", tag)
+	}
+
+	// Nothing left to release.
+	if err := cmdCut(t.Context(), nil); err == nil || !strings.Contains(err.Error(), "no fragments") {
+		t.Fatalf("expected no fragments error, got %v", err)
+	}
+}
+
+func TestCmdCut_Locked(t *testing.T) {
+	dir := setupCutRepo(t)
+	host, _ := os.Hostname()
+	if err := os.WriteFile(filepath.Join(dir, "CHANGELOG.md.lock"), fmt.Appendf(nil, "%d %s 2026-01-01T00:00:00Z\n", os.Getpid(), host), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := cmdCut(t.Context(), []string{"--date", "2026-02-01", "--no-commit", "--no-tag", "--wait", "200ms"})
+	if err == nil || !strings.Contains(err.Error(), "CHANGELOG.md is locked") {
+		t.Fatalf("expected a lock error, got %v", err)
+	}
+	if changelog, _ := os.ReadFile(filepath.Join(dir, "CHANGELOG.md")); strings.Contains(string(changelog), "v1.3.0") {
+		t.Fatalf("a locked cut must not write:\n%s", changelog)
+	}
+}
+
+func TestCmdCut_VersionFileMissingVersion(t *testing.T) {
+	dir := setupCutRepo(t)
+	if err := os.WriteFile(filepath.Join(dir, "VERSION"), []byte("0.9.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := cmdCut(t.Context(), []string{"--version-file", "VERSION", "--no-commit", "--no-tag"})
+	if err == nil || !strings.Contains(err.Error(), "previous version v1.2.0") {
+		t.Fatalf("expected missing version error, got %v", err)
+	}
+	if changelog, _ := os.ReadFile(filepath.Join(dir, "CHANGELOG.md")); strings.Contains(string(changelog), "v1.3.0") {
+		t.Fatalf("changelog written despite the error")
+	}
+}
+
+func TestCmdCut_PushAndGitHubRelease(t *testing.T) {
+	dir := setupCutRepo(t)
+	remote := t.TempDir()
+	gitIn(t, remote, "init", "-q", "--bare")
+	gitIn(t, dir, "remote", "add", "origin", remote)
+
+	var got githubRelease
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/repos/org/repo/releases" || r.Header.Get("Authorization") != "Bearer tok" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = w.Write([]byte(`{"html_url": "https://github.com/org/repo/releases/tag/v1.3.0"}`))
+	}))
+	defer srv.Close()
+	t.Setenv("GITHUB_API_URL", srv.URL)
+	t.Setenv("GITHUB_TOKEN", "tok")
+
+	if err := cmdCut(t.Context(), []string{"--repo", "org/repo"}); err == nil || !strings.Contains(err.Error(), "--push") {
+		t.Fatalf("expected a token without --push to fail, got %v", err)
+	}
+	if err := cmdCut(t.Context(), []string{"--repo", "org/repo", "--push"}); err != nil {
+		t.Fatalf("cut: %v", err)
+	}
+	if got.TagName != "v1.3.0" || !strings.Contains(got.Body, "Add cut.") {
+		t.Fatalf("release request: %+v", got)
+	}
+	if tags := gitOutput(t, remote, "tag", "--list"); tags != "v1.3.0" {
+		t.Fatalf("remote tags %q", tags)
+	}
+}
+
+func TestCmdCut_NoCommitRequiresNoTag(t *testing.T) {
+	dir := setupCutRepo(t)
+	err := cmdCut(t.Context(), []string{"--date", "2026-02-01", "--no-commit"})
+	if err == nil || !strings.Contains(err.Error(), "--no-commit requires --no-tag") {
+		t.Fatalf("expected a --no-tag error, got %v", err)
+	}
+	if tags := gitOutput(t, dir, "tag", "--list", "v1.3.0"); tags != "" {
+		t.Fatalf("tagged %s", tags)
+	}
+	if s := gitOutput(t, dir, "status", "--porcelain"); s != "" {
+		t.Fatalf("rejected cut changed the work tree:\n%s", s)
+	}
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+// dependency is a named dependency at one version. Version may list several versions
+// (comma-separated) when an SBOM contains the same package more than once.
+type dependency struct {
+	Name    string
+	Version string
+}
+
+type dependencyChange struct {
+	Name     string
+	From, To string
+}
+
+// dependencyDiff is the change in a dependency set between two releases, sorted by name.
+type dependencyDiff struct {
+	Added      []dependency
+	Removed    []dependency
+	Upgraded   []dependencyChange
+	Downgraded []dependencyChange
+}
+
+func (d dependencyDiff) empty() bool {
+	return len(d.Added)+len(d.Removed)+len(d.Upgraded)+len(d.Downgraded) == 0
+}
+
+// diffDependencies compares two dependency sets by name. A change counts as a downgrade
+// only when both versions are SemVer and the new one has lower precedence.
+func diffDependencies(old, new []dependency) dependencyDiff {
+	oldBy, newBy := dependencyMap(old), dependencyMap(new)
+	var d dependencyDiff
+	for _, name := range sortedKeys(newBy) {
+		from, ok := oldBy[name]
+		to := newBy[name]
+		switch {
+		case !ok:
+			d.Added = append(d.Added, dependency{Name: name, Version: to})
+		case from != to:
+			c := dependencyChange{Name: name, From: from, To: to}
+			if versionLess(to, from) {
+				d.Downgraded = append(d.Downgraded, c)
+			} else {
+				d.Upgraded = append(d.Upgraded, c)
+			}
+		}
+	}
+	for _, name := range sortedKeys(oldBy) {
+		if _, ok := newBy[name]; !ok {
+			d.Removed = append(d.Removed, dependency{Name: name, Version: oldBy[name]})
+		}
+	}
+	return d
+}
+
+func dependencyMap(deps []dependency) map[string]string {
+	versions := map[string][]string{}
+	for _, dep := range deps {
+		versions[dep.Name] = append(versions[dep.Name], dep.Version)
+	}
+	m := make(map[string]string, len(versions))
+	for name, vs := range versions {
+		sort.Strings(vs)
+		uniq := vs[:0]
+		for i, v := range vs {
+			if i == 0 || v != vs[i-1] {
+				uniq = append(uniq, v)
+			}
+		}
+		m[name] = strings.Join(uniq, ", ")
+	}
+	return m
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func versionLess(a, b string) bool {
+	va, err := parseSemver("v" + strings.TrimPrefix(a, "v"))
+	if err != nil {
+		return false
+	}
+	vb, err := parseSemver("v" + strings.TrimPrefix(b, "v"))
+	if err != nil {
+		return false
+	}
+	return va.Compare(vb) < 0
+}
+
+// writeDependencyDiff renders d under a "### <title>" heading; nothing is written when
+// there are no changes.
+func writeDependencyDiff(buf *bytes.Buffer, title string, d dependencyDiff) {
+	if d.empty() {
+		return
+	}
+	fmt.Fprintf(buf, "### %s\n\n", title)
+	writeDeps := func(label string, deps []dependency) {
+		if len(deps) == 0 {
+			return
+		}
+		fmt.Fprintf(buf, "**%s**\n\n", label)
+		for _, dep := range deps {
+			fmt.Fprintf(buf, "- `%s` %s\n", dep.Name, dep.Version)
+		}
+		buf.WriteString("\n")
+	}
+	writeChanges := func(label string, changes []dependencyChange) {
+		if len(changes) == 0 {
+			return
+		}
+		fmt.Fprintf(buf, "**%s**\n\n", label)
+		for _, c := range changes {
+			fmt.Fprintf(buf, "- `%s` %s → %s\n", c.Name, c.From, c.To)
+		}
+		buf.WriteString("\n")
+	}
+	writeDeps("Added", d.Added)
+	writeDeps("Removed", d.Removed)
+	writeChanges("Upgraded", d.Upgraded)
+	writeChanges("Downgraded", d.Downgraded)
+}
+
+// parseSBOM reads the packages of an SPDX or CycloneDX JSON document. The package the SBOM
+// describes (the project itself) is excluded.
+func parseSBOM(b []byte) ([]dependency, error) {
+	var probe struct {
+		BOMFormat   string `json:"bomFormat"`
+		SPDXVersion string `json:"spdxVersion"`
+	}
+	if err := json.Unmarshal(b, &probe); err != nil {
+		return nil, fmt.Errorf("invalid SBOM JSON: %w", err)
+	}
+	switch {
+	case probe.SPDXVersion != "":
+		return parseSPDX(b)
+	case strings.EqualFold(probe.BOMFormat, "CycloneDX"):
+		return parseCycloneDX(b)
+	}
+	return nil, fmt.Errorf("unrecognized SBOM format (expected SPDX or CycloneDX JSON)")
+}
+
+func parseSPDX(b []byte) ([]dependency, error) {
+	var doc struct {
+		DocumentDescribes []string `json:"documentDescribes"`
+		Packages          []struct {
+			SPDXID      string `json:"SPDXID"`
+			Name        string `json:"name"`
+			VersionInfo string `json:"versionInfo"`
+		} `json:"packages"`
+		Relationships []struct {
+			Element string `json:"spdxElementId"`
+			Type    string `json:"relationshipType"`
+			Related string `json:"relatedSpdxElement"`
+		} `json:"relationships"`
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, fmt.Errorf("invalid SPDX document: %w", err)
+	}
+	described := map[string]bool{}
+	for _, id := range doc.DocumentDescribes {
+		described[id] = true
+	}
+	for _, r := range doc.Relationships {
+		if r.Element == "SPDXRef-DOCUMENT" && r.Type == "DESCRIBES" {
+			described[r.Related] = true
+		}
+	}
+	var deps []dependency
+	for _, p := range doc.Packages {
+		if described[p.SPDXID] || p.Name == "" {
+			continue
+		}
+		deps = append(deps, dependency{Name: p.Name, Version: p.VersionInfo})
+	}
+	return deps, nil
+}
+
+type cycloneDXComponent struct {
+	Group      string               `json:"group"`
+	Name       string               `json:"name"`
+	Version    string               `json:"version"`
+	Components []cycloneDXComponent `json:"components"`
+}
+
+func parseCycloneDX(b []byte) ([]dependency, error) {
+	var doc struct {
+		Components []cycloneDXComponent `json:"components"`
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, fmt.Errorf("invalid CycloneDX document: %w", err)
+	}
+	var deps []dependency
+	var walk func([]cycloneDXComponent)
+	walk = func(cs []cycloneDXComponent) {
+		for _, c := range cs {
+			name := c.Name
+			if c.Group != "" {
+				name = c.Group + "/" + c.Name
+			}
+			if name != "" {
+				deps = append(deps, dependency{Name: name, Version: c.Version})
+			}
+			walk(c.Components)
+		}
+	}
+	walk(doc.Components)
+	return deps, nil
+}
+
+// goModDependencyDiff diffs the go.mod requirements at baseRef against HEAD. A go.mod
+// missing at baseRef counts as having no requirements.
+func goModDependencyDiff(ctx context.Context, baseRef string) (dependencyDiff, error) {
+	if _, err := runGit(ctx, "rev-parse", "--verify", "--quiet", baseRef+"^{commit}"); err != nil {
+		return dependencyDiff{}, fmt.Errorf("unknown git ref %q", baseRef)
+	}
+	var sets [2][]dependency
+	for i, ref := range []string{baseRef, "HEAD"} {
+		b, err := gitFS{ctx: ctx, ref: ref}.ReadFile("go.mod")
+		if err != nil {
+			if i == 0 && errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return dependencyDiff{}, fmt.Errorf("reading go.mod at %s: %w", ref, err)
+		}
+		if sets[i], err = parseGoModRequires(b); err != nil {
+			return dependencyDiff{}, fmt.Errorf("go.mod at %s: %w", ref, err)
+		}
+	}
+	return diffDependencies(sets[0], sets[1]), nil
+}
+
+// parseGoModRequires returns the modules listed in go.mod require directives, both single
+// line and block form. Comments (including "// indirect") are ignored.
+func parseGoModRequires(b []byte) ([]dependency, error) {
+	var deps []dependency
+	inBlock := false
+	for i, line := range strings.Split(string(b), "\n") {
+		if c := strings.Index(line, "//"); c >= 0 {
+			line = line[:c]
+		}
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+			continue
+		case inBlock && fields[0] == ")":
+			inBlock = false
+			continue
+		case !inBlock && fields[0] == "require" && len(fields) == 2 && fields[1] == "(":
+			inBlock = true
+			continue
+		case !inBlock && fields[0] == "require":
+			fields = fields[1:]
+		case !inBlock:
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: malformed require", i+1)
+		}
+		deps = append(deps, dependency{Name: strings.Trim(fields[0], `"`), Version: fields[1]})
+	}
+	return deps, nil
+}
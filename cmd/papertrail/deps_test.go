@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseSBOM(t *testing.T) {
+	t.Parallel()
+
+	spdx := `{
+		"spdxVersion": "SPDX-2.3",
+		"documentDescribes": ["SPDXRef-root"],
+		"packages": [
+			{"SPDXID": "SPDXRef-root", "name": "example.com/app", "versionInfo": "v1.0.0"},
+			{"SPDXID": "SPDXRef-a", "name": "gopkg.in/yaml.v3", "versionInfo": "v3.0.1"}
+		]
+	}`
+	deps, err := parseSBOM([]byte(spdx))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(deps) != 1 || deps[0] != (dependency{Name: "gopkg.in/yaml.v3", Version: "v3.0.1"}) {
+		t.Fatalf("unexpected SPDX deps: %+v", deps)
+	}
+
+	cdx := `{
+		"bomFormat": "CycloneDX",
+		"metadata": {"component": {"name": "app"}},
+		"components": [
+			{"group": "org.example", "name": "lib", "version": "2.0.0", "components": [{"name": "inner", "version": "1.0"}]}
+		]
+	}`
+	deps, err = parseSBOM([]byte(cdx))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(deps) != 2 || deps[0].Name != "org.example/lib" || deps[1].Name != "inner" {
+		t.Fatalf("unexpected CycloneDX deps: %+v", deps)
+	}
+
+	if _, err := parseSBOM([]byte(`{"name": "x"}`)); err == nil {
+		t.Fatalf("expected error for an unknown format")
+	}
+}
+
+func TestDiffDependencies(t *testing.T) {
+	t.Parallel()
+
+	old := []dependency{{"a", "v1.0.0"}, {"b", "v2.0.0"}, {"c", "1.0"}, {"d", "v1.1.0"}}
+	cur := []dependency{{"a", "v1.2.0"}, {"c", "1.0"}, {"d", "v1.0.0"}, {"e", "v0.1.0"}}
+	var buf bytes.Buffer
+	writeDependencyDiff(&buf, "Dependency changes", diffDependencies(old, cur))
+	want := "### Dependency changes\n\n" +
+		"**Added**\n\n- `e` v0.1.0\n\n" +
+		"**Removed**\n\n- `b` v2.0.0\n\n" +
+		"**Upgraded**\n\n- `a` v1.0.0 → v1.2.0\n\n" +
+		"**Downgraded**\n\n- `d` v1.1.0 → v1.0.0\n\n"
+	if buf.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	writeDependencyDiff(&buf, "Dependency changes", diffDependencies(old, old))
+	if buf.Len() != 0 {
+		t.Fatalf("expected no section for identical sets, got:\n%s", buf.String())
+	}
+}
+
+func TestGoModDependencyDiff(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{
+		"go.mod": "module example.com/app\n\ngo 1.22\n\nrequire (\n\tgolang.org/x/text v0.3.0\n\tgopkg.in/yaml.v3 v3.0.0 // indirect\n)\n",
+	})
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("tag", "v1.0.0")
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app\n\ngo 1.22\n\nrequire gopkg.in/yaml.v3 v3.0.1\nrequire github.com/x/y v1.0.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("commit", "-q", "-am", "deps")
+
+	d, err := goModDependencyDiff(t.Context(), "v1.0.0")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var buf bytes.Buffer
+	writeDependencyDiff(&buf, "Dependency changes", d)
+	want := "### Dependency changes\n\n" +
+		"**Added**\n\n- `github.com/x/y` v1.0.0\n\n" +
+		"**Removed**\n\n- `golang.org/x/text` v0.3.0\n\n" +
+		"**Upgraded**\n\n- `gopkg.in/yaml.v3` v3.0.0 → v3.0.1\n\n"
+	if buf.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	if _, err := goModDependencyDiff(t.Context(), "v9.9.9"); err == nil {
+		t.Fatalf("expected error for an unknown ref")
+	}
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// cmdFixChangelog repairs common CHANGELOG corruption: stray preview blocks, release
+// headings merge cannot find, duplicate version sections, and missing or doubled blank
+// lines. It prints what it changed as a diff; --dry-run stops there.
+func cmdFixChangelog(_ context.Context, args []string) error {
+	fs := flag.NewFlagSet("fix-changelog", flag.ContinueOnError)
+	fs.SetOutput(ioDiscard{})
+	changelogPath := fs.String("changelog", "CHANGELOG.md", "changelog path")
+	dryRun := fs.Bool("dry-run", false, "print the repairs as a diff without writing them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	fsys := hostFS{}
+	b, err := readFile(fsys, *changelogPath)
+	if err != nil {
+		return err
+	}
+	fixed, repairs := repairChangelog(string(b))
+	if len(repairs) == 0 {
+		fmt.Fprintf(os.Stdout, "%s: nothing to repair\n", *changelogPath)
+		return nil
+	}
+	for _, r := range repairs {
+		fmt.Fprintf(os.Stdout, "%s: %s\n", *changelogPath, r)
+	}
+	fmt.Fprint(os.Stdout, unifiedDiff(*changelogPath, string(b), fixed))
+	if *dryRun {
+		return nil
+	}
+	return fsys.WriteFile(*changelogPath, []byte(fixed), 0644)
+}
+
+// repairChangelog applies every repair to doc and describes each one that changed it.
+func repairChangelog(doc string) (string, []string) {
+	var repairs []string
+	crlf := strings.Contains(doc, "\r\n")
+	doc = strings.ReplaceAll(doc, "\r\n", "\n")
+	var fixedHeadings []string
+	doc, fixedHeadings = fixReleaseHeadings(doc)
+	for _, h := range fixedHeadings {
+		repairs = append(repairs, "fixed release heading "+h)
+	}
+	if out, n := removePreviewBlocks(doc); n > 0 {
+		doc = out
+		repairs = append(repairs, fmt.Sprintf("removed %d stray changelog preview block(s)", n))
+	}
+	if out, ok := ensureChangelogTitle(doc); ok {
+		doc = out
+		repairs = append(repairs, `added a "# Changelog" title so new sections are inserted above the first release`)
+	}
+	var merged []string
+	doc, merged = mergeDuplicateSections(doc)
+	for _, k := range merged {
+		repairs = append(repairs, "merged duplicate sections for "+k)
+	}
+	if out := normalizeBlankLines(doc); out != doc {
+		doc = out
+		repairs = append(repairs, "fixed blank lines around headings and lists")
+	}
+	if crlf {
+		doc = strings.ReplaceAll(doc, "\n", "\r\n")
+	}
+	return doc, repairs
+}
+
+// removePreviewBlocks drops preview output (the marker through the next "#" or "##"
+// heading) that was pasted into the changelog. Release headings must be fixed first so the
+// block ends at the next release.
+func removePreviewBlocks(doc string) (string, int) {
+	var out []string
+	n := 0
+	skipping := false
+	for _, line := range strings.Split(doc, "\n") {
+		if strings.TrimSpace(line) == previewMarker {
+			skipping = true
+			n++
+			continue
+		}
+		if skipping && (strings.HasPrefix(line, "# ") || strings.HasPrefix(line, "## ")) {
+			skipping = false
+		}
+		if !skipping {
+			out = append(out, line)
+		}
+	}
+	return strings.Join(out, "\n"), n
+}
+
+// brokenReleaseHeadingRE matches release headings merge does not recognize as anchors:
+// "##v1.2.3" or "## 1.2.3" (no "v").
+var brokenReleaseHeadingRE = regexp.MustCompile(`^##\s*v?(\d+\.\d+\.\d+\S*)(.*)$`)
+
+func fixReleaseHeadings(doc string) (string, []string) {
+	var fixed []string
+	lines := strings.Split(doc, "\n")
+	forEachOutsideFence(lines, func(i int) {
+		m := brokenReleaseHeadingRE.FindStringSubmatch(lines[i])
+		if m == nil {
+			return
+		}
+		if _, err := parseSemver("v" + m[1]); err != nil {
+			return
+		}
+		want := "## v" + m[1] + m[2]
+		if want != lines[i] {
+			fixed = append(fixed, fmt.Sprintf("%q → %q", lines[i], want))
+			lines[i] = want
+		}
+	})
+	return strings.Join(lines, "\n"), fixed
+}
+
+// ensureChangelogTitle adds a title when the file starts with a release heading: merge
+// inserts before the first "\n## v", so without one it would insert below that release.
+func ensureChangelogTitle(doc string) (string, bool) {
+	trimmed := strings.TrimLeft(doc, "\n")
+	if !strings.HasPrefix(trimmed, "## v") && !strings.HasPrefix(trimmed, "## 20") {
+		return doc, false
+	}
+	return "# Changelog\n\n" + trimmed, true
+}
+
+// mergeDuplicateSections folds later sections for the same version into the first one,
+// adding component groups and entries the first does not already have.
+func mergeDuplicateSections(doc string) (string, []string) {
+	preamble, sections := splitChangelog(doc)
+	first := map[string]int{}
+	var kept []changelogSection
+	var merged []string
+	for _, s := range sections {
+		i, dup := first[s.Key]
+		if !dup {
+			first[s.Key] = len(kept)
+			kept = append(kept, s)
+			continue
+		}
+		kept[i].Body = mergeSectionBodies(kept[i].Body, s.Body)
+		if !contains(merged, s.Key) {
+			merged = append(merged, s.Key)
+		}
+	}
+	if len(merged) == 0 {
+		return doc, nil
+	}
+	var out strings.Builder
+	out.WriteString(preamble)
+	for _, s := range kept {
+		out.WriteString(s.Body)
+	}
+	return out.String(), merged
+}
+
+// sectionBlock is a run of lines in a release section under one "###" heading (or before
+// the first one, with an empty heading).
+type sectionBlock struct {
+	Heading string
+	Lines   []string
+}
+
+func splitSectionBlocks(body string) (string, []sectionBlock) {
+	lines := strings.Split(strings.TrimRight(body, "\n"), "\n")
+	blocks := []sectionBlock{{}}
+	for _, line := range lines[1:] {
+		if strings.HasPrefix(line, "### ") {
+			blocks = append(blocks, sectionBlock{Heading: strings.TrimSpace(line)})
+			continue
+		}
+		if strings.TrimSpace(line) != "" {
+			blocks[len(blocks)-1].Lines = append(blocks[len(blocks)-1].Lines, line)
+		}
+	}
+	return lines[0], blocks
+}
+
+func mergeSectionBodies(a, b string) string {
+	heading, blocks := splitSectionBlocks(a)
+	_, extra := splitSectionBlocks(b)
+	for _, eb := range extra {
+		i := -1
+		for j := range blocks {
+			if blocks[j].Heading == eb.Heading {
+				i = j
+				break
+			}
+		}
+		if i < 0 {
+			blocks = append(blocks, eb)
+			continue
+		}
+		for _, l := range eb.Lines {
+			if !contains(blocks[i].Lines, l) {
+				blocks[i].Lines = append(blocks[i].Lines, l)
+			}
+		}
+	}
+	var out strings.Builder
+	out.WriteString(heading + "\n\n")
+	for _, blk := range blocks {
+		if blk.Heading == "" && len(blk.Lines) == 0 {
+			continue
+		}
+		if blk.Heading != "" {
+			out.WriteString(blk.Heading + "\n\n")
+		}
+		for _, l := range blk.Lines {
+			out.WriteString(l + "\n")
+		}
+		out.WriteString("\n")
+	}
+	return out.String()
+}
+
+// normalizeBlankLines puts one blank line around headings and before lists, collapses runs
+// of blank lines, and ends the file with a single newline. Fenced code is left alone, and
+// so are list continuation lines, which a blank line would split off the item.
+func normalizeBlankLines(doc string) string {
+	lines := strings.Split(strings.Trim(doc, "\n"), "\n")
+	fenced := make([]bool, len(lines))
+	inFence := false
+	for i, l := range lines {
+		if isFence(l) {
+			inFence = !inFence
+			fenced[i] = true
+			continue
+		}
+		fenced[i] = inFence
+	}
+
+	var out []string
+	blankLast := func() bool { return len(out) == 0 || strings.TrimSpace(out[len(out)-1]) == "" }
+	inList := false
+	for i, l := range lines {
+		if fenced[i] {
+			out = append(out, l)
+			inList = false
+			continue
+		}
+		if strings.TrimSpace(l) == "" {
+			if !blankLast() {
+				out = append(out, "")
+			}
+			inList = false
+			continue
+		}
+		heading := mdHeadingRE.MatchString(l)
+		item := mdListItemRE.MatchString(l)
+		if (heading || (item && !inList)) && !blankLast() {
+			out = append(out, "")
+		}
+		out = append(out, l)
+		if heading && i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" {
+			out = append(out, "")
+		}
+		inList = !heading && (item || inList)
+	}
+	return strings.Join(out, "\n") + "\n"
+}
+
+func isFence(line string) bool {
+	t := strings.TrimSpace(line)
+	return strings.HasPrefix(t, "```") || strings.HasPrefix(t, "~~~")
+}
+
+// forEachOutsideFence calls fn with the index of every line outside fenced code blocks.
+func forEachOutsideFence(lines []string, fn func(i int)) {
+	inFence := false
+	for i, l := range lines {
+		if isFence(l) {
+			inFence = !inFence
+			continue
+		}
+		if !inFence {
+			fn(i)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRepairChangelog(t *testing.T) {
+	t.Parallel()
+
+	in := "## 1.1.0 (2026-02-01)\n" +
+		"### CLI\n" +
+		"- **fix**: b.\n" +
+		"<!-- papertrail-preview -->\n" +
+		"### Changelog preview\n\n" +
+		"#### CLI\n\n" +
+		"- **feature**: pending.\n\n" +
+		"##v1.0.0 (2026-01-01)\n\n\n" +
+		"### CLI\n\n" +
+		"- **feature**: a.\n\n" +
+		"## v1.1.0 (2026-02-01)\n\n" +
+		"### CLI\n\n" +
+		"- **fix**: b.\n" +
+		"- **fix**: c.\n\n" +
+		"### Docs\n\n" +
+		"- **feature**: d.\n\n\n"
+	want := "# Changelog\n\n" +
+		"## v1.1.0 (2026-02-01)\n\n" +
+		"### CLI\n\n" +
+		"- **fix**: b.\n" +
+		"- **fix**: c.\n\n" +
+		"### Docs\n\n" +
+		"- **feature**: d.\n\n" +
+		"## v1.0.0 (2026-01-01)\n\n" +
+		"### CLI\n\n" +
+		"- **feature**: a.\n"
+
+	got, repairs := repairChangelog(in)
+	if got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s\n%s", got, want, unifiedDiff("CHANGELOG.md", want, got))
+	}
+	for _, r := range []string{"preview", `"## 1.1.0 (2026-02-01)"`, `"##v1.0.0 (2026-01-01)"`, "title", "duplicate sections for v1.1.0", "blank lines"} {
+		if !strings.Contains(strings.Join(repairs, "\n"), r) {
+			t.Fatalf("repairs %q missing %q", repairs, r)
+		}
+	}
+	if again, repairs := repairChangelog(got); again != got || len(repairs) > 0 {
+		t.Fatalf("repair is not idempotent: %q", repairs)
+	}
+}
+
+func TestRepairChangelog_KeepsContinuationsAndFences(t *testing.T) {
+	t.Parallel()
+
+	in := "# Changelog\n\n## v1.0.0\n\nIntro text.\n- item\nlazy continuation\n- next\n\n```\n## 1.0.0\n\n\n```\n"
+	want := "# Changelog\n\n## v1.0.0\n\nIntro text.\n\n- item\nlazy continuation\n- next\n\n```\n## 1.0.0\n\n\n```\n"
+	if got, _ := repairChangelog(in); got != want {
+		t.Fatalf("got:\n%q\nwant:\n%q", got, want)
+	}
+	crlf := strings.ReplaceAll(in, "\n", "\r\n")
+	if got, _ := repairChangelog(crlf); got != strings.ReplaceAll(want, "\n", "\r\n") {
+		t.Fatalf("CRLF line endings not kept: %q", got)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	t.Parallel()
+
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	b := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n"
+	want := "--- a/f\n+++ b/f\n" +
+		"@@ -1,6 +1,6 @@\n 1\n 2\n-3\n+three\n 4\n 5\n 6\n" +
+		"@@ -10,3 +10,4 @@\n 10\n 11\n 12\n+13\n"
+	if got := unifiedDiff("f", a, b); got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if got := unifiedDiff("f", a, a); got != "" {
+		t.Fatalf("diff of equal inputs: %q", got)
+	}
+}
+
+func TestCmdFixChangelog(t *testing.T) {
+	t.Chdir(t.TempDir())
+	orig := "# Changelog\n## v1.0.0\n- a\n"
+	if err := os.WriteFile("CHANGELOG.md", []byte(orig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmdFixChangelog(t.Context(), []string{"--dry-run"}); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile("CHANGELOG.md"); string(b) != orig {
+		t.Fatalf("dry run wrote the changelog:\n%s", b)
+	}
+	if err := cmdFixChangelog(t.Context(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile("CHANGELOG.md"); string(b) != "# Changelog\n\n## v1.0.0\n\n- a\n" {
+		t.Fatalf("repaired changelog:\n%q", b)
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Forges papertrail reads pull requests from; GitHub and Gitea also take comments and releases.
+const (
+	forgeGitHub    = "github"
+	forgeGitea     = "gitea"
+	forgeGitLab    = "gitlab"
+	forgeBitbucket = "bitbucket"
+)
+
+// detectForge names the forge the CI job runs for: PAPERTRAIL_FORGE when set, GitLab in merge
+// request pipelines (CI_MERGE_REQUEST_IID), Bitbucket in pull request pipelines
+// (BITBUCKET_PR_ID), Gitea in Gitea or Forgejo Actions (GITEA_ACTIONS or FORGEJO_ACTIONS),
+// and GitHub otherwise.
+func detectForge() (string, error) {
+	if f := strings.ToLower(strings.TrimSpace(os.Getenv("PAPERTRAIL_FORGE"))); f != "" {
+		switch f {
+		case forgeGitHub, forgeGitea, forgeGitLab, forgeBitbucket:
+			return f, nil
+		case "forgejo":
+			return forgeGitea, nil
+		}
+		return "", fmt.Errorf("invalid PAPERTRAIL_FORGE %q (expected github|gitea|forgejo|gitlab|bitbucket)", f)
+	}
+	switch {
+	case strings.TrimSpace(os.Getenv("CI_MERGE_REQUEST_IID")) != "":
+		return forgeGitLab, nil
+	case strings.TrimSpace(os.Getenv("BITBUCKET_PR_ID")) != "":
+		return forgeBitbucket, nil
+	case os.Getenv("GITEA_ACTIONS") == "true" || os.Getenv("FORGEJO_ACTIONS") == "true":
+		return forgeGitea, nil
+	}
+	return forgeGitHub, nil
+}
+
+// forge is the API pull request comments and releases are posted to.
+type forge interface {
+	hasToken() bool
+	listIssueComments(ctx context.Context, repo string, number int) ([]issueComment, error)
+	createIssueComment(ctx context.Context, repo string, number int, body string) (issueComment, error)
+	updateIssueComment(ctx context.Context, repo string, id int64, body string) (issueComment, error)
+	deleteIssueComment(ctx context.Context, repo string, id int64) error
+	createRelease(ctx context.Context, repo string, rel githubRelease) (string, error)
+}
+
+// newForge returns the API client of the detected forge.
+func newForge() (forge, error) {
+	name, err := detectForge()
+	if err != nil {
+		return nil, err
+	}
+	switch name {
+	case forgeGitHub:
+		return newGitHubClient(), nil
+	case forgeGitea:
+		return newGiteaClient()
+	}
+	return nil, fmt.Errorf("comments and releases are not supported on %s", name)
+}
+
+// pullRequest is what papertrail knows about the pull (or merge) request under test.
+type pullRequest struct {
+	// Repo is owner/name.
+	Repo   string
+	Number int
+	Title  string
+	// Body is the description, when the forge provides it.
+	Body   string
+	Labels []string
+	// Base is the commit the request's changes are diffed against, when the forge provides it.
+	Base string
+	// Files are the changed files when the forge lists them; nil means diffing against Base.
+	Files []string
+}
+
+// currentPullRequest reads the pull request under test from a GitLab merge request pipeline,
+// the Bitbucket API, or else the GitHub (or Gitea) event at GITHUB_EVENT_PATH.
+func currentPullRequest(ctx context.Context) (pullRequest, error) {
+	name, err := detectForge()
+	if err != nil {
+		return pullRequest{}, err
+	}
+	switch name {
+	case forgeGitLab:
+		return gitlabMergeRequest(), nil
+	case forgeBitbucket:
+		return bitbucketPullRequest(ctx)
+	}
+	evPath := strings.TrimSpace(os.Getenv("GITHUB_EVENT_PATH"))
+	if evPath == "" {
+		return pullRequest{}, fmt.Errorf("GITHUB_EVENT_PATH is required (or run in a GitLab merge request pipeline)")
+	}
+	return readPullRequestEvent(evPath)
+}
+
+// readPullRequestEvent reads a pull_request event. Gitea and Forgejo send GitHub's shape but
+// may leave out the pull request's number and base commit, which then come from the event's
+// number and the pull request's merge base.
+func readPullRequestEvent(eventPath string) (pullRequest, error) {
+	b, err := os.ReadFile(eventPath)
+	if err != nil {
+		return pullRequest{}, err
+	}
+	var ev struct {
+		Number      int `json:"number"`
+		PullRequest struct {
+			Number int    `json:"number"`
+			Title  string `json:"title"`
+			Body   string `json:"body"`
+			Labels []struct {
+				Name string `json:"name"`
+			} `json:"labels"`
+			Base struct {
+				SHA string `json:"sha"`
+			} `json:"base"`
+			MergeBase string `json:"merge_base"`
+		} `json:"pull_request"`
+		Repository struct {
+			FullName string `json:"full_name"`
+		} `json:"repository"`
+	}
+	if err := json.Unmarshal(b, &ev); err != nil {
+		return pullRequest{}, fmt.Errorf("invalid GitHub event JSON: %w", err)
+	}
+	pr := pullRequest{
+		Repo:   ev.Repository.FullName,
+		Number: ev.PullRequest.Number,
+		Title:  ev.PullRequest.Title,
+		Body:   ev.PullRequest.Body,
+		Base:   ev.PullRequest.Base.SHA,
+	}
+	if pr.Repo == "" {
+		pr.Repo = strings.TrimSpace(os.Getenv("GITHUB_REPOSITORY"))
+	}
+	if pr.Number == 0 {
+		pr.Number = ev.Number
+	}
+	if pr.Base == "" {
+		pr.Base = ev.PullRequest.MergeBase
+	}
+	for _, l := range ev.PullRequest.Labels {
+		if n := strings.TrimSpace(l.Name); n != "" {
+			pr.Labels = append(pr.Labels, n)
+		}
+	}
+	pr.Labels = sortedUnique(pr.Labels)
+	return pr, nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectForge(t *testing.T) {
+	for _, tc := range []struct {
+		env  map[string]string
+		want string
+	}{
+		{nil, forgeGitHub},
+		{map[string]string{"CI_MERGE_REQUEST_IID": "3"}, forgeGitLab},
+		{map[string]string{"BITBUCKET_PR_ID": "5"}, forgeBitbucket},
+		{map[string]string{"GITEA_ACTIONS": "true"}, forgeGitea},
+		{map[string]string{"FORGEJO_ACTIONS": "true"}, forgeGitea},
+		{map[string]string{"GITEA_ACTIONS": "true", "PAPERTRAIL_FORGE": "GitHub"}, forgeGitHub},
+		{map[string]string{"PAPERTRAIL_FORGE": "forgejo"}, forgeGitea},
+		{map[string]string{"PAPERTRAIL_FORGE": "svn"}, ""},
+	} {
+		for _, k := range []string{"PAPERTRAIL_FORGE", "CI_MERGE_REQUEST_IID", "BITBUCKET_PR_ID", "GITEA_ACTIONS", "FORGEJO_ACTIONS"} {
+			t.Setenv(k, tc.env[k])
+		}
+		got, err := detectForge()
+		if got != tc.want || (err != nil) != (tc.want == "") {
+			t.Fatalf("detectForge() with %v = %q, %v; want %q", tc.env, got, err, tc.want)
+		}
+	}
+}
+
+func TestReadPullRequestEvent(t *testing.T) {
+	t.Setenv("GITHUB_REPOSITORY", "org/fallback")
+	dir := t.TempDir()
+	for name, tc := range map[string]struct {
+		event string
+		want  string
+	}{
+		"github": {
+			`{"pull_request": {"number": 7, "labels": [{"name": "b"}, {"name": "a"}, {"name": "b"}], "base": {"sha": "abc"}}, "repository": {"full_name": "org/repo"}}`,
+			"org/repo#7 [a b] abc",
+		},
+		// Gitea and Forgejo events can lack the pull request's number and base commit.
+		"gitea": {
+			`{"number": 9, "pull_request": {"merge_base": "def"}}`,
+			"org/fallback#9 [] def",
+		},
+	} {
+		p := filepath.Join(dir, name+".json")
+		if err := os.WriteFile(p, []byte(tc.event), 0644); err != nil {
+			t.Fatal(err)
+		}
+		pr, err := readPullRequestEvent(p)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if got := fmt.Sprintf("%s#%d %v %s", pr.Repo, pr.Number, pr.Labels, pr.Base); got != tc.want {
+			t.Fatalf("%s: got %q, want %q", name, got, tc.want)
+		}
+	}
+}
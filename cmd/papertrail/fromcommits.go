@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// cmdFromCommits writes one fragment per conventional commit on the branch since --base-ref,
+// so changes whose commit subject already describes them need no hand-written YAML. Merge
+// commits and commits that added a fragment themselves are skipped.
+func cmdFromCommits(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("from-commits", flag.ContinueOnError)
+	fs.SetOutput(ioDiscard{})
+	baseRef := fs.String("base-ref", "", "base ref the branch started from, e.g. origin/main (required)")
+	fragmentsDir := fs.String("fragments", "changelog.d", "fragments directory to write")
+	manifestPath := fs.String("manifest", "", "optional release config YAML path")
+	component := fs.String("component", "", "component for commits whose scope and changed files do not name one")
+	var typeFlags stringList
+	fs.Var(&typeFlags, "type", "map a conventional commit type to a fragment type, as from=to; an empty to skips it (repeatable)")
+	dryRun := fs.Bool("dry-run", false, "print the fragments that would be written without writing")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if strings.TrimSpace(*baseRef) == "" {
+		return fmt.Errorf("--base-ref is required (e.g. origin/main)")
+	}
+	manifest, err := loadManifestDefault(*manifestPath)
+	if err != nil {
+		return err
+	}
+	types, err := commitTypes(manifest, typeFlags)
+	if err != nil {
+		return err
+	}
+	commits, err := logCommits(ctx, *baseRef+"..HEAD", "--no-merges")
+	if err != nil {
+		return err
+	}
+
+	var entries []importedFragment
+	for _, c := range commits {
+		if touchesFragments(c, *fragmentsDir, manifest) {
+			continue
+		}
+		if _, ok := parseConventionalCommit(c.Subject, c.Body); !ok {
+			fmt.Fprintf(os.Stderr, "skipped %s %s: not a conventional commit\n", c.Short(), c.Subject)
+			continue
+		}
+		f, reason := commitFragment(c, types, strings.TrimSpace(*component), manifest)
+		if reason != "" {
+			fmt.Fprintf(os.Stderr, "skipped %s %s: %s\n", c.Short(), c.Subject, reason)
+			continue
+		}
+		entries = append(entries, importedFragment{Source: c.Short(), Name: f.Summary, Frag: f})
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("no conventional commits since %s to generate fragments from", *baseRef)
+	}
+	return writeImportedFragments(entries, *fragmentsDir, manifest, *dryRun, false)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCmdFromCommits(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{
+		".papertrail.config.yml": "changelog:\n  components: [CLI, Docs]\n  component_paths:\n    CLI: [cmd/]\n    Docs: [docs/]\n",
+	})
+	gitIn(t, dir, "checkout", "-q", "-b", "topic")
+	commit := func(msg, file string) {
+		t.Helper()
+		p := filepath.Join(dir, file)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(msg+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		gitIn(t, dir, "add", "-A")
+		gitIn(t, dir, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", msg)
+	}
+	commit("fix: handle a missing config", "cmd/app/main.go")
+	commit("docs(cli): explain the flag", "docs/flags.md")
+	commit("Tidy up", "docs/other.md")
+	commit("feat: manual fragment", "changelog.d/20260101_manual.yml")
+	t.Setenv("SOURCE_DATE_EPOCH", "1790000000") // 2026-09-21
+
+	out, err := captureStdout(t, func() error {
+		return cmdFromCommits(t.Context(), []string{"--base-ref", "main"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(out, " -> ") != 2 {
+		t.Fatalf("expected two fragments:\n%s", out)
+	}
+	for name, want := range map[string]string{
+		"20260921_handle_a_missing_config.yml": "component: CLI\ntype: FIX\nsummary: Handle a missing config\n",
+		"20260921_explain_the_flag.yml":        "component: CLI\ntype: DOCS\nsummary: Explain the flag\n",
+	} {
+		b, err := os.ReadFile(filepath.Join(dir, "changelog.d", name))
+		if err != nil || string(b) != want {
+			t.Fatalf("%s: got %q (%v), want %q", name, b, err, want)
+		}
+	}
+
+	if err := cmdFromCommits(t.Context(), []string{"--base-ref", "HEAD"}); err == nil || !strings.Contains(err.Error(), "no conventional commits") {
+		t.Fatalf("expected no commits, got %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+)
+
+// fsTransaction applies file changes to a writableFS, remembering how to undo each one, so a
+// multi-file update that fails partway can be rolled back to where it started.
+type fsTransaction struct {
+	fsys writableFS
+	undo []func() error
+}
+
+// writeFile writes name, restoring its previous contents (or absence) on rollback.
+func (tx *fsTransaction) writeFile(name string, data []byte, perm fs.FileMode) error {
+	prev, err := fs.ReadFile(tx.fsys, name)
+	existed := err == nil
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err := tx.fsys.WriteFile(name, data, perm); err != nil {
+		return err
+	}
+	tx.undo = append(tx.undo, func() error {
+		if existed {
+			return tx.fsys.WriteFile(name, prev, perm)
+		}
+		return tx.fsys.Remove(name)
+	})
+	return nil
+}
+
+// mkdirAll creates dir and its missing parents, removing the ones it created on rollback.
+func (tx *fsTransaction) mkdirAll(dir string, perm fs.FileMode) error {
+	var created []string
+	for d := path.Clean(dir); d != "." && d != "/"; d = path.Dir(d) {
+		if _, err := fs.Stat(tx.fsys, d); err == nil {
+			break
+		}
+		created = append(created, d)
+	}
+	if err := tx.fsys.MkdirAll(dir, perm); err != nil {
+		return err
+	}
+	tx.undo = append(tx.undo, func() error {
+		for _, d := range created {
+			if err := tx.fsys.Remove(d); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+		}
+		return nil
+	})
+	return nil
+}
+
+// copyFile copies oldname to newname through fsys.WriteFile, removing the copy on rollback.
+// Unlike a rename it works across devices, and it leaves oldname in place until the caller
+// removes it.
+func (tx *fsTransaction) copyFile(oldname, newname string) error {
+	data, err := fs.ReadFile(tx.fsys, oldname)
+	if err != nil {
+		return err
+	}
+	perm := fs.FileMode(0644)
+	if info, err := fs.Stat(tx.fsys, oldname); err == nil {
+		perm = info.Mode().Perm()
+	}
+	return tx.writeFile(newname, data, perm)
+}
+
+// remove deletes name, writing its contents back on rollback.
+func (tx *fsTransaction) remove(name string) error {
+	prev, err := fs.ReadFile(tx.fsys, name)
+	if err != nil {
+		return err
+	}
+	perm := fs.FileMode(0644)
+	if info, err := fs.Stat(tx.fsys, name); err == nil {
+		perm = info.Mode().Perm()
+	}
+	if err := tx.fsys.Remove(name); err != nil {
+		return err
+	}
+	tx.undo = append(tx.undo, func() error { return tx.fsys.WriteFile(name, prev, perm) })
+	return nil
+}
+
+// rollback undoes the applied changes, newest first, and returns err with any undo
+// failures joined to it.
+func (tx *fsTransaction) rollback(err error) error {
+	for i := len(tx.undo) - 1; i >= 0; i-- {
+		if uerr := tx.undo[i](); uerr != nil {
+			err = errors.Join(err, fmt.Errorf("rollback: %w", uerr))
+		}
+	}
+	tx.undo = nil
+	return err
+}
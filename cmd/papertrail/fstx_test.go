@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// failingFS fails writes to or removals of one file, like an archive move that cannot complete.
+type failingFS struct {
+	*memFS
+	fail string
+}
+
+func (f failingFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	if name == f.fail {
+		return errors.New("write failed")
+	}
+	return f.memFS.WriteFile(name, data, perm)
+}
+
+func (f failingFS) Remove(name string) error {
+	if name == f.fail {
+		return errors.New("remove failed")
+	}
+	return f.memFS.Remove(name)
+}
+
+func TestWriteRelease_RollsBackOnFailure(t *testing.T) {
+	t.Parallel()
+
+	const changelog = "# Changelog\n\n## v0.1.0 (2025-01-01)\n\n- old\n"
+	items := []item{
+		{Path: "changelog.d/a.yml", Frag: fragment{Component: "CLI", Type: "FIX", Summary: "Fix a"}},
+		{Path: "changelog.d/b.yml", Frag: fragment{Component: "CLI", Type: "FIX", Summary: "Fix b"}},
+	}
+	section, notes, _ := renderReleaseSection("v0.2.0", "2025-02-01", items, releaseManifest{})
+	for _, tc := range []struct{ fail, want string }{
+		// Staging the archive copy fails before the changelog is touched.
+		{fail: "changelog.d/archived/v0.2.0/b.yml", want: "archive changelog.d/b.yml: write failed"},
+		// Updating a version file fails after the release notes were written.
+		{fail: "VERSION", want: "set the version in VERSION: write failed"},
+		// Removing a staged fragment fails after the changelog was written.
+		{fail: "changelog.d/b.yml", want: "archive changelog.d/b.yml: remove failed"},
+	} {
+		mem := newMemFS(map[string]string{
+			"CHANGELOG.md":      changelog,
+			"notes.md":          "previous notes\n",
+			"VERSION":           "0.1.0\n",
+			"changelog.d/a.yml": "component: CLI\ntype: fix\nsummary: Fix a\n",
+			"changelog.d/b.yml": "component: CLI\ntype: fix\nsummary: Fix b\n",
+		})
+		err := writeRelease(failingFS{mem, tc.fail}, releaseOutput{
+			Version:         "v0.2.0",
+			ChangelogPath:   "CHANGELOG.md",
+			ArchiveDir:      "changelog.d/archived",
+			ReleaseNotesOut: "notes.md",
+			Section:         section,
+			ReleaseNotes:    notes,
+			Items:           items,
+			VersionFiles:    map[string][]byte{"VERSION": []byte("0.2.0\n")},
+		})
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("%s: expected the %q error, got %v", tc.fail, tc.want, err)
+		}
+
+		for name, want := range map[string]string{
+			"CHANGELOG.md":      changelog,
+			"notes.md":          "previous notes\n",
+			"VERSION":           "0.1.0\n",
+			"changelog.d/a.yml": "component: CLI\ntype: fix\nsummary: Fix a\n",
+			"changelog.d/b.yml": "component: CLI\ntype: fix\nsummary: Fix b\n",
+		} {
+			if b, err := fs.ReadFile(mem, name); err != nil || string(b) != want {
+				t.Fatalf("%s: %s not rolled back: %q, %v", tc.fail, name, b, err)
+			}
+		}
+		if _, err := fs.Stat(mem, "changelog.d/archived"); err == nil {
+			t.Fatalf("%s: archive directory should be removed", tc.fail)
+		}
+	}
+}
+
+func TestHostFS_WriteFileAtomic(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	target := filepath.Join(dir, "CHANGELOG.md")
+	if err := os.WriteFile(target, []byte("old\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "link.md")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skip("symlinks unsupported:", err)
+	}
+	if err := (hostFS{}).WriteFile(link, []byte("new\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&fs.ModeSymlink == 0 {
+		t.Fatalf("symlink replaced: %v", err)
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(target); string(b) != "new\n" || info.Mode().Perm() != 0600 {
+		t.Fatalf("target: %q, mode %v", b, info.Mode().Perm())
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Fatalf("temporary files left behind: %v", entries)
+	}
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// writableFS is the file system Papertrail reads fragments from and writes release output
+// to. Reads go through io/fs so any fs.FS (in-memory, git objects, embedded) can back them.
+type writableFS interface {
+	fs.FS
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+	MkdirAll(name string, perm fs.FileMode) error
+	Rename(oldname, newname string) error
+	Remove(name string) error
+}
+
+// readFile is fs.ReadFile, for use where the io/fs package name is shadowed by a flag set.
+func readFile(fsys fs.FS, name string) ([]byte, error) {
+	return fs.ReadFile(fsys, name)
+}
+
+// hostFS is the writableFS backed by the operating system. Unlike os.DirFS it passes names
+// through unchanged, so user-supplied relative and absolute paths work as-is.
+type hostFS struct{}
+
+func (hostFS) Open(name string) (fs.File, error)          { return os.Open(name) }
+func (hostFS) ReadFile(name string) ([]byte, error)       { return os.ReadFile(name) }
+func (hostFS) ReadDir(name string) ([]fs.DirEntry, error) { return os.ReadDir(name) }
+func (hostFS) Stat(name string) (fs.FileInfo, error)      { return os.Stat(name) }
+func (hostFS) MkdirAll(name string, perm fs.FileMode) error {
+	return os.MkdirAll(name, perm)
+}
+func (hostFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	return writeFileAtomic(name, data, perm)
+}
+func (hostFS) Remove(name string) error { return os.Remove(name) }
+
+// Rename falls back to copying and removing when the names are on different devices, where
+// os.Rename fails.
+func (hostFS) Rename(oldname, newname string) error {
+	err := os.Rename(oldname, newname)
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	info, err := os.Stat(oldname)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(oldname)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(newname, data, info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Remove(oldname)
+}
+
+// writeFileAtomic writes data to a temporary file next to name, syncs it, renames it over
+// name, and syncs the directory, so a crash or failure never leaves name partly written. An
+// existing file keeps its permissions, and a symlinked name has its target replaced.
+func writeFileAtomic(name string, data []byte, perm fs.FileMode) error {
+	if target, err := filepath.EvalSymlinks(name); err == nil {
+		name = target
+	}
+	if info, err := os.Stat(name); err == nil {
+		perm = info.Mode().Perm()
+	}
+	f, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, perm)
+	}
+	if err == nil {
+		err = os.Rename(tmp, name)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	syncDir(filepath.Dir(name))
+	return nil
+}
+
+// syncDir flushes dir so a rename into it survives a crash. It is best effort: some platforms
+// cannot sync a directory, and the file contents are already synced.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	_ = d.Close()
+}
+
+// gitFS is a read-only fs.FS over the tree of a git ref, read via the git CLI in the current
+// repository. It lets the same discovery and validation code run without a working tree.
+// fs.FS methods take no context, so the one governing the git invocations is carried here.
+type gitFS struct {
+	ctx context.Context
+	ref string
+}
+
+func (g gitFS) context() context.Context {
+	if g.ctx == nil {
+		return context.Background()
+	}
+	return g.ctx
+}
+
+func (g gitFS) spec(name string) string {
+	if name == "." {
+		return g.ref + ":"
+	}
+	return g.ref + ":" + name
+}
+
+func (g gitFS) objectType(op, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	t, err := runGit(g.context(), "cat-file", "-t", g.spec(name))
+	if err != nil {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return t, nil
+}
+
+func (g gitFS) ReadFile(name string) ([]byte, error) {
+	t, err := g.objectType("read", name)
+	if err != nil {
+		return nil, err
+	}
+	if t != "blob" {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: errors.New("is a directory")}
+	}
+	return runCmdRaw(g.context(), "git", "cat-file", "blob", g.spec(name))
+}
+
+func (g gitFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	t, err := g.objectType("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if t != "tree" {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+	// -z keeps names with non-ASCII or special characters unquoted; --long adds blob sizes.
+	out, err := runCmdRaw(g.context(), "git", "ls-tree", "-z", "--long", g.spec(name))
+	if err != nil {
+		return nil, err
+	}
+	var entries []fs.DirEntry
+	for _, line := range strings.Split(string(out), "\x00") {
+		meta, entry, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		e := gitEntry{name: entry, mode: 0644}
+		fields := strings.Fields(meta)
+		if len(fields) >= 2 && fields[1] == "tree" {
+			e.mode = fs.ModeDir | 0755
+		}
+		if len(fields) >= 4 {
+			e.size, _ = strconv.ParseInt(fields[3], 10, 64)
+		}
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+func (g gitFS) Open(name string) (fs.File, error) {
+	t, err := g.objectType("open", name)
+	if err != nil {
+		return nil, err
+	}
+	info := gitEntry{name: path.Base(name), mode: 0644}
+	if t == "tree" {
+		entries, err := g.ReadDir(name)
+		if err != nil {
+			return nil, err
+		}
+		info.mode = fs.ModeDir | 0755
+		return &gitFile{gitEntry: info, entries: entries}, nil
+	}
+	b, err := g.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	info.size = int64(len(b))
+	return &gitFile{gitEntry: info, Reader: bytes.NewReader(b)}, nil
+}
+
+// gitEntry describes a git tree entry; it is both its fs.DirEntry and its fs.FileInfo.
+type gitEntry struct {
+	name string
+	mode fs.FileMode
+	size int64
+}
+
+func (e gitEntry) Name() string               { return e.name }
+func (e gitEntry) IsDir() bool                { return e.mode.IsDir() }
+func (e gitEntry) Type() fs.FileMode          { return e.mode.Type() }
+func (e gitEntry) Info() (fs.FileInfo, error) { return e, nil }
+func (e gitEntry) Size() int64                { return e.size }
+func (e gitEntry) Mode() fs.FileMode          { return e.mode }
+func (e gitEntry) ModTime() time.Time         { return time.Time{} }
+func (e gitEntry) Sys() any                   { return nil }
+
+// gitFile is an open blob (read from its contents) or tree (listed by ReadDir).
+type gitFile struct {
+	gitEntry
+	*bytes.Reader
+	entries []fs.DirEntry
+}
+
+func (f *gitFile) Stat() (fs.FileInfo, error) { return f.gitEntry, nil }
+func (f *gitFile) Close() error               { return nil }
+
+func (f *gitFile) Read(b []byte) (int, error) {
+	if f.Reader == nil {
+		return 0, &fs.PathError{Op: "read", Path: f.name, Err: errors.New("is a directory")}
+	}
+	return f.Reader.Read(b)
+}
+
+// ReadDir implements fs.ReadDirFile for trees.
+func (f *gitFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	if f.Reader != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: f.name, Err: errors.New("not a directory")}
+	}
+	if n <= 0 {
+		entries := f.entries
+		f.entries = nil
+		return entries, nil
+	}
+	if len(f.entries) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(f.entries))
+	entries := f.entries[:n]
+	f.entries = f.entries[n:]
+	return entries, nil
+}
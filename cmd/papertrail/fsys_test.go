@@ -0,0 +1,203 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+// memFS is an in-memory writableFS for tests. Names are slash-separated; a leading "./" is
+// ignored.
+type memFS struct {
+	mu    sync.Mutex
+	files fstest.MapFS
+}
+
+func newMemFS(files map[string]string) *memFS {
+	m := &memFS{files: fstest.MapFS{}}
+	for name, data := range files {
+		m.files[memName(name)] = &fstest.MapFile{Data: []byte(data), Mode: 0644}
+	}
+	return m
+}
+
+func memName(name string) string {
+	return strings.TrimPrefix(path.Clean(strings.TrimPrefix(name, "/")), "./")
+}
+
+func (m *memFS) Open(name string) (fs.File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// Open a snapshot so callers never observe concurrent writes mid-read.
+	snap := make(fstest.MapFS, len(m.files))
+	for k, v := range m.files {
+		cp := *v
+		snap[k] = &cp
+	}
+	return snap.Open(memName(name))
+}
+
+func (m *memFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := memName(name)
+	if dir := path.Dir(n); dir != "." && !m.isDirLocked(dir) {
+		return &fs.PathError{Op: "write", Path: name, Err: fs.ErrNotExist}
+	}
+	m.files[n] = &fstest.MapFile{Data: append([]byte(nil), data...), Mode: perm, ModTime: time.Now()}
+	return nil
+}
+
+func (m *memFS) MkdirAll(name string, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := memName(name)
+	for n != "." {
+		if f, ok := m.files[n]; ok && !f.Mode.IsDir() {
+			return &fs.PathError{Op: "mkdir", Path: name, Err: errors.New("not a directory")}
+		}
+		if !m.isDirLocked(n) {
+			m.files[n] = &fstest.MapFile{Mode: fs.ModeDir | perm}
+		}
+		n = path.Dir(n)
+	}
+	return nil
+}
+
+func (m *memFS) Rename(oldname, newname string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	o, n := memName(oldname), memName(newname)
+	f, ok := m.files[o]
+	if !ok || f.Mode.IsDir() {
+		return &fs.PathError{Op: "rename", Path: oldname, Err: fs.ErrNotExist}
+	}
+	if dir := path.Dir(n); dir != "." && !m.isDirLocked(dir) {
+		return &fs.PathError{Op: "rename", Path: newname, Err: fs.ErrNotExist}
+	}
+	delete(m.files, o)
+	m.files[n] = f
+	return nil
+}
+
+func (m *memFS) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := memName(name)
+	if _, ok := m.files[n]; !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	delete(m.files, n)
+	return nil
+}
+
+// isDirLocked reports whether n is an explicit or implied (has children) directory.
+func (m *memFS) isDirLocked(n string) bool {
+	if f, ok := m.files[n]; ok {
+		return f.Mode.IsDir()
+	}
+	prefix := n + "/"
+	for k := range m.files {
+		if strings.HasPrefix(k, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestWriteRelease_MemFS(t *testing.T) {
+	t.Parallel()
+
+	fsys := newMemFS(map[string]string{
+		"CHANGELOG.md":                        "# Changelog\n\n## v0.1.0 (2025-01-01)\n\n- old\n",
+		"changelog.d/a.yml":                   "component: CLI\ntype: fix\nsummary: Fix a\n",
+		"changelog.d/archived/.keep":          "",
+		"changelog.d/archived/v0.1.0/old.yml": "component: CLI\ntype: fix\nsummary: old\n",
+	})
+
+	files, cleanup, err := discoverFragments(t.Context(), fsys, "changelog.d", releaseManifest{}, false)
+	defer cleanup()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(files) != 1 || files[0].Path != "changelog.d/a.yml" {
+		t.Fatalf("unexpected files: %+v", files)
+	}
+	f, err := papertrail.ReadFragment(fsys, files[0].Path, releaseManifest{})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	items := []item{{Path: files[0].Path, Frag: f}}
+	section, notes, _ := renderReleaseSection("v0.2.0", "2025-02-01", items, releaseManifest{})
+
+	err = writeRelease(fsys, releaseOutput{
+		Version:         "v0.2.0",
+		ChangelogPath:   "CHANGELOG.md",
+		ArchiveDir:      "changelog.d/archived",
+		ReleaseNotesOut: "notes.md",
+		Section:         section,
+		ReleaseNotes:    notes,
+		Items:           items,
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	changelog, _ := fs.ReadFile(fsys, "CHANGELOG.md")
+	if !strings.Contains(string(changelog), "## v0.2.0 (2025-02-01)\n\n### CLI\n\n- **fix**: Fix a.\n\n## v0.1.0") {
+		t.Fatalf("unexpected changelog:\n%s", changelog)
+	}
+	if _, err := fs.Stat(fsys, "changelog.d/a.yml"); err == nil {
+		t.Fatalf("fragment was not archived")
+	}
+	if _, err := fs.Stat(fsys, "changelog.d/archived/v0.2.0/a.yml"); err != nil {
+		t.Fatalf("archived fragment missing: %v", err)
+	}
+	if _, err := fs.Stat(fsys, "notes.md"); err != nil {
+		t.Fatalf("release notes missing: %v", err)
+	}
+
+	if err := writeRelease(fsys, releaseOutput{Version: "v0.2.0", ChangelogPath: "CHANGELOG.md", Section: section}); err == nil {
+		t.Fatalf("expected duplicate section error")
+	}
+}
+
+func TestCmdMerge_KeepAChangelog(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{
+		".papertrail.config.yml": "changelog:\n  style: keepachangelog\n  compare_url: https://github.com/org/repo/compare/{from}...{to}\n",
+		"CHANGELOG.md":           "# Changelog\n\nAll notable changes to this project are documented in this file.\n\n## [v1.0.0] - 2026-01-01\n\n### Added\n\n- First release.\n",
+		"changelog.d/a.yml":      "component: CLI\ntype: fix\nsummary: Fix a crash\n",
+	})
+	if err := cmdMerge(t.Context(), []string{"--version", "v1.0.1", "--date", "2026-02-01"}); err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	if err := cmdMerge(t.Context(), []string{"--version", "v1.0.1", "--date", "2026-02-01"}); err == nil {
+		t.Fatalf("expected no fragments or a duplicate section error")
+	}
+	b, _ := os.ReadFile(filepath.Join(dir, "CHANGELOG.md"))
+	want := "# Changelog\n\nAll notable changes to this project are documented in this file.\n\n" +
+		"## [Unreleased]\n\n" +
+		"## [v1.0.1] - 2026-02-01\n\n### Fixed\n\n- Fix a crash.\n\n" +
+		"## [v1.0.0] - 2026-01-01\n\n### Added\n\n- First release.\n\n" +
+		"[unreleased]: https://github.com/org/repo/compare/v1.0.1...HEAD\n" +
+		"[v1.0.1]: https://github.com/org/repo/compare/v1.0.0...v1.0.1\n"
+	if string(b) != want {
+		t.Fatalf("changelog:\n%s", b)
+	}
+	// The next release continues from the bracketed heading.
+	if err := os.WriteFile(filepath.Join(dir, "changelog.d", "b.yml"), []byte("component: CLI\ntype: fix\nsummary: Fix more\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmdMerge(t.Context(), []string{"--version", "v1.0.3"}); err == nil || !strings.Contains(err.Error(), "v1.0.1") {
+		t.Fatalf("expected a version continuity error from v1.0.1, got %v", err)
+	}
+}
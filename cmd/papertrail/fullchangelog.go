@@ -0,0 +1,21 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// fullChangelogLine returns the "**Full Changelog**: <url>" line that closes a release:
+// changelog.compare_url from the latest earlier release (the highest of the changelog's
+// top section and the latest git tag) to version. It is empty for the first release.
+func fullChangelogLine(ctx context.Context, changelogPath string, next semver, manifest releaseManifest) (string, error) {
+	compare := changelogCompare(manifest, "v")
+	if compare == nil {
+		return "", fmt.Errorf("--full-changelog needs changelog.compare_url in the manifest")
+	}
+	prev, ok, err := latestRelease(ctx, hostFS{}, changelogPath, "v")
+	if err != nil || !ok || prev.Version.Compare(next) >= 0 {
+		return "", err
+	}
+	return "**Full Changelog**: " + compare(prev.Version.String(), next.String()) + "\n\n", nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCmdMerge_FullChangelog(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{
+		".papertrail.config.yml":     "changelog:\n  compare_url: https://github.com/org/repo/compare/{from}...{to}\n",
+		"CHANGELOG.md":               "# Changelog\n\n## v1.1.0 (2026-01-01)\n\n### CLI\n\n- **fix**: Fix a.\n",
+		"changelog.d/20260102_b.yml": "component: CLI\ntype: fix\nsummary: Fix b\n",
+	})
+	gitIn(t, dir, "tag", "v1.2.0")
+
+	notes := filepath.Join(dir, "notes.md")
+	if err := cmdMerge(t.Context(), []string{"--version", "v1.2.1", "--date", "2026-01-03", "--full-changelog", "--release-notes-out", notes}); err != nil {
+		t.Fatal(err)
+	}
+	const line = "**Full Changelog**: https://github.com/org/repo/compare/v1.2.0...v1.2.1"
+	b, err := os.ReadFile(notes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(string(b), "\n\n"+line+"\n") {
+		t.Fatalf("release notes:\n%s", b)
+	}
+	changelog, err := os.ReadFile(filepath.Join(dir, "CHANGELOG.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(changelog), "- **fix**: Fix b.\n\n"+line+"\n\n## v1.1.0") {
+		t.Fatalf("CHANGELOG.md:\n%s", changelog)
+	}
+}
+
+func TestCmdMerge_FullChangelogNeedsCompareURL(t *testing.T) {
+	initGitRepo(t, map[string]string{
+		"CHANGELOG.md":               "# Changelog\n",
+		"changelog.d/20260102_b.yml": "component: CLI\ntype: fix\nsummary: Fix b\n",
+	})
+	err := cmdMerge(t.Context(), []string{"--version", "v0.1.0", "--date", "2026-01-03", "--full-changelog"})
+	if err == nil || !strings.Contains(err.Error(), "compare_url") {
+		t.Fatalf("expected a compare_url error, got %v", err)
+	}
+}
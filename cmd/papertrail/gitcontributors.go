@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// githubNoreplyRE matches GitHub's private commit emails ([id+]handle@users.noreply.github.com).
+var githubNoreplyRE = regexp.MustCompile(`^(?:\d+\+)?([A-Za-z0-9-]+)@users\.noreply\.github\.com$`)
+
+// addGitAuthors credits each fragment without authors with the authors of the commits that
+// added or changed its file (git log --follow). Fragments listing authors keep them, and
+// fragments from manifest sources are skipped.
+func addGitAuthors(ctx context.Context, items []item) error {
+	for i, it := range items {
+		if it.External || len(it.Frag.Authors) > 0 {
+			continue
+		}
+		authors, err := gitFileAuthors(ctx, it.Path)
+		if err != nil {
+			return err
+		}
+		items[i].Frag.Authors = authors
+	}
+	return nil
+}
+
+// gitFileAuthors returns the distinct commit authors of path, oldest first. Authors are
+// de-duplicated by email and credited by name, or as an @-handle for GitHub noreply emails.
+// A file that was never committed has none.
+func gitFileAuthors(ctx context.Context, path string) ([]string, error) {
+	out, err := runGit(ctx, "log", "--follow", "--reverse", "--format=%an%x00%ae", "--", path)
+	if err != nil {
+		return nil, err
+	}
+	var authors, emails []string
+	for _, line := range strings.Split(out, "\n") {
+		name, email, ok := strings.Cut(strings.TrimSpace(line), "\x00")
+		email = strings.ToLower(strings.TrimSpace(email))
+		if !ok || slices.Contains(emails, email) {
+			continue
+		}
+		emails = append(emails, email)
+		author := strings.TrimSpace(name)
+		if m := githubNoreplyRE.FindStringSubmatch(email); m != nil {
+			author = "@" + m[1]
+		}
+		if author != "" && !slices.Contains(authors, author) {
+			authors = append(authors, author)
+		}
+	}
+	return authors, nil
+}
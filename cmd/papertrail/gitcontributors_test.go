@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCmdMerge_ContributorsFromGit(t *testing.T) {
+	t.Setenv("GITHUB_SERVER_URL", "")
+	dir := initGitRepo(t, map[string]string{
+		"CHANGELOG.md":               "# Changelog\n",
+		"changelog.d/20260101_a.yml": "component: CLI\ntype: feature\nsummary: Add a\n",
+		"changelog.d/20260102_b.yml": "component: CLI\ntype: fix\nsummary: Fix b\nauthors: [Manual Author]\n",
+	})
+	if err := os.WriteFile(filepath.Join(dir, "changelog.d/20260101_a.yml"), []byte("component: CLI\ntype: feature\nsummary: Add a.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitIn(t, dir, "-c", "user.name=Octo Cat", "-c", "user.email=1234+octocat@users.noreply.github.com", "commit", "-qam", "tweak")
+	if err := os.WriteFile(filepath.Join(dir, "changelog.d/20260103_c.yml"), []byte("component: CLI\ntype: fix\nsummary: Fix c\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	notes := filepath.Join(dir, "notes.md")
+	if err := cmdMerge(t.Context(), []string{"--version", "v1.0.0", "--date", "2026-01-04", "--skip-version-check", "--contributors-from-git", "--release-notes-out", notes}); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(notes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "### Contributors\n\n- Manual Author\n- [@octocat](https://github.com/octocat)\n- test\n"
+	if !strings.HasSuffix(string(b), want) {
+		t.Fatalf("release notes:\n%s\nwant suffix:\n%s", b, want)
+	}
+}
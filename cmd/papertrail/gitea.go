@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// giteaClient talks to the Gitea (or Forgejo) API. It serves GitHub's comment and release
+// endpoints, but authenticates with "token" and returns all of an issue's comments at once.
+type giteaClient struct {
+	*githubClient
+}
+
+// newGiteaClient configures a client from the environment of Gitea and Forgejo Actions: the
+// API is GITHUB_API_URL (or GITHUB_SERVER_URL with /api/v1), and GITEA_TOKEN, FORGEJO_TOKEN,
+// or GITHUB_TOKEN is sent when present.
+func newGiteaClient() (*giteaClient, error) {
+	base := strings.TrimSpace(os.Getenv("GITHUB_API_URL"))
+	if server := strings.TrimSpace(os.Getenv("GITHUB_SERVER_URL")); base == "" && server != "" {
+		base = strings.TrimRight(server, "/") + "/api/v1"
+	}
+	if base == "" {
+		return nil, fmt.Errorf("the Gitea API URL is unknown: set GITHUB_API_URL (e.g. https://gitea.example.com/api/v1)")
+	}
+	var token string
+	for _, name := range []string{"GITEA_TOKEN", "FORGEJO_TOKEN", "GITHUB_TOKEN"} {
+		if token = strings.TrimSpace(os.Getenv(name)); token != "" {
+			break
+		}
+	}
+	return &giteaClient{&githubClient{
+		name:       "Gitea",
+		baseURL:    strings.TrimRight(base, "/"),
+		token:      token,
+		authScheme: "token",
+		http:       &http.Client{Timeout: 30 * time.Second},
+	}}, nil
+}
+
+// listIssueComments returns every comment on an issue or pull request.
+func (c *giteaClient) listIssueComments(ctx context.Context, repo string, number int) ([]issueComment, error) {
+	b, err := c.do(ctx, http.MethodGet, fmt.Sprintf("/repos/%s/issues/%d/comments", repo, number), "application/json", nil)
+	if err != nil {
+		return nil, err
+	}
+	var comments []issueComment
+	if err := json.Unmarshal(b, &comments); err != nil {
+		return nil, fmt.Errorf("invalid Gitea comments response for %s#%d: %w", repo, number, err)
+	}
+	return comments, nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGiteaPreviewCommentAndRelease(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{"README.md": "# repo\n"})
+	base := gitOutput(t, dir, "rev-parse", "HEAD")
+	if err := os.MkdirAll(filepath.Join(dir, "changelog.d"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "changelog.d", "new.yml"), []byte("component: CLI\ntype: feature\nsummary: Support Gitea.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitIn(t, dir, "add", "-A")
+	gitIn(t, dir, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "add fragment")
+
+	// Gitea events carry the number at the top level and the base as the merge base.
+	event := filepath.Join(t.TempDir(), "event.json")
+	if err := os.WriteFile(event, []byte(`{"number": 4, "pull_request": {"merge_base": "`+base+`"}, "repository": {"full_name": "org/repo"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var requests []string
+	var comment issueComment
+	var release githubRelease
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.RequestURI())
+		if r.Header.Get("Authorization") != "token tok" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.Method + " " + r.URL.RequestURI() {
+		case "GET /api/v1/repos/org/repo/issues/4/comments":
+			_, _ = w.Write([]byte(`[{"id": 1, "body": "LGTM"}]`))
+		case "POST /api/v1/repos/org/repo/issues/4/comments":
+			_ = json.NewDecoder(r.Body).Decode(&comment)
+			_, _ = w.Write([]byte(`{"id": 2, "html_url": "https://gitea.example.com/org/repo/pulls/4#issuecomment-2"}`))
+		case "POST /api/v1/repos/org/repo/releases":
+			_ = json.NewDecoder(r.Body).Decode(&release)
+			_, _ = w.Write([]byte(`{"html_url": "https://gitea.example.com/org/repo/releases/tag/v1.0.0"}`))
+		default:
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("GITEA_ACTIONS", "true")
+	t.Setenv("GITHUB_EVENT_PATH", event)
+	t.Setenv("GITHUB_API_URL", "")
+	t.Setenv("GITHUB_SERVER_URL", srv.URL)
+	t.Setenv("GITEA_TOKEN", "tok")
+
+	out, err := captureStdout(t, func() error { return cmdPreview(t.Context(), []string{"--comment"}) })
+	if err != nil {
+		t.Fatalf("preview --comment: %v", err)
+	}
+	if !strings.Contains(out, "Created preview comment https://gitea.example.com/") || !strings.Contains(comment.Body, "Support Gitea.") {
+		t.Fatalf("output %q, comment %q, requests %v", out, comment.Body, requests)
+	}
+
+	api, err := newForge()
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, err := api.createRelease(t.Context(), "org/repo", githubRelease{TagName: "v1.0.0", Body: "notes"})
+	if err != nil || !strings.HasSuffix(url, "/releases/tag/v1.0.0") || release.TagName != "v1.0.0" {
+		t.Fatalf("createRelease = %q, %v (request %+v)", url, err, release)
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// errGitHubNotFound is returned when the GitHub API responds with 404.
+var errGitHubNotFound = errors.New("not found")
+
+// githubClient is a minimal GitHub REST API client built on net/http. Gitea and Forgejo
+// serve the same endpoints for comments and releases; giteaClient adjusts the rest.
+type githubClient struct {
+	// name is the forge in error messages.
+	name    string
+	baseURL string
+	token   string
+	// authScheme prefixes the token in the Authorization header.
+	authScheme string
+	http       *http.Client
+}
+
+type githubContent struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	Type string `json:"type"`
+}
+
+// newGitHubClient configures a client from the environment.
+//
+// GITHUB_API_URL overrides the API endpoint (set automatically on GitHub Enterprise runners);
+// GITHUB_TOKEN (or GH_TOKEN) is sent as a bearer token when present.
+func newGitHubClient() *githubClient {
+	base := strings.TrimSpace(os.Getenv("GITHUB_API_URL"))
+	if base == "" {
+		base = "https://api.github.com"
+	}
+	token := strings.TrimSpace(os.Getenv("GITHUB_TOKEN"))
+	if token == "" {
+		token = strings.TrimSpace(os.Getenv("GH_TOKEN"))
+	}
+	return &githubClient{
+		name:       "GitHub",
+		baseURL:    strings.TrimRight(base, "/"),
+		token:      token,
+		authScheme: "Bearer",
+		http:       &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (c *githubClient) hasToken() bool { return c.token != "" }
+
+func (c *githubClient) do(ctx context.Context, method, path string, accept string, body any) ([]byte, error) {
+	var rd io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		rd = bytes.NewReader(b)
+	}
+	if isOffline(ctx) {
+		return nil, fmt.Errorf("%s API %s %s: %w", c.name, method, path, errOffline)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, rd)
+	if err != nil {
+		return nil, err
+	}
+	if accept == "" {
+		accept = "application/vnd.github+json"
+	}
+	req.Header.Set("Accept", accept)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", c.authScheme+" "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%s API %s %s: %w", c.name, method, path, errGitHubNotFound)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("%s API %s %s: %s: %s", c.name, method, path, resp.Status, strings.TrimSpace(string(b)))
+	}
+	return b, nil
+}
+
+func contentsPath(repo, path, ref string) string {
+	p := "/repos/" + repo + "/contents/" + strings.TrimPrefix(path, "/")
+	if ref != "" {
+		p += "?ref=" + url.QueryEscape(ref)
+	}
+	return p
+}
+
+// listDir lists the entries of a directory in repo at ref.
+func (c *githubClient) listDir(ctx context.Context, repo, path, ref string) ([]githubContent, error) {
+	b, err := c.do(ctx, http.MethodGet, contentsPath(repo, path, ref), "", nil)
+	if err != nil {
+		return nil, err
+	}
+	var entries []githubContent
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return nil, fmt.Errorf("invalid GitHub contents response for %s/%s: %w", repo, path, err)
+	}
+	return entries, nil
+}
+
+// getFile returns the raw contents of a file in repo at ref.
+func (c *githubClient) getFile(ctx context.Context, repo, path, ref string) ([]byte, error) {
+	return c.do(ctx, http.MethodGet, contentsPath(repo, path, ref), "application/vnd.github.raw+json", nil)
+}
+
+type githubRelease struct {
+	TagName string `json:"tag_name"`
+	Name    string `json:"name"`
+	Body    string `json:"body"`
+	Draft   bool   `json:"draft"`
+	HTMLURL string `json:"html_url,omitempty"`
+}
+
+// createRelease creates a GitHub Release for an existing tag and returns its page URL.
+func (c *githubClient) createRelease(ctx context.Context, repo string, rel githubRelease) (string, error) {
+	b, err := c.do(ctx, http.MethodPost, "/repos/"+repo+"/releases", "", rel)
+	if err != nil {
+		return "", err
+	}
+	var created githubRelease
+	if err := json.Unmarshal(b, &created); err != nil {
+		return "", fmt.Errorf("invalid %s release response for %s: %w", c.name, repo, err)
+	}
+	return created.HTMLURL, nil
+}
+
+type issueComment struct {
+	ID      int64  `json:"id,omitempty"`
+	Body    string `json:"body"`
+	HTMLURL string `json:"html_url,omitempty"`
+}
+
+// listIssueComments returns every comment on an issue or pull request, following pages.
+func (c *githubClient) listIssueComments(ctx context.Context, repo string, number int) ([]issueComment, error) {
+	const perPage = 100
+	var all []issueComment
+	for page := 1; ; page++ {
+		b, err := c.do(ctx, http.MethodGet, fmt.Sprintf("/repos/%s/issues/%d/comments?per_page=%d&page=%d", repo, number, perPage, page), "", nil)
+		if err != nil {
+			return nil, err
+		}
+		var comments []issueComment
+		if err := json.Unmarshal(b, &comments); err != nil {
+			return nil, fmt.Errorf("invalid %s comments response for %s#%d: %w", c.name, repo, number, err)
+		}
+		all = append(all, comments...)
+		if len(comments) < perPage {
+			return all, nil
+		}
+	}
+}
+
+// createIssueComment comments on an issue or pull request.
+func (c *githubClient) createIssueComment(ctx context.Context, repo string, number int, body string) (issueComment, error) {
+	b, err := c.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/issues/%d/comments", repo, number), "", issueComment{Body: body})
+	if err != nil {
+		return issueComment{}, err
+	}
+	var created issueComment
+	if err := json.Unmarshal(b, &created); err != nil {
+		return issueComment{}, fmt.Errorf("invalid %s comment response for %s#%d: %w", c.name, repo, number, err)
+	}
+	return created, nil
+}
+
+// updateIssueComment replaces the body of a comment.
+func (c *githubClient) updateIssueComment(ctx context.Context, repo string, id int64, body string) (issueComment, error) {
+	b, err := c.do(ctx, http.MethodPatch, fmt.Sprintf("/repos/%s/issues/comments/%d", repo, id), "", issueComment{Body: body})
+	if err != nil {
+		return issueComment{}, err
+	}
+	var updated issueComment
+	if err := json.Unmarshal(b, &updated); err != nil {
+		return issueComment{}, fmt.Errorf("invalid %s comment response for %s: %w", c.name, repo, err)
+	}
+	return updated, nil
+}
+
+// deleteIssueComment deletes a comment.
+func (c *githubClient) deleteIssueComment(ctx context.Context, repo string, id int64) error {
+	_, err := c.do(ctx, http.MethodDelete, fmt.Sprintf("/repos/%s/issues/comments/%d", repo, id), "", nil)
+	return err
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// gitlabMergeRequest reads the merge request of a GitLab merge request pipeline. The base is
+// the merge base with the target branch (CI_MERGE_REQUEST_DIFF_BASE_SHA), falling back to the
+// fetched target branch.
+func gitlabMergeRequest() pullRequest {
+	var labels []string
+	for _, l := range strings.Split(os.Getenv("CI_MERGE_REQUEST_LABELS"), ",") {
+		if l = strings.TrimSpace(l); l != "" {
+			labels = append(labels, l)
+		}
+	}
+	base := strings.TrimSpace(os.Getenv("CI_MERGE_REQUEST_DIFF_BASE_SHA"))
+	if target := strings.TrimSpace(os.Getenv("CI_MERGE_REQUEST_TARGET_BRANCH_NAME")); base == "" && target != "" {
+		base = "origin/" + target
+	}
+	number, _ := strconv.Atoi(strings.TrimSpace(os.Getenv("CI_MERGE_REQUEST_IID")))
+	return pullRequest{
+		Repo:   strings.TrimSpace(os.Getenv("CI_PROJECT_PATH")),
+		Number: number,
+		Title:  strings.TrimSpace(os.Getenv("CI_MERGE_REQUEST_TITLE")),
+		Body:   os.Getenv("CI_MERGE_REQUEST_DESCRIPTION"),
+		Labels: sortedUnique(labels),
+		Base:   base,
+	}
+}
+
+// sortedUnique sorts names and drops duplicates, in place.
+func sortedUnique(names []string) []string {
+	sort.Strings(names)
+	out := names[:0]
+	for i, n := range names {
+		if i > 0 && n == names[i-1] {
+			continue
+		}
+		out = append(out, n)
+	}
+	return out
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCmdPRFragment_GitLab(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{"main.go": "package main\n"})
+	base := gitOutput(t, dir, "rev-parse", "HEAD")
+	commit := func(name, body string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+		gitIn(t, dir, "add", "-A")
+		gitIn(t, dir, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", name)
+	}
+	commit("main.go", "package main\n\nfunc main() {}\n")
+
+	t.Setenv("GITHUB_EVENT_PATH", "")
+	t.Setenv("CI_MERGE_REQUEST_IID", "3")
+	t.Setenv("CI_MERGE_REQUEST_DIFF_BASE_SHA", base)
+	t.Setenv("CI_MERGE_REQUEST_LABELS", "bug,docs")
+
+	if err := cmdPRFragment(t.Context(), nil); err == nil || !strings.Contains(err.Error(), "No changelog fragment") {
+		t.Fatalf("expected a missing fragment error, got %v", err)
+	}
+	t.Setenv("CI_MERGE_REQUEST_LABELS", "bug, no-changelog")
+	if err := cmdPRFragment(t.Context(), nil); err != nil {
+		t.Fatalf("opt-out label: %v", err)
+	}
+	t.Setenv("CI_MERGE_REQUEST_LABELS", "")
+	commit("changelog.d/main.yml", "component: CLI\ntype: feature\nsummary: Add main.\n")
+	if err := cmdPRFragment(t.Context(), nil); err != nil {
+		t.Fatalf("with a fragment: %v", err)
+	}
+}
+
+func TestGitLabMergeRequest(t *testing.T) {
+	t.Setenv("CI_MERGE_REQUEST_LABELS", "b, a,,b")
+	t.Setenv("CI_MERGE_REQUEST_DIFF_BASE_SHA", "")
+	t.Setenv("CI_MERGE_REQUEST_TARGET_BRANCH_NAME", "main")
+	pr := gitlabMergeRequest()
+	if strings.Join(pr.Labels, ",") != "a,b" || pr.Base != "origin/main" {
+		t.Fatalf("gitlabMergeRequest() = %+v", pr)
+	}
+}
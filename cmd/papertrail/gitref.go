@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+// gitPath converts a user-supplied directory into a path inside a git tree.
+func gitPath(dir string) string {
+	return strings.Trim(path.Clean(strings.ReplaceAll(dir, "\\", "/")), "/")
+}
+
+// loadManifestAtRef loads the manifest from ref: manifestPath, or the first default path
+// that exists there.
+func loadManifestAtRef(ctx context.Context, ref, manifestPath string) (releaseManifest, error) {
+	if strings.TrimSpace(manifestPath) != "" {
+		manifestPath = gitPath(manifestPath)
+	}
+	m, err := papertrail.LoadManifest(gitFS{ctx: ctx, ref: ref}, manifestPath)
+	if err != nil {
+		return releaseManifest{}, fmt.Errorf("loading the manifest at %s: %w", ref, err)
+	}
+	return m, nil
+}
+
+// loadItemsAtRef reads and validates fragments from ref without touching the working tree.
+// When paths is empty, every pending fragment under dir at ref is loaded.
+func loadItemsAtRef(ctx context.Context, ref, dir string, paths []string, manifest releaseManifest) ([]item, error) {
+	gfs := gitFS{ctx: ctx, ref: ref}
+	if len(paths) == 0 {
+		var err error
+		paths, err = papertrail.FragmentFiles(gfs, gitPath(dir), manifest)
+		if err != nil {
+			return nil, err
+		}
+		if len(paths) == 0 {
+			return nil, fmt.Errorf("no fragments found under %q at %s", dir, ref)
+		}
+	}
+	items := make([]item, 0, len(paths))
+	for _, p := range paths {
+		p = gitPath(p)
+		fragments, err := papertrail.ReadFragments(gfs, p, manifest)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fragment %s:%s: %w", ref, p, err)
+		}
+		for _, f := range fragments {
+			items = append(items, item{Path: p, Frag: f})
+		}
+	}
+	return items, nil
+}
+
+// refTime returns when ref was released: the tagger date of an annotated tag, otherwise the
+// committer date of the commit it points to.
+func refTime(ctx context.Context, ref string) (time.Time, error) {
+	typ, err := runGit(ctx, "cat-file", "-t", ref)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unknown git ref %q", ref)
+	}
+	if typ == "tag" {
+		tag, err := runGit(ctx, "cat-file", "tag", ref)
+		if err != nil {
+			return time.Time{}, err
+		}
+		for _, line := range strings.Split(tag, "\n") {
+			if line == "" {
+				break // end of the tag header
+			}
+			if rest, ok := strings.CutPrefix(line, "tagger "); ok {
+				return parseGitIdentTime(rest)
+			}
+		}
+		// Tags without a tagger (very old git) fall back to the commit date.
+	}
+	out, err := runGit(ctx, "show", "-s", "--format=%cI", ref+"^{commit}")
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339, out)
+}
+
+// parseGitIdentTime parses the "<unix seconds> <+hhmm>" suffix of a git identity line such as
+// "Jane <jane@example.com> 1700000000 -0500".
+func parseGitIdentTime(ident string) (time.Time, error) {
+	fields := strings.Fields(ident)
+	if len(fields) < 2 {
+		return time.Time{}, fmt.Errorf("malformed git identity %q", ident)
+	}
+	sec, err := strconv.ParseInt(fields[len(fields)-2], 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("malformed git identity %q", ident)
+	}
+	zone, err := time.Parse("-0700", fields[len(fields)-1])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("malformed git identity %q", ident)
+	}
+	return time.Unix(sec, 0).In(zone.Location()), nil
+}
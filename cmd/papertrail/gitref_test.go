@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// initGitRepo creates a git repository in a temp dir, writes files, commits them, and
+// changes into the repository for the rest of the test.
+func initGitRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, body := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"add", "-A"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	t.Chdir(dir)
+	return dir
+}
+
+func TestLoadItemsAtRef(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{
+		"changelog.d/b.yml":                 "component: CLI\ntype: fix\nsummary: b\n",
+		"changelog.d/a.yaml":                "component: CLI\ntype: fix\nsummary: a\n",
+		"changelog.d/notes.txt":             "ignored",
+		"changelog.d/archived/v0.1.0/x.yml": "component: CLI\ntype: fix\nsummary: old\n",
+	})
+
+	// Working tree changes must not affect what is read from the ref.
+	if err := os.Remove(filepath.Join(dir, "changelog.d", "b.yml")); err != nil {
+		t.Fatal(err)
+	}
+
+	items, err := loadItemsAtRef(t.Context(), "HEAD", "changelog.d", nil, releaseManifest{})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(items) != 2 || items[0].Path != "changelog.d/a.yaml" || items[1].Frag.Summary != "b" {
+		t.Fatalf("unexpected items: %+v", items)
+	}
+
+	if _, err := loadItemsAtRef(t.Context(), "HEAD", "missing.d", nil, releaseManifest{}); err == nil {
+		t.Fatalf("expected error for empty fragments dir")
+	}
+}
+
+func TestCmdCheck_Ref(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{
+		".papertrail.config.yml": "types:\n  order: [fix]\n",
+		"changelog.d/a.yml":      "component: CLI\ntype: feature\nsummary: a\n",
+	})
+
+	// The working tree is valid, but HEAD is not: --ref must validate HEAD's content.
+	if err := os.WriteFile(filepath.Join(dir, "changelog.d", "a.yml"), []byte("component: CLI\ntype: fix\nsummary: a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmdCheck(t.Context(), nil); err != nil {
+		t.Fatalf("working tree check: %v", err)
+	}
+	err := cmdCheck(t.Context(), []string{"--ref", "HEAD"})
+	if err == nil || !strings.Contains(err.Error(), `HEAD:changelog.d/a.yml: unknown type "FEATURE"`) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCmdPreview_RefManifest(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{
+		".papertrail.config.yml": "changelog:\n  style:\n    bullet: \"*\"\n",
+		"changelog.d/a.yml":      "component: CLI\ntype: feature\nsummary: Add a\n",
+	})
+
+	// The working tree's config must not affect a preview of the ref.
+	if err := os.WriteFile(filepath.Join(dir, ".papertrail.config.yml"), []byte("types:\n  order: [fix]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out, err := captureStdout(t, func() error { return cmdPreview(t.Context(), []string{"--ref", "HEAD"}) })
+	if err != nil || !strings.Contains(out, "* **feature**: Add a.") {
+		t.Fatalf("preview = %q, %v", out, err)
+	}
+
+	gitIn(t, dir, "add", "-A")
+	gitIn(t, dir, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "config")
+	if err := os.WriteFile(filepath.Join(dir, ".papertrail.config.yml"), []byte("changelog:\n  style:\n    bullet: \"*\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err = cmdPreview(t.Context(), []string{"--ref", "HEAD"})
+	if err == nil || !strings.Contains(err.Error(), `HEAD:changelog.d/a.yml: unknown type "FEATURE"`) {
+		t.Fatalf("expected HEAD's config to reject the fragment, got %v", err)
+	}
+	// A manifest that exists only in the working tree is not at the ref.
+	if err := os.WriteFile(filepath.Join(dir, "local.yml"), []byte("changelog:\n  style:\n    bullet: \"*\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmdPreview(t.Context(), []string{"--ref", "HEAD", "--manifest", "local.yml"}); err == nil || !strings.Contains(err.Error(), "loading the manifest at HEAD") {
+		t.Fatalf("expected a manifest error naming the ref, got %v", err)
+	}
+}
+
+func TestGitFS_NonASCIINames(t *testing.T) {
+	initGitRepo(t, map[string]string{
+		"changelog.d/café.yml":   "component: CLI\ntype: fix\nsummary: café\n",
+		"changelog.d/tab\tx.yml": "component: CLI\ntype: fix\nsummary: tab\n",
+	})
+
+	items, err := loadItemsAtRef(t.Context(), "HEAD", "changelog.d", nil, releaseManifest{})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(items) != 2 || items[0].Path != "changelog.d/café.yml" || items[1].Path != "changelog.d/tab\tx.yml" {
+		t.Fatalf("unexpected items: %+v", items)
+	}
+	if err := fstest.TestFS(gitFS{ctx: t.Context(), ref: "HEAD"}, "changelog.d/café.yml", "changelog.d/tab\tx.yml"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGitFS_Cancelled(t *testing.T) {
+	initGitRepo(t, map[string]string{"changelog.d/a.yml": "component: CLI\ntype: fix\nsummary: a\n"})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	if _, err := loadItemsAtRef(ctx, "HEAD", "changelog.d", nil, releaseManifest{}); err == nil {
+		t.Fatalf("expected error with a cancelled context")
+	}
+}
+
+func TestRefTime(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{"README.md": "x\n"})
+	git := func(env []string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), env...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git([]string{"GIT_COMMITTER_DATE=2024-05-01T23:30:00-04:00"}, "commit", "-q", "--allow-empty", "-m", "release")
+	git(nil, "tag", "v1.0.0")
+	git([]string{"GIT_COMMITTER_DATE=2024-06-10T12:00:00+02:00"}, "tag", "-a", "-m", "v1.0.1", "v1.0.1")
+
+	for ref, want := range map[string]string{
+		"v1.0.0": "2024-05-02T03:30:00Z", // lightweight: commit date
+		"HEAD":   "2024-05-02T03:30:00Z",
+		"v1.0.1": "2024-06-10T10:00:00Z", // annotated: tagger date
+	} {
+		got, err := refTime(t.Context(), ref)
+		if err != nil {
+			t.Fatalf("%s: %v", ref, err)
+		}
+		if s := got.UTC().Format(time.RFC3339); s != want {
+			t.Fatalf("%s: got %s, want %s", ref, s, want)
+		}
+	}
+	if _, err := refTime(t.Context(), "v9.9.9"); err == nil {
+		t.Fatalf("expected an error for an unknown ref")
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+// goModulePath returns the module path declared in go.mod, or "" if there is none.
+func goModulePath(gomod []byte) string {
+	for _, line := range strings.Split(string(gomod), "\n") {
+		if c := strings.Index(line, "//"); c >= 0 {
+			line = line[:c]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`)
+		}
+	}
+	return ""
+}
+
+// checkModuleMajor reports whether v can be tagged for the Go module at modulePath: v2 and
+// later need a matching /vN path suffix (gopkg.in paths always carry .vN), and v0/v1 must
+// not have one.
+func checkModuleMajor(modulePath string, v semver) error {
+	if strings.HasPrefix(modulePath, "gopkg.in/") {
+		dot := strings.LastIndex(modulePath, ".v")
+		if dot < 0 {
+			return nil
+		}
+		if n, err := strconv.ParseUint(modulePath[dot+2:], 10, 64); err == nil && n != v.Major {
+			return fmt.Errorf("version %s does not match Go module %s: gopkg.in paths must use .v%d", v, modulePath, v.Major)
+		}
+		return nil
+	}
+	suffix := uint64(0)
+	if i := strings.LastIndex(modulePath, "/v"); i >= 0 {
+		if n, err := strconv.ParseUint(modulePath[i+2:], 10, 64); err == nil && n >= 2 && !strings.HasPrefix(modulePath[i+2:], "0") {
+			suffix = n
+		}
+	}
+	switch {
+	case suffix == 0 && v.Major >= 2:
+		return fmt.Errorf("version %s does not match Go module %s: major version %d requires the module path to end in /v%d", v, modulePath, v.Major, v.Major)
+	case suffix != 0 && v.Major != suffix:
+		return fmt.Errorf("version %s does not match Go module %s: the /v%d path only accepts v%d.x.y versions", v, modulePath, suffix, suffix)
+	}
+	return nil
+}
+
+// checkGoModuleVersion applies checkModuleMajor when fsys has a go.mod at its root, i.e.
+// when the repository is a Go module.
+func checkGoModuleVersion(fsys fs.FS, v semver) error {
+	b, err := fs.ReadFile(fsys, "go.mod")
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	mod := goModulePath(b)
+	if mod == "" {
+		return nil
+	}
+	return checkModuleMajor(mod, v)
+}
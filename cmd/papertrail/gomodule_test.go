@@ -0,0 +1,41 @@
+package main
+
+import "testing"
+
+func TestCheckModuleMajor(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		module, version string
+		ok              bool
+	}{
+		{"example.com/app", "v0.3.0", true},
+		{"example.com/app", "v1.9.0", true},
+		{"example.com/app", "v2.0.0", false},
+		{"example.com/app/v2", "v2.1.0", true},
+		{"example.com/app/v2", "v3.0.0", false},
+		{"example.com/app/v2", "v1.5.0", false},
+		{"example.com/v1", "v1.0.0", true},
+		{"gopkg.in/yaml.v3", "v3.0.2", true},
+		{"gopkg.in/yaml.v3", "v4.0.0", false},
+		{"gopkg.in/check.v1", "v1.0.0", true},
+	}
+	for _, c := range cases {
+		err := checkModuleMajor(c.module, mustSemver(t, c.version))
+		if (err == nil) != c.ok {
+			t.Fatalf("%s @ %s: got err %v, want ok=%v", c.module, c.version, err, c.ok)
+		}
+	}
+}
+
+func TestCheckGoModuleVersion(t *testing.T) {
+	t.Parallel()
+
+	fsys := newMemFS(map[string]string{"go.mod": "// app\nmodule \"example.com/app\" // comment\n\ngo 1.22\n"})
+	if err := checkGoModuleVersion(fsys, mustSemver(t, "v2.0.0")); err == nil {
+		t.Fatalf("expected a major path mismatch")
+	}
+	if err := checkGoModuleVersion(newMemFS(nil), mustSemver(t, "v2.0.0")); err != nil {
+		t.Fatalf("non-Go repositories are not checked: %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// hookMarker identifies hook scripts written by `hooks install`, which it may overwrite.
+const hookMarker = "# papertrail-hook"
+
+// hookCommands maps each supported git hook to the papertrail invocation it runs. pre-commit
+// validates the fragments being committed as staged; pre-push validates fragments at HEAD.
+// commit-msg, installed only on request, checks the commit message git passes it.
+var hookCommands = map[string]string{
+	"pre-commit": "check --staged",
+	"pre-push":   "check --ref HEAD --allow-empty",
+	"commit-msg": "commit-msg",
+}
+
+func cmdHooks(ctx context.Context, args []string) error {
+	if len(args) == 0 || args[0] != "install" {
+		return fmt.Errorf("usage: papertrail hooks install [--hook pre-commit|pre-push|commit-msg]... [--fragments <dir>] [--manifest <path>] [--bin <command>] [--force]")
+	}
+	fs := flag.NewFlagSet("hooks install", flag.ContinueOnError)
+	fs.SetOutput(ioDiscard{})
+	fragmentsDir := fs.String("fragments", "changelog.d", "fragments directory")
+	manifestPath := fs.String("manifest", "", "optional release config YAML path")
+	bin := fs.String("bin", "papertrail", "command the hooks run papertrail with")
+	force := fs.Bool("force", false, "overwrite existing hooks not installed by papertrail")
+	var hooks stringList
+	fs.Var(&hooks, "hook", "hook to install: pre-commit, pre-push, or commit-msg (repeatable; default: pre-commit and pre-push)")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if len(hooks) == 0 {
+		hooks = stringList{"pre-commit", "pre-push"}
+	}
+
+	dir, err := runGit(ctx, "rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	sort.Strings(hooks)
+	for _, h := range hooks {
+		sub, ok := hookCommands[h]
+		if !ok {
+			return fmt.Errorf("unsupported hook %q (expected pre-commit, pre-push, or commit-msg)", h)
+		}
+		p := filepath.Join(dir, h)
+		if existing, err := os.ReadFile(p); err == nil && !strings.Contains(string(existing), hookMarker) && !*force {
+			return fmt.Errorf("%s already exists and was not installed by papertrail (use --force to overwrite)", p)
+		}
+		if err := os.WriteFile(p, []byte(hookScript(*bin, h, sub, *fragmentsDir, *manifestPath)), 0755); err != nil {
+			return err
+		}
+		// WriteFile keeps the mode of an existing file.
+		if err := os.Chmod(p, 0755); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stdout, "installed %s\n", p)
+	}
+	return nil
+}
+
+func hookScript(bin, hook, sub, fragmentsDir, manifestPath string) string {
+	cmd := bin + " " + sub
+	if hook != "commit-msg" {
+		cmd += " --fragments " + shellQuote(fragmentsDir)
+	}
+	if manifestPath != "" {
+		cmd += " --manifest " + shellQuote(manifestPath)
+	}
+	if hook == "commit-msg" {
+		// git passes the path of the message file.
+		cmd += ` "$1"`
+	}
+	return "#!/bin/sh\n" + hookMarker + " (installed by `papertrail hooks install`)\nexec " + cmd + "\n"
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// stagedFragmentFiles returns the fragments added or modified in the index under dir. They
+// are read from the index (gitFS with an empty ref), not the working tree.
+func stagedFragmentFiles(ctx context.Context, dir string, manifest releaseManifest) ([]fragmentFile, error) {
+	// -z keeps names with non-ASCII or special characters unquoted.
+	out, err := runCmdRaw(ctx, "git", "diff", "--cached", "--name-only", "-z", "--diff-filter=ACMR")
+	if err != nil {
+		return nil, err
+	}
+	dir = gitPath(dir)
+	var files []fragmentFile
+	for _, p := range strings.Split(string(out), "\x00") {
+		if p == "" || !isFragmentPath(p, dir, manifest) || strings.Contains(p, "/archived/") {
+			continue
+		}
+		files = append(files, fragmentFile{FS: gitFS{ctx: ctx}, Path: p, Name: p})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
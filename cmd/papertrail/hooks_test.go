@@ -0,0 +1,114 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func gitIn(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func TestCmdCheck_Staged(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{
+		".papertrail.config.yml": "types:\n  order: [fix]\n",
+		"changelog.d/old.yml":    "component: CLI\ntype: chore\nsummary: committed, not re-checked\n",
+	})
+
+	// Nothing staged: nothing to check.
+	if err := cmdCheck(t.Context(), []string{"--staged"}); err != nil {
+		t.Fatalf("nothing staged: %v", err)
+	}
+
+	p := filepath.Join(dir, "changelog.d", "new.yml")
+	if err := os.WriteFile(p, []byte("component: CLI\ntype: chore\nsummary: bad\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitIn(t, dir, "add", "changelog.d/new.yml")
+	// The staged content is checked, not the working tree.
+	if err := os.WriteFile(p, []byte("component: CLI\ntype: fix\nsummary: fixed but unstaged\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := cmdCheck(t.Context(), []string{"--staged"})
+	if err == nil || !strings.Contains(err.Error(), "changelog.d/new.yml") || strings.Contains(err.Error(), "old.yml") {
+		t.Fatalf("expected only the staged fragment to fail, got %v", err)
+	}
+
+	gitIn(t, dir, "add", "changelog.d/new.yml")
+	if err := cmdCheck(t.Context(), []string{"--staged"}); err != nil {
+		t.Fatalf("valid staged fragment: %v", err)
+	}
+
+	// Names git would otherwise quote are read as staged.
+	if err := os.WriteFile(filepath.Join(dir, "changelog.d", "café.yml"), []byte("component: CLI\ntype: chore\nsummary: bad\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitIn(t, dir, "add", "changelog.d/café.yml")
+	if err := cmdCheck(t.Context(), []string{"--staged"}); err == nil || !strings.Contains(err.Error(), "changelog.d/café.yml") {
+		t.Fatalf("expected the staged café.yml to fail, got %v", err)
+	}
+	gitIn(t, dir, "rm", "-q", "--cached", "changelog.d/café.yml")
+	if err := cmdCheck(t.Context(), []string{"--staged", "--ref", "HEAD"}); err == nil {
+		t.Fatalf("expected --staged with --ref to fail")
+	}
+}
+
+func TestCmdHooksInstall(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{"README.md": "x\n"})
+	hooksDir := filepath.Join(dir, ".git", "hooks")
+
+	if err := cmdHooks(t.Context(), []string{"install", "--fragments", "notes.d"}); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	for h, want := range map[string]string{
+		"pre-commit": "exec papertrail check --staged --fragments 'notes.d'",
+		"pre-push":   "exec papertrail check --ref HEAD --allow-empty --fragments 'notes.d'",
+	} {
+		p := filepath.Join(hooksDir, h)
+		b, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(b), want) {
+			t.Fatalf("%s:\n%s", h, b)
+		}
+		if fi, _ := os.Stat(p); fi.Mode().Perm()&0111 == 0 {
+			t.Fatalf("%s is not executable", h)
+		}
+	}
+
+	// Our own hooks are replaced; foreign hooks need --force.
+	if err := cmdHooks(t.Context(), []string{"install", "--hook", "pre-commit"}); err != nil {
+		t.Fatalf("reinstall: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(hooksDir, "pre-push"), []byte("#!/bin/sh\nmake lint\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmdHooks(t.Context(), []string{"install", "--hook", "pre-push"}); err == nil {
+		t.Fatalf("expected an existing hook to be kept without --force")
+	}
+	if err := cmdHooks(t.Context(), []string{"install", "--hook", "pre-push", "--force"}); err != nil {
+		t.Fatalf("force: %v", err)
+	}
+	// commit-msg is opt-in and checks the message file git passes.
+	if _, err := os.Stat(filepath.Join(hooksDir, "commit-msg")); err == nil {
+		t.Fatalf("commit-msg installed by default")
+	}
+	if err := cmdHooks(t.Context(), []string{"install", "--hook", "commit-msg", "--manifest", "cfg.yml"}); err != nil {
+		t.Fatalf("install commit-msg: %v", err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(hooksDir, "commit-msg")); !strings.Contains(string(b), "exec papertrail commit-msg --manifest 'cfg.yml' \"$1\"\n") {
+		t.Fatalf("commit-msg:\n%s", b)
+	}
+	if err := cmdHooks(t.Context(), []string{"install", "--hook", "post-merge"}); err == nil {
+		t.Fatalf("expected an unsupported hook to fail")
+	}
+}
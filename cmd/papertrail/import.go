@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+// importers are the `import` sources, by tool name.
+var importers = map[string]func(context.Context, []string) error{
+	"towncrier": cmdImportTowncrier,
+	"changie":   cmdImportChangie,
+}
+
+// cmdImport converts another changelog tool's pending entries into papertrail fragments.
+func cmdImport(ctx context.Context, args []string) error {
+	names := make([]string, 0, len(importers))
+	for n := range importers {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	if len(args) == 0 {
+		return fmt.Errorf("usage: papertrail import <%s> [flags]", strings.Join(names, "|"))
+	}
+	run, ok := importers[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown import source %q (expected %s)", args[0], strings.Join(names, "|"))
+	}
+	return run(ctx, args[1:])
+}
+
+// issueNumber matches bare issue numbers, imported as "#123" refs.
+var issueNumber = regexp.MustCompile(`^[0-9]+$`)
+
+// importedFragment is one entry converted from another tool, not yet written.
+type importedFragment struct {
+	// Source is the file the entry came from; Name seeds the new fragment's file name.
+	Source string
+	Name   string
+	Frag   fragment
+}
+
+// importTypes builds the source type → fragment type table: import.types from the manifest,
+// then --type from=to flags. Keys are lowercase; an empty value skips that type.
+func importTypes(manifest releaseManifest, flags []string) (map[string]string, error) {
+	types := map[string]string{}
+	for k, v := range manifest.Import.Types {
+		types[strings.ToLower(strings.TrimSpace(k))] = strings.TrimSpace(v)
+	}
+	for _, f := range flags {
+		from, to, ok := strings.Cut(f, "=")
+		if !ok || strings.TrimSpace(from) == "" {
+			return nil, fmt.Errorf("invalid --type %q (expected from=to)", f)
+		}
+		types[strings.ToLower(strings.TrimSpace(from))] = strings.TrimSpace(to)
+	}
+	return types, nil
+}
+
+// mapImportType maps a source type through types; unmapped types are kept as they are.
+// skip is true for types mapped to "".
+func mapImportType(types map[string]string, t string) (mapped string, skip bool) {
+	if v, ok := types[strings.ToLower(t)]; ok {
+		return v, v == ""
+	}
+	return t, false
+}
+
+// splitImportedText turns free-form entry text into a one-line summary (its first
+// paragraph) and Markdown details (the rest).
+func splitImportedText(text string) (summary, details string) {
+	text = strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n"))
+	first, rest, _ := strings.Cut(text, "\n\n")
+	return strings.Join(strings.Fields(first), " "), strings.TrimSpace(rest)
+}
+
+// writeImportedFragments validates every imported entry, then writes each as a fragment
+// under fragmentsDir: YAML, or Markdown when it has details and fragments.formats enables
+// Markdown. Nothing is written if any entry
+// is invalid or would overwrite a file. With remove, the source files are deleted once all
+// fragments are written.
+func writeImportedFragments(entries []importedFragment, fragmentsDir string, manifest releaseManifest, dryRun, remove bool) error {
+	if len(entries) == 0 {
+		return fmt.Errorf("nothing to import")
+	}
+	now, err := releaseTime(manifest)
+	if err != nil {
+		return err
+	}
+	type output struct {
+		path    string
+		content []byte
+	}
+	outputs := make([]output, len(entries))
+	seen := map[string]bool{}
+	var problems []string
+	for i, e := range entries {
+		content, ext, err := importedFragmentContent(e.Frag, manifest)
+		if err != nil {
+			return err
+		}
+		p := filepath.Join(fragmentsDir, now.Format("20060102")+"_"+fragmentSlug(e.Name)+ext)
+		if _, err := papertrail.ParseFragmentFile(p, content, manifest); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", e.Source, err))
+			continue
+		}
+		if _, err := os.Stat(p); err == nil || seen[p] {
+			problems = append(problems, fmt.Sprintf("%s: %s already exists", e.Source, p))
+			continue
+		}
+		seen[p] = true
+		outputs[i] = output{path: p, content: content}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("nothing imported:\n%s", strings.Join(problems, "\n"))
+	}
+
+	if !dryRun {
+		if err := os.MkdirAll(fragmentsDir, 0755); err != nil {
+			return err
+		}
+	}
+	for i, o := range outputs {
+		if !dryRun {
+			if err := os.WriteFile(o.path, o.content, 0644); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintf(os.Stdout, "%s -> %s\n", entries[i].Source, o.path)
+	}
+	if dryRun || !remove {
+		return nil
+	}
+	removed := map[string]bool{}
+	for _, e := range entries {
+		if removed[e.Source] {
+			continue
+		}
+		removed[e.Source] = true
+		if err := os.Remove(e.Source); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importedFragmentContent renders f as a fragment file in lint's canonical form and returns
+// its extension.
+func importedFragmentContent(f fragment, manifest releaseManifest) ([]byte, string, error) {
+	details := f.Details
+	if slices.Contains(manifest.FragmentFormats(), papertrail.FormatMarkdown) {
+		// Details go in a Markdown body, which reads better than a YAML block.
+		f.Details = ""
+	}
+	f.Refs = append([]string(nil), f.Refs...)
+	sort.Strings(f.Refs)
+	b, err := encodeFragment(f)
+	if err != nil {
+		return nil, "", err
+	}
+	if f.Details != "" || details == "" {
+		return b, ".yml", nil
+	}
+	return []byte("---\n" + string(b) + "---\n\n" + details + "\n"), ".md", nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// changieRefKeys are the changie custom fields (lowercase) imported as refs.
+var changieRefKeys = []string{"issue", "pr", "pull", "pullrequest", "pull_request"}
+
+// changieEntry is a changie change file.
+type changieEntry struct {
+	Component string            `yaml:"component"`
+	Kind      string            `yaml:"kind"`
+	Body      string            `yaml:"body"`
+	Custom    map[string]string `yaml:"custom"`
+}
+
+// cmdImportChangie converts changie's unreleased change files into papertrail fragments.
+func cmdImportChangie(_ context.Context, args []string) error {
+	fs := flag.NewFlagSet("import changie", flag.ContinueOnError)
+	fs.SetOutput(ioDiscard{})
+	dir := fs.String("dir", filepath.Join(".changes", "unreleased"), "changie unreleased changes directory")
+	fragmentsDir := fs.String("fragments", "changelog.d", "fragments directory to write")
+	manifestPath := fs.String("manifest", "", "optional release config YAML path")
+	component := fs.String("component", "", "component for changes without one")
+	var typeFlags stringList
+	fs.Var(&typeFlags, "type", "map a changie kind to a fragment type, as from=to; an empty to skips it (repeatable)")
+	dryRun := fs.Bool("dry-run", false, "print what would be imported without writing")
+	remove := fs.Bool("remove", false, "delete the changie files once imported")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	manifest, err := loadManifestDefault(*manifestPath)
+	if err != nil {
+		return err
+	}
+	types, err := importTypes(manifest, typeFlags)
+	if err != nil {
+		return err
+	}
+	entries, err := readChangieEntries(*dir, strings.TrimSpace(*component), types, manifest)
+	if err != nil {
+		return err
+	}
+	return writeImportedFragments(entries, *fragmentsDir, manifest, *dryRun, *remove)
+}
+
+// readChangieEntries reads the .yaml change files in dir.
+func readChangieEntries(dir, component string, types map[string]string, manifest releaseManifest) ([]importedFragment, error) {
+	des, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var entries []importedFragment
+	var problems []string
+	for _, de := range des {
+		ext := strings.ToLower(filepath.Ext(de.Name()))
+		if de.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		p := filepath.Join(dir, de.Name())
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		var c changieEntry
+		if err := yaml.Unmarshal(b, &c); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: invalid YAML: %v", p, err))
+			continue
+		}
+		kind := strings.TrimSpace(c.Kind)
+		if kind == "" {
+			problems = append(problems, fmt.Sprintf("%s: no kind", p))
+			continue
+		}
+		typ, skip := mapImportType(types, kind)
+		if skip {
+			fmt.Fprintf(os.Stderr, "skipping %s: kind %s is not imported\n", p, kind)
+			continue
+		}
+		e := importedFragment{
+			Source: p,
+			Name:   strings.TrimSuffix(de.Name(), filepath.Ext(de.Name())),
+			Frag: fragment{
+				Component: strings.TrimSpace(c.Component),
+				Type:      manifest.CanonicalType(typ),
+				Refs:      changieRefs(c.Custom),
+			},
+		}
+		e.Frag.Summary, e.Frag.Details = splitImportedText(c.Body)
+		if e.Frag.Component == "" {
+			e.Frag.Component = component
+		}
+		if e.Frag.Component == "" {
+			problems = append(problems, fmt.Sprintf("%s: no component (use --component)", p))
+			continue
+		}
+		entries = append(entries, e)
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("nothing imported:\n%s", strings.Join(problems, "\n"))
+	}
+	return entries, nil
+}
+
+// changieRefs returns the issue and pull request custom fields as refs, "#123" for numbers.
+func changieRefs(custom map[string]string) []string {
+	var refs []string
+	for _, k := range changieRefKeys {
+		for key, v := range custom {
+			v = strings.TrimSpace(v)
+			if strings.ToLower(strings.ReplaceAll(key, " ", "")) != k || v == "" {
+				continue
+			}
+			if issueNumber.MatchString(v) {
+				v = "#" + v
+			}
+			refs = append(refs, v)
+		}
+	}
+	return refs
+}
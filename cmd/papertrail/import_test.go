@@ -0,0 +1,160 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseTowncrierName(t *testing.T) {
+	t.Parallel()
+
+	types := map[string]string{"security": "fix"}
+	for name, want := range map[string][2]string{
+		"123.feature":      {"123", "feature"},
+		"123.bugfix.1":     {"123", "bugfix"},
+		"123.doc.md":       {"123", "doc"},
+		"+orphan.misc.rst": {"+orphan", "misc"},
+		"gh-7.security":    {"gh-7", "security"},
+		"123.unknown":      {},
+		"README.rst":       {},
+		"template.jinja":   {},
+		"no-type":          {},
+		".feature":         {},
+	} {
+		issue, typ, ok := parseTowncrierName(name, types)
+		if ok != (want[1] != "") || issue != want[0] || typ != want[1] {
+			t.Fatalf("parseTowncrierName(%q) = %q, %q, %v; want %q", name, issue, typ, ok, want)
+		}
+	}
+}
+
+func TestCmdImportTowncrier(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{
+		".papertrail.config.yml":       "import:\n  types:\n    bugfix: fix\n    misc: \"\"\nfragments:\n  formats: [yaml, markdown]\n",
+		"newsfragments/123.feature":    "Add the thing.\n",
+		"newsfragments/45.bugfix.md":   "Fix the\nother thing.\n\nIt crashed on *empty* input.\n",
+		"newsfragments/+x.misc":        "Internal cleanup.\n",
+		"newsfragments/api/+a.removal": "Drop the v1 endpoint.\n",
+		"newsfragments/.gitignore":     "!.gitignore\n",
+	})
+	t.Setenv("SOURCE_DATE_EPOCH", "1790000000") // 2026-09-21
+
+	if err := cmdImport(t.Context(), []string{"towncrier"}); err == nil || !strings.Contains(err.Error(), "no component") {
+		t.Fatalf("expected a missing component error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "changelog.d")); err == nil {
+		t.Fatalf("nothing should be written when an entry is invalid")
+	}
+
+	out, err := captureStdout(t, func() error {
+		return cmdImport(t.Context(), []string{"towncrier", "--component", "CLI", "--type", "removal=breaking", "--remove"})
+	})
+	if err != nil {
+		t.Fatalf("import towncrier: %v", err)
+	}
+	for _, want := range []string{
+		"newsfragments/123.feature -> changelog.d/20260921_123_feature.yml",
+		"newsfragments/45.bugfix.md -> changelog.d/20260921_45_bugfix.md",
+		"newsfragments/api/+a.removal -> changelog.d/20260921_a_removal.yml",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("output missing %q:\n%s", want, out)
+		}
+	}
+	read := func(p string) string {
+		b, err := os.ReadFile(filepath.Join(dir, p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	if got := read("changelog.d/20260921_123_feature.yml"); got != "component: CLI\ntype: FEATURE\nsummary: Add the thing.\nrefs:\n  - '#123'\n" {
+		t.Fatalf("feature fragment:\n%s", got)
+	}
+	if got := read("changelog.d/20260921_45_bugfix.md"); !strings.Contains(got, "type: FIX\nsummary: Fix the other thing.\n") || !strings.HasSuffix(got, "---\n\nIt crashed on *empty* input.\n") {
+		t.Fatalf("bugfix fragment:\n%s", got)
+	}
+	if got := read("changelog.d/20260921_a_removal.yml"); strings.Contains(got, "refs") || !strings.Contains(got, "type: BREAKING") {
+		t.Fatalf("removal fragment:\n%s", got)
+	}
+	// Skipped entries are left in place; imported ones are removed.
+	if _, err := os.Stat(filepath.Join(dir, "newsfragments", "+x.misc")); err != nil {
+		t.Fatalf("skipped fragment removed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "newsfragments", "123.feature")); !os.IsNotExist(err) {
+		t.Fatalf("imported fragment not removed: %v", err)
+	}
+	if err := cmdCheck(t.Context(), nil); err != nil {
+		t.Fatalf("imported fragments do not pass check: %v", err)
+	}
+}
+
+func TestCmdImport_CanonicalForm(t *testing.T) {
+	initGitRepo(t, map[string]string{
+		"newsfragments/123.feature": "Add the thing.\n",
+		"newsfragments/45.bugfix":   "Fix the other thing.\n\nIt crashed on *empty* input.\n",
+	})
+	t.Setenv("SOURCE_DATE_EPOCH", "1790000000")
+
+	if _, err := captureStdout(t, func() error {
+		return cmdImport(t.Context(), []string{"towncrier", "--component", "CLI", "--type", "bugfix=fix"})
+	}); err != nil {
+		t.Fatalf("import towncrier: %v", err)
+	}
+	if err := cmdLint(t.Context(), nil); err != nil {
+		t.Fatalf("imported fragments are not in canonical form: %v", err)
+	}
+}
+
+func TestCmdImportChangie(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{
+		".papertrail.config.yml":                                "import:\n  types:\n    added: feature\n    fixed: fix\n    dependencies: \"\"\n",
+		".changes/unreleased/Added-20260901-101500.yaml":        "component: API\nkind: Added\nbody: Add the endpoint\ntime: 2026-09-01T10:15:00Z\ncustom:\n  Issue: \"12\"\n",
+		".changes/unreleased/Fixed-20260902-090000.yaml":        "kind: Fixed\nbody: |-\n  Fix the crash.\n\n  It happened on *empty* input.\ncustom:\n  PR: https://example.com/pr/3\n",
+		".changes/unreleased/Dependencies-20260903-090000.yaml": "kind: Dependencies\nbody: Bump yaml\n",
+		".changes/unreleased/.gitkeep":                          "",
+	})
+	t.Setenv("SOURCE_DATE_EPOCH", "1790000000") // 2026-09-21
+
+	if err := cmdImport(t.Context(), []string{"changie"}); err == nil || !strings.Contains(err.Error(), "Fixed-20260902-090000.yaml: no component") {
+		t.Fatalf("expected a missing component error, got %v", err)
+	}
+	out, err := captureStdout(t, func() error {
+		return cmdImport(t.Context(), []string{"changie", "--component", "CLI", "--dry-run"})
+	})
+	if err != nil {
+		t.Fatalf("import changie --dry-run: %v", err)
+	}
+	// Without Markdown in fragments.formats, details stay in the YAML fragment.
+	if !strings.Contains(out, "-> changelog.d/20260921_fixed_20260902_090000.yml") {
+		t.Fatalf("output:\n%s", out)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "changelog.d")); err == nil {
+		t.Fatalf("--dry-run wrote fragments")
+	}
+
+	if _, err := captureStdout(t, func() error {
+		return cmdImport(t.Context(), []string{"changie", "--component", "CLI"})
+	}); err != nil {
+		t.Fatalf("import changie: %v", err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "changelog.d", "20260921_added_20260901_101500.yml"))
+	if err != nil || string(b) != "component: API\ntype: FEATURE\nsummary: Add the endpoint\nrefs:\n  - '#12'\n" {
+		t.Fatalf("added fragment: %q, %v", b, err)
+	}
+	b, err = os.ReadFile(filepath.Join(dir, "changelog.d", "20260921_fixed_20260902_090000.yml"))
+	if err != nil || !strings.Contains(string(b), "component: CLI\ntype: FIX\n") || !strings.Contains(string(b), "- https://example.com/pr/3\n") || !strings.Contains(string(b), "details: It happened on *empty* input.\n") {
+		t.Fatalf("fixed fragment: %q, %v", b, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "changelog.d", "20260921_dependencies_20260903_090000.yml")); err == nil {
+		t.Fatalf("skipped kind was imported")
+	}
+	if err := cmdImport(t.Context(), []string{"changie", "--component", "CLI"}); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("expected re-importing to refuse overwriting, got %v", err)
+	}
+	if err := cmdImport(t.Context(), []string{"svn"}); err == nil || !strings.Contains(err.Error(), "unknown import source") {
+		t.Fatalf("expected an unknown source error, got %v", err)
+	}
+}
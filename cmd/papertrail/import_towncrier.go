@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// towncrierTypes are towncrier's default fragment types.
+var towncrierTypes = []string{"feature", "bugfix", "doc", "removal", "misc"}
+
+// cmdImportTowncrier converts towncrier news fragments into papertrail fragments.
+func cmdImportTowncrier(_ context.Context, args []string) error {
+	fs := flag.NewFlagSet("import towncrier", flag.ContinueOnError)
+	fs.SetOutput(ioDiscard{})
+	dir := fs.String("dir", "newsfragments", "towncrier fragments directory")
+	fragmentsDir := fs.String("fragments", "changelog.d", "fragments directory to write")
+	manifestPath := fs.String("manifest", "", "optional release config YAML path")
+	component := fs.String("component", "", "component for imported fragments (default: the towncrier section directory)")
+	var typeFlags stringList
+	fs.Var(&typeFlags, "type", "map a towncrier type to a fragment type, as from=to; an empty to skips it (repeatable)")
+	dryRun := fs.Bool("dry-run", false, "print what would be imported without writing")
+	remove := fs.Bool("remove", false, "delete the towncrier fragments once imported")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	manifest, err := loadManifestDefault(*manifestPath)
+	if err != nil {
+		return err
+	}
+	types, err := importTypes(manifest, typeFlags)
+	if err != nil {
+		return err
+	}
+	entries, err := readTowncrierFragments(*dir, strings.TrimSpace(*component), types, manifest)
+	if err != nil {
+		return err
+	}
+	return writeImportedFragments(entries, *fragmentsDir, manifest, *dryRun, *remove)
+}
+
+// readTowncrierFragments reads the towncrier fragments in dir and in its section
+// subdirectories, whose names become the component unless one is given.
+func readTowncrierFragments(dir, component string, types map[string]string, manifest releaseManifest) ([]importedFragment, error) {
+	des, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var entries []importedFragment
+	var problems []string
+	read := func(p, section string) error {
+		e, ok, err := readTowncrierFragment(p, types)
+		if err != nil || !ok {
+			return err
+		}
+		e.Frag.Component = component
+		if e.Frag.Component == "" {
+			e.Frag.Component = section
+		}
+		if e.Frag.Component == "" {
+			problems = append(problems, fmt.Sprintf("%s: no component (use --component)", p))
+			return nil
+		}
+		e.Frag.Type = manifest.CanonicalType(e.Frag.Type)
+		entries = append(entries, e)
+		return nil
+	}
+	for _, de := range des {
+		p := filepath.Join(dir, de.Name())
+		if strings.HasPrefix(de.Name(), ".") {
+			continue
+		}
+		if !de.IsDir() {
+			if err := read(p, ""); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		sub, err := os.ReadDir(p)
+		if err != nil {
+			return nil, err
+		}
+		for _, se := range sub {
+			if se.IsDir() || strings.HasPrefix(se.Name(), ".") {
+				continue
+			}
+			if err := read(filepath.Join(p, se.Name()), de.Name()); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("nothing imported:\n%s", strings.Join(problems, "\n"))
+	}
+	return entries, nil
+}
+
+// readTowncrierFragment reads one towncrier fragment, named <issue>.<type>[.<counter>] with
+// an optional .md, .rst, or .txt extension. ok is false for files that are not fragments of
+// a known type (towncrier's defaults and the mapped types) and for types mapped to "".
+func readTowncrierFragment(p string, types map[string]string) (e importedFragment, ok bool, err error) {
+	issue, typ, ok := parseTowncrierName(filepath.Base(p), types)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "skipping %s: not a towncrier fragment of a known type\n", p)
+		return e, false, nil
+	}
+	mapped, skip := mapImportType(types, typ)
+	if skip {
+		fmt.Fprintf(os.Stderr, "skipping %s: type %s is not imported\n", p, typ)
+		return e, false, nil
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return e, false, err
+	}
+	summary, details := splitImportedText(string(b))
+	e = importedFragment{
+		Source: p,
+		Name:   strings.TrimPrefix(issue, "+") + "_" + typ,
+		Frag:   fragment{Type: mapped, Summary: summary, Details: details},
+	}
+	if ref := towncrierRef(issue); ref != "" {
+		e.Frag.Refs = []string{ref}
+	}
+	return e, true, nil
+}
+
+// parseTowncrierName splits a towncrier fragment file name into its issue and type.
+func parseTowncrierName(name string, types map[string]string) (issue, typ string, ok bool) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".md", ".rst", ".txt":
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	parts := strings.Split(name, ".")
+	if len(parts) > 2 && issueNumber.MatchString(parts[len(parts)-1]) {
+		parts = parts[:len(parts)-1]
+	}
+	if len(parts) < 2 {
+		return "", "", false
+	}
+	typ = strings.ToLower(parts[len(parts)-1])
+	issue = strings.Join(parts[:len(parts)-1], ".")
+	if _, mapped := types[typ]; issue == "" || (!mapped && !slices.Contains(towncrierTypes, typ)) {
+		return "", "", false
+	}
+	return issue, typ, true
+}
+
+// towncrierRef turns a towncrier issue into a fragment ref: "#123" for issue numbers, none
+// for orphan fragments ("+name"), and the issue itself otherwise.
+func towncrierRef(issue string) string {
+	switch {
+	case strings.HasPrefix(issue, "+"):
+		return ""
+	case issueNumber.MatchString(issue):
+		return "#" + issue
+	}
+	return issue
+}
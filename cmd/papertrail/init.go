@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+// starterConfig is the .papertrail.config.yml init writes. Its comments state the default
+// papertrail uses when each setting is absent.
+const starterConfig = `# Papertrail release configuration.
+
+versioning:
+  # SemVer bump per fragment type (major|minor|patch; "*" matches any other type).
+  # Types without a rule bump patch.
+  rules:
+    breaking: major
+    feature: minor
+    deprecation: minor
+    fix: patch
+
+changelog:
+  # Component headings in output order. Components not listed follow alphabetically.
+  components: []
+  # Reject fragments whose component is not listed above (default: false).
+  strict_components: false
+  # IANA timezone for default release dates (default: UTC).
+  # timezone: America/New_York
+
+types:
+  # Allowed fragment types, in output order. When empty, any type is accepted and types
+  # are ordered alphabetically.
+  order:
+    - breaking
+    - feature
+    - deprecation
+    - fix
+  # Alternate spellings mapped to the types above.
+  aliases:
+    bugfix: fix
+`
+
+// starterChangelog is the empty CHANGELOG.md init writes; merge inserts releases below
+// papertrail.ReleaseAnchor.
+const starterChangelog = "# Changelog\n\nAll notable changes to this project are documented here.\n\n" + papertrail.ReleaseAnchor + "\n"
+
+const exampleFragment = `component: General
+type: feature
+summary: Start keeping a changelog with papertrail fragments.
+`
+
+type initFile struct {
+	path    string
+	content string
+}
+
+// cmdInit bootstraps a repository: a starter config, an empty changelog with an insertion
+// anchor, the fragments directory, and an example fragment. Existing files are kept.
+func cmdInit(_ context.Context, args []string) error {
+	fs := flag.NewFlagSet("init", flag.ContinueOnError)
+	fs.SetOutput(ioDiscard{})
+	fragmentsDir := fs.String("fragments", "changelog.d", "fragments directory")
+	changelogPath := fs.String("changelog", "CHANGELOG.md", "changelog path")
+	configPath := fs.String("config", ".papertrail.config.yml", "config path")
+	noExample := fs.Bool("no-example", false, "do not write the example fragment")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	manifest, err := papertrail.ParseManifest([]byte(starterConfig))
+	if err != nil {
+		return fmt.Errorf("starter config: %w", err)
+	}
+	now, err := releaseTime(manifest)
+	if err != nil {
+		return err
+	}
+
+	files := []initFile{
+		{*configPath, starterConfig},
+		{*changelogPath, starterChangelog},
+	}
+	// Only seed the example into a new fragments directory, so re-running init is a no-op.
+	_, err = os.Stat(*fragmentsDir)
+	newDir := errors.Is(err, os.ErrNotExist)
+	if err := os.MkdirAll(*fragmentsDir, 0755); err != nil {
+		return err
+	}
+	if newDir {
+		fmt.Fprintf(os.Stdout, "created %s/\n", *fragmentsDir)
+	}
+	if !*noExample && newDir {
+		files = append(files, initFile{filepath.Join(*fragmentsDir, now.Format("20060102")+"_adopt_papertrail.yml"), exampleFragment})
+	}
+
+	for _, f := range files {
+		if _, err := os.Stat(f.path); err == nil {
+			fmt.Fprintf(os.Stdout, "kept existing %s\n", f.path)
+			continue
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		if dir := filepath.Dir(f.path); dir != "." {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return err
+			}
+		}
+		if err := os.WriteFile(f.path, []byte(f.content), 0644); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stdout, "created %s\n", f.path)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCmdInit(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("SOURCE_DATE_EPOCH", "1767225600") // 2026-01-01
+
+	if err := cmdInit(t.Context(), nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{".papertrail.config.yml", "CHANGELOG.md", "changelog.d/20260101_adopt_papertrail.yml"} {
+		if _, err := os.Stat(p); err != nil {
+			t.Fatalf("init did not create %s: %v", p, err)
+		}
+	}
+	if err := cmdCheck(t.Context(), []string{"--strict"}); err != nil {
+		t.Fatalf("example fragment does not pass check: %v", err)
+	}
+
+	// Releases go below the anchor, newest first.
+	if err := cmdMerge(t.Context(), []string{"--version", "v0.1.0", "--skip-version-check"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmdNew(t.Context(), []string{"--no-input", "--component", "General", "--type", "fix", "--summary", "Fix it"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmdMerge(t.Context(), []string{"--version", "v0.1.1", "--skip-version-check"}); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := os.ReadFile("CHANGELOG.md")
+	want := starterChangelog + "\n" +
+		"## v0.1.1 (2026-01-01)\n\n### General\n\n- **fix**: Fix it.\n\n" +
+		"## v0.1.0 (2026-01-01)\n\n### General\n\n- **feature**: Start keeping a changelog with papertrail fragments.\n\n"
+	if string(b) != want {
+		t.Fatalf("CHANGELOG.md:\n%s\nwant:\n%s", b, want)
+	}
+
+	// Re-running keeps everything, including not re-seeding the example.
+	if err := cmdInit(t.Context(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if b2, _ := os.ReadFile("CHANGELOG.md"); string(b2) != string(b) {
+		t.Fatalf("init overwrote the changelog")
+	}
+	entries, _ := os.ReadDir("changelog.d")
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".yml") {
+			t.Fatalf("init re-seeded an example fragment: %s", e.Name())
+		}
+	}
+}
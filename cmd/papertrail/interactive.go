@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// errReleaseAborted is returned when the user declines an interactive release.
+var errReleaseAborted = errors.New("release aborted; nothing was written")
+
+// confirmRelease shows the rendered section and the fragments it includes, then asks for
+// confirmation on in. Answering with fragment numbers toggles them in or out of the release;
+// the section is re-rendered until the user answers y (keep the selection) or n. Deselected
+// fragments stay pending. files and items are as returned by loadPendingItems; a file holding
+// several fragments is toggled as a whole.
+func confirmRelease(in io.Reader, out io.Writer, render func([]item) []byte, files []fragmentFile, items []item) ([]fragmentFile, []item, error) {
+	selected := make([]bool, len(files))
+	for i := range selected {
+		selected[i] = true
+	}
+	pick := func() ([]fragmentFile, []item) {
+		var fs []fragmentFile
+		paths := map[string]bool{}
+		for i, ok := range selected {
+			if ok {
+				fs = append(fs, files[i])
+				paths[files[i].Path] = true
+			}
+		}
+		var its []item
+		for _, it := range items {
+			if paths[it.Path] {
+				its = append(its, it)
+			}
+		}
+		return fs, its
+	}
+
+	r := bufio.NewReader(in)
+	for {
+		fs, its := pick()
+		if len(its) > 0 {
+			_, _ = out.Write(render(its))
+			fmt.Fprintln(out)
+		}
+		fmt.Fprintln(out, "Fragments:")
+		for i, ff := range files {
+			mark, action := "x", "archive"
+			if !selected[i] {
+				mark, action = " ", "keep pending"
+			}
+			if ff.External {
+				action = "external, not archived"
+				if !selected[i] {
+					action = "external, skipped"
+				}
+			}
+			fmt.Fprintf(out, "  [%s] %d  %s (%s)\n", mark, i+1, ff.Name, action)
+		}
+		fmt.Fprint(out, "Write this release? [y]es, [n]o, or fragment numbers to toggle: ")
+
+		line, err := r.ReadString('\n')
+		answer := strings.ToLower(strings.TrimSpace(line))
+		if err != nil && answer == "" {
+			fmt.Fprintln(out)
+			return nil, nil, errReleaseAborted
+		}
+		switch answer {
+		case "y", "yes":
+			if len(its) == 0 {
+				fmt.Fprintln(out, "No fragments selected.")
+				continue
+			}
+			return fs, its, nil
+		case "n", "no", "q", "quit":
+			return nil, nil, errReleaseAborted
+		}
+		for _, tok := range strings.FieldsFunc(answer, func(r rune) bool { return r == ',' || r == ' ' }) {
+			n, err := strconv.Atoi(tok)
+			if err != nil || n < 1 || n > len(files) {
+				fmt.Fprintf(out, "Not a fragment number: %q\n", tok)
+				continue
+			}
+			selected[n-1] = !selected[n-1]
+		}
+	}
+}
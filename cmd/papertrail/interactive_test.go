@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestConfirmRelease(t *testing.T) {
+	t.Parallel()
+
+	files := []fragmentFile{{Path: "a.yml", Name: "a.yml"}, {Path: "b.yml", Name: "b.yml"}, {Path: "c.yml", Name: "c.yml"}}
+	items := []item{
+		{Path: "a.yml", Frag: fragment{Component: "CLI", Type: "feature", Summary: "Alpha"}},
+		{Path: "b.yml", Frag: fragment{Component: "CLI", Type: "fix", Summary: "Beta"}},
+		{Path: "b.yml", Frag: fragment{Component: "API", Type: "fix", Summary: "Beta two"}},
+		{Path: "c.yml", Frag: fragment{Component: "CLI", Type: "fix", Summary: "Gamma"}},
+	}
+	render := func(its []item) []byte {
+		section, _, _ := renderReleaseSection("v1.0.0", "2026-01-01", its, releaseManifest{})
+		return section
+	}
+
+	var out bytes.Buffer
+	gotFiles, gotItems, err := confirmRelease(strings.NewReader("2\n9\ny\n"), &out, render, files, items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gotFiles) != 2 || gotFiles[0].Name != "a.yml" || gotFiles[1].Name != "c.yml" || len(gotItems) != 2 || gotItems[1].Path != "c.yml" {
+		t.Fatalf("selection: files=%v items=%v", gotFiles, gotItems)
+	}
+	s := out.String()
+	if !strings.Contains(s, "[ ] 2  b.yml (keep pending)") || !strings.Contains(s, `Not a fragment number: "9"`) {
+		t.Fatalf("output:\n%s", s)
+	}
+	// The last preview no longer includes the deselected file's fragments.
+	if last := s[strings.LastIndex(s, "## v1.0.0"):]; strings.Contains(last, "Beta") || !strings.Contains(last, "Gamma") {
+		t.Fatalf("final preview:\n%s", last)
+	}
+
+	for _, in := range []string{"n\n", "", "1 2 3\ny\n"} {
+		if _, _, err := confirmRelease(strings.NewReader(in), &bytes.Buffer{}, render, files, items); !errors.Is(err, errReleaseAborted) {
+			t.Fatalf("input %q: got %v, want errReleaseAborted", in, err)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+// ruleReadError reports a fragment file check could not read; it is always an error.
+const ruleReadError = "read_error"
+
+// checkOutputFormat validates a --format value for commands with text and JSON output.
+func checkOutputFormat(format string) error {
+	if format != "text" && format != "json" {
+		return fmt.Errorf("invalid --format %q (expected text|json)", format)
+	}
+	return nil
+}
+
+// writeJSON writes v as indented JSON followed by a newline.
+func writeJSON(w io.Writer, v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// checkResult is the validation outcome for one fragment file.
+type checkResult struct {
+	Name   string
+	Issues []papertrail.Issue
+	// Path is the file's path in the working tree for annotations, or "" when it is not
+	// there (e.g. read from a git ref or a manifest source).
+	Path string
+	// Source is the file's contents, for locating issues.
+	Source []byte
+}
+
+// checkReport is `check --format json` output. OK mirrors the exit status.
+type checkReport struct {
+	OK       bool              `json:"ok"`
+	Errors   int               `json:"errors"`
+	Warnings int               `json:"warnings"`
+	Files    []checkFileReport `json:"files"`
+}
+
+type checkFileReport struct {
+	Path   string             `json:"path"`
+	Issues []checkIssueReport `json:"issues"`
+}
+
+type checkIssueReport struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Field    string `json:"field,omitempty"`
+	Message  string `json:"message"`
+}
+
+// newCheckReport summarizes results sorted by path; every checked file is listed, with an
+// empty issue list when it is valid.
+func newCheckReport(results []checkResult, strict bool) checkReport {
+	r := checkReport{Files: []checkFileReport{}}
+	for _, res := range results {
+		f := checkFileReport{Path: res.Name, Issues: []checkIssueReport{}}
+		for _, is := range res.Issues {
+			if is.Severity == papertrail.SeverityWarning {
+				r.Warnings++
+			} else {
+				r.Errors++
+			}
+			f.Issues = append(f.Issues, checkIssueReport{Rule: is.Rule, Severity: is.Severity, Field: is.Field, Message: is.Message})
+		}
+		r.Files = append(r.Files, f)
+	}
+	sort.Slice(r.Files, func(i, j int) bool { return r.Files[i].Path < r.Files[j].Path })
+	r.OK = r.Errors == 0 && (!strict || r.Warnings == 0)
+	return r
+}
+
+// bumpReport is `bump --format json` output.
+type bumpReport struct {
+	Base      string `json:"base"`
+	Bump      string `json:"bump"`
+	Next      string `json:"next"`
+	Fragments int    `json:"fragments"`
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// captureStdout runs fn with os.Stdout redirected and returns what it wrote.
+func captureStdout(t *testing.T, fn func() error) (string, error) {
+	t.Helper()
+	f, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	orig := os.Stdout
+	os.Stdout = f
+	runErr := fn()
+	os.Stdout = orig
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b), runErr
+}
+
+func TestCmdCheck_JSON(t *testing.T) {
+	initGitRepo(t, map[string]string{
+		".papertrail.config.yml":       "types:\n  order: [fix]\nchangelog:\n  components: [CLI]\nvalidation:\n  severity:\n    unknown_component: warning\n",
+		"changelog.d/20260101_ok.yml":  "component: CLI\ntype: fix\nsummary: Fine\n",
+		"changelog.d/20260102_bad.yml": "component: Web\ntype: chore\n",
+	})
+
+	out, err := captureStdout(t, func() error { return cmdCheck(t.Context(), []string{"--format", "json"}) })
+	if err == nil || !strings.Contains(err.Error(), "2 error(s), 1 warning(s)") {
+		t.Fatalf("expected a failing check, got %v", err)
+	}
+	var report checkReport
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if report.OK || len(report.Files) != 2 || report.Files[0].Path != "changelog.d/20260101_ok.yml" || len(report.Files[0].Issues) != 0 {
+		t.Fatalf("unexpected report: %s", out)
+	}
+	var rules []string
+	for _, is := range report.Files[1].Issues {
+		rules = append(rules, is.Rule+"/"+is.Severity+"/"+is.Field)
+	}
+	if got := strings.Join(rules, " "); got != "missing_field/error/summary unknown_component/warning/component unknown_type/error/type" {
+		t.Fatalf("issues: %s", got)
+	}
+
+	if err := os.Remove("changelog.d/20260102_bad.yml"); err != nil {
+		t.Fatal(err)
+	}
+	out, err = captureStdout(t, func() error { return cmdCheck(t.Context(), []string{"--format", "json"}) })
+	if err != nil || !strings.Contains(out, `"ok": true`) {
+		t.Fatalf("valid fragments: %v\n%s", err, out)
+	}
+	if err := cmdCheck(t.Context(), []string{"--format", "yaml"}); err == nil || !strings.Contains(err.Error(), "text|json") {
+		t.Fatalf("expected invalid --format error, got %v", err)
+	}
+}
+
+func TestCmdBump_JSON(t *testing.T) {
+	initGitRepo(t, map[string]string{
+		".papertrail.config.yml":     "versioning:\n  rules:\n    feature: minor\n",
+		"changelog.d/20260101_a.yml": "component: CLI\ntype: feature\nsummary: Add a\n",
+		"changelog.d/20260102_b.yml": "component: CLI\ntype: fix\nsummary: Fix b\n",
+	})
+	out, err := captureStdout(t, func() error {
+		return cmdBump(t.Context(), []string{"--base", "v1.2.3", "--skip-version-check", "--format", "json"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report bumpReport
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if want := (bumpReport{Base: "v1.2.3", Bump: "minor", Next: "v1.3.0", Fragments: 2}); report != want {
+		t.Fatalf("got %+v, want %+v", report, want)
+	}
+}
+
+func TestCmdBump_ZeroMajorPolicy(t *testing.T) {
+	initGitRepo(t, map[string]string{
+		".papertrail.config.yml":     "versioning:\n  zero_major_policy: true\n  rules:\n    breaking: major\n    feature: minor\n",
+		"changelog.d/20260101_a.yml": "component: CLI\ntype: breaking\nsummary: Drop a\n",
+	})
+	for _, tc := range []struct{ base, want string }{
+		{"v0.4.2", "v0.5.0\n"},
+		{"v1.4.2", "v2.0.0\n"},
+	} {
+		out, err := captureStdout(t, func() error {
+			return cmdBump(t.Context(), []string{"--base", tc.base, "--skip-version-check"})
+		})
+		if err != nil || out != tc.want {
+			t.Fatalf("bump from %s: got %q, %v; want %q", tc.base, out, err, tc.want)
+		}
+	}
+}
+
+func TestCmdBump_ShowKind(t *testing.T) {
+	initGitRepo(t, map[string]string{
+		".papertrail.config.yml":     "versioning:\n  rules:\n    feature: minor\n",
+		"changelog.d/20260101_a.yml": "component: CLI\ntype: feature\nsummary: Add a\n",
+	})
+	out, err := captureStdout(t, func() error {
+		return cmdBump(t.Context(), []string{"--base", "v1.2.3", "--skip-version-check", "--show-kind"})
+	})
+	if err != nil || out != "v1.3.0 minor\n" {
+		t.Fatalf("got %q, %v", out, err)
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// latestReport is `latest --format json` output.
+type latestReport struct {
+	Version string `json:"version"`
+	Date    string `json:"date,omitempty"`
+	Source  string `json:"source"`
+}
+
+// cmdLatest prints the most recent released version and its date: the changelog's top
+// release section, or with --tags the latest release tag when it is newer.
+func cmdLatest(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("latest", flag.ContinueOnError)
+	fs.SetOutput(ioDiscard{})
+	changelogPath := fs.String("changelog", "CHANGELOG.md", "changelog path")
+	tags := fs.Bool("tags", false, "also consider git tags with --tag-prefix; the newer of the tag and the changelog wins")
+	tagPrefix := fs.String("tag-prefix", "v", "prefix of release tags (e.g. api/v), with --tags")
+	component := fs.String("component", "", "report an independently versioned component (versioning.components) from its own changelog and tags")
+	manifestPath := fs.String("manifest", "", "optional release config YAML path")
+	format := fs.String("format", "text", "output format: text|json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := checkOutputFormat(*format); err != nil {
+		return err
+	}
+	manifest, err := loadManifestDefault(*manifestPath)
+	if err != nil {
+		return err
+	}
+	if *component != "" {
+		vc, ok := manifest.VersionedComponent(*component)
+		if !ok {
+			return fmt.Errorf("component %q is not versioned independently (add it under versioning.components)", *component)
+		}
+		set := map[string]bool{}
+		fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+		if !set["tag-prefix"] {
+			*tagPrefix = vc.TagPrefix
+		}
+		if !set["changelog"] {
+			*changelogPath = vc.Changelog
+		}
+	}
+
+	r, ok, err := latestReleased(ctx, hostFS{}, *changelogPath, *tags, *tagPrefix, manifest)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if *tags {
+			return fmt.Errorf("no release found in %s or git tags matching %s<version>", *changelogPath, *tagPrefix)
+		}
+		return fmt.Errorf("no release section found in %s", *changelogPath)
+	}
+	if *format == "json" {
+		return writeJSON(os.Stdout, r)
+	}
+	if r.Date == "" {
+		_, _ = fmt.Fprintln(os.Stdout, r.Version)
+	} else {
+		_, _ = fmt.Fprintln(os.Stdout, r.Version, r.Date)
+	}
+	return nil
+}
+
+// latestReleased returns the changelog's top release section and, when tags is set, the
+// latest tag with tagPrefix if that is newer. A tag's date is its tagger (or commit) date in
+// the release timezone. A missing changelog counts as having no releases.
+func latestReleased(ctx context.Context, fsys fs.FS, changelogPath string, tags bool, tagPrefix string, manifest releaseManifest) (r latestReport, ok bool, err error) {
+	var (
+		top       semver
+		topSemver bool
+	)
+	b, err := fs.ReadFile(fsys, changelogPath)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return latestReport{}, false, err
+	default:
+		if version, date, found := firstReleaseHeading(string(b)); found {
+			r, ok = latestReport{Version: version, Date: date, Source: changelogPath}, true
+			top, err = parseSemver(version)
+			topSemver = err == nil
+		}
+	}
+	if !tags {
+		return r, ok, nil
+	}
+	v, tag, found, err := latestTagVersion(ctx, tagPrefix)
+	if err != nil {
+		return latestReport{}, false, err
+	}
+	// On a tie the changelog wins: it is where the release date was recorded. Date-based
+	// versions cannot be compared with tags, so they win too.
+	if !found || (ok && (!topSemver || v.Compare(top) <= 0)) {
+		return r, ok, nil
+	}
+	t, err := refTime(ctx, tag)
+	if err != nil {
+		return latestReport{}, false, err
+	}
+	if t, err = inReleaseTimezone(t, manifest); err != nil {
+		return latestReport{}, false, err
+	}
+	return latestReport{Version: v.String(), Date: t.Format("2006-01-02"), Source: "git tag " + tag}, true, nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCmdLatest(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{
+		"CHANGELOG.md": "# Changelog\n\n## Unreleased\n\n## v1.2.0 (2026-01-02)\n\n## v1.1.0 (2026-01-01)\n",
+	})
+
+	out, err := captureStdout(t, func() error { return cmdLatest(t.Context(), nil) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "v1.2.0 2026-01-02\n" {
+		t.Fatalf("unexpected output: %q", out)
+	}
+
+	// A newer tag wins with --tags, dated by its commit.
+	t.Setenv("GIT_COMMITTER_DATE", "2026-02-03T12:00:00Z")
+	gitIn(t, dir, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--allow-empty", "-q", "-m", "release")
+	gitIn(t, dir, "tag", "v1.3.0")
+	out, err = captureStdout(t, func() error { return cmdLatest(t.Context(), []string{"--tags", "--format", "json"}) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r latestReport
+	if err := json.Unmarshal([]byte(out), &r); err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+	if r.Version != "v1.3.0" || r.Source != "git tag v1.3.0" || r.Date != "2026-02-03" {
+		t.Fatalf("unexpected report: %+v", r)
+	}
+
+	if err := cmdLatest(t.Context(), []string{"--changelog", "missing.md"}); err == nil || !strings.Contains(err.Error(), "no release section") {
+		t.Fatalf("expected an error, got %v", err)
+	}
+}
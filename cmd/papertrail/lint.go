@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+	"gopkg.in/yaml.v3"
+)
+
+func cmdLint(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	fs.SetOutput(ioDiscard{})
+	fragmentsDir := fs.String("fragments", "changelog.d", "fragments directory")
+	manifestPath := fs.String("manifest", "", "optional release config YAML path")
+	fix := fs.Bool("fix", false, "rewrite fragments in place to canonical form")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	manifest, err := loadManifestDefault(*manifestPath)
+	if err != nil {
+		return err
+	}
+
+	// Only local fragments are linted; fragments from manifest sources are not ours to rewrite.
+	var fsys writableFS = hostFS{}
+	files, err := papertrail.FragmentFiles(fsys, *fragmentsDir, manifest)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no fragments found under %q", *fragmentsDir)
+	}
+
+	var problems []string
+	for _, path := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		b, err := readFile(fsys, path)
+		if err != nil {
+			return err
+		}
+		canon, err := canonicalFragmentFile(path, b, manifest)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", path, err.Error()))
+			continue
+		}
+		if bytes.Equal(b, canon) {
+			continue
+		}
+		if !*fix {
+			problems = append(problems, fmt.Sprintf("%s: not in canonical form", path))
+			continue
+		}
+		if err := fsys.WriteFile(path, canon, 0644); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stdout, "fixed %s\n", path)
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		if !*fix {
+			problems = append(problems, "run `papertrail lint --fix` to rewrite fragments in canonical form")
+		}
+		return errors.New(strings.Join(problems, "\n"))
+	}
+	return nil
+}
+
+// canonicalFragmentFile renders the fragment file name in canonical form: YAML files as
+// canonicalFragmentBytes, Markdown fragments with canonical front matter and the body as
+// written. JSON and TOML fragments are usually generated, so they are only validated and
+// returned as they are.
+func canonicalFragmentFile(name string, b []byte, manifest releaseManifest) ([]byte, error) {
+	switch papertrail.FragmentFormat(name) {
+	case papertrail.FormatJSON, papertrail.FormatTOML:
+		if _, err := papertrail.ParseFragmentFile(name, b, manifest); err != nil {
+			return nil, err
+		}
+		return b, nil
+	case papertrail.FormatMarkdown:
+		return canonicalMarkdownFragment(b, manifest)
+	}
+	return canonicalFragmentBytes(b, manifest)
+}
+
+// canonicalMarkdownFragment canonicalizes a Markdown fragment's front matter, keeping the
+// body as written.
+func canonicalMarkdownFragment(b []byte, manifest releaseManifest) ([]byte, error) {
+	front, body, err := papertrail.SplitFrontMatter(b)
+	if err != nil {
+		return nil, err
+	}
+	f, err := canonicalFragment(front, manifest)
+	if err != nil {
+		return nil, err
+	}
+	fb, err := encodeFragment(f)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte("---\n"), fb...), append([]byte("---\n"), body...)...), nil
+}
+
+// canonicalFragmentBytes renders a valid fragment file in canonical form: trimmed fields,
+// alias-resolved uppercase type, component casing matching the manifest, sorted refs, and a
+// fixed key order. A file with several fragments becomes one top-level list. Fragments with
+// unknown keys are rejected rather than silently dropped.
+func canonicalFragmentBytes(b []byte, manifest releaseManifest) ([]byte, error) {
+	entries, err := fragmentEntries(b)
+	if err != nil {
+		return nil, fmt.Errorf("cannot canonicalize: %w", err)
+	}
+	fragments := make([]fragment, len(entries))
+	for i, eb := range entries {
+		if fragments[i], err = canonicalFragment(eb, manifest); err != nil {
+			if len(entries) > 1 {
+				err = fmt.Errorf("entry %d: %w", i+1, err)
+			}
+			return nil, err
+		}
+	}
+
+	if len(fragments) == 1 {
+		return encodeFragment(fragments[0])
+	}
+	return encodeFragment(fragments)
+}
+
+// encodeFragment encodes a fragment (or a list of them) as YAML with two-space indentation.
+func encodeFragment(v any) ([]byte, error) {
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// fragmentEntries splits a fragment file into the YAML of each fragment in it: every
+// document, with top-level lists expanded. An empty file is one empty entry.
+func fragmentEntries(b []byte) ([][]byte, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	var entries [][]byte
+	for {
+		var doc yaml.Node
+		if err := dec.Decode(&doc); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if len(doc.Content) == 0 {
+			continue
+		}
+		nodes := []*yaml.Node{doc.Content[0]}
+		switch root := doc.Content[0]; {
+		case root.Kind == yaml.SequenceNode:
+			nodes = root.Content
+		case root.Kind == yaml.ScalarNode && root.Tag == "!!null":
+			nodes = nil
+		}
+		for _, n := range nodes {
+			eb, err := yaml.Marshal(n)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, eb)
+		}
+	}
+	if len(entries) == 0 {
+		entries = [][]byte{nil}
+	}
+	return entries, nil
+}
+
+// canonicalFragment parses and canonicalizes a single fragment.
+func canonicalFragment(b []byte, manifest releaseManifest) (fragment, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	var raw fragment
+	if err := dec.Decode(&raw); err != nil && !errors.Is(err, io.EOF) {
+		return fragment{}, fmt.Errorf("cannot canonicalize: %w", err)
+	}
+
+	// Fix component casing before validation so strict_components doesn't reject it.
+	for _, c := range manifest.ComponentOrder() {
+		if strings.EqualFold(c, strings.TrimSpace(raw.Component)) {
+			raw.Component = c
+			break
+		}
+	}
+	rb, err := yaml.Marshal(raw)
+	if err != nil {
+		return fragment{}, err
+	}
+	f, err := papertrail.ParseFragment(rb, manifest)
+	if err != nil {
+		return fragment{}, err
+	}
+	refs := f.Refs[:0]
+	for _, r := range f.Refs {
+		if r != "" {
+			refs = append(refs, r)
+		}
+	}
+	sort.Strings(refs)
+	f.Refs = refs
+	return f, nil
+}
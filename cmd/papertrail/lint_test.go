@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCanonicalFragmentBytes(t *testing.T) {
+	t.Parallel()
+
+	var m releaseManifest
+	m.Changelog.Components = []string{"GitHub Actions"}
+	m.Changelog.StrictComponents = true
+	m.Types.Aliases = map[string]string{"BUGFIX": "FIX"}
+	m.Types.Order = []string{"FIX"}
+
+	in := []byte("refs:\n    - b\n    - a\nsummary: '  Fix it  '\ntype: bugfix\ncomponent: github actions\n")
+	got, err := canonicalFragmentBytes(in, m)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	want := "component: GitHub Actions\ntype: FIX\nsummary: Fix it\nrefs:\n  - a\n  - b\n"
+	if string(got) != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	again, err := canonicalFragmentBytes(got, m)
+	if err != nil || string(again) != want {
+		t.Fatalf("canonical form is not stable: %q, %v", again, err)
+	}
+
+	if _, err := canonicalFragmentBytes([]byte("component: CLI\ntype: fix\nsummary: x\nextra: y\n"), m); err == nil {
+		t.Fatalf("expected error for unknown key")
+	}
+
+	multi := []byte("- component: github actions\n  type: bugfix\n  summary: One\n---\ncomponent: GitHub Actions\ntype: fix\nsummary: Two\n")
+	want = "- component: GitHub Actions\n  type: FIX\n  summary: One\n- component: GitHub Actions\n  type: FIX\n  summary: Two\n"
+	if got, err := canonicalFragmentBytes(multi, m); err != nil || string(got) != want {
+		t.Fatalf("multi-fragment file: got %q, %v; want %q", got, err, want)
+	}
+	if _, err := canonicalFragmentBytes([]byte("- component: GitHub Actions\n  type: fix\n  summary: x\n- type: fix\n"), m); err == nil || !strings.Contains(err.Error(), "entry 2") {
+		t.Fatalf("expected an entry 2 error, got %v", err)
+	}
+
+	md := []byte("---\ntype: bugfix\ncomponent: github actions\nsummary: Fix it\n---\n\nLonger *prose*.\n")
+	want = "---\ncomponent: GitHub Actions\ntype: FIX\nsummary: Fix it\n---\n\nLonger *prose*.\n"
+	if got, err := canonicalFragmentFile("changelog.d/x.md", md, m); err != nil || string(got) != want {
+		t.Fatalf("markdown fragment: got %q, %v; want %q", got, err, want)
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// lockStaleAfter is the age past which a lock is considered abandoned, whatever its holder.
+const lockStaleAfter = time.Hour
+
+// lockRetryInterval is how often a waiting run checks the lock again.
+const lockRetryInterval = 100 * time.Millisecond
+
+// acquireChangelogLock takes the advisory lock that serializes runs writing changelogPath:
+// the file <changelogPath>.lock, created exclusively and holding the owner's pid, host, and
+// start time. A lock left by a process that no longer runs on this host, or older than
+// lockStaleAfter, is taken over (see removeStaleLock). When the lock is held, it waits up to
+// wait for it. unlock removes the lock file.
+func acquireChangelogLock(ctx context.Context, changelogPath string, wait time.Duration) (unlock func(), err error) {
+	lockPath := changelogPath + ".lock"
+	host, _ := os.Hostname()
+	deadline := time.Now().Add(wait)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, werr := fmt.Fprintf(f, "%d %s %s\n", os.Getpid(), host, time.Now().UTC().Format(time.RFC3339))
+			if cerr := f.Close(); werr == nil {
+				werr = cerr
+			}
+			if werr != nil {
+				_ = os.Remove(lockPath)
+				return nil, werr
+			}
+			return func() { _ = os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		}
+
+		owner, stale, seen := inspectLock(lockPath, host)
+		if stale {
+			removed, err := removeStaleLock(lockPath, seen)
+			if err != nil {
+				return nil, err
+			}
+			if removed {
+				fmt.Fprintf(os.Stderr, "removed stale lock %s (%s)\n", lockPath, owner)
+			}
+			// Whoever removed it, the lock is taken by the next exclusive create.
+			continue
+		}
+		if !time.Now().Before(deadline) {
+			return nil, fmt.Errorf("%s is locked by another papertrail run (%s); pass --wait to wait for it, or remove %s if that run is gone", changelogPath, owner, lockPath)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(min(lockRetryInterval, time.Until(deadline))):
+		}
+	}
+}
+
+// lockSnapshot identifies one lock file: its content and modification time.
+type lockSnapshot struct {
+	content string
+	modTime int64
+}
+
+func snapshotLock(p string) (lockSnapshot, bool) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return lockSnapshot{}, false
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return lockSnapshot{}, false
+	}
+	return lockSnapshot{content: string(b), modTime: info.ModTime().UnixNano()}, true
+}
+
+// inspectLock describes the lock's owner and reports whether the lock is stale: older than
+// lockStaleAfter, or held by a pid that is no longer running on this host. seen identifies
+// the lock file it inspected.
+func inspectLock(lockPath, host string) (owner string, stale bool, seen lockSnapshot) {
+	seen, ok := snapshotLock(lockPath)
+	if !ok {
+		// Released in the meantime; the next attempt takes it.
+		return "released", false, seen
+	}
+	fields := strings.Fields(seen.content)
+	owner = "owner unknown"
+	if len(fields) >= 3 {
+		owner = fmt.Sprintf("pid %s on %s since %s", fields[0], fields[1], fields[2])
+	}
+	if time.Since(time.Unix(0, seen.modTime)) > lockStaleAfter {
+		return owner, true, seen
+	}
+	if len(fields) >= 2 && fields[1] == host {
+		if pid, err := strconv.Atoi(fields[0]); err == nil && !processRunning(pid) {
+			return owner, true, seen
+		}
+	}
+	return owner, false, seen
+}
+
+// removeStaleLock removes the stale lock inspected as seen, without the race of removing by
+// name: two runs that both found it stale could otherwise each remove it, the second one
+// deleting the lock the first had taken meanwhile. The lock is renamed aside to a name of
+// this process's own, which only one run can do for a given file, and removed only if it is
+// still the lock seen; a newer lock renamed aside by mistake is linked back in place.
+// removed reports whether this run removed the stale lock.
+func removeStaleLock(lockPath string, seen lockSnapshot) (removed bool, err error) {
+	aside := fmt.Sprintf("%s.stale-%d", lockPath, os.Getpid())
+	if err := os.Rename(lockPath, aside); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			// Another run removed it first.
+			return false, nil
+		}
+		return false, err
+	}
+	if got, ok := snapshotLock(aside); ok && got == seen {
+		return true, os.Remove(aside)
+	}
+	// Another run took the lock after we inspected it; give the lock back.
+	if err := os.Link(aside, lockPath); err != nil && !errors.Is(err, fs.ErrExist) {
+		return false, err
+	}
+	return false, os.Remove(aside)
+}
+
+// processRunning reports whether pid is a running process. Without a way to probe (Windows),
+// every process is assumed to be running.
+func processRunning(pid int) bool {
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAcquireChangelogLock(t *testing.T) {
+	t.Parallel()
+
+	changelog := filepath.Join(t.TempDir(), "CHANGELOG.md")
+	unlock, err := acquireChangelogLock(t.Context(), changelog, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := acquireChangelogLock(t.Context(), changelog, 0); err == nil || !strings.Contains(err.Error(), "locked by another papertrail run") {
+		t.Fatalf("expected a lock error, got %v", err)
+	}
+
+	// A waiting run takes the lock once the holder releases it.
+	go func() {
+		time.Sleep(150 * time.Millisecond)
+		unlock()
+	}()
+	unlock2, err := acquireChangelogLock(t.Context(), changelog, 5*time.Second)
+	if err != nil {
+		t.Fatalf("--wait should outlast the holder: %v", err)
+	}
+	unlock2()
+	if _, err := os.Stat(changelog + ".lock"); !os.IsNotExist(err) {
+		t.Fatalf("unlock should remove the lock file: %v", err)
+	}
+}
+
+func TestAcquireChangelogLock_Stale(t *testing.T) {
+	t.Parallel()
+
+	host, _ := os.Hostname()
+	for name, tc := range map[string]struct {
+		content string
+		age     time.Duration
+	}{
+		"dead pid": {content: fmt.Sprintf("%d %s 2026-01-01T00:00:00Z\n", 1<<22+12345, host)},
+		"old lock": {content: fmt.Sprintf("%d other-host 2026-01-01T00:00:00Z\n", os.Getpid()), age: 2 * lockStaleAfter},
+	} {
+		changelog := filepath.Join(t.TempDir(), "CHANGELOG.md")
+		if err := os.WriteFile(changelog+".lock", []byte(tc.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if tc.age > 0 {
+			old := time.Now().Add(-tc.age)
+			if err := os.Chtimes(changelog+".lock", old, old); err != nil {
+				t.Fatal(err)
+			}
+		}
+		unlock, err := acquireChangelogLock(t.Context(), changelog, 0)
+		if err != nil {
+			t.Fatalf("%s: stale lock not taken over: %v", name, err)
+		}
+		unlock()
+	}
+}
+
+func TestRemoveStaleLock(t *testing.T) {
+	t.Parallel()
+
+	lock := filepath.Join(t.TempDir(), "CHANGELOG.md.lock")
+	if err := os.WriteFile(lock, []byte("1 host 2026-01-01T00:00:00Z\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	seen, ok := snapshotLock(lock)
+	if !ok {
+		t.Fatal("no snapshot")
+	}
+
+	// Another run removed the stale lock and took its own after this one inspected it.
+	if err := os.WriteFile(lock, []byte("2 host 2026-01-02T00:00:00Z\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if removed, err := removeStaleLock(lock, seen); err != nil || removed {
+		t.Fatalf("removed a newer lock: %v, %v", removed, err)
+	}
+	if b, _ := os.ReadFile(lock); string(b) != "2 host 2026-01-02T00:00:00Z\n" {
+		t.Fatalf("the newer lock must be kept, got %q", b)
+	}
+	if matches, _ := filepath.Glob(lock + ".stale-*"); len(matches) != 0 {
+		t.Fatalf("left behind %v", matches)
+	}
+
+	seen, _ = snapshotLock(lock)
+	if removed, err := removeStaleLock(lock, seen); err != nil || !removed {
+		t.Fatalf("stale lock not removed: %v, %v", removed, err)
+	}
+	if _, err := os.Stat(lock); !os.IsNotExist(err) {
+		t.Fatalf("lock still present: %v", err)
+	}
+	// Removed by another run in the meantime.
+	if removed, err := removeStaleLock(lock, seen); err != nil || removed {
+		t.Fatalf("missing lock: %v, %v", removed, err)
+	}
+}
+
+func TestCmdMerge_Locked(t *testing.T) {
+	initGitRepo(t, map[string]string{
+		"CHANGELOG.md":               "# Changelog\n",
+		"changelog.d/20260102_a.yml": "component: CLI\ntype: fix\nsummary: Fix a\n",
+	})
+	host, _ := os.Hostname()
+	if err := os.WriteFile("CHANGELOG.md.lock", fmt.Appendf(nil, "%d %s 2026-01-01T00:00:00Z\n", os.Getpid(), host), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := cmdMerge(t.Context(), []string{"--version", "v0.1.0", "--date", "2026-01-03", "--wait", "200ms"})
+	if err == nil || !strings.Contains(err.Error(), "CHANGELOG.md is locked") {
+		t.Fatalf("expected a lock error, got %v", err)
+	}
+	if b, _ := os.ReadFile("CHANGELOG.md"); string(b) != "# Changelog\n" {
+		t.Fatalf("a locked merge must not write:\n%s", b)
+	}
+}
@@ -0,0 +1,482 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/textproto"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+	"gopkg.in/yaml.v3"
+)
+
+// cmdLSP serves a minimal Language Server Protocol session over stdin/stdout for fragment
+// files: diagnostics (the same rules as check), completion of keys and of type and component
+// values from the manifest, and hover documentation.
+func cmdLSP(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("lsp", flag.ContinueOnError)
+	fs.SetOutput(ioDiscard{})
+	manifestPath := fs.String("manifest", "", "optional release config YAML path")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	manifest, err := loadManifestDefault(*manifestPath)
+	if err != nil {
+		return err
+	}
+	s := &lspServer{manifest: manifest, docs: map[string]string{}}
+	return s.serve(ctx, os.Stdin, os.Stdout)
+}
+
+// JSON-RPC error codes used by the server.
+const (
+	lspMethodNotFound = -32601
+	lspInvalidParams  = -32602
+	lspInvalidRequest = -32600
+)
+
+type lspMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *lspError       `json:"error,omitempty"`
+}
+
+type lspError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+type lspDiagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Code     string   `json:"code,omitempty"`
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
+}
+
+type lspCompletionItem struct {
+	Label      string `json:"label"`
+	Kind       int    `json:"kind"`
+	Detail     string `json:"detail,omitempty"`
+	InsertText string `json:"insertText,omitempty"`
+}
+
+type lspMarkupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+type lspHover struct {
+	Contents lspMarkupContent `json:"contents"`
+	Range    *lspRange        `json:"range,omitempty"`
+}
+
+type lspTextDocumentPosition struct {
+	TextDocument struct {
+		URI string `json:"uri"`
+	} `json:"textDocument"`
+	Position lspPosition `json:"position"`
+}
+
+// LSP enum values.
+const (
+	lspSeverityError        = 1
+	lspSeverityWarning      = 2
+	lspCompletionProperty   = 10
+	lspCompletionValue      = 12
+	lspTextDocumentSyncFull = 1
+)
+
+// fragmentFieldDocs documents the fragment keys, in the order completion offers them.
+var fragmentFieldDocs = []struct{ Key, Doc string }{
+	{"component", "The component heading the entry is listed under in the changelog."},
+	{"type", "The kind of change. It sets the heading within the component and, via `versioning.rules`, the version bump."},
+	{"summary", "One user-facing sentence describing the change."},
+	{"refs", "Optional list of references (issues, PRs, files) for the change."},
+	{"authors", "Optional list of people credited for the change: GitHub @-handles or names. Release notes list them under Contributors."},
+	{"highlight", "Set to true to also list the entry under Highlights at the top of the release notes."},
+	{"removal_version", "Optional release (e.g. v2.0.0) that removes what this deprecation deprecates. It is shown under Deprecations, and `bump` warns once the next version reaches it."},
+	{"details", "Optional Markdown (e.g. a `|` block) rendered as an indented paragraph under the entry, for changes the summary cannot explain."},
+}
+
+// lspServer holds the open documents of one session. Documents are synced in full.
+type lspServer struct {
+	manifest releaseManifest
+	docs     map[string]string
+	out      *bufio.Writer
+	shutdown bool
+}
+
+// serve handles messages from r until the client sends exit or closes the stream.
+func (s *lspServer) serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	in := bufio.NewReader(r)
+	s.out = bufio.NewWriter(w)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		msg, err := readLSPMessage(in)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return errors.New("lsp: exit without shutdown")
+			}
+			return nil
+		}
+		if err := s.handle(msg); err != nil {
+			return err
+		}
+		if err := s.out.Flush(); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *lspServer) handle(msg lspMessage) error {
+	isRequest := len(msg.ID) > 0
+	var (
+		result any
+		rerr   *lspError
+	)
+	switch msg.Method {
+	case "initialize":
+		result = map[string]any{
+			"capabilities": map[string]any{
+				"textDocumentSync":   lspTextDocumentSyncFull,
+				"completionProvider": map[string]any{"triggerCharacters": []string{":", " "}},
+				"hoverProvider":      true,
+			},
+			"serverInfo": map[string]string{"name": "papertrail"},
+		}
+	case "shutdown":
+		s.shutdown = true
+	case "textDocument/didOpen":
+		var p struct {
+			TextDocument struct {
+				URI  string `json:"uri"`
+				Text string `json:"text"`
+			} `json:"textDocument"`
+		}
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			return nil
+		}
+		s.docs[p.TextDocument.URI] = p.TextDocument.Text
+		return s.publishDiagnostics(p.TextDocument.URI)
+	case "textDocument/didChange":
+		var p struct {
+			TextDocument struct {
+				URI string `json:"uri"`
+			} `json:"textDocument"`
+			ContentChanges []struct {
+				Text string `json:"text"`
+			} `json:"contentChanges"`
+		}
+		if err := json.Unmarshal(msg.Params, &p); err != nil || len(p.ContentChanges) == 0 {
+			return nil
+		}
+		s.docs[p.TextDocument.URI] = p.ContentChanges[len(p.ContentChanges)-1].Text
+		return s.publishDiagnostics(p.TextDocument.URI)
+	case "textDocument/didClose":
+		var p struct {
+			TextDocument struct {
+				URI string `json:"uri"`
+			} `json:"textDocument"`
+		}
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			return nil
+		}
+		delete(s.docs, p.TextDocument.URI)
+		return s.notify("textDocument/publishDiagnostics", map[string]any{"uri": p.TextDocument.URI, "diagnostics": []lspDiagnostic{}})
+	case "textDocument/completion", "textDocument/hover":
+		var p lspTextDocumentPosition
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			rerr = &lspError{Code: lspInvalidParams, Message: err.Error()}
+			break
+		}
+		text := s.docs[p.TextDocument.URI]
+		if msg.Method == "textDocument/completion" {
+			result = fragmentCompletions(text, p.Position, s.manifest)
+		} else if h := fragmentHover(text, p.Position, s.manifest); h != nil {
+			result = h
+		}
+	default:
+		if !isRequest {
+			// Unknown notifications (initialized, $/cancelRequest, ...) are ignored.
+			return nil
+		}
+		rerr = &lspError{Code: lspMethodNotFound, Message: "method not found: " + msg.Method}
+	}
+	if !isRequest {
+		return nil
+	}
+	if s.shutdown && msg.Method != "shutdown" {
+		rerr = &lspError{Code: lspInvalidRequest, Message: "server is shutting down"}
+	}
+	resp := lspMessage{JSONRPC: "2.0", ID: msg.ID, Error: rerr}
+	if rerr == nil {
+		b, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		resp.Result = b
+	}
+	return writeLSPMessage(s.out, resp)
+}
+
+func (s *lspServer) notify(method string, params any) error {
+	b, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return writeLSPMessage(s.out, lspMessage{JSONRPC: "2.0", Method: method, Params: b})
+}
+
+func (s *lspServer) publishDiagnostics(uri string) error {
+	return s.notify("textDocument/publishDiagnostics", map[string]any{
+		"uri":         uri,
+		"diagnostics": fragmentDiagnostics(s.docs[uri], s.manifest),
+	})
+}
+
+// readLSPMessage reads one base-protocol message: headers, a blank line, and a
+// Content-Length JSON body.
+func readLSPMessage(r *bufio.Reader) (lspMessage, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		if errors.Is(err, io.EOF) && len(header) == 0 {
+			return lspMessage{}, io.EOF
+		}
+		return lspMessage{}, fmt.Errorf("lsp: reading headers: %w", err)
+	}
+	n, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil || n < 0 {
+		return lspMessage{}, fmt.Errorf("lsp: invalid Content-Length %q", header.Get("Content-Length"))
+	}
+	body := make([]byte, n)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return lspMessage{}, fmt.Errorf("lsp: reading body: %w", err)
+	}
+	var msg lspMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return lspMessage{}, fmt.Errorf("lsp: invalid message: %w", err)
+	}
+	return msg, nil
+}
+
+func writeLSPMessage(w io.Writer, msg lspMessage) error {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", len(b)); err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+var yamlErrorLine = regexp.MustCompile(`line (\d+)`)
+
+// fragmentDiagnostics validates text with the same rules as check. Issues about a field are
+// placed on its value (or the first line when the field is missing).
+func fragmentDiagnostics(text string, manifest releaseManifest) []lspDiagnostic {
+	lines := strings.Split(text, "\n")
+	_, issues := papertrail.ValidateFragments([]byte(text), manifest)
+	values := fragmentValueNodes(text)
+	diags := []lspDiagnostic{}
+	for _, is := range issues {
+		line, col := issuePosition(is, values)
+		r := lspRange{Start: lspPosition{Line: line}, End: lspPosition{Line: line}}
+		if line >= 0 && line < len(lines) {
+			l := strings.TrimRight(lines[line], "\r")
+			r.Start.Character = utf16Len(l[:min(col, len(l))])
+			r.End.Character = utf16Len(l)
+		}
+		sev := lspSeverityError
+		if is.Severity == papertrail.SeverityWarning {
+			sev = lspSeverityWarning
+		}
+		diags = append(diags, lspDiagnostic{Range: r, Severity: sev, Code: is.Rule, Source: "papertrail", Message: is.Message})
+	}
+	return diags
+}
+
+// issuePosition returns the zero-based line and column an issue is about: where the YAML
+// parser failed, or the value of the issue's field; 0, 0 when unknown.
+func issuePosition(is papertrail.Issue, values map[string]*yaml.Node) (line, col int) {
+	if is.Rule == papertrail.RuleInvalidYAML {
+		if m := yamlErrorLine.FindStringSubmatch(is.Message); m != nil {
+			line, _ = strconv.Atoi(m[1])
+			return line - 1, 0
+		}
+	} else if n, ok := values[is.Field]; ok {
+		return n.Line - 1, n.Column - 1
+	}
+	return 0, 0
+}
+
+// fragmentValueNodes maps the top-level keys of a fragment to their value nodes. Invalid
+// YAML yields an empty map.
+func fragmentValueNodes(text string) map[string]*yaml.Node {
+	out := map[string]*yaml.Node{}
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(text), &doc); err != nil || len(doc.Content) == 0 {
+		return out
+	}
+	m := doc.Content[0]
+	if m.Kind != yaml.MappingNode {
+		return out
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		out[m.Content[i].Value] = m.Content[i+1]
+	}
+	return out
+}
+
+// fragmentLineAt returns the line under pos, the top-level key it starts with (if any), and
+// whether pos lies after that key's colon.
+func fragmentLineAt(text string, pos lspPosition) (line, key string, inValue bool) {
+	lines := strings.Split(text, "\n")
+	if pos.Line < 0 || pos.Line >= len(lines) {
+		return "", "", false
+	}
+	line = strings.TrimRight(lines[pos.Line], "\r")
+	k, _, ok := strings.Cut(line, ":")
+	if !ok || k != strings.TrimSpace(k) || strings.HasPrefix(k, "#") {
+		return line, "", false
+	}
+	colon := utf16Len(k)
+	return line, k, pos.Character > colon
+}
+
+func fragmentCompletions(text string, pos lspPosition, manifest releaseManifest) []lspCompletionItem {
+	line, key, inValue := fragmentLineAt(text, pos)
+	items := []lspCompletionItem{}
+	if !inValue {
+		if strings.TrimSpace(line) != "" && key != "" {
+			return items
+		}
+		present := fragmentValueNodes(text)
+		for _, f := range fragmentFieldDocs {
+			if _, ok := present[f.Key]; ok {
+				continue
+			}
+			items = append(items, lspCompletionItem{Label: f.Key, Kind: lspCompletionProperty, Detail: f.Doc, InsertText: f.Key + ": "})
+		}
+		return items
+	}
+	switch key {
+	case "type":
+		for _, t := range manifest.TypeOrder() {
+			items = append(items, lspCompletionItem{Label: displayType(t), Kind: lspCompletionValue, Detail: typeBumpDetail(t, manifest)})
+		}
+	case "component":
+		for _, c := range manifest.ComponentOrder() {
+			items = append(items, lspCompletionItem{Label: c, Kind: lspCompletionValue})
+		}
+	}
+	return items
+}
+
+// typeBumpDetail describes the version bump a fragment type causes under the manifest rules.
+func typeBumpDetail(t string, manifest releaseManifest) string {
+	bump, ok := manifest.BumpFor(manifest.CanonicalType(t))
+	if !ok {
+		return "no bump rule (patch)"
+	}
+	return bump.String() + " bump"
+}
+
+func fragmentHover(text string, pos lspPosition, manifest releaseManifest) *lspHover {
+	line, key, inValue := fragmentLineAt(text, pos)
+	if key == "" {
+		return nil
+	}
+	var doc string
+	for _, f := range fragmentFieldDocs {
+		if f.Key == key {
+			doc = f.Doc
+		}
+	}
+	if doc == "" {
+		return nil
+	}
+	value := strings.Trim(strings.TrimSpace(line[len(key)+1:]), `"'`)
+	if !inValue || value == "" {
+		return &lspHover{Contents: lspMarkupContent{Kind: "markdown", Value: fmt.Sprintf("**%s**\n\n%s", key, doc)}}
+	}
+	var b strings.Builder
+	switch key {
+	case "type":
+		canon := manifest.CanonicalType(value)
+		fmt.Fprintf(&b, "**type** `%s`", displayType(canon))
+		if canon != strings.ToUpper(value) {
+			fmt.Fprintf(&b, " (alias of `%s`)", displayType(canon))
+		}
+		if !manifest.TypeAllowed(canon) {
+			b.WriteString("\n\nNot a configured type.")
+		} else {
+			fmt.Fprintf(&b, "\n\nReleases with this type get a %s.", typeBumpDetail(canon, manifest))
+		}
+		if aliases := typeAliasesOf(canon, manifest); len(aliases) > 0 {
+			fmt.Fprintf(&b, "\n\nAliases: %s", strings.Join(aliases, ", "))
+		}
+	case "component":
+		fmt.Fprintf(&b, "**component** `%s`", value)
+		if order := manifest.ComponentOrder(); len(order) > 0 {
+			if i := slices.Index(order, value); i >= 0 {
+				fmt.Fprintf(&b, "\n\nHeading %d of %d in the changelog.", i+1, len(order))
+			} else {
+				b.WriteString("\n\nNot a configured component.")
+			}
+		}
+	default:
+		fmt.Fprintf(&b, "**%s**\n\n%s", key, doc)
+	}
+	return &lspHover{Contents: lspMarkupContent{Kind: "markdown", Value: b.String()}}
+}
+
+func typeAliasesOf(canon string, manifest releaseManifest) []string {
+	var out []string
+	for alias, target := range manifest.Types.Aliases {
+		if target == canon {
+			out = append(out, displayType(alias))
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// utf16Len is the length of s in UTF-16 code units, the unit of LSP character offsets.
+func utf16Len(s string) int {
+	return len(utf16.Encode([]rune(s)))
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+func lspTestManifest(t *testing.T) releaseManifest {
+	t.Helper()
+	m, err := papertrail.ParseManifest([]byte(`
+versioning:
+  rules:
+    breaking: major
+    feature: minor
+types:
+  order: [breaking, feature, fix]
+  aliases:
+    bugfix: fix
+changelog:
+  components: [CLI, API]
+  strict_components: true
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestFragmentDiagnostics(t *testing.T) {
+	t.Parallel()
+	m := lspTestManifest(t)
+
+	diags := fragmentDiagnostics("component: Web\ntype: chore\nsummary: x\n", m)
+	if len(diags) != 2 {
+		t.Fatalf("diagnostics: %+v", diags)
+	}
+	byCode := map[string]lspDiagnostic{}
+	for _, d := range diags {
+		byCode[d.Code] = d
+	}
+	if d := byCode[papertrail.RuleUnknownComponent]; d.Range != (lspRange{Start: lspPosition{0, 11}, End: lspPosition{0, 14}}) || d.Severity != lspSeverityError {
+		t.Fatalf("component diagnostic: %+v", d)
+	}
+	if d := byCode[papertrail.RuleUnknownType]; d.Range.Start != (lspPosition{1, 6}) {
+		t.Fatalf("type diagnostic: %+v", d)
+	}
+
+	diags = fragmentDiagnostics("component: CLI\ntype: fix\n  bad: indent\n", m)
+	if len(diags) != 1 || diags[0].Code != papertrail.RuleInvalidYAML || diags[0].Range.Start.Line != 2 {
+		t.Fatalf("invalid YAML diagnostics: %+v", diags)
+	}
+
+	if diags := fragmentDiagnostics("component: CLI\ntype: bugfix\nsummary: ok\n", m); len(diags) != 0 {
+		t.Fatalf("valid fragment: %+v", diags)
+	}
+}
+
+func TestFragmentCompletions(t *testing.T) {
+	t.Parallel()
+	m := lspTestManifest(t)
+
+	labels := func(items []lspCompletionItem) string {
+		var ls []string
+		for _, it := range items {
+			ls = append(ls, it.Label)
+		}
+		return strings.Join(ls, ",")
+	}
+
+	text := "component: CLI\ntype: \n"
+	got := fragmentCompletions(text, lspPosition{1, 6}, m)
+	if labels(got) != "breaking,feature,fix" || got[0].Detail != "major bump" {
+		t.Fatalf("type completions: %+v", got)
+	}
+	if got := fragmentCompletions("component: ", lspPosition{0, 11}, m); labels(got) != "CLI,API" {
+		t.Fatalf("component completions: %+v", got)
+	}
+	if got := fragmentCompletions(text+"\n", lspPosition{2, 0}, m); labels(got) != "summary,refs,authors,highlight,removal_version,details" {
+		t.Fatalf("key completions: %+v", got)
+	}
+}
+
+func TestFragmentHover(t *testing.T) {
+	t.Parallel()
+	m := lspTestManifest(t)
+
+	h := fragmentHover("type: bugfix\n", lspPosition{0, 8}, m)
+	if h == nil || !strings.Contains(h.Contents.Value, "alias of `fix`") || !strings.Contains(h.Contents.Value, "patch") {
+		t.Fatalf("type hover: %+v", h)
+	}
+	h = fragmentHover("component: Web\n", lspPosition{0, 12}, m)
+	if h == nil || !strings.Contains(h.Contents.Value, "Not a configured component") {
+		t.Fatalf("component hover: %+v", h)
+	}
+	h = fragmentHover("summary: x\n", lspPosition{0, 2}, m)
+	if h == nil || !strings.Contains(h.Contents.Value, "user-facing") {
+		t.Fatalf("key hover: %+v", h)
+	}
+	if h := fragmentHover("# comment\n", lspPosition{0, 2}, m); h != nil {
+		t.Fatalf("comment hover: %+v", h)
+	}
+}
+
+func TestLSPServerSession(t *testing.T) {
+	t.Parallel()
+
+	var in bytes.Buffer
+	send := func(id int, method string, params any) {
+		p, _ := json.Marshal(params)
+		msg := lspMessage{JSONRPC: "2.0", Method: method, Params: p}
+		if id > 0 {
+			msg.ID = json.RawMessage(fmt.Sprint(id))
+		}
+		if err := writeLSPMessage(&in, msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	uri := "file:///repo/changelog.d/x.yml"
+	send(1, "initialize", map[string]any{})
+	send(0, "initialized", map[string]any{})
+	send(0, "textDocument/didOpen", map[string]any{"textDocument": map[string]any{"uri": uri, "text": "component: Web\ntype: fix\nsummary: x\n"}})
+	send(2, "textDocument/hover", map[string]any{"textDocument": map[string]any{"uri": uri}, "position": lspPosition{1, 1}})
+	send(3, "workspace/symbol", map[string]any{})
+	send(4, "shutdown", nil)
+	send(0, "exit", nil)
+
+	s := &lspServer{manifest: lspTestManifest(t), docs: map[string]string{}}
+	var out bytes.Buffer
+	if err := s.serve(t.Context(), &in, &out); err != nil {
+		t.Fatalf("serve: %v", err)
+	}
+
+	r := bufio.NewReader(&out)
+	var msgs []lspMessage
+	for {
+		msg, err := readLSPMessage(r)
+		if err != nil {
+			break
+		}
+		msgs = append(msgs, msg)
+	}
+	if len(msgs) != 5 {
+		t.Fatalf("got %d messages: %+v", len(msgs), msgs)
+	}
+	if string(msgs[0].ID) != "1" || !strings.Contains(string(msgs[0].Result), `"hoverProvider":true`) {
+		t.Fatalf("initialize response: %+v", msgs[0])
+	}
+	if msgs[1].Method != "textDocument/publishDiagnostics" || !strings.Contains(string(msgs[1].Params), papertrail.RuleUnknownComponent) {
+		t.Fatalf("diagnostics: %+v", msgs[1])
+	}
+	if !strings.Contains(string(msgs[2].Result), "kind of change") {
+		t.Fatalf("hover response: %s", msgs[2].Result)
+	}
+	if msgs[3].Error == nil || msgs[3].Error.Code != lspMethodNotFound {
+		t.Fatalf("unknown method response: %+v", msgs[3])
+	}
+	if string(msgs[4].ID) != "4" || string(msgs[4].Result) != "null" {
+		t.Fatalf("shutdown response: %+v", msgs[4])
+	}
+}
+
+func TestLSPServerExitWithoutShutdown(t *testing.T) {
+	t.Parallel()
+
+	var in bytes.Buffer
+	if err := writeLSPMessage(&in, lspMessage{JSONRPC: "2.0", Method: "exit"}); err != nil {
+		t.Fatal(err)
+	}
+	s := &lspServer{docs: map[string]string{}}
+	if err := s.serve(t.Context(), &in, &bytes.Buffer{}); err == nil {
+		t.Fatalf("expected an error for exit without shutdown")
+	}
+}
@@ -2,343 +2,879 @@ package main
 
 import (
 	"bytes"
-	"encoding/json"
+	"context"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"io/fs"
+	"maps"
 	"os"
 	"os/exec"
-	"path/filepath"
+	"os/signal"
+	"path"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
+	_ "time/tzdata" // changelog.timezone must work on hosts without a zoneinfo database
 
-	"gopkg.in/yaml.v3"
+	"github.com/bnprtr/papertrail/pkg/papertrail"
 )
 
-type fragment struct {
-	Component string   `yaml:"component"`
-	Type      string   `yaml:"type"`
-	Summary   string   `yaml:"summary"`
-	Refs      []string `yaml:"refs,omitempty"`
-}
+// fragment and releaseManifest are the library's Fragment and Manifest; the CLI keeps its
+// historical names.
+type (
+	fragment        = papertrail.Fragment
+	releaseManifest = papertrail.Manifest
+)
 
 type item struct {
 	Path string
 	Frag fragment
+	// External marks fragments discovered via manifest sources; merge never archives them.
+	External bool
+	// Source is an External item's sources.sha256 line (see releasedSources), which merge
+	// records so later releases skip the file.
+	Source string
 }
 
-type releaseManifest struct {
-	Versioning struct {
-		Rules map[string]string `yaml:"rules"`
-	} `yaml:"versioning"`
-
-	Changelog struct {
-		// Components defines the preferred order for component headings.
-		// Unknown components are appended deterministically.
-		Components []string `yaml:"components"`
-
-		// ComponentsOrder is a legacy alias for Components (kept for backward compatibility).
-		ComponentsOrder []string `yaml:"components_order"`
-
-		StrictComponents bool `yaml:"strict_components"`
-	} `yaml:"changelog"`
-
-	Types struct {
-		// Order defines the allowed fragment types and the preferred ordering in output.
-		// Values are treated case-insensitively and normalized internally.
-		Order []string `yaml:"order"`
-		// Aliases maps alternate type spellings to canonical types.
-		Aliases map[string]string `yaml:"aliases"`
-	} `yaml:"types"`
-
-	PRPolicy struct {
-		FragmentRequirement struct {
-			OptOutLabel string `yaml:"opt_out_label"`
-		} `yaml:"fragment_requirement"`
-	} `yaml:"pr_policy"`
-}
-
-var (
-	// No repo-specific defaults.
-	//
-	// If the config does not specify `changelog.components`, Papertrail will order
-	// components deterministically (lexicographic).
-	defaultComponentOrder []string
-
-	// If the config does not specify `types.order`, Papertrail will order types
-	// deterministically (lexicographic) and will not enforce an allowlist.
-	defaultTypeOrder []string
-)
-
-const (
-	previewMarker = "<!-- papertrail-preview -->"
-)
+const previewMarker = papertrail.PreviewMarker
 
 func main() {
-	if len(os.Args) < 2 {
+	args := os.Args[1:]
+	offline := len(args) > 0 && args[0] == "--offline"
+	if offline {
+		args = args[1:]
+	}
+	if len(args) < 1 {
 		usage(os.Stderr)
 		os.Exit(2)
 	}
 
-	switch os.Args[1] {
-	case "check":
-		if err := cmdCheck(os.Args[2:]); err != nil {
-			fmt.Fprintln(os.Stderr, err.Error())
-			os.Exit(1)
-		}
-	case "bump":
-		if err := cmdBump(os.Args[2:]); err != nil {
-			fmt.Fprintln(os.Stderr, err.Error())
-			os.Exit(1)
-		}
-	case "pr-fragment":
-		if err := cmdPRFragment(os.Args[2:]); err != nil {
-			fmt.Fprintln(os.Stderr, err.Error())
-			os.Exit(1)
-		}
-	case "preview":
-		if err := cmdPreview(os.Args[2:]); err != nil {
-			fmt.Fprintln(os.Stderr, err.Error())
-			os.Exit(1)
-		}
-	case "merge":
-		if err := cmdMerge(os.Args[2:]); err != nil {
-			fmt.Fprintln(os.Stderr, err.Error())
-			os.Exit(1)
-		}
-	default:
+	commands := map[string]func(context.Context, []string) error{
+		"check":              cmdCheck,
+		"bump":               cmdBump,
+		"pr-fragment":        cmdPRFragment,
+		"pr-body":            cmdPRBody,
+		"preview":            cmdPreview,
+		"merge":              cmdMerge,
+		"new":                cmdNew,
+		"lint":               cmdLint,
+		"commit-message":     cmdCommitMessage,
+		"commit-msg":         cmdCommitMsg,
+		"aggregate":          cmdAggregate,
+		"merge-driver":       cmdMergeDriver,
+		"verify-attestation": cmdVerifyAttestation,
+		"notes":              cmdNotes,
+		"lsp":                cmdLSP,
+		"hooks":              cmdHooks,
+		"cut":                cmdCut,
+		"fix-changelog":      cmdFixChangelog,
+		"fmt":                cmdFmt,
+		"version-order":      cmdVersionOrder,
+		"verify-tag":         cmdVerifyTag,
+		"init":               cmdInit,
+		"import":             cmdImport,
+		"backfill":           cmdBackfill,
+		"from-commits":       cmdFromCommits,
+		"unreleased":         cmdUnreleased,
+		"unmerge":            cmdUnmerge,
+		"latest":             cmdLatest,
+		"show":               cmdShow,
+		"verify-changelog":   cmdVerifyChangelog,
+	}
+	run, ok := commands[args[0]]
+	if !ok {
 		usage(os.Stderr)
 		os.Exit(2)
 	}
+
+	ctx, cancel, err := rootContext()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(2)
+	}
+	if ctx, err = offlineContext(ctx, offline); err != nil {
+		cancel()
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(2)
+	}
+	err = run(ctx, args[1:])
+	cancel()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+}
+
+// rootContext returns the context every command runs under. It is cancelled on SIGINT or
+// SIGTERM, and PAPERTRAIL_TIMEOUT (a Go duration such as "2m") bounds the whole run so CI
+// wrappers can enforce a deadline on git and API calls.
+func rootContext() (context.Context, context.CancelFunc, error) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	raw := strings.TrimSpace(os.Getenv("PAPERTRAIL_TIMEOUT"))
+	if raw == "" {
+		return ctx, stop, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		stop()
+		return nil, nil, fmt.Errorf("invalid PAPERTRAIL_TIMEOUT %q (expected a positive duration like 90s or 5m)", raw)
+	}
+	ctx, cancel := context.WithTimeout(ctx, d)
+	return ctx, func() { cancel(); stop() }, nil
 }
 
 func usage(w *os.File) {
 	fmt.Fprintln(w, "papertrail: manage changelog fragments and releases")
 	fmt.Fprintln(w, "")
 	fmt.Fprintln(w, "Usage:")
-	fmt.Fprintln(w, "  papertrail check --fragments <dir>")
-	fmt.Fprintln(w, "  papertrail bump --base vX.Y.Z --fragments <dir> [--manifest <path>]")
-	fmt.Fprintln(w, "  papertrail pr-fragment --base-ref <ref> --fragments <dir> [--manifest <path>]   (reads GITHUB_EVENT_PATH)")
-	fmt.Fprintln(w, "  papertrail preview <fragment.yml> [more fragments...]")
-	fmt.Fprintln(w, "  papertrail merge --version vX.Y.Z --fragments <dir> --changelog <path> [--date YYYY-MM-DD] [--release-notes-out <path>]")
+	fmt.Fprintln(w, "  papertrail [--offline] <command> [flags]   (--offline or PAPERTRAIL_OFFLINE=1: never access the network)")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "  papertrail init [--fragments <dir>] [--changelog <path>] [--config <path>] [--no-example]")
+	fmt.Fprintln(w, "  papertrail check --fragments <dir> [--strict] [--ref <git-ref> | --staged] [--allow-empty] [--remote-sources] [--format text|json|sarif] [--annotations]")
+	fmt.Fprintln(w, "  papertrail bump [--base vX.Y.Z|auto] [--tag-prefix <prefix>] [--prerelease <id>] [--build <meta> | --keep-build] [--component <name>] --fragments <dir> [--manifest <path>] [--archive <dir>] [--skip-version-check] [--show-kind] [--explain] [--format text|json]")
+	fmt.Fprintln(w, "  papertrail pr-fragment --base-ref <ref> --fragments <dir> [--manifest <path>] [--api-diff] [--write-suggestion] [--format text|json]   (reads GITHUB_EVENT_PATH, CI_MERGE_REQUEST_* on GitLab, or the Bitbucket API)")
+	fmt.Fprintln(w, "  papertrail pr-body [--manifest <path>]   (enforce pr_policy.body on the PR description; reads the PR like pr-fragment)")
+	fmt.Fprintln(w, "  papertrail preview [--format markdown|json|html] <fragment.yml|-> [more fragments...]   (- reads a fragment from stdin)")
+	fmt.Fprintln(w, "  papertrail preview --all [--fragments <dir>] [--remote-sources]")
+	fmt.Fprintln(w, "  papertrail preview --ref <git-ref> [--fragments <dir>] [fragment paths...]")
+	fmt.Fprintln(w, "  papertrail preview --comment [--base-ref <ref>] [--fragments <dir>]   (reads GITHUB_EVENT_PATH; creates or updates the PR preview comment)")
+	fmt.Fprintln(w, "  papertrail merge --version vX.Y.Z --fragments <dir> --changelog <path> [--date YYYY-MM-DD | --date-from-ref <tag|commit>] [--release-notes-out <path>] [--skip-version-check] [--deps-since <ref>] [--full-changelog] [--contributors-from-git] [--lint-output] [--interactive] [--dry-run] [--wait <duration>] [--sign-key <key.pem> --attestation-out <path>]")
+	fmt.Fprintln(w, "  papertrail merge (--component <name> [--version vX.Y.Z] | --all-components) --fragments <dir> [--date YYYY-MM-DD] [--archive <dir>] [--lint-output]")
+	fmt.Fprintln(w, "  papertrail cut [--version vX.Y.Z] [--version-file <path>]... [--dry-run] [--no-commit] [--no-tag] [--push [--remote <name>]] [--no-github-release] [--repo <owner/name>] [--draft] [--lint-output] [--wait <duration>]   (uses GITHUB_TOKEN)")
+	fmt.Fprintln(w, "  papertrail new --type <type> [--component <name>] [--summary <text>] [--ref <ref>]... [--name <slug>]")
+	fmt.Fprintln(w, "  papertrail lint --fragments <dir> [--fix]")
+	fmt.Fprintln(w, "  papertrail import towncrier [--dir <newsfragments>] [--component <name>] [--type <from=to>]... [--fragments <dir>] [--dry-run] [--remove]")
+	fmt.Fprintln(w, "  papertrail import changie [--dir <.changes/unreleased>] [--component <name>] [--type <from=to>]... [--fragments <dir>] [--dry-run] [--remove]")
+	fmt.Fprintln(w, "  papertrail backfill --since <tag|commit> [--component <name>] [--type <from=to>]... [--fragments <dir>] [--dry-run]   (draft fragments from merged commits and PR titles)")
+	fmt.Fprintln(w, "  papertrail from-commits --base-ref <ref> [--component <name>] [--type <from=to>]... [--fragments <dir>] [--dry-run]   (one fragment per conventional commit on the branch)")
+	fmt.Fprintln(w, "  papertrail commit-message (--base-ref <ref> | <fragment.yml>...) [--out <path>]")
+	fmt.Fprintln(w, "  papertrail commit-msg <message-file> [--manifest <path>]   (commit-msg hook: the subject follows the PR title rules)")
+	fmt.Fprintln(w, "  papertrail aggregate --version vX.Y.Z --repo <owner/name[@vX.Y.Z]> [--repo ...] [--ref <ref>] [--out <path>]   (uses GITHUB_TOKEN)")
+	fmt.Fprintln(w, "  papertrail unmerge --version vX.Y.Z [--fragments <dir>] [--changelog <path>] [--archive <dir>] [--wait <duration>]   (roll back the newest merge)")
+	fmt.Fprintln(w, "  papertrail notes [--version vX.Y.Z] [--changelog <path>] [--sbom-base <old.json> --sbom <new.json> | --deps-since <ref>] [--artifacts <checksums.txt> [--provenance <url>]] [--out <path>]")
+	fmt.Fprintln(w, "  papertrail verify-attestation --key <pub.pem> --attestation <path> [--release-notes <path>]")
+	fmt.Fprintln(w, "  papertrail verify-tag vX.Y.Z [--changelog <path>]   (tag is annotated with the section's release notes or their checksum)")
+	fmt.Fprintln(w, "  papertrail hooks install [--hook pre-commit|pre-push|commit-msg]... [--fragments <dir>] [--manifest <path>] [--bin <command>] [--force]")
+	fmt.Fprintln(w, "  papertrail lsp [--manifest <path>]   (language server for fragment files over stdio)")
+	fmt.Fprintln(w, "  papertrail fmt [--changelog <path>] [--manifest <path>] [--check]   (re-render release sections in the current changelog.style)")
+	fmt.Fprintln(w, "  papertrail unreleased [--fragments <dir>] [--changelog <path>] [--manifest <path>] [--check] [--remote-sources]   (keep pending fragments in an Unreleased block; merge replaces it)")
+	fmt.Fprintln(w, "  papertrail latest [--changelog <path>] [--tags [--tag-prefix <prefix>]] [--component <name>] [--format text|json]   (print the latest released version and its date)")
+	fmt.Fprintln(w, "  papertrail show <vX.Y.Z|latest> [--changelog <path>] [--body-only] [--out <path>]   (print a released version's changelog section)")
+	fmt.Fprintln(w, "  papertrail verify-changelog [--changelog <path>] [--manifest <path>]   (check headings, version and date order, duplicates, and group headings)")
+	fmt.Fprintln(w, "  papertrail version-order [--changelog <path>] [--fix]   (verify release sections are newest first; --fix re-sorts)")
+	fmt.Fprintln(w, "  papertrail fix-changelog [--changelog <path>] [--dry-run]   (repair duplicate sections, headings, blank lines, stray previews)")
+	fmt.Fprintln(w, "  papertrail merge-driver <base> <ours> <theirs>   (git merge driver for CHANGELOG.md: %O %A %B)")
 	fmt.Fprintln(w, "")
 }
 
-func cmdCheck(args []string) error {
+func cmdCheck(ctx context.Context, args []string) error {
 	fs := flag.NewFlagSet("check", flag.ContinueOnError)
 	fs.SetOutput(ioDiscard{})
 	fragmentsDir := fs.String("fragments", "changelog.d", "fragments directory")
 	manifestPath := fs.String("manifest", "", "optional release config YAML path")
+	strict := fs.Bool("strict", false, "fail on warnings as well as errors")
+	ref := fs.String("ref", "", "validate fragments (and the manifest) from this git ref instead of the working tree")
+	staged := fs.Bool("staged", false, "validate only fragments staged for commit, as staged (for pre-commit hooks)")
+	allowEmpty := fs.Bool("allow-empty", false, "succeed when there are no fragments")
+	format := fs.String("format", "text", "output format: text|json|sarif")
+	annotations := fs.Bool("annotations", false, "also print GitHub Actions error/warning annotations for each issue (default: on under GitHub Actions with --format text)")
+	remoteSources := fs.Bool("remote-sources", false, remoteSourcesUsage)
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
-
-	manifest, _ := loadManifestDefault(*manifestPath)
-
-	files, err := listFragmentFiles(*fragmentsDir)
-	if err != nil {
-		return err
+	switch *format {
+	case "text", "json", "sarif":
+	default:
+		return fmt.Errorf("invalid --format %q (expected text|json|sarif)", *format)
+	}
+	annotationsSet := false
+	fs.Visit(func(f *flag.Flag) { annotationsSet = annotationsSet || f.Name == "annotations" })
+	if !annotationsSet {
+		*annotations = actionsAnnotationsDefault() && *format == "text"
+	} else if *annotations && *format != "text" {
+		return fmt.Errorf("--annotations cannot be combined with --format %s", *format)
+	}
+	if *staged && *ref != "" {
+		return fmt.Errorf("--staged and --ref cannot be combined")
+	}
+
+	var (
+		manifest releaseManifest
+		files    []fragmentFile
+	)
+	if *staged {
+		// The manifest is read from the index too, so a commit is checked against the config
+		// it will contain.
+		m, err := papertrail.LoadManifest(gitFS{ctx: ctx}, *manifestPath)
+		if err != nil {
+			return err
+		}
+		manifest = m
+		if files, err = stagedFragmentFiles(ctx, *fragmentsDir, manifest); err != nil {
+			return err
+		}
+		// Commits without fragment changes have nothing to check.
+		*allowEmpty = true
+	} else if *ref != "" {
+		// Everything comes from git objects so this works in bare repositories; manifest
+		// sources are not consulted.
+		gfs := gitFS{ctx: ctx, ref: *ref}
+		m, err := papertrail.LoadManifest(gfs, *manifestPath)
+		if err != nil {
+			return err
+		}
+		manifest = m
+		paths, err := papertrail.FragmentFiles(gfs, gitPath(*fragmentsDir), manifest)
+		if err != nil {
+			return err
+		}
+		for _, p := range paths {
+			files = append(files, fragmentFile{FS: gfs, Path: p, Name: *ref + ":" + p})
+		}
+	} else {
+		manifest, _ = loadManifestDefault(*manifestPath)
+		discovered, cleanup, err := discoverFragments(ctx, hostFS{}, *fragmentsDir, manifest, *remoteSources)
+		defer cleanup()
+		if err != nil {
+			return err
+		}
+		files = discovered
 	}
 	if len(files) == 0 {
+		if *allowEmpty {
+			switch *format {
+			case "json":
+				return writeJSON(os.Stdout, newCheckReport(nil, *strict))
+			case "sarif":
+				return writeSARIF(os.Stdout, nil)
+			}
+			return nil
+		}
 		return fmt.Errorf("no fragments found under %q", *fragmentsDir)
 	}
 
-	var allErrs []string
-	for _, path := range files {
-		_, err := readAndValidateFragment(path, manifest)
-		if err != nil {
-			allErrs = append(allErrs, fmt.Sprintf("%s: %s", path, err.Error()))
+	results := make([]checkResult, 0, len(files))
+	for _, ff := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		res := checkResult{Name: ff.Name}
+		if _, ok := ff.FS.(hostFS); ok && !ff.External {
+			res.Path = ff.Path
+		}
+		if b, err := ff.read(); err != nil {
+			res.Issues = []papertrail.Issue{{Rule: ruleReadError, Severity: papertrail.SeverityError, Message: err.Error()}}
+		} else {
+			res.Source = b
+			_, res.Issues = papertrail.ValidateFragmentFile(ff.Path, b, manifest)
+		}
+		results = append(results, res)
+	}
+	report := newCheckReport(results, *strict)
+	if *format != "text" {
+		write := func() error { return writeJSON(os.Stdout, report) }
+		if *format == "sarif" {
+			write = func() error { return writeSARIF(os.Stdout, results) }
+		}
+		if err := write(); err != nil {
+			return err
+		}
+		if !report.OK {
+			return fmt.Errorf("check failed: %d error(s), %d warning(s)", report.Errors, report.Warnings)
 		}
+		return nil
 	}
-	if len(allErrs) > 0 {
-		sort.Strings(allErrs)
-		return errors.New(strings.Join(allErrs, "\n"))
+
+	if *annotations {
+		writeAnnotations(os.Stdout, results)
 	}
+
+	var errs, warns []string
+	for _, res := range results {
+		for _, is := range res.Issues {
+			if is.Severity == papertrail.SeverityWarning {
+				warns = append(warns, fmt.Sprintf("%s: warning: %s", res.Name, is.Message))
+				continue
+			}
+			errs = append(errs, fmt.Sprintf("%s: %s", res.Name, is.Message))
+		}
+	}
+	if len(errs) == 0 && len(warns) == 0 {
+		return nil
+	}
+
+	sort.Strings(errs)
+	sort.Strings(warns)
+	lines := append(errs, warns...)
+	lines = append(lines, fmt.Sprintf("%d error(s), %d warning(s)", len(errs), len(warns)))
+	text := strings.Join(lines, "\n")
+	if !report.OK {
+		return errors.New(text)
+	}
+	fmt.Fprintln(os.Stderr, text)
 	return nil
 }
 
-func cmdBump(args []string) error {
+func cmdBump(ctx context.Context, args []string) error {
 	fs := flag.NewFlagSet("bump", flag.ContinueOnError)
 	fs.SetOutput(ioDiscard{})
 
-	base := fs.String("base", "", "base version like v1.2.3 (required)")
+	base := fs.String("base", "auto", "base version like v1.2.3, or auto for the latest git tag with --tag-prefix")
+	tagPrefix := fs.String("tag-prefix", "v", "prefix of release tags (e.g. api/v), for --base auto and the version check")
 	fragmentsDir := fs.String("fragments", "changelog.d", "fragments directory")
 	manifestPath := fs.String("manifest", "", "optional release config YAML path")
+	changelogPath := fs.String("changelog", "CHANGELOG.md", "changelog path (for the version continuity check)")
+	skipVersionCheck := fs.Bool("skip-version-check", false, "do not require --base to be the latest git tag / changelog release")
+	prerelease := fs.String("prerelease", "", "produce the next prerelease with this identifier (e.g. rc: v1.3.0-rc.1, then v1.3.0-rc.2)")
+	build := fs.String("build", "", "set build metadata on the next version (e.g. nightly.45 for v1.3.0+nightly.45)")
+	keepBuild := fs.Bool("keep-build", false, "carry the base version's build metadata over to the next version")
+	component := fs.String("component", "", "version an independently versioned component (versioning.components) from its own fragments, tags, and changelog")
+	showKind := fs.Bool("show-kind", false, "print the bump kind (major, minor, or patch) after the next version")
+	archiveDir := fs.String("archive", "changelog.d/archived", "archive directory, searched with the pending fragments for deprecations whose removal_version the next version reaches")
+	explain := fs.Bool("explain", false, "list the fragments behind each bump level and the versioning rule each matched (on stderr, or in the JSON output)")
+	format := fs.String("format", "text", "output format: text|json")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
-	if *base == "" {
-		return fmt.Errorf("--base is required (e.g. v0.1.0)")
-	}
-	if !isSemverV(*base) {
-		return fmt.Errorf("invalid --base %q (expected vMAJOR.MINOR.PATCH)", *base)
+	if err := checkOutputFormat(*format); err != nil {
+		return err
 	}
-
 	manifest, err := loadManifestDefault(*manifestPath)
 	if err != nil {
 		return err
 	}
-
-	files, err := listFragmentFiles(*fragmentsDir)
-	if err != nil {
-		return err
+	if *component != "" {
+		vc, ok := manifest.VersionedComponent(*component)
+		if !ok {
+			return fmt.Errorf("component %q is not versioned independently (add it under versioning.components)", *component)
+		}
+		// The component's tags and changelog apply unless overridden explicitly.
+		set := map[string]bool{}
+		fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+		if !set["tag-prefix"] {
+			*tagPrefix = vc.TagPrefix
+		}
+		if !set["changelog"] {
+			*changelogPath = vc.Changelog
+		}
 	}
-	if len(files) == 0 {
-		return fmt.Errorf("no fragments found under %q", *fragmentsDir)
+	if *build != "" && *keepBuild {
+		return fmt.Errorf("--build and --keep-build cannot be combined")
 	}
-
-	rules := manifest.Versioning.Rules
-
-	var bump bumpKind = bumpPatch
-	for _, path := range files {
-		f, err := readAndValidateFragment(path, manifest)
+	var buildIDs []string
+	if *build != "" {
+		ids, err := papertrail.ParseBuildMetadata(*build)
 		if err != nil {
-			return fmt.Errorf("invalid fragment %s: %w", path, err)
+			return fmt.Errorf("invalid --build %q: %v", *build, err)
 		}
-		bt, ok := bumpFromRules(rules, f.Type)
-		if !ok {
-			// No bump mapping found (e.g., no manifest, or manifest missing an explicit mapping and '*').
-			// Default to patch to avoid surprising "semantic" hard-codes; configure desired mapping in `.papertrail.config.yml`.
-			bt = bumpPatch
+		buildIDs = ids
+	}
+	var baseVersion semver
+	if *base == "" || *base == "auto" {
+		v, _, found, err := latestTagVersion(ctx, *tagPrefix)
+		if err != nil {
+			return err
 		}
-		if bt > bump {
-			bump = bt
+		if !found {
+			return fmt.Errorf("--base auto: no git tag matching %s<version> (pass --base vX.Y.Z)", *tagPrefix)
 		}
-		if bump == bumpMajor {
-			break
+		baseVersion = v
+	} else {
+		v, err := parseSemver(*base)
+		if err != nil {
+			return fmt.Errorf("invalid --base %q: %v (expected vMAJOR.MINOR.PATCH or auto)", *base, err)
+		}
+		baseVersion = v
+	}
+	if !*skipVersionCheck {
+		latest, ok, err := latestRelease(ctx, hostFS{}, *changelogPath, *tagPrefix)
+		if err != nil {
+			return err
+		}
+		if ok {
+			if err := checkBumpBase(baseVersion, latest); err != nil {
+				return err
+			}
 		}
 	}
 
-	next, err := bumpSemver(*base, bump)
+	_, items, cleanup, err := loadPendingItems(ctx, *fragmentsDir, *archiveDir, manifest)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+	if len(manifest.Versioning.Components) > 0 {
+		if _, items, err = streamItems(nil, items, manifest, *component); err != nil {
+			return err
+		}
+	}
+	archived, err := archivedItems(hostFS{}, *archiveDir, manifest)
 	if err != nil {
 		return err
 	}
+	archived = slices.DeleteFunc(archived, func(it item) bool { return !inStream(it, manifest, *component) })
+
+	kind := nextBump(items, manifest, baseVersion)
+	next := baseVersion.Bump(kind)
+	if *prerelease != "" {
+		if next, err = baseVersion.BumpPrerelease(kind, *prerelease); err != nil {
+			return err
+		}
+	}
+	// Build metadata does not affect precedence, so bumping drops it unless asked otherwise.
+	next.Build = buildIDs
+	if *keepBuild {
+		next.Build = baseVersion.Build
+	}
+	if err := checkGoModuleVersion(hostFS{}, next); err != nil {
+		// bump only computes the version; merge is where a mismatch is fatal.
+		fmt.Fprintln(os.Stderr, "warning: "+err.Error())
+	}
+	writeDueRemovals(os.Stderr, dueRemovals(slices.Concat(items, archived), baseVersion, next), next)
+	if err := writeActionsOutputs(releaseOutputs(next.String(), kind, len(items), "")...); err != nil {
+		return err
+	}
+	report := bumpReport{Base: baseVersion.String(), Bump: kind.String(), Next: next.String(), Fragments: len(items)}
+	if *explain {
+		reasons := explainBump(items, manifest)
+		pending := pendingBump(items, manifest)
+		if *format == "json" {
+			r := bumpExplainReport{bumpReport: report, Explain: reasons}
+			if pending != kind {
+				r.Degraded = pending.String()
+			}
+			return writeJSON(os.Stdout, r)
+		}
+		writeBumpExplanation(os.Stderr, reasons, pending, kind)
+	}
+	if *format == "json" {
+		return writeJSON(os.Stdout, report)
+	}
+	if *showKind {
+		_, _ = fmt.Fprintln(os.Stdout, next, kind)
+		return nil
+	}
 	_, _ = fmt.Fprintln(os.Stdout, next)
 	return nil
 }
 
-func cmdPreview(args []string) error {
+func cmdPreview(ctx context.Context, args []string) error {
 	fs := flag.NewFlagSet("preview", flag.ContinueOnError)
 	fs.SetOutput(ioDiscard{})
 	manifestPath := fs.String("manifest", "", "optional release config YAML path")
+	all := fs.Bool("all", false, "preview every pending fragment under --fragments")
+	ref := fs.String("ref", "", "read fragments from this git ref instead of the working tree")
+	fragmentsDir := fs.String("fragments", "changelog.d", "fragments directory (with --all or --ref)")
+	format := fs.String("format", "markdown", "output format: "+strings.Join(papertrail.Formats(), "|"))
+	comment := fs.Bool("comment", false, "post the preview of the fragments changed in the pull request (GITHUB_EVENT_PATH) as a PR comment, updating the previous one")
+	baseRef := fs.String("base-ref", "", "with --comment, the ref to diff against (default: the PR's base commit)")
+	remoteSources := fs.Bool("remote-sources", false, remoteSourcesUsage)
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
-	files := fs.Args()
-	if len(files) == 0 {
-		return fmt.Errorf("preview requires at least one fragment file path")
+	renderer, err := lookupRenderer(*format)
+	if err != nil {
+		return err
 	}
 
-	manifest, _ := loadManifestDefault(*manifestPath)
+	var manifest releaseManifest
+	if *ref != "" {
+		// The manifest is read at the ref too, so the preview renders with that branch's config.
+		if manifest, err = loadManifestAtRef(ctx, *ref, *manifestPath); err != nil {
+			return err
+		}
+	} else {
+		manifest, _ = loadManifestDefault(*manifestPath)
+	}
 
-	items := make([]item, 0, len(files))
-	for _, p := range files {
-		f, err := readAndValidateFragment(p, manifest)
+	if *comment {
+		if *all || *ref != "" || fs.NArg() > 0 || *format != "markdown" {
+			return fmt.Errorf("--comment previews the PR's changed fragments as markdown; it cannot be combined with --all, --ref, --format, or fragment paths")
+		}
+		return commentPreview(ctx, strings.TrimSpace(*baseRef), *fragmentsDir, manifest)
+	}
+	if *baseRef != "" {
+		return fmt.Errorf("--base-ref requires --comment")
+	}
+
+	var items []item
+	switch {
+	case *ref != "":
+		if *all {
+			return fmt.Errorf("--ref and --all cannot be combined (--ref without paths previews all pending fragments at the ref)")
+		}
+		items, err = loadItemsAtRef(ctx, *ref, *fragmentsDir, fs.Args(), manifest)
+		if err != nil {
+			return err
+		}
+	case *all:
+		if fs.NArg() > 0 {
+			return fmt.Errorf("preview --all does not accept fragment paths")
+		}
+		files, cleanup, err := discoverFragments(ctx, hostFS{}, *fragmentsDir, manifest, *remoteSources)
+		defer cleanup()
 		if err != nil {
-			return fmt.Errorf("invalid fragment %s: %w", p, err)
+			return err
+		}
+		if len(files) == 0 {
+			return fmt.Errorf("no fragments found under %q", *fragmentsDir)
+		}
+		for _, ff := range files {
+			its, err := readItems(ff, manifest)
+			if err != nil {
+				return err
+			}
+			items = append(items, its...)
+		}
+	default:
+		if fs.NArg() == 0 {
+			return fmt.Errorf("preview requires at least one fragment file path (or --all)")
+		}
+		stdinUsed := false
+		for _, p := range fs.Args() {
+			if p == "-" {
+				// "-" reads one fragment file from stdin.
+				if stdinUsed {
+					return fmt.Errorf("stdin (-) can only be given once")
+				}
+				stdinUsed = true
+				b, err := io.ReadAll(os.Stdin)
+				if err != nil {
+					return err
+				}
+				fragments, err := papertrail.ParseFragments(b, manifest)
+				if err != nil {
+					return fmt.Errorf("invalid fragment <stdin>: %w", err)
+				}
+				for _, f := range fragments {
+					items = append(items, item{Path: "<stdin>", Frag: f})
+				}
+				continue
+			}
+			its, err := readItems(fragmentFile{FS: hostFS{}, Path: p, Name: p}, manifest)
+			if err != nil {
+				return err
+			}
+			items = append(items, its...)
 		}
-		items = append(items, item{Path: p, Frag: f})
 	}
 
-	out := renderPreview(items, manifest)
+	model := newReleaseModel("", "", items, manifest)
+	if *format == "markdown" {
+		if model, err = withTemplate(model, manifest.Changelog.Templates.Preview); err != nil {
+			return err
+		}
+	}
+	out, err := renderer.Render(model)
+	if err != nil {
+		return err
+	}
 	_, _ = os.Stdout.Write(out)
 	return nil
 }
 
-func cmdPRFragment(args []string) error {
+func cmdPRFragment(ctx context.Context, args []string) error {
 	fs := flag.NewFlagSet("pr-fragment", flag.ContinueOnError)
 	fs.SetOutput(ioDiscard{})
-	baseRef := fs.String("base-ref", "", "base ref to diff against (required), e.g. origin/main")
-	fragmentsDir := fs.String("fragments", "changelog.d", "fragments directory")
-	manifestPath := fs.String("manifest", "", "optional release config YAML path")
+	var opts prFragmentOptions
+	fs.StringVar(&opts.BaseRef, "base-ref", "", "base ref to diff against, e.g. origin/main (required outside GitLab merge request pipelines)")
+	fs.StringVar(&opts.FragmentsDir, "fragments", "changelog.d", "fragments directory")
+	fs.StringVar(&opts.ManifestPath, "manifest", "", "optional release config YAML path")
+	fs.BoolVar(&opts.APIDiff, "api-diff", false, "warn when the exported Go API changed incompatibly but no fragment bumps the major version")
+	fs.BoolVar(&opts.WriteSuggestion, "write-suggestion", false, "when the fragment is missing, also write the suggested fragment to its path under --fragments (for the workflow to commit)")
+	format := fs.String("format", "text", "output format: text|json (json prints the result and the suggested title and fragment on stdout)")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
-	if strings.TrimSpace(*baseRef) == "" {
-		return fmt.Errorf("--base-ref is required")
+	if err := checkOutputFormat(*format); err != nil {
+		return err
+	}
+	if *format == "text" {
+		return prFragment(ctx, opts, &prFragmentReport{})
 	}
 
-	manifest, err := loadManifestDefault(*manifestPath)
+	// The report is the only output on stdout, so check prints no annotations there.
+	opts.NoAnnotations = true
+	report := &prFragmentReport{}
+	err := prFragment(ctx, opts, report)
+	report.OK = err == nil
 	if err != nil {
-		return err
+		report.Message = err.Error()
 	}
-	cfg := prPolicyFromManifest(manifest)
-
-	evPath := strings.TrimSpace(os.Getenv("GITHUB_EVENT_PATH"))
-	if evPath == "" {
-		return fmt.Errorf("GITHUB_EVENT_PATH is required")
+	if werr := writeJSON(os.Stdout, report); werr != nil {
+		return errors.Join(err, werr)
 	}
-	labels, err := readPRLabels(evPath)
+	if err != nil {
+		return errors.New("pr-fragment failed")
+	}
+	return nil
+}
+
+// prFragmentOptions are the pr-fragment flags.
+type prFragmentOptions struct {
+	BaseRef, FragmentsDir, ManifestPath string
+	APIDiff, WriteSuggestion            bool
+	// NoAnnotations keeps check from printing GitHub Actions annotations.
+	NoAnnotations bool
+}
+
+// prFragmentReport is the pr-fragment --format json output: whether the PR passed, the
+// failure as the text output prints it, and the suggested title and fragment if any.
+type prFragmentReport struct {
+	OK                bool               `json:"ok"`
+	Message           string             `json:"message,omitempty"`
+	SuggestedTitle    string             `json:"suggested_title,omitempty"`
+	SuggestedFragment *suggestedFragment `json:"suggested_fragment,omitempty"`
+}
+
+// suggestedFragment is the fragment pr-fragment suggests for a PR without one.
+type suggestedFragment struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+	// Written reports whether --write-suggestion wrote it to Path.
+	Written bool `json:"written"`
+}
+
+// prFragment runs the pr-fragment checks, recording the suggestions it makes in report.
+func prFragment(ctx context.Context, opts prFragmentOptions, report *prFragmentReport) error {
+	pr, err := currentPullRequest(ctx)
 	if err != nil {
 		return err
 	}
+	baseRef := strings.TrimSpace(opts.BaseRef)
+	if baseRef == "" {
+		if pr.Base == "" {
+			return fmt.Errorf("--base-ref is required")
+		}
+		baseRef = pr.Base
+	}
 
-	changed, err := gitChangedFiles(*baseRef)
+	manifest, err := loadManifestDefault(opts.ManifestPath)
 	if err != nil {
 		return err
 	}
+	cfg := prPolicyFromManifest(manifest)
+	withSuggestion := func(err error) error {
+		report.SuggestedTitle, _ = suggestPRTitle(pr.Title, manifest)
+		return withTitleSuggestion(err, pr.Title, manifest)
+	}
 
-	if cfg.OptOutLabel != "" && contains(labels, cfg.OptOutLabel) {
+	changed := pr.Files
+	if changed == nil {
+		if changed, err = gitChangedFiles(ctx, baseRef); err != nil {
+			return err
+		}
+	}
+
+	if err := checkPRTitle(pr.Title, manifest); err != nil {
+		return withSuggestion(err)
+	}
+	if cfg.OptOutLabel != "" && contains(pr.Labels, cfg.OptOutLabel) {
 		return nil
 	}
 
 	// Fragment required: ensure at least one fragment file is part of the PR diff.
 	var fragChanged bool
 	for _, f := range changed {
-		if strings.HasPrefix(f, *fragmentsDir+"/") && (strings.HasSuffix(f, ".yml") || strings.HasSuffix(f, ".yaml")) {
+		if isFragmentPath(f, opts.FragmentsDir, manifest) {
 			fragChanged = true
 			break
 		}
 	}
 	if !fragChanged {
-		msg := "❌ No changelog fragment found under " + *fragmentsDir + "/ (required for non-doc changes)"
+		msg := "❌ No changelog fragment found under " + opts.FragmentsDir + "/ (required for non-doc changes)"
 		if cfg.OptOutLabel != "" {
 			msg += "\n💡 If this change has no user-visible impact, add the PR label: " + cfg.OptOutLabel
 		}
+		p, content, reason := suggestFragment(pr, changed, opts.FragmentsDir, manifest)
+		if reason != "" {
+			return withSuggestion(errors.New(msg + "\n(no fragment suggested from the PR title: " + reason + ")"))
+		}
+		report.SuggestedFragment = &suggestedFragment{Path: p, Content: string(content)}
+		msg += "\n" + fragmentSuggestion(p, content)
+		if opts.WriteSuggestion {
+			if err := os.MkdirAll(path.Dir(p), 0755); err != nil {
+				return err
+			}
+			if err := os.WriteFile(p, content, 0644); err != nil {
+				return err
+			}
+			report.SuggestedFragment.Written = true
+			msg += "\nWrote " + p
+		}
 		return errors.New(msg)
 	}
 
 	// Validate all fragments in the repo (catches schema drift deterministically).
-	return cmdCheck([]string{"--fragments", *fragmentsDir, "--manifest", *manifestPath})
+	checkArgs := []string{"--fragments", opts.FragmentsDir, "--manifest", opts.ManifestPath}
+	if opts.NoAnnotations {
+		checkArgs = append(checkArgs, "--annotations=false")
+	}
+	if err := cmdCheck(ctx, checkArgs); err != nil {
+		return err
+	}
+	if err := checkTitleTypes(pr, changed, opts.FragmentsDir, manifest); err != nil {
+		return err
+	}
+	if err := checkBreakingTitle(pr, changed, opts.FragmentsDir, manifest); err != nil {
+		return err
+	}
+	if opts.APIDiff {
+		return warnUndeclaredAPIBreaks(ctx, baseRef, opts.FragmentsDir, changed, manifest)
+	}
+	return nil
+}
+
+// warnUndeclaredAPIBreaks prints a warning when the exported Go API changed incompatibly
+// since baseRef but none of the PR's fragments has a type that bumps the major version.
+func warnUndeclaredAPIBreaks(ctx context.Context, baseRef, fragmentsDir string, changed []string, manifest releaseManifest) error {
+	for _, p := range changed {
+		if !isFragmentPath(p, fragmentsDir, manifest) {
+			continue
+		}
+		fragments, err := papertrail.ReadFragments(hostFS{}, p, manifest)
+		if err != nil {
+			// Deleted in the PR, or already reported by check.
+			continue
+		}
+		for _, f := range fragments {
+			if commitBump(f, manifest) == bumpMajor {
+				return nil
+			}
+		}
+	}
+	breaks, err := apiBreaksSince(ctx, baseRef)
+	if err != nil {
+		return err
+	}
+	if len(breaks) == 0 {
+		return nil
+	}
+	const limit = 20
+	msg := "⚠️ The exported Go API has incompatible changes, but no fragment in this PR bumps the major version:"
+	for i, b := range breaks {
+		if i == limit {
+			msg += fmt.Sprintf("\n  … and %d more", len(breaks)-limit)
+			break
+		}
+		msg += "\n  - " + b
+	}
+	fmt.Fprintln(os.Stderr, msg)
+	return nil
 }
 
-func cmdMerge(args []string) error {
+func cmdMerge(ctx context.Context, args []string) error {
 	fs := flag.NewFlagSet("merge", flag.ContinueOnError)
 	fs.SetOutput(ioDiscard{})
 
-	version := fs.String("version", "", "version like v1.2.3 (required)")
-	date := fs.String("date", "", "release date YYYY-MM-DD (default: today UTC)")
+	version := fs.String("version", "", "version like v1.2.3 (required unless --component or --all-components)")
+	date := fs.String("date", "", "release date YYYY-MM-DD (default: today in changelog.timezone, or at SOURCE_DATE_EPOCH)")
 	fragmentsDir := fs.String("fragments", "changelog.d", "fragments directory")
 	changelogPath := fs.String("changelog", "CHANGELOG.md", "changelog path")
 	archiveDir := fs.String("archive", "changelog.d/archived", "archive directory")
 	releaseNotesOut := fs.String("release-notes-out", "", "write release notes body to this path")
 	manifestPath := fs.String("manifest", "", "optional release config YAML path")
+	skipVersionCheck := fs.Bool("skip-version-check", false, "do not require --version to directly follow the latest git tag / changelog release or to match the Go module major path")
+	signKey := fs.String("sign-key", "", "Ed25519 private key (PEM) to sign a release attestation with")
+	attestationOut := fs.String("attestation-out", "", "write the signed attestation to this path (requires --sign-key)")
+	depsSince := fs.String("deps-since", "", "git ref (e.g. the previous tag) to diff go.mod requirements against HEAD; adds a dependency changes subsection")
+	fullChangelog := fs.Bool("full-changelog", false, "end the section and release notes with a \"**Full Changelog**\" compare link (changelog.compare_url) from the previous release")
+	gitContributors := fs.Bool("contributors-from-git", false, "credit fragments without authors with the commit authors of their files (git log --follow) in the release notes' contributors")
+	var dateFromRef string
+	fs.StringVar(&dateFromRef, "date-from-ref", "", "date the release by this tag (tagger date for annotated tags) or commit instead of today")
+	fs.StringVar(&dateFromRef, "date-from-tag", "", "alias for --date-from-ref")
+	lintOutput := fs.Bool("lint-output", false, "check the generated section and release notes against markdownlint rules (MD012, MD022, MD032, MD047) before writing")
+	wait := fs.Duration("wait", 0, "wait this long (e.g. 2m) for another merge, unmerge, or cut of the changelog to finish instead of failing")
+	dryRun := fs.Bool("dry-run", false, "print the section, where it would be inserted, and the fragments that would be archived, without writing anything")
+	interactive := fs.Bool("interactive", false, "show the section and fragments, and ask for confirmation (and which fragments to include) before writing")
+	component := fs.String("component", "", "release only this independently versioned component (versioning.components) into its own changelog; --version is then optional")
+	allComponents := fs.Bool("all-components", false, "release every independently versioned component with pending fragments, each into its own changelog")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
-
-	if *version == "" {
-		return fmt.Errorf("--version is required (e.g. v0.1.0)")
+	if *date != "" && dateFromRef != "" {
+		return fmt.Errorf("--date and --date-from-ref cannot be combined")
 	}
-	if !strings.HasPrefix(*version, "v") || strings.Count(*version, ".") != 2 {
-		return fmt.Errorf("invalid --version %q (expected vMAJOR.MINOR.PATCH)", *version)
+	if (*signKey == "") != (*attestationOut == "") {
+		return fmt.Errorf("--sign-key and --attestation-out must be used together")
 	}
-
-	releaseDate := *date
-	if releaseDate == "" {
-		releaseDate = time.Now().UTC().Format("2006-01-02")
-	} else if !looksLikeDate(releaseDate) {
-		return fmt.Errorf("invalid --date %q (expected YYYY-MM-DD)", releaseDate)
+	if *component != "" || *allComponents {
+		switch {
+		case *component != "" && *allComponents:
+			return fmt.Errorf("--component and --all-components cannot be combined")
+		case *interactive || *signKey != "" || *depsSince != "" || *fullChangelog || *dryRun:
+			return fmt.Errorf("--interactive, --sign-key, --deps-since, --full-changelog, and --dry-run are not supported with --component or --all-components")
+		case *allComponents && (*version != "" || *releaseNotesOut != ""):
+			return fmt.Errorf("--version and --release-notes-out need a single --component")
+		}
+		manifest, err := loadManifestDefault(*manifestPath)
+		if err != nil {
+			return err
+		}
+		releaseDate, err := resolveReleaseDate(ctx, *date, dateFromRef, manifest)
+		if err != nil {
+			return err
+		}
+		_, items, cleanup, err := loadPendingItems(ctx, *fragmentsDir, *archiveDir, manifest)
+		defer cleanup()
+		if err != nil {
+			return err
+		}
+		if *gitContributors {
+			if err := addGitAuthors(ctx, items); err != nil {
+				return err
+			}
+		}
+		components := []string{*component}
+		if *allComponents {
+			if components = pendingComponents(items, manifest); len(components) == 0 {
+				return fmt.Errorf("no pending fragments for independently versioned components")
+			}
+		}
+		return mergeComponents(ctx, components, componentMergeOptions{
+			Version:          *version,
+			Date:             releaseDate,
+			ArchiveDir:       *archiveDir,
+			ReleaseNotesOut:  *releaseNotesOut,
+			SkipVersionCheck: *skipVersionCheck,
+			Lint:             *lintOutput || manifest.Changelog.Style.Markdownlint,
+			Wait:             *wait,
+		}, items, manifest)
+	}
+
+	if !*dryRun {
+		unlock, err := acquireChangelogLock(ctx, *changelogPath, *wait)
+		if err != nil {
+			return err
+		}
+		defer unlock()
 	}
 
-	files, err := listFragmentFiles(*fragmentsDir)
+	if *version == "" {
+		return fmt.Errorf("--version is required (e.g. v0.1.0)")
+	}
+	next, err := parseSemver(*version)
 	if err != nil {
-		return err
+		return fmt.Errorf("invalid --version %q: %v (expected vMAJOR.MINOR.PATCH)", *version, err)
 	}
-	if len(files) == 0 {
-		return fmt.Errorf("no fragments found under %q", *fragmentsDir)
+	if !*skipVersionCheck {
+		latest, ok, err := latestRelease(ctx, hostFS{}, *changelogPath, "v")
+		if err != nil {
+			return err
+		}
+		if ok {
+			if err := checkNextVersion(next, latest); err != nil {
+				return err
+			}
+		}
+		if err := checkGoModuleVersion(hostFS{}, next); err != nil {
+			return fmt.Errorf("%w; pass --skip-version-check to release it anyway", err)
+		}
 	}
 
 	manifest, err := loadManifestDefault(*manifestPath)
@@ -346,265 +882,413 @@ func cmdMerge(args []string) error {
 		return err
 	}
 
-	items := make([]item, 0, len(files))
-	for _, p := range files {
-		f, err := readAndValidateFragment(p, manifest)
-		if err != nil {
-			return fmt.Errorf("invalid fragment %s: %w", p, err)
-		}
-		items = append(items, item{Path: p, Frag: f})
-	}
-
-	section, releaseNotes := renderReleaseSection(*version, releaseDate, items, manifest)
-
-	orig, err := os.ReadFile(*changelogPath)
+	releaseDate, err := resolveReleaseDate(ctx, *date, dateFromRef, manifest)
 	if err != nil {
 		return err
 	}
-	if bytes.Contains(orig, []byte("\n## "+*version+" (")) {
-		return fmt.Errorf("CHANGELOG already contains a section for %s", *version)
-	}
-	updated, err := insertReleaseSection(orig, section)
+
+	files, items, cleanup, err := loadPendingItems(ctx, *fragmentsDir, *archiveDir, manifest)
+	defer cleanup()
 	if err != nil {
 		return err
 	}
-	if err := os.WriteFile(*changelogPath, updated, 0644); err != nil {
-		return err
+	if len(manifest.Versioning.Components) > 0 {
+		if files, items, err = streamItems(files, items, manifest, ""); err != nil {
+			return err
+		}
 	}
-
-	if *releaseNotesOut != "" {
-		if err := os.WriteFile(*releaseNotesOut, releaseNotes, 0644); err != nil {
+	if *gitContributors {
+		if err := addGitAuthors(ctx, items); err != nil {
+			return err
+		}
+	}
+	if *interactive {
+		render := func(its []item) []byte {
+			// The same templates render the release below, which reports any error.
+			section, _, _ := renderReleaseSection(*version, releaseDate, its, manifest)
+			return section
+		}
+		if files, items, err = confirmRelease(os.Stdin, os.Stderr, render, files, items); err != nil {
 			return err
 		}
 	}
 
-	archivePath := filepath.Join(*archiveDir, *version)
-	if err := os.MkdirAll(archivePath, 0755); err != nil {
+	section, releaseNotes, err := renderReleaseSection(*version, releaseDate, items, manifest)
+	if err != nil {
 		return err
 	}
-	for _, it := range items {
-		dst := filepath.Join(archivePath, filepath.Base(it.Path))
-		if err := os.Rename(it.Path, dst); err != nil {
+	if *depsSince != "" {
+		d, err := goModDependencyDiff(ctx, *depsSince)
+		if err != nil {
+			return err
+		}
+		var deps bytes.Buffer
+		writeDependencyDiff(&deps, "Dependency changes", d)
+		section = append(section, deps.Bytes()...)
+		releaseNotes = append(releaseNotes, deps.Bytes()...)
+	}
+	if *fullChangelog {
+		line, err := fullChangelogLine(ctx, *changelogPath, next, manifest)
+		if err != nil {
+			return err
+		}
+		section = append(section, line...)
+		releaseNotes = append(releaseNotes, line...)
+	}
+	releaseNotes = trimTrailingNewlines(releaseNotes)
+	if *lintOutput || manifest.Changelog.Style.Markdownlint {
+		if err := lintReleaseOutput(section, releaseNotes); err != nil {
 			return err
 		}
 	}
 
-	return nil
-}
-
-func listFragmentFiles(dir string) ([]string, error) {
-	var files []string
-	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+	var attestation []byte
+	if *signKey != "" {
+		key, err := loadSigningKey(hostFS{}, *signKey)
 		if err != nil {
 			return err
 		}
-		if d.IsDir() {
-			// Skip archives.
-			if path != dir && filepath.Base(path) == "archived" {
-				return filepath.SkipDir
-			}
-			return nil
+		st, err := newReleaseStatement(*version, releaseNotes, *archiveDir, files)
+		if err != nil {
+			return err
 		}
-		ext := strings.ToLower(filepath.Ext(path))
-		if ext != ".yml" && ext != ".yaml" {
-			return nil
+		if attestation, err = signReleaseStatement(st, key); err != nil {
+			return err
+		}
+	}
+
+	out := releaseOutput{
+		Version:         *version,
+		ChangelogPath:   *changelogPath,
+		ArchiveDir:      *archiveDir,
+		ReleaseNotesOut: *releaseNotesOut,
+		AttestationOut:  *attestationOut,
+		Section:         section,
+		ReleaseNotes:    releaseNotes,
+		Attestation:     attestation,
+		Items:           items,
+		Style:           manifest.Changelog.Style,
+		Compare:         changelogCompare(manifest, "v"),
+	}
+	if *dryRun {
+		updated, err := releaseChangelog(hostFS{}, out)
+		if err != nil {
+			return err
 		}
-		files = append(files, path)
+		writeMergePlan(os.Stdout, out, updated)
 		return nil
-	})
+	}
+	if err := writeRelease(hostFS{}, out); err != nil {
+		return err
+	}
+	return writeActionsOutputs(releaseOutputs(*version, pendingBump(items, manifest), len(items), *releaseNotesOut)...)
+}
+
+// releaseOutput is everything merge writes for a release.
+type releaseOutput struct {
+	Version         string
+	ChangelogPath   string
+	ArchiveDir      string
+	ReleaseNotesOut string
+	AttestationOut  string
+	Section         []byte
+	ReleaseNotes    []byte
+	Attestation     []byte
+	Items           []item
+	// Style is changelog.style; the keepachangelog profile keeps an Unreleased section above
+	// the releases and, with Compare (see changelogCompare), link references below them.
+	Style   papertrail.Style
+	Compare func(from, to string) string
+	// VersionFiles are the files cut rewrites with the new version, by path.
+	VersionFiles map[string][]byte
+}
+
+// archivedPaths returns the fragment files merge archives: each local item's file, once.
+func archivedPaths(items []item) []string {
+	var paths []string
+	for _, it := range items {
+		if !it.External && !slices.Contains(paths, it.Path) {
+			paths = append(paths, it.Path)
+		}
+	}
+	return paths
+}
+
+// releaseChangelog returns the changelog with the release section inserted.
+func releaseChangelog(fsys fs.FS, out releaseOutput) ([]byte, error) {
+	orig, err := fs.ReadFile(fsys, out.ChangelogPath)
 	if err != nil {
 		return nil, err
 	}
-	sort.Strings(files)
-	return files, nil
+	if _, ok := papertrail.ExtractSection(string(orig), out.Version); ok {
+		return nil, fmt.Errorf("CHANGELOG already contains a section for %s", out.Version)
+	}
+	if out.Style.KeepAChangelog() {
+		orig = papertrail.SetUnreleased(orig, nil, out.Style)
+		return papertrail.InsertKeepAChangelogSection(orig, out.Section, out.Version, out.Compare)
+	}
+	if replaced, ok := papertrail.ReplaceUnreleased(orig, out.Section); ok {
+		// The release takes the place of the block `unreleased` keeps.
+		return replaced, nil
+	}
+	return papertrail.InsertSection(orig, out.Section)
 }
 
-func readAndValidateFragment(path string, manifest releaseManifest) (fragment, error) {
-	b, err := os.ReadFile(path)
+// writeRelease inserts the release section into the changelog, writes release notes, archives
+// local fragments under <archive>/<version>/ and records source fragments and the changelog
+// edits there, and writes the attestation and version files if there are any. It is all or
+// nothing: when a step fails, the steps before it are rolled back.
+func writeRelease(fsys writableFS, out releaseOutput) error {
+	orig, err := readFile(fsys, out.ChangelogPath)
 	if err != nil {
-		return fragment{}, err
+		return err
 	}
-	var f fragment
-	if err := yaml.Unmarshal(b, &f); err != nil {
-		return fragment{}, fmt.Errorf("invalid YAML: %w", err)
+	updated, err := releaseChangelog(fsys, out)
+	if err != nil {
+		return err
 	}
-	f.Component = strings.TrimSpace(f.Component)
-	f.Type = strings.TrimSpace(strings.ToUpper(f.Type))
-	f.Summary = strings.TrimSpace(f.Summary)
-	for i := range f.Refs {
-		f.Refs[i] = strings.TrimSpace(f.Refs[i])
+	tx := &fsTransaction{fsys: fsys}
+	if err := applyRelease(tx, out, updated, changelogEdits(orig, updated)); err != nil {
+		return tx.rollback(err)
 	}
+	return nil
+}
 
-	if f.Component == "" {
-		return fragment{}, fmt.Errorf("missing required field: component")
+// applyRelease stages the archive copies first, then writes the release output with the
+// changelog last, and only then removes the archived fragments. Every write goes to a synced
+// temporary file renamed into place, so an interrupted merge leaves the changelog either
+// untouched or complete, with the fragments still pending or already archived.
+func applyRelease(tx *fsTransaction, out releaseOutput, changelog, undo []byte) error {
+	archivePath := path.Join(out.ArchiveDir, out.Version)
+	if err := tx.mkdirAll(archivePath, 0755); err != nil {
+		return err
+	}
+	archived := archivedPaths(out.Items)
+	for _, p := range archived {
+		if err := tx.copyFile(p, path.Join(archivePath, path.Base(p))); err != nil {
+			return fmt.Errorf("archive %s: %w", p, err)
+		}
 	}
-	if f.Type == "" {
-		return fragment{}, fmt.Errorf("missing required field: type")
+	if out.ReleaseNotesOut != "" {
+		if err := tx.writeFile(out.ReleaseNotesOut, out.ReleaseNotes, 0644); err != nil {
+			return err
+		}
+	}
+	if sources := releasedSourceLines(out.Items); len(sources) > 0 {
+		if err := tx.writeFile(path.Join(archivePath, sourcesLedger), sources, 0644); err != nil {
+			return err
+		}
 	}
-	if f.Summary == "" {
-		return fragment{}, fmt.Errorf("missing required field: summary")
+	if err := tx.writeFile(path.Join(archivePath, changelogUndo), undo, 0644); err != nil {
+		return err
 	}
-	if manifest.Changelog.StrictComponents {
-		order := componentOrderFromManifest(manifest)
-		if !contains(order, f.Component) {
-			return fragment{}, fmt.Errorf("unknown component %q (expected one of %s)", f.Component, strings.Join(order, ", "))
+	if out.AttestationOut != "" {
+		if err := tx.writeFile(out.AttestationOut, out.Attestation, 0644); err != nil {
+			return err
 		}
 	}
-	f.Type = canonicalizeFragmentType(f.Type, manifest)
-	order := typeOrderFromManifest(manifest)
-	// If a type order is configured, treat it as an allowlist.
-	// If no type order is configured, accept any type.
-	if len(manifest.Types.Order) > 0 && !contains(order, f.Type) {
-		return fragment{}, fmt.Errorf("unknown type %q (expected one of %s)", f.Type, strings.Join(order, ", "))
+	for _, p := range slices.Sorted(maps.Keys(out.VersionFiles)) {
+		if err := tx.writeFile(p, out.VersionFiles[p], 0644); err != nil {
+			return fmt.Errorf("set the version in %s: %w", p, err)
+		}
 	}
-	return f, nil
+	if err := tx.writeFile(out.ChangelogPath, changelog, 0644); err != nil {
+		return err
+	}
+	for _, p := range archived {
+		if err := tx.remove(p); err != nil {
+			return fmt.Errorf("archive %s: %w", p, err)
+		}
+	}
+	return nil
 }
 
-func renderReleaseSection(version, date string, items []item, manifest releaseManifest) (section []byte, releaseNotes []byte) {
-	// Deterministic ordering: component order, then type order, then filename.
-	type row struct {
-		path string
-		frag fragment
+// changelogCompare returns the link func for keepachangelog link references, comparing the
+// release tags (tagPrefix plus version), or nil when changelog.compare_url is not set.
+func changelogCompare(manifest releaseManifest, tagPrefix string) func(from, to string) string {
+	if strings.TrimSpace(manifest.Changelog.CompareURL) == "" {
+		return nil
 	}
-	rows := make([]row, 0, len(items))
-	for _, it := range items {
-		rows = append(rows, row{path: it.Path, frag: it.Frag})
+	tag := func(v string) string {
+		if sv, err := parseSemver(v); err == nil {
+			return tagName(tagPrefix, sv)
+		}
+		return v
 	}
+	return func(from, to string) string {
+		return manifest.CompareURL(tag(from), tag(to))
+	}
+}
 
-	sort.Slice(rows, func(i, j int) bool {
-		compOrder := componentOrderFromManifest(manifest)
-		if c := compareByOrderOrLex(rows[i].frag.Component, rows[j].frag.Component, compOrder); c != 0 {
-			return c < 0
-		}
-		typeOrder := typeOrderFromManifest(manifest)
-		if c := compareByOrderOrLex(rows[i].frag.Type, rows[j].frag.Type, typeOrder); c != 0 {
-			return c < 0
+// loadPendingItems discovers and validates every pending fragment, local and from manifest
+// sources, skipping source files already released under archiveDir. It fails when there are
+// none. A file may hold several fragments, so items can
+// outnumber files; they share the file's Path. cleanup must be called even on error.
+func loadPendingItems(ctx context.Context, fragmentsDir, archiveDir string, manifest releaseManifest) ([]fragmentFile, []item, func(), error) {
+	files, cleanup, err := discoverFragments(ctx, hostFS{}, fragmentsDir, manifest, true)
+	if err != nil {
+		return nil, nil, cleanup, err
+	}
+	if files, err = skipReleasedSources(hostFS{}, archiveDir, files); err != nil {
+		return nil, nil, cleanup, err
+	}
+	if len(files) == 0 {
+		return nil, nil, cleanup, fmt.Errorf("no fragments found under %q", fragmentsDir)
+	}
+	items := make([]item, 0, len(files))
+	for _, ff := range files {
+		its, err := readItems(ff, manifest)
+		if err != nil {
+			return nil, nil, cleanup, err
 		}
-		return filepath.Base(rows[i].path) < filepath.Base(rows[j].path)
-	})
+		items = append(items, its...)
+	}
+	return files, items, cleanup, nil
+}
 
-	byComponent := map[string][]row{}
-	for _, r := range rows {
-		byComponent[r.frag.Component] = append(byComponent[r.frag.Component], r)
+// readItems reads every fragment in ff, in file order.
+func readItems(ff fragmentFile, manifest releaseManifest) ([]item, error) {
+	fragments, err := papertrail.ReadFragments(ff.FS, ff.Path, manifest)
+	if err != nil {
+		return nil, fmt.Errorf("invalid fragment %s: %w", ff.Name, err)
+	}
+	var source string
+	if ff.External {
+		b, err := ff.read()
+		if err != nil {
+			return nil, err
+		}
+		source = sourceLine(b, ff.Name)
+	}
+	items := make([]item, len(fragments))
+	for i, f := range fragments {
+		items[i] = item{Path: ff.Path, Frag: f, External: ff.External, Source: source}
 	}
+	return items, nil
+}
 
-	var buf bytes.Buffer
-	var notes bytes.Buffer
+// pendingBump is the highest bump the items' types call for under versioning.rules.
+func pendingBump(items []item, manifest releaseManifest) bumpKind {
+	return papertrail.ComputeBump(itemFragments(items), manifest)
+}
 
-	fmt.Fprintf(&buf, "## %s (%s)\n\n", version, date)
-	fmt.Fprintf(&notes, "## %s\n\n", version)
+// nextBump is the pendingBump for a release after base, degraded for 0.x versions under
+// versioning.zero_major_policy.
+func nextBump(items []item, manifest releaseManifest, base semver) bumpKind {
+	return manifest.ZeroMajorBump(pendingBump(items, manifest), base.Major)
+}
 
-	for _, comp := range orderedComponents(items, manifest) {
-		rs := byComponent[comp]
-		if len(rs) == 0 {
-			continue
+// resolveReleaseDate returns date if given (validated), else the date of fromRef if given,
+// else today; both computed dates are in the release timezone.
+func resolveReleaseDate(ctx context.Context, date, fromRef string, manifest releaseManifest) (string, error) {
+	switch {
+	case date != "":
+		if !looksLikeDate(date) {
+			return "", fmt.Errorf("invalid --date %q (expected YYYY-MM-DD)", date)
+		}
+		return date, nil
+	case fromRef != "":
+		t, err := refTime(ctx, fromRef)
+		if err != nil {
+			return "", err
 		}
-		fmt.Fprintf(&buf, "### %s\n\n", comp)
-		fmt.Fprintf(&notes, "### %s\n\n", comp)
-		for _, r := range rs {
-			fmt.Fprintf(&buf, "- **%s**: %s\n", displayType(r.frag.Type), ensurePeriod(r.frag.Summary))
-			fmt.Fprintf(&notes, "- **%s**: %s\n", displayType(r.frag.Type), ensurePeriod(r.frag.Summary))
+		if t, err = inReleaseTimezone(t, manifest); err != nil {
+			return "", err
 		}
-		fmt.Fprintf(&buf, "\n")
-		fmt.Fprintf(&notes, "\n")
+		return t.Format("2006-01-02"), nil
 	}
+	now, err := releaseTime(manifest)
+	if err != nil {
+		return "", err
+	}
+	return now.Format("2006-01-02"), nil
+}
 
-	return buf.Bytes(), notes.Bytes()
+// isFragmentPath reports whether a repo-relative path (as printed by git) is a fragment
+// file under fragmentsDir in one of the manifest's formats.
+func isFragmentPath(path, fragmentsDir string, manifest releaseManifest) bool {
+	return strings.HasPrefix(path, fragmentsDir+"/") && manifest.IsFragmentFile(path)
 }
 
-func renderPreview(items []item, manifest releaseManifest) []byte {
-	// Deterministic ordering: component order, then type order, then filename.
-	type row struct {
-		path string
-		frag fragment
+// renderReleaseSection renders the CHANGELOG.md section and the release notes (the section
+// without its date, followed by the fragment authors under "Contributors") for a release,
+// through changelog.templates when they are set.
+func renderReleaseSection(version, date string, items []item, manifest releaseManifest) (section []byte, releaseNotes []byte, err error) {
+	templates := manifest.Changelog.Templates
+	m, err := withTemplate(newReleaseModel(version, date, items, manifest), templates.Release)
+	if err != nil {
+		return nil, nil, err
 	}
-	rows := make([]row, 0, len(items))
-	for _, it := range items {
-		rows = append(rows, row{path: it.Path, frag: it.Frag})
+	if section, err = (papertrail.MarkdownRenderer{}).Render(m); err != nil {
+		return nil, nil, err
 	}
+	m.Date = ""
+	if m, err = withTemplate(m, templates.ReleaseNotes); err != nil {
+		return nil, nil, err
+	}
+	if releaseNotes, err = (papertrail.MarkdownRenderer{}).Render(m); err != nil {
+		return nil, nil, err
+	}
+	buf := bytes.NewBuffer(releaseNotes)
+	writeContributors(buf, papertrail.Contributors(itemFragments(items)))
+	return section, buf.Bytes(), nil
+}
 
-	sort.Slice(rows, func(i, j int) bool {
-		compOrder := componentOrderFromManifest(manifest)
-		if c := compareByOrderOrLex(rows[i].frag.Component, rows[j].frag.Component, compOrder); c != 0 {
-			return c < 0
-		}
-		typeOrder := typeOrderFromManifest(manifest)
-		if c := compareByOrderOrLex(rows[i].frag.Type, rows[j].frag.Type, typeOrder); c != 0 {
-			return c < 0
-		}
-		return filepath.Base(rows[i].path) < filepath.Base(rows[j].path)
-	})
-
-	byComponent := map[string][]row{}
-	for _, r := range rows {
-		byComponent[r.frag.Component] = append(byComponent[r.frag.Component], r)
+func renderPreview(items []item, manifest releaseManifest) ([]byte, error) {
+	m, err := withTemplate(newReleaseModel("", "", items, manifest), manifest.Changelog.Templates.Preview)
+	if err != nil {
+		return nil, err
 	}
+	return papertrail.MarkdownRenderer{}.Render(m)
+}
 
-	var buf bytes.Buffer
-	buf.WriteString(previewMarker + "\n")
-	buf.WriteString("### Changelog preview\n\n")
+// componentGroup is a component heading with its items in deterministic order.
+type componentGroup struct {
+	Name  string
+	Items []item
+}
 
-	for _, comp := range orderedComponents(items, manifest) {
-		rs := byComponent[comp]
-		if len(rs) == 0 {
+// groupItems groups items by component in configured component order; empty groups are
+// omitted.
+func groupItems(items []item, manifest releaseManifest) []componentGroup {
+	var groups []componentGroup
+	for _, it := range sortedItems(items, manifest) {
+		if n := len(groups); n > 0 && groups[n-1].Name == it.Frag.Component {
+			groups[n-1].Items = append(groups[n-1].Items, it)
 			continue
 		}
-		fmt.Fprintf(&buf, "#### %s\n\n", comp)
-		for _, r := range rs {
-			fmt.Fprintf(&buf, "- **%s**: %s\n", displayType(r.frag.Type), ensurePeriod(r.frag.Summary))
-		}
-		buf.WriteString("\n")
+		groups = append(groups, componentGroup{Name: it.Frag.Component, Items: []item{it}})
 	}
+	return groups
+}
 
-	return buf.Bytes()
+// sortedItems returns a copy of items in the library's deterministic output order, so the
+// input order never affects output.
+func sortedItems(items []item, manifest releaseManifest) []item {
+	rows := slices.Clone(items)
+	slices.SortStableFunc(rows, func(a, b item) int {
+		return manifest.CompareFragments(a.fragment(), b.fragment())
+	})
+	return rows
 }
 
-func displayType(t string) string {
-	return strings.ToLower(strings.TrimSpace(t))
+// fragment returns the item's fragment with its Path set, as the library orders by it.
+func (it item) fragment() fragment {
+	f := it.Frag
+	f.Path = it.Path
+	return f
 }
 
-func insertReleaseSection(changelog []byte, section []byte) ([]byte, error) {
-	s := string(changelog)
-	idx := findReleaseInsertionIndex(s)
-	if idx < 0 || idx > len(s) {
-		return nil, fmt.Errorf("could not find insertion point for release section in CHANGELOG")
+func itemFragments(items []item) []fragment {
+	out := make([]fragment, len(items))
+	for i, it := range items {
+		out[i] = it.fragment()
 	}
-
-	head := s[:idx]
-	tail := s[idx:]
-
-	var out bytes.Buffer
-	out.WriteString(head)
-	if len(head) > 0 && !strings.HasSuffix(head, "\n\n") {
-		if strings.HasSuffix(head, "\n") {
-			out.WriteString("\n")
-		} else {
-			out.WriteString("\n\n")
-		}
-	}
-	out.Write(section)
-	out.WriteString(tail)
-	return out.Bytes(), nil
+	return out
 }
 
-func findReleaseInsertionIndex(changelog string) int {
-	candidates := []int{
-		strings.Index(changelog, "\n## 20"),
-		strings.Index(changelog, "\n## v"),
-	}
-	best := -1
-	for _, c := range candidates {
-		if c < 0 {
-			continue
-		}
-		if best < 0 || c < best {
-			best = c
-		}
-	}
-	if best < 0 {
-		return len(changelog)
-	}
-	return best + 1
+func displayType(t string) string {
+	return strings.ToLower(strings.TrimSpace(t))
 }
 
 func ensurePeriod(s string) string {
@@ -627,33 +1311,6 @@ func contains(xs []string, x string) bool {
 	return false
 }
 
-func compareByOrderOrLex(a, b string, order []string) int {
-	if a == b {
-		return 0
-	}
-	if len(order) > 0 {
-		ai := indexIn(order, a)
-		bi := indexIn(order, b)
-		if ai != bi {
-			return ai - bi
-		}
-		// Both unknown: stable lexicographic tiebreaker.
-	}
-	if a < b {
-		return -1
-	}
-	return 1
-}
-
-func indexIn(order []string, v string) int {
-	for i, o := range order {
-		if o == v {
-			return i
-		}
-	}
-	return len(order) + 1
-}
-
 func looksLikeDate(s string) bool {
 	if len(s) != len("2006-01-02") {
 		return false
@@ -665,182 +1322,44 @@ func looksLikeDate(s string) bool {
 	return err == nil
 }
 
-type bumpKind int
-
-const (
-	bumpPatch bumpKind = iota
-	bumpMinor
-	bumpMajor
-)
-
-func bumpSemver(base string, bump bumpKind) (string, error) {
-	v := strings.TrimPrefix(base, "v")
-	parts := strings.Split(v, ".")
-	if len(parts) != 3 {
-		return "", fmt.Errorf("invalid semver %q", base)
-	}
-	ma, err := atoiStrict(parts[0])
-	if err != nil {
-		return "", fmt.Errorf("invalid semver %q", base)
-	}
-	mi, err := atoiStrict(parts[1])
-	if err != nil {
-		return "", fmt.Errorf("invalid semver %q", base)
+// buildTime is the time used for default dates. SOURCE_DATE_EPOCH (Unix seconds, see
+// reproducible-builds.org) overrides the clock so release output depends only on inputs.
+func buildTime() (time.Time, error) {
+	raw := strings.TrimSpace(os.Getenv("SOURCE_DATE_EPOCH"))
+	if raw == "" {
+		return time.Now().UTC(), nil
 	}
-	pa, err := atoiStrict(parts[2])
+	sec, err := strconv.ParseInt(raw, 10, 64)
 	if err != nil {
-		return "", fmt.Errorf("invalid semver %q", base)
-	}
-
-	switch bump {
-	case bumpMajor:
-		ma++
-		mi = 0
-		pa = 0
-	case bumpMinor:
-		mi++
-		pa = 0
-	case bumpPatch:
-		pa++
-	default:
-		return "", fmt.Errorf("unknown bump kind")
-	}
-	return fmt.Sprintf("v%d.%d.%d", ma, mi, pa), nil
-}
-
-func atoiStrict(s string) (int, error) {
-	if s == "" {
-		return 0, fmt.Errorf("empty")
-	}
-	n := 0
-	for _, r := range s {
-		if r < '0' || r > '9' {
-			return 0, fmt.Errorf("not a number")
-		}
-		n = n*10 + int(r-'0')
+		return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH %q (expected Unix seconds)", raw)
 	}
-	return n, nil
+	return time.Unix(sec, 0).UTC(), nil
 }
 
-func isSemverV(s string) bool {
-	if !strings.HasPrefix(s, "v") {
-		return false
-	}
-	v := strings.TrimPrefix(s, "v")
-	parts := strings.Split(v, ".")
-	if len(parts) != 3 {
-		return false
-	}
-	for _, p := range parts {
-		if _, err := atoiStrict(p); err != nil {
-			return false
-		}
-	}
-	return true
-}
-
-func semverMajor(s string) int {
-	if !strings.HasPrefix(s, "v") {
-		return -1
-	}
-	v := strings.TrimPrefix(s, "v")
-	parts := strings.Split(v, ".")
-	if len(parts) != 3 {
-		return -1
-	}
-	ma, err := atoiStrict(parts[0])
+// releaseTime is buildTime in the project's release timezone (changelog.timezone, default
+// UTC), so evening releases are not dated the next day.
+func releaseTime(manifest releaseManifest) (time.Time, error) {
+	now, err := buildTime()
 	if err != nil {
-		return -1
-	}
-	return ma
-}
-
-func validateBumpRules(rules map[string]string, path string) error {
-	for k, v := range rules {
-		vn := strings.ToLower(strings.TrimSpace(v))
-		switch vn {
-		case "major", "minor", "patch":
-			// ok
-		default:
-			return fmt.Errorf("invalid %s[%q]=%q (expected major|minor|patch)", path, k, v)
-		}
+		return time.Time{}, err
 	}
-	return nil
+	return inReleaseTimezone(now, manifest)
 }
 
-func bumpFromRules(rules map[string]string, fragmentType string) (bumpKind, bool) {
-	if len(rules) == 0 {
-		return bumpPatch, false
-	}
-	ft := strings.ToUpper(strings.TrimSpace(fragmentType))
-	v, ok := rules[ft]
-	if !ok {
-		v, ok = rules["*"]
-		if !ok {
-			return bumpPatch, false
-		}
+func inReleaseTimezone(t time.Time, manifest releaseManifest) (time.Time, error) {
+	tz := strings.TrimSpace(manifest.Changelog.Timezone)
+	if tz == "" {
+		return t.UTC(), nil
 	}
-	switch strings.ToLower(strings.TrimSpace(v)) {
-	case "major":
-		return bumpMajor, true
-	case "minor":
-		return bumpMinor, true
-	case "patch":
-		return bumpPatch, true
-	default:
-		return bumpPatch, false
-	}
-}
-
-func loadManifestDefault(path string) (releaseManifest, error) {
-	mp := strings.TrimSpace(path)
-	if mp == "" {
-		for _, cand := range []string{".papertrail.config.yml", "papertrail.config.yml"} {
-			if _, err := os.Stat(cand); err == nil {
-				mp = cand
-				break
-			}
-		}
-	}
-	if mp == "" {
-		return releaseManifest{}, nil
-	}
-	b, err := os.ReadFile(mp)
+	loc, err := time.LoadLocation(tz)
 	if err != nil {
-		return releaseManifest{}, err
+		return time.Time{}, fmt.Errorf("invalid changelog.timezone %q: %w", tz, err)
 	}
-	var manifest releaseManifest
-	if err := yaml.Unmarshal(b, &manifest); err != nil {
-		return releaseManifest{}, fmt.Errorf("invalid manifest YAML: %w", err)
-	}
-	if err := validateBumpRules(manifest.Versioning.Rules, "versioning.rules"); err != nil {
-		return releaseManifest{}, err
-	}
-	manifest.Types.Aliases = normalizeTypeAliases(manifest.Types.Aliases)
-	manifest.Types.Order = normalizeTypeOrder(manifest.Types.Order, manifest.Types.Aliases)
-	manifest.Versioning.Rules = normalizeBumpRuleKeys(manifest.Versioning.Rules, manifest.Types.Aliases)
-	return manifest, nil
+	return t.In(loc), nil
 }
 
-func normalizeBumpRuleKeys(rules map[string]string, typeAliases map[string]string) map[string]string {
-	if len(rules) == 0 {
-		return rules
-	}
-	out := make(map[string]string, len(rules))
-	for k, v := range rules {
-		kk := strings.TrimSpace(k)
-		if kk == "" {
-			continue
-		}
-		if kk != "*" {
-			kk = strings.ToUpper(kk)
-			if canon, ok := typeAliases[kk]; ok {
-				kk = canon
-			}
-		}
-		out[kk] = v
-	}
-	return out
+func loadManifestDefault(path string) (releaseManifest, error) {
+	return papertrail.LoadManifest(hostFS{}, path)
 }
 
 type prPolicy struct {
@@ -857,8 +1376,8 @@ func prPolicyFromManifest(m releaseManifest) prPolicy {
 	return p
 }
 
-func gitChangedFiles(baseRef string) ([]string, error) {
-	out, err := runGit("diff", "--name-only", baseRef+"...HEAD")
+func gitChangedFiles(ctx context.Context, baseRef string) ([]string, error) {
+	out, err := runGit(ctx, "diff", "--name-only", baseRef+"...HEAD")
 	if err != nil {
 		return nil, err
 	}
@@ -873,46 +1392,24 @@ func gitChangedFiles(baseRef string) ([]string, error) {
 	return files, nil
 }
 
-func readPRLabels(eventPath string) (labels []string, err error) {
-	b, err := os.ReadFile(eventPath)
-	if err != nil {
-		return nil, err
-	}
-	var ev struct {
-		PullRequest struct {
-			Labels []struct {
-				Name string `json:"name"`
-			} `json:"labels"`
-		} `json:"pull_request"`
-	}
-	if err := json.Unmarshal(b, &ev); err != nil {
-		return nil, fmt.Errorf("invalid GitHub event JSON: %w", err)
-	}
-	for _, l := range ev.PullRequest.Labels {
-		n := strings.TrimSpace(l.Name)
-		if n != "" {
-			labels = append(labels, n)
-		}
-	}
-	sort.Strings(labels)
-	out := labels[:0]
-	var last string
-	for _, n := range labels {
-		if n == last {
-			continue
-		}
-		out = append(out, n)
-		last = n
-	}
-	return out, nil
+func runGit(ctx context.Context, args ...string) (string, error) {
+	return runCmd(ctx, "git", args...)
 }
 
-func runGit(args ...string) (string, error) {
-	return runCmd("git", args...)
+func runCmd(ctx context.Context, bin string, args ...string) (string, error) {
+	out, err := runCmdRaw(ctx, bin, args...)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
 }
 
-func runCmd(bin string, args ...string) (string, error) {
-	cmd := exec.Command(bin, args...)
+// runCmdRaw runs bin and returns its untrimmed stdout.
+func runCmdRaw(ctx context.Context, bin string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, bin, args...)
+	if bin == "git" && isOffline(ctx) {
+		cmd.Env = append(os.Environ(), "GIT_ALLOW_PROTOCOL=file")
+	}
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
@@ -921,119 +1418,9 @@ func runCmd(bin string, args ...string) (string, error) {
 		if msg == "" {
 			msg = err.Error()
 		}
-		return "", fmt.Errorf("%s %s: %s", bin, strings.Join(args, " "), msg)
-	}
-	return strings.TrimSpace(stdout.String()), nil
-}
-
-func componentOrderFromManifest(manifest releaseManifest) []string {
-	components := manifest.Changelog.Components
-	if len(components) == 0 {
-		components = manifest.Changelog.ComponentsOrder
-	}
-	if len(components) > 0 {
-		seen := map[string]bool{}
-		var out []string
-		for _, c := range components {
-			c = strings.TrimSpace(c)
-			if c == "" || seen[c] {
-				continue
-			}
-			seen[c] = true
-			out = append(out, c)
-		}
-		if len(out) > 0 {
-			return out
-		}
+		return nil, fmt.Errorf("%s %s: %s", bin, strings.Join(args, " "), msg)
 	}
-	return defaultComponentOrder
-}
-
-func normalizeTypeAliases(in map[string]string) map[string]string {
-	if len(in) == 0 {
-		return nil
-	}
-	out := make(map[string]string, len(in))
-	for k, v := range in {
-		kk := strings.ToUpper(strings.TrimSpace(k))
-		vv := strings.ToUpper(strings.TrimSpace(v))
-		if kk == "" || vv == "" {
-			continue
-		}
-		out[kk] = vv
-	}
-	return out
-}
-
-func normalizeTypeOrder(order []string, aliases map[string]string) []string {
-	if len(order) == 0 {
-		return nil
-	}
-	seen := map[string]bool{}
-	var out []string
-	for _, t := range order {
-		tt := strings.ToUpper(strings.TrimSpace(t))
-		if tt == "" {
-			continue
-		}
-		if canon, ok := aliases[tt]; ok {
-			tt = canon
-		}
-		if seen[tt] {
-			continue
-		}
-		seen[tt] = true
-		out = append(out, tt)
-	}
-	return out
-}
-
-func typeOrderFromManifest(manifest releaseManifest) []string {
-	if len(manifest.Types.Order) > 0 {
-		return manifest.Types.Order
-	}
-	return defaultTypeOrder
-}
-
-func canonicalizeFragmentType(t string, manifest releaseManifest) string {
-	tt := strings.ToUpper(strings.TrimSpace(t))
-	if tt == "" {
-		return tt
-	}
-	if canon, ok := manifest.Types.Aliases[tt]; ok {
-		return canon
-	}
-	return tt
-}
-
-func orderedComponents(items []item, manifest releaseManifest) []string {
-	known := componentOrderFromManifest(manifest)
-	seenKnown := map[string]bool{}
-	for _, c := range known {
-		seenKnown[c] = true
-	}
-
-	present := map[string]bool{}
-	for _, it := range items {
-		present[it.Frag.Component] = true
-	}
-
-	var out []string
-	for _, c := range known {
-		if present[c] {
-			out = append(out, c)
-		}
-	}
-
-	var unknown []string
-	for c := range present {
-		if !seenKnown[c] {
-			unknown = append(unknown, c)
-		}
-	}
-	sort.Strings(unknown)
-	out = append(out, unknown...)
-	return out
+	return stdout.Bytes(), nil
 }
 
 type ioDiscard struct{}
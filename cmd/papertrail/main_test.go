@@ -1,8 +1,13 @@
 package main
 
 import (
+	"context"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
 )
 
 func TestBumpSemver(t *testing.T) {
@@ -40,7 +45,7 @@ func TestCanonicalizeType_Alias(t *testing.T) {
 	m.Types.Aliases = map[string]string{
 		"CI": "PATCH",
 	}
-	got := canonicalizeFragmentType("ci", m)
+	got := m.CanonicalType("ci")
 	if got != "PATCH" {
 		t.Fatalf("got %q, want %q", got, "PATCH")
 	}
@@ -59,7 +64,7 @@ func TestRenderReleaseSection_DeterministicOrdering(t *testing.T) {
 		{Path: "changelog.d/20250101_a_break.yml", Frag: fragment{Component: "A", Type: "BREAKING CHANGE", Summary: "z"}},
 	}
 
-	section, notes := renderReleaseSection("v0.1.0", "2025-12-23", items, m)
+	section, notes, _ := renderReleaseSection("v0.1.0", "2025-12-23", items, m)
 	s := string(section)
 	n := string(notes)
 
@@ -86,3 +91,154 @@ func TestRenderReleaseSection_DeterministicOrdering(t *testing.T) {
 }
 
 
+
+func TestRootContext_Timeout(t *testing.T) {
+	t.Setenv("PAPERTRAIL_TIMEOUT", "1ms")
+	ctx, cancel, err := rootContext()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer cancel()
+	<-ctx.Done()
+	if ctx.Err() != context.DeadlineExceeded {
+		t.Fatalf("got %v, want deadline exceeded", ctx.Err())
+	}
+
+	t.Setenv("PAPERTRAIL_TIMEOUT", "soon")
+	if _, _, err := rootContext(); err == nil || !strings.Contains(err.Error(), "PAPERTRAIL_TIMEOUT") {
+		t.Fatalf("expected invalid timeout error, got %v", err)
+	}
+}
+
+func TestRenderReleaseSection_InputOrderIndependent(t *testing.T) {
+	t.Parallel()
+
+	// Same file name in different directories (e.g. a manifest source) must not depend on
+	// discovery order.
+	items := []item{
+		{Path: "changelog.d/x.yml", Frag: fragment{Component: "A", Type: "FIX", Summary: "local"}},
+		{Path: "services/api/changelog.d/x.yml", Frag: fragment{Component: "A", Type: "FIX", Summary: "api"}},
+	}
+	reversed := []item{items[1], items[0]}
+	a, _, _ := renderReleaseSection("v1.0.0", "2026-01-01", items, releaseManifest{})
+	b, _, _ := renderReleaseSection("v1.0.0", "2026-01-01", reversed, releaseManifest{})
+	if string(a) != string(b) {
+		t.Fatalf("output depends on input order:\n%s\n---\n%s", a, b)
+	}
+}
+
+func TestCmdMerge_MultiFragmentFile(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{
+		"CHANGELOG.md": "# Changelog\n",
+		"changelog.d/20260101_big_pr.yml": "- component: CLI\n  type: fix\n  summary: Zeta fix\n" +
+			"- component: CLI\n  type: fix\n  summary: Alpha fix\n" +
+			"---\ncomponent: API\ntype: fix\nsummary: API fix\n",
+	})
+
+	if err := cmdMerge(t.Context(), []string{"--version", "v0.1.0", "--date", "2026-01-02"}); err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	changelog, _ := os.ReadFile(filepath.Join(dir, "CHANGELOG.md"))
+	// Entries of one file keep their order in it.
+	want := "### API\n\n- **fix**: API fix.\n\n### CLI\n\n- **fix**: Zeta fix.\n- **fix**: Alpha fix.\n"
+	if !strings.Contains(string(changelog), want) {
+		t.Fatalf("changelog:\n%s", changelog)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "changelog.d", "archived", "v0.1.0", "20260101_big_pr.yml")); err != nil {
+		t.Fatalf("fragment file not archived: %v", err)
+	}
+}
+
+func TestCmdMerge_MarkdownFragment(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{
+		".papertrail.config.yml": "fragments:\n  formats: [yaml, markdown]\n",
+		"CHANGELOG.md":           "# Changelog\n",
+		"changelog.d/README.md":  "# Fragments\n\nNot a fragment.\n",
+		"changelog.d/20260101_templates.md": "---\ncomponent: CLI\ntype: feature\nsummary: Add templates\n---\n\n" +
+			"Templates live in `templates/`:\n\n- one\n- two\n",
+	})
+
+	if err := cmdCheck(t.Context(), nil); err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	if err := cmdMerge(t.Context(), []string{"--version", "v0.1.0", "--date", "2026-01-02", "--lint-output"}); err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	changelog, _ := os.ReadFile(filepath.Join(dir, "CHANGELOG.md"))
+	want := "- **feature**: Add templates.\n\n  Templates live in `templates/`:\n\n  - one\n  - two\n"
+	if !strings.Contains(string(changelog), want) {
+		t.Fatalf("changelog:\n%s", changelog)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "changelog.d", "README.md")); err != nil {
+		t.Fatalf("README.md was archived: %v", err)
+	}
+}
+
+func TestCmdMerge_JSONAndTOMLFragments(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{
+		"CHANGELOG.md":                "# Changelog\n",
+		"changelog.d/20260101_a.json": `[{"component": "CLI", "type": "fix", "summary": "From JSON"}]`,
+		"changelog.d/20260101_b.toml": "component = \"CLI\"\ntype = \"fix\"\nsummary = \"From TOML\"\n",
+		"changelog.d/20260101_c.yml":  "component: CLI\ntype: fix\nsummary: From YAML\n",
+	})
+
+	// Without fragments.formats, JSON and TOML files are not fragments.
+	if err := cmdMerge(t.Context(), []string{"--version", "v0.1.0", "--date", "2026-01-02"}); err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".papertrail.config.yml"), []byte("fragments:\n  formats: [yaml, json, toml]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmdCheck(t.Context(), nil); err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	if err := cmdMerge(t.Context(), []string{"--version", "v0.2.0", "--date", "2026-01-03"}); err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	changelog, _ := os.ReadFile(filepath.Join(dir, "CHANGELOG.md"))
+	want := "## v0.2.0 (2026-01-03)\n\n### CLI\n\n- **fix**: From JSON.\n- **fix**: From TOML.\n\n## v0.1.0 (2026-01-02)\n\n### CLI\n\n- **fix**: From YAML.\n"
+	if !strings.Contains(string(changelog), want) {
+		t.Fatalf("changelog:\n%s", changelog)
+	}
+}
+
+func TestBuildTime_SourceDateEpoch(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+	got, err := buildTime()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if got.Format("2006-01-02T15:04:05Z07:00") != "2023-11-14T22:13:20Z" {
+		t.Fatalf("got %v", got)
+	}
+
+	t.Setenv("SOURCE_DATE_EPOCH", "yesterday")
+	if _, err := buildTime(); err == nil || !strings.Contains(err.Error(), "SOURCE_DATE_EPOCH") {
+		t.Fatalf("expected invalid SOURCE_DATE_EPOCH error, got %v", err)
+	}
+}
+
+func TestReleaseTime_Timezone(t *testing.T) {
+	// 2026-03-04 02:30 UTC is still March 3rd in New York.
+	t.Setenv("SOURCE_DATE_EPOCH", "1772591400")
+	m, err := papertrail.ParseManifest([]byte("changelog:\n  timezone: America/New_York\n"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for _, tc := range []struct {
+		m    releaseManifest
+		want string
+	}{{releaseManifest{}, "2026-03-04"}, {m, "2026-03-03"}} {
+		got, err := releaseTime(tc.m)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if d := got.Format("2006-01-02"); d != tc.want {
+			t.Fatalf("timezone %q: got %s, want %s", tc.m.Changelog.Timezone, d, tc.want)
+		}
+	}
+
+	if _, err := papertrail.ParseManifest([]byte("changelog:\n  timezone: Mars/Olympus_Mons\n")); err == nil || !strings.Contains(err.Error(), "changelog.timezone") {
+		t.Fatalf("expected invalid timezone error, got %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// markdownIssue is one markdownlint rule the generated markdown breaks.
+type markdownIssue struct {
+	Line int
+	Rule string
+	Msg  string
+}
+
+func (i markdownIssue) String() string {
+	return fmt.Sprintf("line %d: %s %s", i.Line, i.Rule, i.Msg)
+}
+
+var (
+	mdHeadingRE  = regexp.MustCompile(`^ {0,3}#{1,6}(\s|$)`)
+	mdListItemRE = regexp.MustCompile(`^ {0,3}([-*+]|\d{1,9}[.)])(\s|$)`)
+)
+
+// lintMarkdown checks b against the markdownlint rules generated output must satisfy:
+// MD012 (no consecutive blank lines), MD022 (blank lines around headings), MD032 (blank
+// lines around lists), and MD047 (a single trailing newline). A standalone document must end
+// with exactly one newline; otherwise b is a section inserted before other content and must
+// end with a blank line instead.
+func lintMarkdown(b []byte, standalone bool) []markdownIssue {
+	s := string(b)
+	var issues []markdownIssue
+	add := func(line int, rule, msg string) {
+		issues = append(issues, markdownIssue{Line: line, Rule: rule, Msg: msg})
+	}
+
+	lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	blank := func(i int) bool { return i < 0 || i >= len(lines) || strings.TrimSpace(lines[i]) == "" }
+	inList := false
+	for i, line := range lines {
+		n := i + 1
+		if blank(i) {
+			if i > 0 && blank(i-1) {
+				add(n, "MD012", "multiple consecutive blank lines")
+			}
+			inList = false
+			continue
+		}
+		item := mdListItemRE.MatchString(line)
+		continuation := inList && !item && (line[0] == ' ' || line[0] == '\t')
+		if inList && !item && !continuation {
+			add(n-1, "MD032", "list should be followed by a blank line")
+		}
+		if item && !inList && !blank(i-1) {
+			add(n, "MD032", "list should be preceded by a blank line")
+		}
+		if mdHeadingRE.MatchString(line) {
+			if i > 0 && !blank(i-1) {
+				add(n, "MD022", "heading should be preceded by a blank line")
+			}
+			if i < len(lines)-1 && !blank(i+1) {
+				add(n, "MD022", "heading should be followed by a blank line")
+			}
+		}
+		inList = item || continuation
+	}
+
+	last := len(lines)
+	switch {
+	case standalone && (!strings.HasSuffix(s, "\n") || strings.HasSuffix(s, "\n\n")):
+		add(last, "MD047", "file should end with a single newline")
+	case !standalone && !strings.HasSuffix(s, "\n\n"):
+		add(last, "MD022", "section should end with a blank line before the content that follows it")
+	}
+	return issues
+}
+
+// lintReleaseOutput runs lintMarkdown over the changelog section and release notes a
+// release is about to write, and fails with every issue found.
+func lintReleaseOutput(section, releaseNotes []byte) error {
+	var problems []string
+	for _, issue := range lintMarkdown(section, false) {
+		problems = append(problems, "changelog section "+issue.String())
+	}
+	for _, issue := range lintMarkdown(releaseNotes, true) {
+		problems = append(problems, "release notes "+issue.String())
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("generated markdown fails lint; nothing was written:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// trimTrailingNewlines ends b with exactly one newline.
+func trimTrailingNewlines(b []byte) []byte {
+	b = []byte(strings.TrimRight(string(b), "\n"))
+	return append(b, '\n')
+}
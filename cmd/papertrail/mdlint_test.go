@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+func TestLintMarkdown(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name       string
+		in         string
+		standalone bool
+		want       []string
+	}{
+		{"clean", "## v1\n\n### A\n\n- x\n  wrapped\n- y\n\n### B\n\n- z\n", true, nil},
+		{"heading after list", "## v1\n\n### A\n\n- x\n### B\n\n- y\n", true, []string{"line 5: MD032", "line 6: MD022"}},
+		{"heading before list", "## v1\n### A\n- x\n", true, []string{"line 1: MD022", "line 2: MD022", "line 2: MD022", "line 3: MD032"}},
+		{"blank lines", "## v1\n\n\n- x\n", true, []string{"line 3: MD012"}},
+		{"trailing blank line", "## v1\n\n- x\n\n", true, []string{"MD047"}},
+		{"no trailing newline", "## v1\n\n- x", true, []string{"MD047"}},
+		{"section", "## v1\n\n- x\n\n", false, nil},
+		{"section without blank line", "## v1\n\n- x\n", false, []string{"MD022"}},
+	} {
+		var got []string
+		for _, issue := range lintMarkdown([]byte(tc.in), tc.standalone) {
+			got = append(got, issue.String())
+		}
+		if len(got) != len(tc.want) {
+			t.Fatalf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+		for i := range got {
+			if !strings.Contains(got[i], tc.want[i]) {
+				t.Fatalf("%s: got %q, want %q", tc.name, got, tc.want)
+			}
+		}
+	}
+}
+
+// TestRenderedOutputPassesMarkdownlint guards the compliance guarantee: every markdown the
+// release path generates in a markdownlint-compatible style lints clean.
+func TestRenderedOutputPassesMarkdownlint(t *testing.T) {
+	t.Parallel()
+
+	items := []item{
+		{Path: "a.yml", Frag: fragment{Component: "A", Type: "fix", Summary: "Fix a bug that made - list markers and # headings appear mid-sentence in long summaries"}},
+		{Path: "b.yml", Frag: fragment{Component: "A", Type: "feature", Summary: "Add b"}},
+		{Path: "c.yml", Frag: fragment{Component: "B", Type: "fix", Summary: "Fix c"}},
+	}
+	for _, style := range []papertrail.Style{
+		{},
+		{Bullet: "*", TypeLabel: "plain", HeadingCase: "title", Wrap: 40, Markdownlint: true},
+	} {
+		var m releaseManifest
+		m.Changelog.Style = style
+		section, notes, _ := renderReleaseSection("v1.0.0", "2026-01-01", items, m)
+		var deps bytes.Buffer
+		writeDependencyDiff(&deps, "Dependency changes", dependencyDiff{Added: []dependency{{Name: "example.com/x", Version: "v1.0.0"}}})
+		section = append(section, deps.Bytes()...)
+		notes = trimTrailingNewlines(append(notes, deps.Bytes()...))
+		if err := lintReleaseOutput(section, notes); err != nil {
+			t.Fatalf("style %+v: %v\nsection:\n%s", style, err, section)
+		}
+	}
+
+	var m releaseManifest
+	zero := 0
+	m.Changelog.Style.GroupSpacing = &zero
+	section, notes, _ := renderReleaseSection("v1.0.0", "2026-01-01", items, m)
+	if err := lintReleaseOutput(section, trimTrailingNewlines(notes)); err == nil || !strings.Contains(err.Error(), "MD022") {
+		t.Fatalf("expected group_spacing 0 to fail lint, got %v", err)
+	}
+	if _, err := papertrail.ParseManifest([]byte("changelog:\n  style:\n    markdownlint: true\n    group_spacing: 0\n")); err == nil || !strings.Contains(err.Error(), "markdownlint") {
+		t.Fatalf("expected markdownlint/group_spacing conflict, got %v", err)
+	}
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+// cmdMergeDriver is a git merge driver for CHANGELOG.md. Configure it with:
+//
+//	git config merge.papertrail.driver "papertrail merge-driver %O %A %B"
+//	echo "CHANGELOG.md merge=papertrail" >> .gitattributes
+//
+// git passes the ancestor, ours, and theirs; the result is written over ours, and a non-zero
+// exit leaves conflict markers for the sections that could not be resolved.
+func cmdMergeDriver(_ context.Context, args []string) error {
+	fs := flag.NewFlagSet("merge-driver", flag.ContinueOnError)
+	fs.SetOutput(ioDiscard{})
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 3 {
+		return fmt.Errorf("merge-driver requires <base> <ours> <theirs> (git's %%O %%A %%B)")
+	}
+	fsys := hostFS{}
+	var docs [3]string
+	for i, p := range fs.Args() {
+		b, err := readFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		docs[i] = string(b)
+	}
+	merged, conflicts := mergeChangelogs(docs[0], docs[1], docs[2])
+	if err := fsys.WriteFile(fs.Arg(1), []byte(merged), 0644); err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("conflicting changes to %s", strings.Join(conflicts, ", "))
+	}
+	return nil
+}
+
+// changelogSection is one release section: its "## ..." heading line through the line
+// before the next release heading.
+type changelogSection struct {
+	Key  string
+	Body string
+}
+
+// unreleasedKey is the key of the Keep a Changelog Unreleased section.
+const unreleasedKey = "Unreleased"
+
+// splitChangelog splits a changelog into the text before the first release heading and the
+// release sections in document order. Release headings are those merge inserts before, and
+// the Keep a Changelog Unreleased heading.
+func splitChangelog(doc string) (string, []changelogSection) {
+	var preamble strings.Builder
+	var sections []changelogSection
+	for _, line := range strings.SplitAfter(doc, "\n") {
+		if version, _, ok := papertrail.ParseReleaseHeading(line); ok {
+			sections = append(sections, changelogSection{Key: version})
+		} else if strings.EqualFold(strings.TrimSpace(line), papertrail.UnreleasedHeading) {
+			sections = append(sections, changelogSection{Key: unreleasedKey})
+		}
+		if len(sections) == 0 {
+			preamble.WriteString(line)
+			continue
+		}
+		sections[len(sections)-1].Body += line
+	}
+	return preamble.String(), sections
+}
+
+// mergeChangelogs three-way merges changelogs section by section. Sections are kept from
+// both sides, a side's edit or deletion wins over an unchanged section, and the result is
+// ordered newest version first. The Keep a Changelog Unreleased section keeps the entries
+// either side added, and the link references that end the document merge one label at a
+// time. It returns the keys of sections and links edited differently on both sides; those
+// are written with conflict markers.
+func mergeChangelogs(base, ours, theirs string) (string, []string) {
+	base, baseRefs := papertrail.SplitLinkReferences(base)
+	ours, ourRefs := papertrail.SplitLinkReferences(ours)
+	theirs, theirRefs := papertrail.SplitLinkReferences(theirs)
+	basePre, baseSecs := splitChangelog(base)
+	ourPre, ourSecs := splitChangelog(ours)
+	theirPre, theirSecs := splitChangelog(theirs)
+
+	var conflicts []string
+	preamble, ok := merge3(basePre, ourPre, theirPre)
+	if !ok {
+		conflicts = append(conflicts, "the changelog header")
+	}
+
+	baseBy, ourBy, theirBy := sectionMap(baseSecs), sectionMap(ourSecs), sectionMap(theirSecs)
+	var keys []string
+	seen := map[string]bool{}
+	for _, s := range append(append([]changelogSection{}, ourSecs...), theirSecs...) {
+		if !seen[s.Key] {
+			seen[s.Key] = true
+			keys = append(keys, s.Key)
+		}
+	}
+
+	var merged []changelogSection
+	for _, k := range keys {
+		b, inBase := baseBy[k]
+		o, inOurs := ourBy[k]
+		t, inTheirs := theirBy[k]
+		switch {
+		case inOurs && inTheirs && k == unreleasedKey:
+			merged = append(merged, changelogSection{Key: k, Body: unionUnreleased(b, o, t)})
+		case inOurs && inTheirs:
+			body, ok := merge3(b, o, t)
+			if !ok {
+				conflicts = append(conflicts, "section "+k)
+			}
+			merged = append(merged, changelogSection{Key: k, Body: body})
+		case inOurs && !(inBase && sameSection(b, o)):
+			merged = append(merged, changelogSection{Key: k, Body: o})
+		case inTheirs && !(inBase && sameSection(b, t)):
+			merged = append(merged, changelogSection{Key: k, Body: t})
+		}
+	}
+
+	sortSectionsNewestFirst(merged)
+	refs, refConflicts := mergeLinkReferences(baseRefs, ourRefs, theirRefs)
+	conflicts = append(conflicts, refConflicts...)
+	doc := joinChangelog(preamble, merged)
+	if refs != "" {
+		doc = strings.TrimRight(doc, "\n") + "\n\n" + refs
+	}
+	return doc, conflicts
+}
+
+// mergeLinkReferences merges link reference definitions as a set keyed by label, like
+// sections: a side's new, edited, or deleted definition wins over an unchanged one. It
+// returns the definitions, Unreleased first and then newest version first, and the labels
+// edited differently on both sides.
+func mergeLinkReferences(base, ours, theirs []papertrail.LinkReference) (string, []string) {
+	byLabel := func(refs []papertrail.LinkReference) map[string]string {
+		m := make(map[string]string, len(refs))
+		for _, r := range refs {
+			m[strings.ToLower(r.Label)] = r.String() + "\n"
+		}
+		return m
+	}
+	baseBy, ourBy, theirBy := byLabel(base), byLabel(ours), byLabel(theirs)
+	var merged []changelogSection
+	var conflicts []string
+	seen := map[string]bool{}
+	for _, r := range append(append([]papertrail.LinkReference{}, ours...), theirs...) {
+		k := strings.ToLower(r.Label)
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		b, inBase := baseBy[k]
+		o, inOurs := ourBy[k]
+		t, inTheirs := theirBy[k]
+		key := r.Label
+		if k == strings.ToLower(unreleasedKey) {
+			key = unreleasedKey
+		}
+		switch {
+		case inOurs && inTheirs:
+			line, ok := merge3(b, o, t)
+			if !ok {
+				conflicts = append(conflicts, "link ["+r.Label+"]")
+			}
+			merged = append(merged, changelogSection{Key: key, Body: strings.TrimRight(line, "\n") + "\n"})
+		case inOurs && !(inBase && b == o):
+			merged = append(merged, changelogSection{Key: key, Body: o})
+		case inTheirs && !(inBase && b == t):
+			merged = append(merged, changelogSection{Key: key, Body: t})
+		}
+	}
+	sortSectionsNewestFirst(merged)
+	var out strings.Builder
+	for _, r := range merged {
+		out.WriteString(r.Body)
+	}
+	return out.String(), conflicts
+}
+
+// unionUnreleased merges the Unreleased section as a union: it keeps every entry either
+// side added and drops the ones either side removed, under its category heading. Categories
+// follow Keep a Changelog order; an entry is a list item with its continuation lines.
+func unionUnreleased(base, ours, theirs string) string {
+	if body, ok := merge3(base, ours, theirs); ok {
+		return body
+	}
+	baseCats, ourCats, theirCats := unreleasedEntries(base), unreleasedEntries(ours), unreleasedEntries(theirs)
+	var order []string
+	for _, side := range []map[string][]string{ourCats, theirCats} {
+		for _, c := range slices.Sorted(maps.Keys(side)) {
+			if !slices.Contains(order, c) {
+				order = append(order, c)
+			}
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool { return categoryRank(order[i]) < categoryRank(order[j]) })
+
+	var out strings.Builder
+	out.WriteString(strings.SplitAfter(ours, "\n")[0])
+	for _, c := range order {
+		var entries []string
+		for _, e := range ourCats[c] {
+			if slices.Contains(theirCats[c], e) || !slices.Contains(baseCats[c], e) {
+				entries = append(entries, e)
+			}
+		}
+		for _, e := range theirCats[c] {
+			if !slices.Contains(entries, e) && !slices.Contains(baseCats[c], e) {
+				entries = append(entries, e)
+			}
+		}
+		if len(entries) == 0 {
+			continue
+		}
+		out.WriteString("\n")
+		if c != "" {
+			out.WriteString(c + "\n\n")
+		}
+		out.WriteString(strings.Join(entries, ""))
+	}
+	return out.String()
+}
+
+// unreleasedEntries returns the entries of an Unreleased section by category heading line
+// ("### Added"), with "" for entries before the first heading.
+func unreleasedEntries(section string) map[string][]string {
+	cats := map[string][]string{}
+	category, entry := "", -1
+	for i, line := range strings.SplitAfter(section, "\n") {
+		switch {
+		case i == 0 || strings.TrimSpace(line) == "":
+		case strings.HasPrefix(line, "### "):
+			category, entry = strings.TrimRight(line, "\n"), -1
+		case (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && entry >= 0:
+			cats[category][entry] += line
+		default:
+			cats[category] = append(cats[category], strings.TrimRight(line, "\n")+"\n")
+			entry = len(cats[category]) - 1
+		}
+	}
+	return cats
+}
+
+// categoryRank orders category heading lines as KeepAChangelogCategories does, after the
+// entries without one and before other headings.
+func categoryRank(heading string) int {
+	if heading == "" {
+		return -1
+	}
+	if i := slices.Index(papertrail.KeepAChangelogCategories, strings.TrimSpace(strings.TrimPrefix(heading, "###"))); i >= 0 {
+		return i
+	}
+	return len(papertrail.KeepAChangelogCategories)
+}
+
+// sortSectionsNewestFirst orders sections by version, newest first, after the Unreleased
+// section; sections without a SemVer key (dated headings) keep their relative order after
+// all versioned sections.
+func sortSectionsNewestFirst(secs []changelogSection) {
+	sort.SliceStable(secs, func(i, j int) bool {
+		vi, erri := parseSemver(secs[i].Key)
+		vj, errj := parseSemver(secs[j].Key)
+		switch {
+		case secs[j].Key == unreleasedKey:
+			return false
+		case secs[i].Key == unreleasedKey:
+			return true
+		case erri == nil && errj == nil:
+			return vi.Compare(vj) > 0
+		case erri == nil:
+			return true
+		}
+		return false
+	})
+}
+
+// joinChangelog reassembles a changelog from splitChangelog's parts with one blank line
+// between sections.
+func joinChangelog(preamble string, secs []changelogSection) string {
+	var out strings.Builder
+	out.WriteString(preamble)
+	for i, s := range secs {
+		body := strings.TrimRight(s.Body, "\n") + "\n"
+		if i < len(secs)-1 {
+			body += "\n"
+		}
+		out.WriteString(body)
+	}
+	return out.String()
+}
+
+func sectionMap(secs []changelogSection) map[string]string {
+	m := make(map[string]string, len(secs))
+	for _, s := range secs {
+		m[s.Key] = s.Body
+	}
+	return m
+}
+
+// sameSection compares sections ignoring trailing blank lines, which move as sections are
+// reordered.
+func sameSection(a, b string) bool {
+	return strings.TrimRight(a, "\n") == strings.TrimRight(b, "\n")
+}
+
+// merge3 resolves one region: identical sides or a one-sided change merge cleanly; otherwise
+// both sides are kept between conflict markers.
+func merge3(base, ours, theirs string) (string, bool) {
+	switch {
+	case sameSection(ours, theirs), sameSection(base, theirs):
+		return ours, true
+	case sameSection(base, ours):
+		return theirs, true
+	}
+	return "<<<<<<< ours\n" + strings.TrimRight(ours, "\n") + "\n=======\n" +
+		strings.TrimRight(theirs, "\n") + "\n>>>>>>> theirs\n\n", false
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMergeChangelogs(t *testing.T) {
+	t.Parallel()
+
+	head := "# Changelog\n\nIntro.\n\n"
+	base := head + "## v1.0.0 (2025-01-01)\n\n- **fix**: a.\n"
+	ours := head + "## v1.1.0 (2025-02-01)\n\n### CLI\n\n- **feature**: b.\n\n" + "## v1.0.0 (2025-01-01)\n\n- **fix**: a.\n"
+	theirs := head + "## v1.0.1 (2025-01-15)\n\n- **fix**: c.\n\n" + "## v1.0.0 (2025-01-01)\n\n- **fix**: a (edited).\n"
+
+	got, conflicts := mergeChangelogs(base, ours, theirs)
+	if len(conflicts) > 0 {
+		t.Fatalf("unexpected conflicts: %v", conflicts)
+	}
+	want := head +
+		"## v1.1.0 (2025-02-01)\n\n### CLI\n\n- **feature**: b.\n\n" +
+		"## v1.0.1 (2025-01-15)\n\n- **fix**: c.\n\n" +
+		"## v1.0.0 (2025-01-01)\n\n- **fix**: a (edited).\n"
+	if got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestMergeChangelogs_Conflict(t *testing.T) {
+	t.Parallel()
+
+	base := "# Changelog\n\n"
+	ours := base + "## v1.1.0 (2025-02-01)\n\n- **fix**: ours.\n"
+	theirs := base + "## v1.1.0 (2025-02-02)\n\n- **fix**: theirs.\n"
+
+	got, conflicts := mergeChangelogs(base, ours, theirs)
+	if len(conflicts) != 1 || conflicts[0] != "section v1.1.0" {
+		t.Fatalf("unexpected conflicts: %v", conflicts)
+	}
+	if !strings.Contains(got, "<<<<<<< ours\n## v1.1.0 (2025-02-01)") || !strings.Contains(got, ">>>>>>> theirs") {
+		t.Fatalf("missing conflict markers:\n%s", got)
+	}
+}
+
+func TestMergeChangelogs_Deletion(t *testing.T) {
+	t.Parallel()
+
+	base := "# Changelog\n\n## v1.0.0\n\n- a.\n\n## v0.9.0\n\n- b.\n"
+	ours := "# Changelog\n\n## v1.0.0\n\n- a.\n"
+	got, conflicts := mergeChangelogs(base, ours, base)
+	if len(conflicts) > 0 || got != ours {
+		t.Fatalf("got %v:\n%s", conflicts, got)
+	}
+}
+
+func TestMergeChangelogs_KeepAChangelogUnreleased(t *testing.T) {
+	t.Parallel()
+
+	head := "# Changelog\n\n"
+	release := "## [v1.0.0] - 2025-01-01\n\n### Added\n\n- Old.\n\n"
+	links := "[unreleased]: https://example.com/compare/v1.0.0...HEAD\n[v1.0.0]: https://example.com/releases/v1.0.0\n"
+	base := head + "## [Unreleased]\n\n### Added\n\n- A.\n\n" + release + links
+	ours := head + "## [Unreleased]\n\n### Added\n\n- A.\n- B,\n  wrapped.\n\n" + release + links
+	theirs := head + "## [Unreleased]\n\n### Added\n\n- A.\n- C.\n\n### Fixed\n\n- D.\n\n" + release + links
+
+	got, conflicts := mergeChangelogs(base, ours, theirs)
+	if len(conflicts) > 0 {
+		t.Fatalf("unexpected conflicts: %v", conflicts)
+	}
+	want := head + "## [Unreleased]\n\n### Added\n\n- A.\n- B,\n  wrapped.\n- C.\n\n### Fixed\n\n- D.\n\n" + release + links
+	if got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// A release moves entries out of Unreleased; a branch adding one keeps only its own.
+	released := head + "## [Unreleased]\n\n## [v1.1.0] - 2025-02-01\n\n### Added\n\n- A.\n\n" + release +
+		"[unreleased]: https://example.com/compare/v1.1.0...HEAD\n[v1.1.0]: https://example.com/compare/v1.0.0...v1.1.0\n[v1.0.0]: https://example.com/releases/v1.0.0\n"
+	got, conflicts = mergeChangelogs(base, released, ours)
+	if len(conflicts) > 0 {
+		t.Fatalf("unexpected conflicts: %v", conflicts)
+	}
+	want = head + "## [Unreleased]\n\n### Added\n\n- B,\n  wrapped.\n\n## [v1.1.0] - 2025-02-01\n\n### Added\n\n- A.\n\n" + release +
+		"[unreleased]: https://example.com/compare/v1.1.0...HEAD\n[v1.1.0]: https://example.com/compare/v1.0.0...v1.1.0\n[v1.0.0]: https://example.com/releases/v1.0.0\n"
+	if got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestMergeChangelogs_LinkReferences(t *testing.T) {
+	t.Parallel()
+
+	body := "# Changelog\n\n## [v1.1.0] - 2025-02-01\n\n- New.\n\n## [v1.0.0] - 2025-01-01\n\n- Old.\n\n"
+	base := body + "[v1.0.0]: https://example.com/v1.0.0\n[docs]: https://example.com/docs\n"
+	ours := body + "[v1.1.0]: https://example.com/v1.1.0\n[v1.0.0]: https://example.com/v1.0.0\n[docs]: https://example.com/docs\n"
+	theirs := body + "[v1.0.0]: https://example.com/releases/v1.0.0\n"
+
+	got, conflicts := mergeChangelogs(base, ours, theirs)
+	if len(conflicts) > 0 {
+		t.Fatalf("unexpected conflicts: %v", conflicts)
+	}
+	want := body + "[v1.1.0]: https://example.com/v1.1.0\n[v1.0.0]: https://example.com/releases/v1.0.0\n"
+	if got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	theirs = body + "[v1.0.0]: https://example.com/other/v1.0.0\n[docs]: https://example.com/docs\n"
+	ours = body + "[v1.0.0]: https://example.com/mine/v1.0.0\n[docs]: https://example.com/docs\n"
+	got, conflicts = mergeChangelogs(base, ours, theirs)
+	if len(conflicts) != 1 || conflicts[0] != "link [v1.0.0]" {
+		t.Fatalf("unexpected conflicts: %v", conflicts)
+	}
+	if !strings.Contains(got, "<<<<<<< ours\n[v1.0.0]: https://example.com/mine/v1.0.0\n=======\n") {
+		t.Fatalf("missing conflict markers:\n%s", got)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// writeMergePlan prints what merge would write for out, given the updated changelog: where
+// the section goes, the files it writes and archives, then the section itself.
+func writeMergePlan(w io.Writer, out releaseOutput, updated []byte) {
+	fmt.Fprintln(w, "Dry run; would:")
+	fmt.Fprintf(w, "  - insert the %s section into %s %s\n", out.Version, out.ChangelogPath, insertionPoint(updated, out.Section))
+	if out.ReleaseNotesOut != "" {
+		fmt.Fprintf(w, "  - write release notes to %s\n", out.ReleaseNotesOut)
+	}
+	paths := archivedPaths(out.Items)
+	fmt.Fprintf(w, "  - archive %d fragment file(s) under %s\n", len(paths), path.Join(out.ArchiveDir, out.Version))
+	for _, p := range paths {
+		fmt.Fprintf(w, "      %s\n", p)
+	}
+	if out.AttestationOut != "" {
+		fmt.Fprintf(w, "  - write the attestation to %s\n", out.AttestationOut)
+	}
+	fmt.Fprintln(w)
+	_, _ = w.Write(out.Section)
+}
+
+// insertionPoint describes where section starts in the updated changelog: its line, and the
+// line that follows it.
+func insertionPoint(updated, section []byte) string {
+	idx := bytes.Index(updated, section)
+	if idx < 0 {
+		return ""
+	}
+	line := bytes.Count(updated[:idx], []byte("\n")) + 1
+	for _, l := range strings.Split(string(updated[idx+len(section):]), "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			return fmt.Sprintf("at line %d (before %q)", line, l)
+		}
+	}
+	return fmt.Sprintf("at line %d (at the end)", line)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCmdMerge_DryRun(t *testing.T) {
+	initGitRepo(t, map[string]string{
+		"CHANGELOG.md":               "# Changelog\n\n## v1.0.0 (2026-01-01)\n\n### CLI\n\n- **fix**: Fix z.\n",
+		"changelog.d/20260102_a.yml": "component: CLI\ntype: fix\nsummary: Fix a\n",
+		"changelog.d/20260103_b.yml": "- component: CLI\n  type: fix\n  summary: Fix b\n- component: API\n  type: fix\n  summary: Fix c\n",
+	})
+	before, _ := os.ReadFile("CHANGELOG.md")
+
+	out, err := captureStdout(t, func() error {
+		return cmdMerge(t.Context(), []string{"--version", "v1.0.1", "--date", "2026-01-04", "--release-notes-out", "notes.md", "--dry-run"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `Dry run; would:
+  - insert the v1.0.1 section into CHANGELOG.md at line 3 (before "## v1.0.0 (2026-01-01)")
+  - write release notes to notes.md
+  - archive 2 fragment file(s) under changelog.d/archived/v1.0.1
+      changelog.d/20260102_a.yml
+      changelog.d/20260103_b.yml
+
+## v1.0.1 (2026-01-04)
+`
+	if !strings.HasPrefix(out, want) {
+		t.Fatalf("output:\n%s\nwant prefix:\n%s", out, want)
+	}
+
+	after, _ := os.ReadFile("CHANGELOG.md")
+	if string(after) != string(before) {
+		t.Fatalf("dry run changed CHANGELOG.md:\n%s", after)
+	}
+	for _, p := range []string{"notes.md", "changelog.d/archived"} {
+		if _, err := os.Stat(p); err == nil {
+			t.Fatalf("dry run wrote %s", p)
+		}
+	}
+	if _, err := os.Stat("changelog.d/20260102_a.yml"); err != nil {
+		t.Fatalf("dry run archived a fragment: %v", err)
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// inStream reports whether it is released together with component (see streamItems).
+func inStream(it item, manifest releaseManifest, component string) bool {
+	_, independent := manifest.VersionedComponent(it.Frag.Component)
+	return (component == "" && !independent) || (component != "" && it.Frag.Component == component)
+}
+
+// streamItems keeps the items released together with component: that component's items, or
+// with component "" the repository's own stream, i.e. every component that is not versioned
+// independently (versioning.components). files, when given, is filtered alongside items; a
+// file whose fragments belong to different streams is an error, as it is archived whole.
+func streamItems(files []fragmentFile, items []item, manifest releaseManifest, component string) ([]fragmentFile, []item, error) {
+	if component != "" {
+		if _, ok := manifest.VersionedComponent(component); !ok {
+			return nil, nil, fmt.Errorf("component %q is not versioned independently (add it under versioning.components)", component)
+		}
+	}
+	var kept []item
+	streamOf := map[string]bool{}
+	for _, it := range items {
+		keep := inStream(it, manifest, component)
+		if was, seen := streamOf[it.Path]; seen && was != keep {
+			return nil, nil, fmt.Errorf("%s mixes independently versioned components with others; split it into one file per release stream", it.Path)
+		}
+		streamOf[it.Path] = keep
+		if keep {
+			kept = append(kept, it)
+		}
+	}
+	var keptFiles []fragmentFile
+	for _, ff := range files {
+		if streamOf[ff.Path] {
+			keptFiles = append(keptFiles, ff)
+		}
+	}
+	switch {
+	case len(kept) > 0:
+		return keptFiles, kept, nil
+	case component != "":
+		return nil, nil, fmt.Errorf("no pending fragments for component %q", component)
+	default:
+		return nil, nil, fmt.Errorf("no pending fragments outside independently versioned components (%s); release those with --component",
+			strings.Join(independentComponents(manifest), ", "))
+	}
+}
+
+// independentComponents lists versioning.components, sorted.
+func independentComponents(manifest releaseManifest) []string {
+	names := make([]string, 0, len(manifest.Versioning.Components))
+	for name := range manifest.Versioning.Components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// tagName is the release tag for v under prefix. The version's "v" is dropped when the
+// prefix already ends in one (api/v + v1.2.0 is api/v1.2.0).
+func tagName(prefix string, v semver) string {
+	if strings.HasSuffix(prefix, "v") {
+		return prefix + strings.TrimPrefix(v.String(), "v")
+	}
+	return prefix + v.String()
+}
+
+// componentSlug is a component name usable as a path element: lowercase, spaces as dashes.
+func componentSlug(component string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(component)), " ", "-")
+}
+
+// componentRelease is one independently versioned component's part of a merge.
+type componentRelease struct {
+	Component string
+	Version   semver
+	Tag       string
+	Output    releaseOutput
+}
+
+// componentMergeOptions are the merge flags that apply to component releases.
+type componentMergeOptions struct {
+	// Version overrides the computed version; only valid for a single component.
+	Version          string
+	Date             string
+	ArchiveDir       string
+	ReleaseNotesOut  string
+	SkipVersionCheck bool
+	Lint             bool
+	// Wait is how long to wait for another run holding a component changelog's lock.
+	Wait time.Duration
+}
+
+// planComponentRelease renders the next release of an independently versioned component
+// from its pending items. The version follows the component's latest tag or changelog
+// section unless opts.Version is set.
+func planComponentRelease(ctx context.Context, component string, opts componentMergeOptions, items []item, manifest releaseManifest) (componentRelease, error) {
+	vc, _ := manifest.VersionedComponent(component)
+	latest, hasLatest, err := latestRelease(ctx, hostFS{}, vc.Changelog, vc.TagPrefix)
+	if err != nil {
+		return componentRelease{}, err
+	}
+	var next semver
+	switch {
+	case opts.Version != "":
+		if next, err = parseSemver(opts.Version); err != nil {
+			return componentRelease{}, fmt.Errorf("invalid --version %q: %v (expected vMAJOR.MINOR.PATCH)", opts.Version, err)
+		}
+		if hasLatest && !opts.SkipVersionCheck {
+			if err := checkNextVersion(next, latest); err != nil {
+				return componentRelease{}, fmt.Errorf("%s: %w", component, err)
+			}
+		}
+	case hasLatest:
+		next = latest.Version.Bump(nextBump(items, manifest, latest.Version))
+	default:
+		return componentRelease{}, fmt.Errorf("%s has no release yet (no %s<version> tag or section in %s); pass --component %q --version for its first one",
+			component, vc.TagPrefix, vc.Changelog, component)
+	}
+
+	section, releaseNotes, err := renderReleaseSection(next.String(), opts.Date, items, manifest)
+	if err != nil {
+		return componentRelease{}, err
+	}
+	return componentRelease{
+		Component: component,
+		Version:   next,
+		Tag:       tagName(vc.TagPrefix, next),
+		Output: releaseOutput{
+			Version:         next.String(),
+			ChangelogPath:   vc.Changelog,
+			ArchiveDir:      path.Join(opts.ArchiveDir, componentSlug(component)),
+			ReleaseNotesOut: opts.ReleaseNotesOut,
+			Section:         section,
+			ReleaseNotes:    trimTrailingNewlines(releaseNotes),
+			Items:           items,
+			Style:           manifest.Changelog.Style,
+			Compare:         changelogCompare(manifest, vc.TagPrefix),
+		},
+	}, nil
+}
+
+// mergeComponents releases each independently versioned component with pending fragments
+// into its own changelog, in one run, and prints the tag each release should get. Every
+// release is planned before any is written, so one bad component writes nothing. Each
+// component changelog is locked once, in path order, before any is read.
+func mergeComponents(ctx context.Context, components []string, opts componentMergeOptions, items []item, manifest releaseManifest) error {
+	var changelogs []string
+	for _, c := range components {
+		if vc, ok := manifest.VersionedComponent(c); ok && !slices.Contains(changelogs, path.Clean(vc.Changelog)) {
+			changelogs = append(changelogs, path.Clean(vc.Changelog))
+		}
+	}
+	slices.Sort(changelogs)
+	for _, p := range changelogs {
+		unlock, err := acquireChangelogLock(ctx, p, opts.Wait)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+
+	var releases []componentRelease
+	for _, c := range components {
+		_, its, err := streamItems(nil, items, manifest, c)
+		if err != nil {
+			return err
+		}
+		rel, err := planComponentRelease(ctx, c, opts, its, manifest)
+		if err != nil {
+			return err
+		}
+		if opts.Lint {
+			if err := lintReleaseOutput(rel.Output.Section, rel.Output.ReleaseNotes); err != nil {
+				return fmt.Errorf("%s: %w", c, err)
+			}
+		}
+		releases = append(releases, rel)
+	}
+	for _, rel := range releases {
+		if err := writeRelease(hostFS{}, rel.Output); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stdout, "%s %s: %s (tag %s)\n", rel.Component, rel.Version, rel.Output.ChangelogPath, rel.Tag)
+	}
+	return nil
+}
+
+// pendingComponents lists the independently versioned components that have pending items.
+func pendingComponents(items []item, manifest releaseManifest) []string {
+	var out []string
+	for _, c := range independentComponents(manifest) {
+		for _, it := range items {
+			if it.Frag.Component == c {
+				out = append(out, c)
+				break
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTagName(t *testing.T) {
+	t.Parallel()
+
+	v := mustSemver(t, "v1.2.0")
+	for prefix, want := range map[string]string{
+		"api/v": "api/v1.2.0",
+		"api-":  "api-v1.2.0",
+		"v":     "v1.2.0",
+	} {
+		if got := tagName(prefix, v); got != want {
+			t.Fatalf("tagName(%q) = %q, want %q", prefix, got, want)
+		}
+	}
+}
+
+// setupMonorepo creates a repository whose API component is versioned independently
+// (released at api/v0.4.0) next to the repository's own v1.2.0 stream.
+func setupMonorepo(t *testing.T) string {
+	t.Helper()
+	dir := initGitRepo(t, map[string]string{
+		".papertrail.config.yml": "versioning:\n  rules:\n    feature: minor\n  components:\n    API:\n      changelog: api/CHANGELOG.md\n",
+		"CHANGELOG.md":           "# Changelog\n\n## v1.2.0 (2026-01-01)\n\n- old\n",
+		"api/CHANGELOG.md":       "# API Changelog\n\n## v0.4.0 (2026-01-01)\n\n- old\n",
+		"changelog.d/cli.yml":    "component: CLI\ntype: fix\nsummary: Fix the CLI\n",
+		"changelog.d/api.yml":    "component: API\ntype: feature\nsummary: Add an endpoint\n",
+	})
+	gitIn(t, dir, "tag", "v1.2.0")
+	gitIn(t, dir, "tag", "api/v0.4.0")
+	return dir
+}
+
+func TestCmdBump_Component(t *testing.T) {
+	setupMonorepo(t)
+
+	out, err := captureStdout(t, func() error { return cmdBump(t.Context(), []string{"--component", "API"}) })
+	if err != nil || out != "v0.5.0\n" {
+		t.Fatalf("bump --component API = %q, %v; want v0.5.0", out, err)
+	}
+	// The repository's own stream ignores the API feature.
+	out, err = captureStdout(t, func() error { return cmdBump(t.Context(), nil) })
+	if err != nil || out != "v1.2.1\n" {
+		t.Fatalf("bump = %q, %v; want v1.2.1", out, err)
+	}
+	if err := cmdBump(t.Context(), []string{"--component", "CLI"}); err == nil || !strings.Contains(err.Error(), "not versioned independently") {
+		t.Fatalf("expected an error for a component released with the repository, got %v", err)
+	}
+}
+
+func TestCmdMerge_Components(t *testing.T) {
+	dir := setupMonorepo(t)
+
+	if err := cmdMerge(t.Context(), []string{"--component", "API", "--all-components"}); err == nil {
+		t.Fatalf("expected --component with --all-components to fail")
+	}
+	out, err := captureStdout(t, func() error {
+		return cmdMerge(t.Context(), []string{"--all-components", "--date", "2026-02-01"})
+	})
+	if err != nil {
+		t.Fatalf("merge --all-components: %v", err)
+	}
+	if out != "API v0.5.0: api/CHANGELOG.md (tag api/v0.5.0)\n" {
+		t.Fatalf("output %q", out)
+	}
+	api, _ := os.ReadFile(filepath.Join(dir, "api", "CHANGELOG.md"))
+	if !strings.Contains(string(api), "## v0.5.0 (2026-02-01)") || !strings.Contains(string(api), "Add an endpoint.") {
+		t.Fatalf("api changelog:\n%s", api)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "changelog.d", "archived", "api", "v0.5.0", "api.yml")); err != nil {
+		t.Fatalf("API fragment not archived: %v", err)
+	}
+
+	// The repository release picks up only the CLI fragment.
+	if err := cmdMerge(t.Context(), []string{"--version", "v1.2.1", "--date", "2026-02-01"}); err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	root, _ := os.ReadFile(filepath.Join(dir, "CHANGELOG.md"))
+	if !strings.Contains(string(root), "Fix the CLI.") || strings.Contains(string(root), "endpoint") {
+		t.Fatalf("root changelog:\n%s", root)
+	}
+	if err := cmdMerge(t.Context(), []string{"--all-components"}); err == nil || !strings.Contains(err.Error(), "no fragments") {
+		t.Fatalf("expected no fragments error, got %v", err)
+	}
+}
+
+func TestCmdMerge_ComponentLocksItsChangelog(t *testing.T) {
+	dir := setupMonorepo(t)
+
+	// A run releasing the repository does not block a component release.
+	unlock, err := acquireChangelogLock(t.Context(), "CHANGELOG.md", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+	unlockAPI, err := acquireChangelogLock(t.Context(), "api/CHANGELOG.md", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmdMerge(t.Context(), []string{"--component", "API", "--date", "2026-02-01"}); err == nil || !strings.Contains(err.Error(), "locked by another papertrail run") {
+		t.Fatalf("expected the API changelog lock to be held, got %v", err)
+	}
+	unlockAPI()
+	if _, err := captureStdout(t, func() error {
+		return cmdMerge(t.Context(), []string{"--component", "API", "--date", "2026-02-01"})
+	}); err != nil {
+		t.Fatalf("merge --component API: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "api", "CHANGELOG.md.lock")); !os.IsNotExist(err) {
+		t.Fatalf("the API changelog lock should be released: %v", err)
+	}
+}
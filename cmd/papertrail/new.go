@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultFragmentTemplate is used by `new` when the manifest has no template for a type.
+const defaultFragmentTemplate = `component: {{ yaml .Component }}
+type: {{ yaml .Type }}
+summary: {{ yaml .Summary }}
+{{- if .Refs }}
+refs:
+{{- range .Refs }}
+  - {{ yaml . }}
+{{- end }}
+{{- end }}
+`
+
+// fragmentTemplateData is the data passed to fragment templates.
+type fragmentTemplateData struct {
+	Component string
+	Type      string
+	Summary   string
+	Refs      []string
+}
+
+// stringList is a repeatable string flag.
+type stringList []string
+
+func (s *stringList) String() string { return strings.Join(*s, ",") }
+
+func (s *stringList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+func cmdNew(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("new", flag.ContinueOnError)
+	fs.SetOutput(ioDiscard{})
+	fragmentsDir := fs.String("fragments", "changelog.d", "fragments directory")
+	manifestPath := fs.String("manifest", "", "optional release config YAML path")
+	component := fs.String("component", "", "fragment component")
+	typ := fs.String("type", "", "fragment type (required)")
+	summary := fs.String("summary", "", "fragment summary")
+	name := fs.String("name", "", "file name slug (default: derived from the summary or type)")
+	var refs stringList
+	fs.Var(&refs, "ref", "reference (repeatable)")
+	noInput := fs.Bool("no-input", false, "never prompt for missing fields, even on a terminal")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	manifest, err := loadManifestDefault(*manifestPath)
+	if err != nil {
+		return err
+	}
+
+	data := fragmentTemplateData{
+		Component: strings.TrimSpace(*component),
+		Type:      strings.TrimSpace(*typ),
+		Summary:   strings.TrimSpace(*summary),
+		Refs:      refs,
+	}
+	missing := data.Component == "" || data.Type == "" || data.Summary == ""
+	if missing && !*noInput && stdinIsTerminal() {
+		if err := promptFragmentFields(os.Stdin, os.Stderr, &data, manifest); err != nil {
+			return err
+		}
+	}
+	if data.Type == "" {
+		return fmt.Errorf("--type is required")
+	}
+	data.Type = manifest.CanonicalType(data.Type)
+	content, err := renderFragmentTemplate(data, manifest)
+	if err != nil {
+		return err
+	}
+	if err := checkNewFragment(content, manifest); err != nil {
+		return err
+	}
+
+	slug := *name
+	if strings.TrimSpace(slug) == "" {
+		slug = data.Summary
+	}
+	if strings.TrimSpace(slug) == "" {
+		slug = data.Type
+	}
+	now, err := releaseTime(manifest)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(*fragmentsDir, now.Format("20060102")+"_"+fragmentSlug(slug)+".yml")
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("fragment %s already exists (use --name to choose another)", path)
+	}
+	if err := os.MkdirAll(*fragmentsDir, 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintln(os.Stdout, path)
+	return nil
+}
+
+// checkNewFragment validates a fragment about to be written. Missing fields are allowed so a
+// template skeleton can be filled in later, but the YAML must parse and the component and
+// type must be ones the manifest accepts.
+func checkNewFragment(content []byte, manifest releaseManifest) error {
+	_, issues := papertrail.ValidateFragment(content, manifest)
+	var problems []string
+	for _, issue := range issues {
+		switch {
+		case issue.Rule == papertrail.RuleMissingField:
+		case issue.Severity == papertrail.SeverityError:
+			problems = append(problems, issue.Message)
+		default:
+			fmt.Fprintf(os.Stderr, "warning: %s\n", issue.Message)
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("not writing an invalid fragment: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// stdinIsTerminal reports whether stdin is an interactive character device; /dev/null is one
+// too, so it is excluded.
+func stdinIsTerminal() bool {
+	fi, err := os.Stdin.Stat()
+	if err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	null, err := os.Stat(os.DevNull)
+	return err != nil || !os.SameFile(fi, null)
+}
+
+// promptFragmentFields asks on out for each of component, type, and summary that is still
+// empty (and for refs when prompting at all), reading answers from in. Components and types
+// are checked against the manifest as they are entered, and asked again until valid.
+func promptFragmentFields(in io.Reader, out io.Writer, data *fragmentTemplateData, manifest releaseManifest) error {
+	r := bufio.NewReader(in)
+	ask := func(prompt string) (string, error) {
+		fmt.Fprint(out, prompt)
+		line, err := r.ReadString('\n')
+		if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+			fmt.Fprintln(out)
+			return "", errors.New("input ended before the fragment was complete")
+		}
+		return strings.TrimSpace(line), nil
+	}
+	// askValid asks until the answer is non-empty and validation has no error for field.
+	askValid := func(prompt, field string, set func(*fragment, string)) (string, error) {
+		for {
+			v, err := ask(prompt)
+			if err != nil {
+				return "", err
+			}
+			if v == "" {
+				fmt.Fprintf(out, "%s is required.\n", field)
+				continue
+			}
+			f := fragment{Component: "x", Type: "x", Summary: "x"}
+			set(&f, v)
+			b, _ := yaml.Marshal(f)
+			_, issues := papertrail.ValidateFragment(b, manifest)
+			ok := true
+			for _, issue := range issues {
+				if issue.Field == field && issue.Severity == papertrail.SeverityError {
+					fmt.Fprintln(out, issue.Message)
+					ok = false
+				}
+			}
+			if ok {
+				return v, nil
+			}
+		}
+	}
+	choices := func(xs []string, show func(string) string) string {
+		if len(xs) == 0 {
+			return ""
+		}
+		shown := make([]string, len(xs))
+		for i, x := range xs {
+			shown[i] = show(x)
+		}
+		return " (" + strings.Join(shown, ", ") + ")"
+	}
+
+	var err error
+	if data.Component == "" {
+		comps := manifest.ComponentOrder()
+		if data.Component, err = askValid("Component"+choices(comps, strings.TrimSpace)+": ", "component", func(f *fragment, v string) { f.Component = v }); err != nil {
+			return err
+		}
+	}
+	if data.Type == "" {
+		types := manifest.TypeOrder()
+		if data.Type, err = askValid("Type"+choices(types, displayType)+": ", "type", func(f *fragment, v string) { f.Type = v }); err != nil {
+			return err
+		}
+	}
+	if data.Summary == "" {
+		if data.Summary, err = askValid("Summary: ", "summary", func(f *fragment, v string) { f.Summary = v }); err != nil {
+			return err
+		}
+	}
+	if len(data.Refs) == 0 {
+		v, err := ask("Refs (comma-separated, optional): ")
+		if err != nil {
+			return err
+		}
+		for _, ref := range strings.Split(v, ",") {
+			if ref = strings.TrimSpace(ref); ref != "" {
+				data.Refs = append(data.Refs, ref)
+			}
+		}
+	}
+	return nil
+}
+
+// renderFragmentTemplate renders the manifest template for data.Type (or the default).
+// Templates are text/template strings; the `yaml` function quotes a value as a YAML scalar.
+func renderFragmentTemplate(data fragmentTemplateData, manifest releaseManifest) ([]byte, error) {
+	text := defaultFragmentTemplate
+	name := "default"
+	if t, ok := manifest.Templates[data.Type]; ok {
+		text = t
+		name = "templates." + displayType(data.Type)
+	}
+	tmpl, err := template.New(name).Option("missingkey=error").Funcs(template.FuncMap{
+		"yaml": yamlScalar,
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid fragment template %s: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("rendering fragment template %s: %w", name, err)
+	}
+	return buf.Bytes(), nil
+}
+
+func yamlScalar(v string) (string, error) {
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(b), "\n"), nil
+}
+
+// fragmentSlug turns free text into a short snake_case file name component.
+func fragmentSlug(s string) string {
+	var b strings.Builder
+	underscore := false
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			underscore = false
+			continue
+		}
+		if !underscore && b.Len() > 0 {
+			b.WriteByte('_')
+			underscore = true
+		}
+	}
+	slug := strings.TrimSuffix(b.String(), "_")
+	if len(slug) > 40 {
+		slug = strings.TrimSuffix(slug[:40], "_")
+	}
+	if slug == "" {
+		slug = "change"
+	}
+	return slug
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+func TestRenderFragmentTemplate(t *testing.T) {
+	t.Parallel()
+
+	var m releaseManifest
+	m.Templates = map[string]string{
+		"BREAKING": "component: {{ yaml .Component }}\ntype: {{ yaml .Type }}\nsummary: {{ yaml .Summary }}\n# Migration: describe how users should update.\n",
+	}
+
+	got, err := renderFragmentTemplate(fragmentTemplateData{Component: "CLI", Type: "BREAKING", Summary: "Drop `--foo`: use --bar"}, m)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	want := "component: CLI\ntype: BREAKING\nsummary: 'Drop `--foo`: use --bar'\n# Migration: describe how users should update.\n"
+	if string(got) != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	got, err = renderFragmentTemplate(fragmentTemplateData{Component: "CLI", Type: "FIX", Summary: "Fix it", Refs: []string{"#1"}}, m)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	want = "component: CLI\ntype: FIX\nsummary: Fix it\nrefs:\n  - '#1'\n"
+	if string(got) != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFragmentSlug(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"Add `new` command!": "add_new_command",
+		"  ":                 "change",
+		"BREAKING CHANGE":    "breaking_change",
+		"a very long summary that keeps going past the limit": "a_very_long_summary_that_keeps_going_pas",
+	}
+	for in, want := range cases {
+		if got := fragmentSlug(in); got != want {
+			t.Fatalf("fragmentSlug(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestPromptFragmentFields(t *testing.T) {
+	t.Parallel()
+
+	m, err := papertrail.ParseManifest([]byte("changelog:\n  components: [CLI, Docs]\n  strict_components: true\ntypes:\n  order: [feature, fix]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := fragmentTemplateData{Summary: "Given as a flag"}
+	var out bytes.Buffer
+	in := strings.NewReader("Web\nCLI\n\nchore\nfix\n#12, #13\n")
+	if err := promptFragmentFields(in, &out, &data, m); err != nil {
+		t.Fatal(err)
+	}
+	want := fragmentTemplateData{Component: "CLI", Type: "fix", Summary: "Given as a flag", Refs: []string{"#12", "#13"}}
+	if !reflect.DeepEqual(data, want) {
+		t.Fatalf("got %+v, want %+v", data, want)
+	}
+	s := out.String()
+	for _, w := range []string{"Component (CLI, Docs): ", `unknown component "Web"`, "type is required.", "Type (feature, fix): ", `unknown type "CHORE"`} {
+		if !strings.Contains(s, w) {
+			t.Fatalf("prompt output missing %q:\n%s", w, s)
+		}
+	}
+	if strings.Contains(s, "Summary:") {
+		t.Fatalf("prompted for a summary given as a flag:\n%s", s)
+	}
+
+	if err := promptFragmentFields(strings.NewReader("CLI\n"), &bytes.Buffer{}, &fragmentTemplateData{}, m); err == nil {
+		t.Fatalf("expected an error when input ends early")
+	}
+}
+
+func TestCmdNew_Validates(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile(".papertrail.config.yml", []byte("types:\n  order: [feature, fix]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := cmdNew(t.Context(), []string{"--no-input", "--component", "CLI", "--type", "chore", "--summary", "Tidy up"})
+	if err == nil || !strings.Contains(err.Error(), `unknown type "CHORE"`) {
+		t.Fatalf("expected an unknown type error, got %v", err)
+	}
+	if entries, _ := os.ReadDir("changelog.d"); len(entries) > 0 {
+		t.Fatalf("invalid fragment was written: %v", entries)
+	}
+	if err := cmdNew(t.Context(), []string{"--no-input", "--component", "CLI", "--type", "fix", "--summary", "Fix it"}); err != nil {
+		t.Fatal(err)
+	}
+	files, _ := filepath.Glob("changelog.d/*_fix_it.yml")
+	if len(files) != 1 {
+		t.Fatalf("fragment not written: %v", files)
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+// cmdNotes prints the release notes for a released version from the changelog, optionally
+// followed by a dependency changes section (diffed from two SBOMs or from go.mod in git) and
+// an Artifacts section built from a checksums file.
+func cmdNotes(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("notes", flag.ContinueOnError)
+	fs.SetOutput(ioDiscard{})
+	version := fs.String("version", "", "released version (default: the top section of the changelog)")
+	changelogPath := fs.String("changelog", "CHANGELOG.md", "changelog path")
+	artifacts := fs.String("artifacts", "", "checksums file (sha256sum or BSD format) to list as an Artifacts section")
+	provenance := fs.String("provenance", "", "provenance (e.g. SLSA attestation) URL to link from the Artifacts section")
+	sbomBase := fs.String("sbom-base", "", "SPDX or CycloneDX JSON SBOM of the previous release (with --sbom)")
+	sbom := fs.String("sbom", "", "SPDX or CycloneDX JSON SBOM of this release; adds a dependency changes section")
+	depsSince := fs.String("deps-since", "", "git ref (e.g. the previous tag) to diff go.mod requirements against HEAD")
+	out := fs.String("out", "", "write the notes to this path (default: stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *provenance != "" && *artifacts == "" {
+		return fmt.Errorf("--provenance requires --artifacts")
+	}
+	if (*sbomBase == "") != (*sbom == "") {
+		return fmt.Errorf("--sbom-base and --sbom must be used together")
+	}
+	if *depsSince != "" && *sbom != "" {
+		return fmt.Errorf("--deps-since and --sbom cannot be combined")
+	}
+
+	fsys := hostFS{}
+	changelog, err := readFile(fsys, *changelogPath)
+	if err != nil {
+		return err
+	}
+	v := *version
+	if v == "" {
+		top, ok := topChangelogVersion(string(changelog))
+		if !ok {
+			return fmt.Errorf("%s has no released version sections", *changelogPath)
+		}
+		v = top.String()
+	}
+	body, ok := papertrail.ExtractSection(string(changelog), v)
+	if !ok {
+		return fmt.Errorf("%s has no section for %s", *changelogPath, v)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "## %s\n\n", v)
+	if body != "" {
+		buf.WriteString(body)
+		buf.WriteString("\n")
+	}
+	if *sbom != "" {
+		var sets [2][]dependency
+		for i, p := range []string{*sbomBase, *sbom} {
+			b, err := readFile(fsys, p)
+			if err != nil {
+				return err
+			}
+			if sets[i], err = parseSBOM(b); err != nil {
+				return fmt.Errorf("%s: %w", p, err)
+			}
+		}
+		writeDependencyDiff(&buf, "Dependency changes", diffDependencies(sets[0], sets[1]))
+	}
+	if *depsSince != "" {
+		d, err := goModDependencyDiff(ctx, *depsSince)
+		if err != nil {
+			return err
+		}
+		writeDependencyDiff(&buf, "Dependency changes", d)
+	}
+	if *artifacts != "" {
+		b, err := readFile(fsys, *artifacts)
+		if err != nil {
+			return err
+		}
+		digests, err := parseChecksums(b)
+		if err != nil {
+			return fmt.Errorf("invalid --artifacts %s: %w", *artifacts, err)
+		}
+		writeArtifactsSection(&buf, digests, *provenance)
+	}
+
+	notes := bytes.TrimRight(buf.Bytes(), "\n")
+	notes = append(notes, '\n')
+	if *out != "" {
+		return fsys.WriteFile(*out, notes, 0644)
+	}
+	_, _ = os.Stdout.Write(notes)
+	return nil
+}
+
+// artifactDigest is one line of a checksums file.
+type artifactDigest struct {
+	Name      string
+	Algorithm string
+	Digest    string
+}
+
+// digestAlgorithms maps hex digest lengths to algorithm names for sha*sum output, which does
+// not name the algorithm.
+var digestAlgorithms = map[int]string{40: "sha1", 64: "sha256", 128: "sha512"}
+
+// parseChecksums reads GNU coreutils ("<hex>  <name>", "<hex> *<name>") and BSD
+// ("SHA256 (<name>) = <hex>") checksum lines, in file order. Blank and # lines are skipped.
+func parseChecksums(b []byte) ([]artifactDigest, error) {
+	var out []artifactDigest
+	for i, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var d artifactDigest
+		if alg, rest, ok := strings.Cut(line, " ("); ok && strings.Contains(rest, ") = ") {
+			name, sum, _ := strings.Cut(rest, ") = ")
+			d = artifactDigest{Name: name, Algorithm: strings.ToLower(alg), Digest: strings.ToLower(sum)}
+		} else {
+			sum, name, ok := strings.Cut(line, " ")
+			if !ok {
+				return nil, fmt.Errorf("line %d: expected \"<digest>  <file>\"", i+1)
+			}
+			name = strings.TrimPrefix(strings.TrimLeft(name, " "), "*")
+			d = artifactDigest{Name: name, Algorithm: digestAlgorithms[len(sum)], Digest: strings.ToLower(sum)}
+		}
+		if _, err := hex.DecodeString(d.Digest); err != nil || d.Digest == "" {
+			return nil, fmt.Errorf("line %d: invalid digest %q", i+1, d.Digest)
+		}
+		if d.Algorithm == "" {
+			return nil, fmt.Errorf("line %d: cannot tell the algorithm of a %d-character digest", i+1, len(d.Digest))
+		}
+		if d.Name == "" {
+			return nil, fmt.Errorf("line %d: missing file name", i+1)
+		}
+		out = append(out, d)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no checksums found")
+	}
+	return out, nil
+}
+
+func writeArtifactsSection(buf *bytes.Buffer, digests []artifactDigest, provenance string) {
+	buf.WriteString("### Artifacts\n\n")
+	buf.WriteString("| File | Digest |\n| --- | --- |\n")
+	for _, d := range digests {
+		fmt.Fprintf(buf, "| `%s` | `%s:%s` |\n", strings.ReplaceAll(d.Name, "|", "\\|"), d.Algorithm, d.Digest)
+	}
+	if provenance != "" {
+		fmt.Fprintf(buf, "\nProvenance: %s\n", provenance)
+	}
+	buf.WriteString("\n")
+}
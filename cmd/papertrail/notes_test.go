@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseChecksums(t *testing.T) {
+	t.Parallel()
+
+	sha256 := strings.Repeat("ab", 32)
+	got, err := parseChecksums([]byte("# generated\n" +
+		sha256 + "  papertrail_linux_amd64.tar.gz\n" +
+		strings.Repeat("CD", 64) + " *papertrail.exe\n\n" +
+		"SHA256 (sbom.json) = " + sha256 + "\n"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	want := []artifactDigest{
+		{Name: "papertrail_linux_amd64.tar.gz", Algorithm: "sha256", Digest: sha256},
+		{Name: "papertrail.exe", Algorithm: "sha512", Digest: strings.Repeat("cd", 64)},
+		{Name: "sbom.json", Algorithm: "sha256", Digest: sha256},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("entry %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	for _, bad := range []string{"", "zz  file\n", "abcd  file\n", sha256 + "\n"} {
+		if _, err := parseChecksums([]byte(bad)); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestCmdNotes_Artifacts(t *testing.T) {
+	t.Chdir(t.TempDir())
+	changelog := "# Changelog\n\n## v1.1.0 (2025-02-01)\n\n### CLI\n\n- **fix**: b.\n\n## v1.0.0 (2025-01-01)\n\n- **fix**: a.\n"
+	sum := strings.Repeat("0f", 32)
+	if err := os.WriteFile("CHANGELOG.md", []byte(changelog), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("checksums.txt", []byte(sum+"  app.tar.gz\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := cmdNotes(t.Context(), []string{"--artifacts", "checksums.txt", "--provenance", "https://example.com/att", "--out", "notes.md"})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	got, _ := os.ReadFile("notes.md")
+	want := "## v1.1.0\n\n### CLI\n\n- **fix**: b.\n\n### Artifacts\n\n| File | Digest |\n| --- | --- |\n" +
+		"| `app.tar.gz` | `sha256:" + sum + "` |\n\nProvenance: https://example.com/att\n"
+	if string(got) != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	if err := cmdNotes(t.Context(), []string{"--version", "v2.0.0"}); err == nil {
+		t.Fatalf("expected error for a missing section")
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// errOffline is returned in place of any network access while offline mode is on.
+var errOffline = errors.New("network access is disabled (offline mode: --offline or PAPERTRAIL_OFFLINE)")
+
+type offlineKey struct{}
+
+// offlineContext marks ctx as offline when flag is set or PAPERTRAIL_OFFLINE is true.
+// Offline commands make no network calls: GitHub API requests and tarball sources fail with
+// errOffline, and git runs with GIT_ALLOW_PROTOCOL=file so it cannot reach remotes either.
+func offlineContext(ctx context.Context, flag bool) (context.Context, error) {
+	if raw := strings.TrimSpace(os.Getenv("PAPERTRAIL_OFFLINE")); raw != "" {
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PAPERTRAIL_OFFLINE %q (expected true or false)", raw)
+		}
+		flag = flag || v
+	}
+	if !flag {
+		return ctx, nil
+	}
+	return context.WithValue(ctx, offlineKey{}, true), nil
+}
+
+func isOffline(ctx context.Context) bool {
+	v, _ := ctx.Value(offlineKey{}).(bool)
+	return v
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+func TestOfflineContext(t *testing.T) {
+	t.Setenv("PAPERTRAIL_OFFLINE", "")
+	ctx, err := offlineContext(t.Context(), false)
+	if err != nil || isOffline(ctx) {
+		t.Fatalf("default: offline=%v err=%v", isOffline(ctx), err)
+	}
+	if ctx, _ := offlineContext(t.Context(), true); !isOffline(ctx) {
+		t.Fatalf("--offline did not enable offline mode")
+	}
+
+	t.Setenv("PAPERTRAIL_OFFLINE", "1")
+	if ctx, _ := offlineContext(t.Context(), false); !isOffline(ctx) {
+		t.Fatalf("PAPERTRAIL_OFFLINE=1 did not enable offline mode")
+	}
+	t.Setenv("PAPERTRAIL_OFFLINE", "maybe")
+	if _, err := offlineContext(t.Context(), false); err == nil || !strings.Contains(err.Error(), "PAPERTRAIL_OFFLINE") {
+		t.Fatalf("expected invalid value error, got %v", err)
+	}
+}
+
+func TestOffline_NoNetwork(t *testing.T) {
+	t.Setenv("PAPERTRAIL_OFFLINE", "")
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { hits++ }))
+	defer srv.Close()
+	ctx, _ := offlineContext(t.Context(), true)
+
+	c := &githubClient{baseURL: srv.URL, http: srv.Client()}
+	if _, err := c.getFile(ctx, "o/r", "CHANGELOG.md", ""); !errors.Is(err, errOffline) {
+		t.Fatalf("GitHub API: got %v, want errOffline", err)
+	}
+	if err := fetchTarball(ctx, srv.URL+"/x.tar.gz", t.TempDir()); !errors.Is(err, errOffline) {
+		t.Fatalf("tarball: got %v, want errOffline", err)
+	}
+	if err := cloneSource(ctx, papertrail.FragmentSource{Git: "https://example.invalid/repo.git"}, t.TempDir()); !errors.Is(err, errOffline) {
+		t.Fatalf("git source: got %v, want errOffline", err)
+	}
+	if hits != 0 {
+		t.Fatalf("server was contacted %d time(s)", hits)
+	}
+}
+
+func TestOffline_LocalGitSource(t *testing.T) {
+	t.Setenv("PAPERTRAIL_OFFLINE", "")
+	src := initGitRepo(t, map[string]string{"changelog.d/a.yml": "component: CLI\ntype: fix\nsummary: a\n"})
+	ctx, _ := offlineContext(t.Context(), true)
+
+	dst := filepath.Join(t.TempDir(), "clone")
+	if err := cloneSource(ctx, papertrail.FragmentSource{Git: src}, dst); err != nil {
+		t.Fatalf("local clone offline: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "changelog.d", "a.yml")); err != nil {
+		t.Fatal(err)
+	}
+}
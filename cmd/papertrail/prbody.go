@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"strings"
+)
+
+// cmdPRBody enforces pr_policy.body on the pull request description, read like pr-fragment
+// reads the pull request (GITHUB_EVENT_PATH, GitLab merge request pipelines, Bitbucket).
+func cmdPRBody(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("pr-body", flag.ContinueOnError)
+	fs.SetOutput(ioDiscard{})
+	manifestPath := fs.String("manifest", "", "optional release config YAML path")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	manifest, err := loadManifestDefault(*manifestPath)
+	if err != nil {
+		return err
+	}
+	rules := manifest.PRPolicy.Body
+	if !rules.Enabled() {
+		return nil
+	}
+	pr, err := currentPullRequest(ctx)
+	if err != nil {
+		return err
+	}
+	if problems := rules.Check(pr.Body); len(problems) > 0 {
+		return errors.New("❌ The PR description does not follow pr_policy.body:\n  - " + strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCmdPRBody(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	if err := os.WriteFile(".papertrail.config.yml", []byte("pr_policy:\n  body:\n    required_sections: ['## Testing']\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	event := filepath.Join(dir, "event.json")
+	t.Setenv("GITHUB_EVENT_PATH", event)
+	for body, want := range map[string]string{
+		`"## Testing\n\nRan the unit tests."`: "",
+		`"Quick fix"`:                         `missing section "## Testing"`,
+		`null`:                                `missing section "## Testing"`,
+	} {
+		if err := os.WriteFile(event, []byte(`{"pull_request":{"number":1,"title":"fix: x","body":`+body+`}}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+		err := cmdPRBody(t.Context(), nil)
+		if want == "" && err != nil {
+			t.Fatalf("%s: %v", body, err)
+		}
+		if want != "" && (err == nil || !strings.Contains(err.Error(), want)) {
+			t.Fatalf("%s: expected %q, got %v", body, want, err)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+// commentPreview keeps a single preview comment, found by previewMarker, on the pull request
+// under test: it is created or updated with the preview of the fragments the PR adds or
+// changes, and deleted when the PR no longer has any. baseRef defaults to the PR's base
+// commit.
+func commentPreview(ctx context.Context, baseRef, fragmentsDir string, manifest releaseManifest) error {
+	pr, err := currentPullRequest(ctx)
+	if err != nil {
+		return err
+	}
+	repo, number := pr.Repo, pr.Number
+	switch {
+	case number == 0:
+		return fmt.Errorf("no pull request in the event; preview --comment runs on pull_request events")
+	case repo == "":
+		return fmt.Errorf("no repository in the event (set GITHUB_REPOSITORY)")
+	}
+	if baseRef == "" {
+		if baseRef = pr.Base; baseRef == "" {
+			return fmt.Errorf("no base commit in the event (use --base-ref)")
+		}
+	}
+	api, err := newForge()
+	if err != nil {
+		return err
+	}
+
+	changed, err := gitChangedFiles(ctx, baseRef)
+	if err != nil {
+		return err
+	}
+	var items []item
+	for _, p := range changed {
+		if !isFragmentPath(p, fragmentsDir, manifest) {
+			continue
+		}
+		its, err := readItems(fragmentFile{FS: hostFS{}, Path: p, Name: p}, manifest)
+		if errors.Is(err, fs.ErrNotExist) {
+			// Deleted in the PR.
+			continue
+		} else if err != nil {
+			return err
+		}
+		items = append(items, its...)
+	}
+	var body string
+	if len(items) > 0 {
+		model, err := withTemplate(newReleaseModel("", "", items, manifest), manifest.Changelog.Templates.Preview)
+		if err != nil {
+			return err
+		}
+		out, err := papertrail.MarkdownRenderer{}.Render(model)
+		if err != nil {
+			return err
+		}
+		body = string(out)
+	}
+
+	comments, err := api.listIssueComments(ctx, repo, number)
+	if err != nil {
+		return err
+	}
+	var existing *issueComment
+	for i := range comments {
+		if strings.Contains(comments[i].Body, previewMarker) {
+			existing = &comments[i]
+			break
+		}
+	}
+	switch {
+	case body == "" && existing == nil:
+		fmt.Fprintf(os.Stdout, "No fragments changed in %s#%d; no preview comment\n", repo, number)
+	case body == "":
+		if err := api.deleteIssueComment(ctx, repo, existing.ID); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stdout, "No fragments changed in %s#%d; deleted the preview comment\n", repo, number)
+	case existing == nil:
+		c, err := api.createIssueComment(ctx, repo, number, body)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stdout, "Created preview comment "+c.HTMLURL)
+	case existing.Body == body:
+		fmt.Fprintln(os.Stdout, "Preview comment is up to date "+existing.HTMLURL)
+	default:
+		c, err := api.updateIssueComment(ctx, repo, existing.ID, body)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stdout, "Updated preview comment "+c.HTMLURL)
+	}
+	return nil
+}
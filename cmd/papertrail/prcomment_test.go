@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCmdPreview_Comment(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{
+		"changelog.d/old.yml": "component: CLI\ntype: fix\nsummary: Already on main.\n",
+	})
+	base := gitOutput(t, dir, "rev-parse", "HEAD")
+	if err := os.WriteFile(filepath.Join(dir, "changelog.d", "new.yml"), []byte("component: CLI\ntype: feature\nsummary: Add comments.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitIn(t, dir, "add", "-A")
+	gitIn(t, dir, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "add fragment")
+
+	event := filepath.Join(t.TempDir(), "event.json")
+	ev := fmt.Sprintf(`{"pull_request": {"number": 7, "base": {"sha": %q}}, "repository": {"full_name": "org/repo"}}`, base)
+	if err := os.WriteFile(event, []byte(ev), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GITHUB_EVENT_PATH", event)
+
+	// A fake issue comments API holding one unrelated comment.
+	comments := map[int64]string{1: "LGTM"}
+	nextID := int64(2)
+	var methods []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		var req issueComment
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/org/repo/issues/7/comments":
+			var list []issueComment
+			for id := int64(1); id < nextID; id++ {
+				if body, ok := comments[id]; ok {
+					list = append(list, issueComment{ID: id, Body: body})
+				}
+			}
+			_ = json.NewEncoder(w).Encode(list)
+		case r.Method == http.MethodPost && r.URL.Path == "/repos/org/repo/issues/7/comments":
+			comments[nextID] = req.Body
+			_ = json.NewEncoder(w).Encode(issueComment{ID: nextID, Body: req.Body})
+			nextID++
+		case r.Method == http.MethodPatch && r.URL.Path == "/repos/org/repo/issues/comments/2":
+			comments[2] = req.Body
+			_ = json.NewEncoder(w).Encode(issueComment{ID: 2, Body: req.Body})
+		case r.Method == http.MethodDelete && r.URL.Path == "/repos/org/repo/issues/comments/2":
+			delete(comments, 2)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("GITHUB_API_URL", srv.URL)
+
+	preview := func() {
+		t.Helper()
+		if _, err := captureStdout(t, func() error { return cmdPreview(t.Context(), []string{"--comment"}) }); err != nil {
+			t.Fatalf("preview --comment: %v", err)
+		}
+	}
+	preview()
+	if body := comments[2]; !strings.HasPrefix(body, previewMarker+"\n") || !strings.Contains(body, "Add comments.") || strings.Contains(body, "Already on main.") {
+		t.Fatalf("created comment:\n%s", body)
+	}
+
+	// A second run updates the same comment instead of adding one.
+	if err := os.WriteFile(filepath.Join(dir, "changelog.d", "new.yml"), []byte("component: CLI\ntype: feature\nsummary: Add sticky comments.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	preview()
+	if len(comments) != 2 || !strings.Contains(comments[2], "Add sticky comments.") || comments[1] != "LGTM" {
+		t.Fatalf("comments after update: %v", comments)
+	}
+
+	// Without changed fragments the stale preview is removed.
+	if err := os.Remove(filepath.Join(dir, "changelog.d", "new.yml")); err != nil {
+		t.Fatal(err)
+	}
+	preview()
+	if _, ok := comments[2]; ok || len(comments) != 1 {
+		t.Fatalf("comments after removing the fragment: %v", comments)
+	}
+	if got := strings.Join(methods, " "); got != "GET POST GET PATCH GET DELETE" {
+		t.Fatalf("API calls: %s", got)
+	}
+
+	if err := cmdPreview(t.Context(), []string{"--comment", "--all"}); err == nil {
+		t.Fatalf("expected --comment with --all to fail")
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+// checkPRTitle enforces pr_policy.title_validation on a pull request title. Titles that are
+// not conventional commits are not checked.
+func checkPRTitle(title string, manifest releaseManifest) error {
+	cc, ok := parseConventionalCommit(title, "")
+	if !ok {
+		return nil
+	}
+	problems := titleProblems(cc, title, manifest)
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("❌ The PR title %q does not follow pr_policy.title_validation:\n  - %s", title, strings.Join(problems, "\n  - "))
+}
+
+// titleProblems lists how a conventional commit title breaks pr_policy.title_validation.
+func titleProblems(cc conventionalCommit, title string, manifest releaseManifest) []string {
+	rules := manifest.PRPolicy.TitleValidation
+	var problems []string
+	if _, ok := rules.ResolveScope(cc.Scope, manifest.ComponentOrder()); cc.Scope != "" && !ok {
+		problems = append(problems, fmt.Sprintf("scope %q is not allowed (%s)", cc.Scope, allowedScopes(rules, manifest)))
+	}
+	if n := utf8.RuneCountInString(strings.TrimSpace(title)); rules.MaxLength > 0 && n > rules.MaxLength {
+		problems = append(problems, fmt.Sprintf("title is %d characters long (at most %d allowed)", n, rules.MaxLength))
+	}
+	if rules.NoTrailingPeriod && strings.HasSuffix(cc.Description, ".") {
+		problems = append(problems, "description ends with a period")
+	}
+	if r, _ := utf8.DecodeRuneInString(cc.Description); unicode.IsLetter(r) {
+		switch {
+		case rules.SubjectCase == "lower" && !unicode.IsLower(r):
+			problems = append(problems, "description must start with a lowercase letter")
+		case rules.SubjectCase == "sentence" && !unicode.IsUpper(r):
+			problems = append(problems, "description must start with an uppercase letter")
+		}
+	}
+	return problems
+}
+
+// checkBreakingTitle enforces pr_policy.title_validation.breaking_fragment: a title with the
+// breaking marker ("feat!: ...") needs a fragment added in the PR that bumps the major
+// version, so the title and the release version agree.
+func checkBreakingTitle(pr pullRequest, changed []string, fragmentsDir string, manifest releaseManifest) error {
+	if !manifest.PRPolicy.TitleValidation.BreakingFragment {
+		return nil
+	}
+	cc, ok := parseConventionalCommit(pr.Title, "")
+	if !ok || !cc.Breaking {
+		return nil
+	}
+	for _, p := range changed {
+		if !isFragmentPath(p, fragmentsDir, manifest) || strings.Contains(p, "/archived/") {
+			continue
+		}
+		fragments, err := papertrail.ReadFragments(hostFS{}, p, manifest)
+		if err != nil {
+			// Deleted in the PR, or already reported by check.
+			continue
+		}
+		for _, f := range fragments {
+			if commitBump(f, manifest) == bumpMajor {
+				return nil
+			}
+		}
+	}
+	return errors.New("❌ The PR title marks a breaking change (!), but no fragment added in this PR bumps the major version\n💡 Add a fragment whose type bumps the major version (see versioning.rules), or drop the ! from the title")
+}
+
+// allowedScopes describes the valid scopes for a scope error: the allowed scopes, then the
+// aliases.
+func allowedScopes(rules papertrail.PRTitleRules, manifest releaseManifest) string {
+	msg := "allowed scopes: " + strings.Join(rules.Scopes(manifest.ComponentOrder()), ", ")
+	if len(rules.ScopeAliases) > 0 {
+		aliases := make([]string, 0, len(rules.ScopeAliases))
+		for alias, target := range rules.ScopeAliases {
+			aliases = append(aliases, alias+" → "+target)
+		}
+		sort.Strings(aliases)
+		msg += "; aliases: " + strings.Join(aliases, ", ")
+	}
+	return msg
+}
+
+// looseTitleRE matches near-conventional titles: any case, stray spaces, and the breaking
+// marker before or after the scope, as in "Feat!(api) : add x".
+var looseTitleRE = regexp.MustCompile(`^([A-Za-z][A-Za-z-]*)\s*(!?)\s*(?:\(\s*([^)]*?)\s*\))?\s*(!?)\s*:\s*(.+)$`)
+
+// suggestPRTitle returns the title rewritten as a conventional commit that follows
+// pr_policy.title_validation where it can be fixed mechanically: the type lowercased and
+// resolved from a fragment type or alias (see titleType), a scope alias replaced by its
+// scope, whitespace trimmed, the breaking marker after the scope, and the description's
+// trailing period and first letter per the rules. ok is false when the title cannot be read
+// or needs no change.
+func suggestPRTitle(title string, manifest releaseManifest) (suggested string, ok bool) {
+	m := looseTitleRE.FindStringSubmatch(strings.TrimSpace(title))
+	if m == nil {
+		return "", false
+	}
+	rules := manifest.PRPolicy.TitleValidation
+	desc := strings.TrimSpace(m[5])
+	if rules.NoTrailingPeriod {
+		desc = strings.TrimSpace(strings.TrimRight(desc, "."))
+	}
+	if r, n := utf8.DecodeRuneInString(desc); unicode.IsLetter(r) {
+		switch rules.SubjectCase {
+		case "lower":
+			desc = string(unicode.ToLower(r)) + desc[n:]
+		case "sentence":
+			desc = string(unicode.ToUpper(r)) + desc[n:]
+		}
+	}
+	if desc == "" {
+		return "", false
+	}
+	suggested = titleType(strings.ToLower(m[1]), manifest)
+	if scope := m[3]; scope != "" {
+		if resolved, ok := rules.ResolveScope(scope, manifest.ComponentOrder()); ok {
+			scope = resolved
+		}
+		suggested += "(" + scope + ")"
+	}
+	if m[2] != "" || m[4] != "" {
+		suggested += "!"
+	}
+	suggested += ": " + desc
+	return suggested, suggested != title
+}
+
+// titleType resolves a title type that is not a conventional commit type but names a
+// fragment type or alias (such as "feature") to the conventional type mapped to that
+// fragment type, preferring one spelled like it.
+func titleType(t string, manifest releaseManifest) string {
+	types, err := commitTypes(manifest, nil)
+	if err != nil {
+		return t
+	}
+	if _, ok := types[t]; ok || !typeAllowed(manifest, t) {
+		return t
+	}
+	canonical := manifest.CanonicalType(t)
+	var candidates []string
+	for ct, ft := range types {
+		if ft != "" && manifest.CanonicalType(ft) == canonical {
+			candidates = append(candidates, ct)
+		}
+	}
+	if len(candidates) == 0 {
+		return t
+	}
+	sort.Strings(candidates)
+	for _, ct := range candidates {
+		if strings.EqualFold(ct, canonical) {
+			return ct
+		}
+	}
+	return candidates[0]
+}
+
+// withTitleSuggestion adds the suggested title (see suggestPRTitle) to a pr-fragment failure
+// about the title, and sets it as the suggested_title step output for a workflow to post.
+func withTitleSuggestion(err error, title string, manifest releaseManifest) error {
+	suggested, ok := suggestPRTitle(title, manifest)
+	if !ok {
+		return err
+	}
+	if oerr := writeActionsOutputs(actionsOutput{"suggested_title", suggested}); oerr != nil {
+		return errors.Join(err, oerr)
+	}
+	return fmt.Errorf("%w\n💡 Suggested title: %s", err, suggested)
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+func TestCheckPRTitle_Scopes(t *testing.T) {
+	t.Parallel()
+
+	manifest, err := papertrail.ParseManifest([]byte(`changelog:
+  components: [CLI, Server/Auth]
+pr_policy:
+  title_validation:
+    allowed_scopes: [api, " deps "]
+    component_scopes: true
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, title := range []string{
+		"feat(api): add x",
+		"fix(DEPS): bump y",
+		"fix(cli): handle z",
+		"fix(server/auth): handle z",
+		"fix: no scope",
+		"Not conventional (web)",
+	} {
+		if err := checkPRTitle(title, manifest); err != nil {
+			t.Fatalf("%q: %v", title, err)
+		}
+	}
+	err = checkPRTitle("feat(web): add x", manifest)
+	if err == nil || !strings.Contains(err.Error(), `scope "web" is not allowed (allowed scopes: api, deps, CLI, Server/Auth)`) {
+		t.Fatalf("expected a scope error, got %v", err)
+	}
+
+	// Without component_scopes only the listed scopes are allowed.
+	manifest.PRPolicy.TitleValidation.ComponentScopes = false
+	if err := checkPRTitle("fix(cli): handle z", manifest); err == nil {
+		t.Fatal("expected a scope error for a component scope")
+	}
+
+	if _, err := papertrail.ParseManifest([]byte("pr_policy:\n  title_validation:\n    allowed_scopes: [\"\"]\n")); err == nil || !strings.Contains(err.Error(), "pr_policy.title_validation.allowed_scopes") {
+		t.Fatalf("expected an empty scope error, got %v", err)
+	}
+}
+
+func TestCheckPRTitle_ScopeAliases(t *testing.T) {
+	t.Parallel()
+
+	manifest, err := papertrail.ParseManifest([]byte(`changelog:
+  components: [CLI]
+pr_policy:
+  title_validation:
+    allowed_scopes: [api]
+    component_scopes: true
+    scope_aliases:
+      APIs: api
+      cmd: cli
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, title := range []string{"feat(apis): add x", "fix(CMD): handle y"} {
+		if err := checkPRTitle(title, manifest); err != nil {
+			t.Fatalf("%q: %v", title, err)
+		}
+	}
+	err = checkPRTitle("feat(web): add x", manifest)
+	if err == nil || !strings.Contains(err.Error(), `scope "web" is not allowed (allowed scopes: api, CLI; aliases: apis → api, cmd → CLI)`) {
+		t.Fatalf("expected a scope error listing the scopes and aliases, got %v", err)
+	}
+	if got, ok := suggestPRTitle("feat(APIs) !: add x", manifest); !ok || got != "feat(api)!: add x" {
+		t.Fatalf("suggestion: %q, %v", got, ok)
+	}
+
+	for _, bad := range []string{"scope_aliases: {apis: web}", "scope_aliases: {\"\": api}"} {
+		if _, err := papertrail.ParseManifest([]byte("pr_policy:\n  title_validation:\n    allowed_scopes: [api]\n    " + bad + "\n")); err == nil || !strings.Contains(err.Error(), "pr_policy.title_validation.scope_aliases") {
+			t.Fatalf("%s: expected a config error, got %v", bad, err)
+		}
+	}
+}
+
+func TestCheckPRTitle_Rules(t *testing.T) {
+	t.Parallel()
+
+	manifest, err := papertrail.ParseManifest([]byte("pr_policy:\n  title_validation:\n    max_length: 30\n    no_trailing_period: true\n    subject_case: Lower\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, title := range []string{"fix: handle x", "fix: `--flag` works again", "Not conventional."} {
+		if err := checkPRTitle(title, manifest); err != nil {
+			t.Fatalf("%q: %v", title, err)
+		}
+	}
+	err = checkPRTitle("fix(cli): Handle a very long title.", manifest)
+	for _, want := range []string{
+		"title is 35 characters long (at most 30 allowed)",
+		"description ends with a period",
+		"description must start with a lowercase letter",
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q, got %v", want, err)
+		}
+	}
+
+	manifest.PRPolicy.TitleValidation.SubjectCase = "sentence"
+	if err := checkPRTitle("fix: handle x", manifest); err == nil || !strings.Contains(err.Error(), "description must start with an uppercase letter") {
+		t.Fatalf("expected a sentence case error, got %v", err)
+	}
+
+	for _, bad := range []string{"max_length: -1", "subject_case: title"} {
+		if _, err := papertrail.ParseManifest([]byte("pr_policy:\n  title_validation:\n    " + bad + "\n")); err == nil || !strings.Contains(err.Error(), "pr_policy.title_validation") {
+			t.Fatalf("%s: expected a config error, got %v", bad, err)
+		}
+	}
+}
+
+func TestCheckBreakingTitle(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.MkdirAll("changelog.d", 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, body := range map[string]string{
+		"changelog.d/fix.yml":      "component: CLI\ntype: fix\nsummary: Fix a.\n",
+		"changelog.d/breaking.yml": "component: CLI\ntype: breaking\nsummary: Drop b.\n",
+	} {
+		if err := os.WriteFile(filepath.FromSlash(name), []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	manifest, err := papertrail.ParseManifest([]byte("versioning:\n  rules:\n    breaking: major\n    fix: patch\npr_policy:\n  title_validation:\n    breaking_fragment: true\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, title := range []string{"feat!: drop b", "feat(cli)!: drop b"} {
+		err := checkBreakingTitle(pullRequest{Title: title}, []string{"changelog.d/fix.yml"}, "changelog.d", manifest)
+		if err == nil || !strings.Contains(err.Error(), "no fragment added in this PR bumps the major version") {
+			t.Fatalf("%q: expected a breaking fragment error, got %v", title, err)
+		}
+		if err := checkBreakingTitle(pullRequest{Title: title}, []string{"changelog.d/fix.yml", "changelog.d/breaking.yml"}, "changelog.d", manifest); err != nil {
+			t.Fatalf("%q: %v", title, err)
+		}
+	}
+	if err := checkBreakingTitle(pullRequest{Title: "fix: a"}, []string{"changelog.d/fix.yml"}, "changelog.d", manifest); err != nil {
+		t.Fatalf("a title without ! needs no breaking fragment: %v", err)
+	}
+	manifest.PRPolicy.TitleValidation.BreakingFragment = false
+	if err := checkBreakingTitle(pullRequest{Title: "feat!: drop b"}, []string{"changelog.d/fix.yml"}, "changelog.d", manifest); err != nil {
+		t.Fatalf("breaking_fragment is off: %v", err)
+	}
+}
+
+func TestSuggestPRTitle(t *testing.T) {
+	t.Parallel()
+
+	manifest, err := papertrail.ParseManifest([]byte("types:\n  aliases:\n    bugfix: fix\npr_policy:\n  title_validation:\n    no_trailing_period: true\n    subject_case: lower\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for title, want := range map[string]string{
+		"Feat!(api) : Add x.":     "feat(api)!: add x",
+		"  feature(cli):add x ":   "feat(cli): add x",
+		"bugfix: Handle y":        "fix: handle y",
+		"fix( api ) !: handle z.": "fix(api)!: handle z",
+		"perf: `--fast` is fast":  "",
+		"Not conventional":        "",
+		"fix: ":                   "",
+	} {
+		got, ok := suggestPRTitle(title, manifest)
+		if want == "" {
+			if ok {
+				t.Fatalf("%q: unexpected suggestion %q", title, got)
+			}
+			continue
+		}
+		if !ok || got != want {
+			t.Fatalf("%q: got %q, %v; want %q", title, got, ok, want)
+		}
+		if err := checkPRTitle(got, manifest); err != nil {
+			t.Fatalf("suggested title %q fails: %v", got, err)
+		}
+	}
+}
+
+func TestWithTitleSuggestion(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "output")
+	t.Setenv("GITHUB_OUTPUT", out)
+
+	manifest, err := papertrail.ParseManifest([]byte("pr_policy:\n  title_validation:\n    subject_case: lower\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	title := "Fix: Handle x"
+	err = withTitleSuggestion(checkPRTitle(title, manifest), title, manifest)
+	if err == nil || !strings.Contains(err.Error(), "description must start with a lowercase letter") || !strings.HasSuffix(err.Error(), "\n💡 Suggested title: fix: handle x") {
+		t.Fatalf("got %v", err)
+	}
+	if b, _ := os.ReadFile(out); string(b) != "suggested_title=fix: handle x\n" {
+		t.Fatalf("step output: %q", b)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+)
+
+// archivedItems reads the fragments merge archived under dir, for deprecations whose
+// removal is due. A missing archive has none; an archived file that no longer validates
+// against the manifest is an error naming it.
+func archivedItems(fsys fs.FS, dir string, manifest releaseManifest) ([]item, error) {
+	var items []item
+	err := fs.WalkDir(fsys, dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == dir && errors.Is(err, fs.ErrNotExist) {
+				return fs.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() || !manifest.IsFragmentFile(p) {
+			return nil
+		}
+		its, err := readItems(fragmentFile{FS: fsys, Path: p, Name: p}, manifest)
+		if err != nil {
+			return err
+		}
+		items = append(items, its...)
+		return nil
+	})
+	return items, err
+}
+
+// dueRemovals returns the deprecations among items whose removal_version the release from
+// base to next reaches: base < removal_version <= next. Later releases do not warn again.
+func dueRemovals(items []item, base, next semver) []item {
+	var due []item
+	for _, it := range items {
+		if it.Frag.RemovalVersion == "" {
+			continue
+		}
+		removal, err := parseSemver(it.Frag.RemovalVersion)
+		if err == nil && base.Compare(removal) < 0 && next.Compare(removal) >= 0 {
+			due = append(due, it)
+		}
+	}
+	return due
+}
+
+// writeDueRemovals warns about each deprecation whose removal next reaches.
+func writeDueRemovals(w io.Writer, due []item, next semver) {
+	for _, it := range due {
+		fmt.Fprintf(w, "warning: %s reaches the removal version (%s) of %s (%s): %s\n",
+			next, it.Frag.RemovalVersion, it.Path, it.Frag.Component, ensurePeriod(it.Frag.Summary))
+	}
+}
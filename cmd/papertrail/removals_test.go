@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestDueRemovals(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"changelog.d/archived/v1.2.0/old.yml": {Data: []byte("component: CLI\ntype: deprecation\nsummary: Deprecate --legacy\nremoval_version: v2.0.0\n")},
+	}
+	var m releaseManifest
+	archived, err := archivedItems(fsys, "changelog.d/archived", m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archived) != 1 {
+		t.Fatalf("archived = %+v", archived)
+	}
+	if missing, err := archivedItems(fsys, "nope", m); err != nil || len(missing) != 0 {
+		t.Fatalf("missing archive: %+v, %v", missing, err)
+	}
+
+	pending := item{Path: "changelog.d/new.yml", Frag: fragment{Component: "API", Type: "DEPRECATION", Summary: "Deprecate v1.", RemovalVersion: "v3.0.0"}}
+	items := append([]item{pending}, archived...)
+	for _, tc := range []struct {
+		base, next string
+		want       int
+	}{
+		{"v1.2.0", "v1.3.0", 0},
+		{"v1.3.0", "v2.0.0-rc.1", 0},
+		{"v1.3.0", "v2.0.0", 1},
+		{"v1.3.0", "v3.1.0", 2},
+		// Releases after a removal version do not warn about it again.
+		{"v2.0.0", "v2.1.0", 0},
+		{"v2.0.0", "v3.0.0", 1},
+		{"v3.4.0", "v3.5.0", 0},
+	} {
+		base, err := parseSemver(tc.base)
+		if err != nil {
+			t.Fatal(err)
+		}
+		next, err := parseSemver(tc.next)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := dueRemovals(items, base, next); len(got) != tc.want {
+			t.Errorf("dueRemovals(%s, %s) = %+v, want %d", tc.base, tc.next, got, tc.want)
+		}
+	}
+
+	var buf bytes.Buffer
+	base, _ := parseSemver("v1.3.0")
+	next, _ := parseSemver("v2.0.0")
+	writeDueRemovals(&buf, dueRemovals(items, base, next), next)
+	want := "warning: v2.0.0 reaches the removal version (v2.0.0) of changelog.d/archived/v1.2.0/old.yml (CLI): Deprecate --legacy.\n"
+	if buf.String() != want {
+		t.Fatalf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestArchivedItems_InvalidFragment(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"changelog.d/archived/v1.2.0/bad.yml": {Data: []byte("component: CLI\n")},
+	}
+	_, err := archivedItems(fsys, "changelog.d/archived", releaseManifest{})
+	if err == nil || !strings.Contains(err.Error(), "changelog.d/archived/v1.2.0/bad.yml") {
+		t.Fatalf("expected an error naming the archived fragment, got %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+// newReleaseModel groups items into a papertrail.ReleaseModel in the manifest's style. The
+// renderers and their registry live in the library, so programs embedding it can add
+// formats; --format flags look them up with lookupRenderer.
+func newReleaseModel(version, date string, items []item, manifest releaseManifest) papertrail.ReleaseModel {
+	return papertrail.NewReleaseModel(version, date, itemFragments(items), manifest)
+}
+
+// withTemplate sets the model's template to the changelog.templates file at path, if any.
+func withTemplate(m papertrail.ReleaseModel, path string) (papertrail.ReleaseModel, error) {
+	if path == "" {
+		return m, nil
+	}
+	text, err := readFile(hostFS{}, path)
+	if err != nil {
+		return m, err
+	}
+	if m.Template, err = papertrail.ParseReleaseTemplate(path, string(text)); err != nil {
+		return m, err
+	}
+	return m, nil
+}
+
+func lookupRenderer(name string) (papertrail.Renderer, error) {
+	r, err := papertrail.Lookup(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --format %q (expected %s)", name, strings.Join(papertrail.Formats(), "|"))
+	}
+	return r, nil
+}
+
+// writeComponentGroups renders groups under component headings using the given heading
+// prefix (e.g. "###") and style. The output always ends with one blank line.
+func writeComponentGroups(buf *bytes.Buffer, groups []componentGroup, heading string, style papertrail.Style) {
+	buf.Write(papertrail.RenderGroups(libraryGroups(groups), heading, style))
+}
+
+func libraryGroups(groups []componentGroup) []papertrail.ComponentGroup {
+	lib := make([]papertrail.ComponentGroup, len(groups))
+	for i, g := range groups {
+		lib[i] = papertrail.ComponentGroup{Name: g.Name, Fragments: itemFragments(g.Items)}
+	}
+	return lib
+}
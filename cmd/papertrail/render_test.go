@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+func TestLookupRenderer(t *testing.T) {
+	t.Parallel()
+
+	if _, err := lookupRenderer("asciidoc"); err == nil || !strings.Contains(err.Error(), "invalid --format \"asciidoc\" (expected markdown|html|json)") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestChangelogTemplates(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{
+		".papertrail.config.yml": "changelog:\n  templates:\n    release: .github/release.tmpl\n    release_notes: .github/notes.tmpl\n    preview: .github/preview.tmpl\n",
+		".github/release.tmpl":   "## Release {{ .Version }} ({{ .Date }})\n{{ range .Fragments }}\n* {{ .Component }} / {{ type .Type }}: {{ period .Summary }}{{ range .Refs }} {{ . }}{{ end }}{{ end }}\n",
+		".github/notes.tmpl":     "{{ range .Fragments }}- {{ .Summary }}\n{{ end }}",
+		".github/preview.tmpl":   "Pending: {{ len .Fragments }}\n",
+		"CHANGELOG.md":           "# Changelog\n\n## v1.0.0 (2026-01-01)\n\n- old\n",
+		"changelog.d/a.yml":      "component: CLI\ntype: fix\nsummary: Fix a crash\nrefs: ['#7']\n",
+	})
+
+	out, err := captureStdout(t, func() error { return cmdPreview(t.Context(), []string{"--all"}) })
+	if err != nil || out != previewMarker+"\nPending: 1\n\n" {
+		t.Fatalf("preview = %q, %v", out, err)
+	}
+	// Other formats ignore the markdown templates.
+	if out, err := captureStdout(t, func() error { return cmdPreview(t.Context(), []string{"--all", "--format", "json"}) }); err != nil || !strings.Contains(out, `"summary": "Fix a crash."`) {
+		t.Fatalf("json preview = %q, %v", out, err)
+	}
+
+	if err := cmdMerge(t.Context(), []string{"--version", "v1.0.1", "--date", "2026-02-01", "--release-notes-out", "notes.md"}); err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	changelog, _ := os.ReadFile(filepath.Join(dir, "CHANGELOG.md"))
+	if !strings.Contains(string(changelog), "## Release v1.0.1 (2026-02-01)\n\n* CLI / fix: Fix a crash. #7\n\n## v1.0.0") {
+		t.Fatalf("changelog:\n%s", changelog)
+	}
+	notes, _ := os.ReadFile(filepath.Join(dir, "notes.md"))
+	if string(notes) != "- Fix a crash\n" {
+		t.Fatalf("release notes: %q", notes)
+	}
+	if err := cmdFmt(t.Context(), nil); err == nil {
+		t.Fatalf("expected fmt to refuse templated changelogs")
+	}
+}
+
+func TestRenderPreview_GroupByType(t *testing.T) {
+	t.Parallel()
+
+	m, err := papertrail.ParseManifest([]byte("changelog:\n  group_by: type\n  type_headings:\n    fix: Fixed\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := []item{{Path: "changelog.d/a.yml", Frag: fragment{Component: "CLI", Type: "FIX", Summary: "Fix a"}}}
+	got, err := renderPreview(items, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := previewMarker + "\n### Changelog preview\n\n#### Fixed\n\n- **CLI**: Fix a.\n\n"; string(got) != want {
+		t.Fatalf("got:\n%q\nwant:\n%q", got, want)
+	}
+
+	doc := "## v1.0.0 (2026-01-01)\n\n### Fixed\n\n- **CLI**: Fix a.\n"
+	if problems := changelogProblems(doc, m); len(problems) != 0 {
+		t.Fatalf("unexpected problems: %q", problems)
+	}
+	if got := mustFormatChangelog(t, doc, m); got != doc {
+		t.Fatalf("fmt changed a section grouped by type:\n%q", got)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"io"
+	"sort"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+// sarifRuleDescriptions describe the check rules in SARIF output. Rules missing here are
+// described by their ID.
+var sarifRuleDescriptions = map[string]string{
+	papertrail.RuleInvalidYAML:           "Fragment is not valid YAML or front matter",
+	papertrail.RuleMissingField:          "Fragment is missing a required field",
+	papertrail.RuleInvalidAuthor:         "Fragment author is not a GitHub @-handle or a one-line name",
+	papertrail.RuleInvalidComponent:      "Fragment component is nested more than one level or has an empty part",
+	papertrail.RuleInvalidRemovalVersion: "Fragment removal_version is not a release version like v2.0.0",
+	papertrail.RuleUnknownComponent:      "Fragment component is not in the manifest",
+	papertrail.RuleUnknownType:           "Fragment type is not in the manifest",
+	papertrail.RuleSummaryFormat:         "Fragment summary breaks the changelog.summary rules",
+	papertrail.RuleMarkdownSyntax:        "Fragment summary has broken inline Markdown",
+	papertrail.RuleRefFormat:             "Fragment refs break the refs rules",
+	ruleReadError:                        "Fragment file could not be read",
+}
+
+// sarifLog is a SARIF 2.1.0 log with the subset of properties check fills in.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+}
+
+// writeSARIF writes `check --format sarif` output: one result per issue, with a rule per
+// issue category and the issue's file, line, and column.
+func writeSARIF(w io.Writer, results []checkResult) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "papertrail",
+			InformationURI: "https://github.com/bnprtr/papertrail",
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+	ruleIndex := map[string]int{}
+	sorted := append([]checkResult(nil), results...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	for _, res := range sorted {
+		uri := res.Path
+		if uri == "" {
+			uri = res.Name
+		}
+		for _, is := range res.Issues {
+			idx, ok := ruleIndex[is.Rule]
+			if !ok {
+				desc := sarifRuleDescriptions[is.Rule]
+				if desc == "" {
+					desc = is.Rule
+				}
+				idx = len(run.Tool.Driver.Rules)
+				ruleIndex[is.Rule] = idx
+				run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: is.Rule, ShortDescription: sarifMessage{Text: desc}})
+			}
+			level := "error"
+			if is.Severity == papertrail.SeverityWarning {
+				level = "warning"
+			}
+			line, col := res.issueLine(is)
+			run.Results = append(run.Results, sarifResult{
+				RuleID:    is.Rule,
+				RuleIndex: idx,
+				Level:     level,
+				Message:   sarifMessage{Text: is.Message},
+				Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: uri},
+					Region:           sarifRegion{StartLine: line, StartColumn: col},
+				}}},
+			})
+		}
+	}
+	return writeJSON(w, sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCmdCheck_SARIF(t *testing.T) {
+	initGitRepo(t, map[string]string{
+		".papertrail.config.yml":   "types:\n  order: [fix]\nvalidation:\n  severity:\n    unknown_type: warning\n",
+		"changelog.d/ok.yml":       "component: CLI\ntype: fix\nsummary: Fine.\n",
+		"changelog.d/bad_type.yml": "component: CLI\ntype: chore\nsummary: Tidy up.\n",
+		"changelog.d/missing.yml":  "component: CLI\ntype: fix\n",
+	})
+	t.Setenv("GITHUB_ACTIONS", "true")
+
+	out, err := captureStdout(t, func() error { return cmdCheck(t.Context(), []string{"--format", "sarif"}) })
+	if err == nil || !strings.Contains(err.Error(), "1 error(s), 1 warning(s)") {
+		t.Fatalf("expected check to fail, got %v", err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal([]byte(out), &log); err != nil {
+		t.Fatalf("invalid SARIF JSON: %v\n%s", err, out)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("SARIF log: %+v", log)
+	}
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != 2 || len(run.Results) != 2 {
+		t.Fatalf("rules %+v, results %+v", run.Tool.Driver.Rules, run.Results)
+	}
+	r := run.Results[0]
+	loc := r.Locations[0].PhysicalLocation
+	if r.RuleID != "unknown_type" || r.Level != "warning" || run.Tool.Driver.Rules[r.RuleIndex].ID != r.RuleID ||
+		loc.ArtifactLocation.URI != "changelog.d/bad_type.yml" || loc.Region.StartLine != 2 || loc.Region.StartColumn != 7 {
+		t.Fatalf("first result: %+v", r)
+	}
+	if r := run.Results[1]; r.RuleID != "missing_field" || r.Level != "error" || r.Locations[0].PhysicalLocation.Region.StartLine != 1 {
+		t.Fatalf("second result: %+v", r)
+	}
+	if strings.Contains(out, "::") {
+		t.Fatalf("annotations mixed into SARIF output:\n%s", out)
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+// bumpKind is the library's Bump under the CLI's historical names.
+type bumpKind = papertrail.Bump
+
+const (
+	bumpPatch = papertrail.Patch
+	bumpMinor = papertrail.Minor
+	bumpMajor = papertrail.Major
+)
+
+// semver is the library's Version under the CLI's historical name.
+type semver = papertrail.Version
+
+func parseSemver(s string) (semver, error) {
+	return papertrail.ParseVersion(s)
+}
+
+func bumpSemver(base string, bump bumpKind) (string, error) {
+	v, err := parseSemver(base)
+	if err != nil {
+		return "", fmt.Errorf("invalid semver %q: %w", base, err)
+	}
+	return v.Bump(bump).String(), nil
+}
@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestSemverBump_Prerelease(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		base string
+		kind bumpKind
+		want string
+	}{
+		{"v1.2.3-rc.1", bumpPatch, "v1.2.3"},
+		{"v1.3.0-rc.1", bumpMinor, "v1.3.0"},
+		{"v1.3.1-rc.1", bumpMinor, "v1.4.0"},
+		{"v2.0.0-beta", bumpMajor, "v2.0.0"},
+		{"v2.1.0-beta", bumpMajor, "v3.0.0"},
+		{"v1.2.3+build.7", bumpPatch, "v1.2.4"},
+	}
+	for _, c := range cases {
+		got, err := bumpSemver(c.base, c.kind)
+		if err != nil {
+			t.Fatalf("%s: %v", c.base, err)
+		}
+		if got != c.want {
+			t.Fatalf("bump %s: got %s, want %s", c.base, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+// cmdShow prints the changelog section of a released version (or "latest", the top release
+// section) as it appears in the changelog, heading included unless --body-only is set.
+func cmdShow(_ context.Context, args []string) error {
+	fs := flag.NewFlagSet("show", flag.ContinueOnError)
+	fs.SetOutput(ioDiscard{})
+	changelogPath := fs.String("changelog", "CHANGELOG.md", "changelog path")
+	bodyOnly := fs.Bool("body-only", false, "leave out the section heading (e.g. for a GitHub Release body)")
+	out := fs.String("out", "", "write the section to this path (default: stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("show requires exactly one version (e.g. v1.2.3, or latest)")
+	}
+
+	fsys := hostFS{}
+	changelog, err := readFile(fsys, *changelogPath)
+	if err != nil {
+		return err
+	}
+	section, err := showSection(string(changelog), fs.Arg(0), !*bodyOnly)
+	if err != nil {
+		return fmt.Errorf("%s: %w", *changelogPath, err)
+	}
+	if *out != "" {
+		return fsys.WriteFile(*out, []byte(section), 0644)
+	}
+	_, _ = os.Stdout.WriteString(section)
+	return nil
+}
+
+// showSection returns version's section of the changelog, with its heading line when heading
+// is set. "latest" is the first release section; a version without its leading "v" is found
+// too.
+func showSection(changelog, version string, heading bool) (string, error) {
+	if version == "latest" {
+		top, _, ok := firstReleaseHeading(changelog)
+		if !ok {
+			return "", fmt.Errorf("no release sections")
+		}
+		version = top
+	}
+	body, ok := papertrail.ExtractSection(changelog, version)
+	if !ok && !strings.HasPrefix(version, "v") {
+		if body, ok = papertrail.ExtractSection(changelog, "v"+version); ok {
+			version = "v" + version
+		}
+	}
+	if !ok {
+		return "", fmt.Errorf("no section for %s", version)
+	}
+	if !heading {
+		return body, nil
+	}
+	h := releaseHeadingLine(changelog, version)
+	if body == "" {
+		return h + "\n", nil
+	}
+	return h + "\n\n" + body, nil
+}
+
+// releaseHeadingLine returns the changelog's heading line for version, or a plain
+// "## <version>" heading if it has none in a recognized shape.
+func releaseHeadingLine(changelog, version string) string {
+	for _, line := range strings.Split(changelog, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if v, _, ok := papertrail.ParseReleaseHeading(line); ok && v == version {
+			return line
+		}
+	}
+	return "## " + version
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestShowSection(t *testing.T) {
+	t.Parallel()
+
+	changelog := "# Changelog\n\n## [Unreleased]\n\n## [v1.2.0] - 2026-01-02\n\n### Fixed\n\n- Fix a\n\n## [v1.1.0] - 2026-01-01\n\n[v1.2.0]: https://example.com/compare/v1.1.0...v1.2.0\n"
+	for _, tc := range []struct {
+		version string
+		heading bool
+		want    string
+	}{
+		{"latest", true, "## [v1.2.0] - 2026-01-02\n\n### Fixed\n\n- Fix a\n"},
+		{"1.2.0", false, "### Fixed\n\n- Fix a\n"},
+		{"v1.1.0", true, "## [v1.1.0] - 2026-01-01\n"},
+	} {
+		got, err := showSection(changelog, tc.version, tc.heading)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.version, err)
+		}
+		if got != tc.want {
+			t.Fatalf("%s: got\n%q\nwant\n%q", tc.version, got, tc.want)
+		}
+	}
+	if _, err := showSection(changelog, "v0.9.0", true); err == nil || !strings.Contains(err.Error(), "no section for v0.9.0") {
+		t.Fatalf("expected a missing section error, got %v", err)
+	}
+	if _, err := showSection("# Changelog\n", "latest", true); err == nil {
+		t.Fatal("expected an error without release sections")
+	}
+}
+
+func TestCmdShow_Out(t *testing.T) {
+	t.Chdir(t.TempDir())
+	fsys := hostFS{}
+	if err := fsys.WriteFile("CHANGELOG.md", []byte("# Changelog\n\n## v0.2.0 (2026-01-02)\n\n### CLI\n\n- Add b\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmdShow(t.Context(), []string{"--body-only", "--out", "notes.md", "v0.2.0"}); err != nil {
+		t.Fatal(err)
+	}
+	b, err := readFile(fsys, "notes.md")
+	if err != nil || string(b) != "### CLI\n\n- Add b\n" {
+		t.Fatalf("unexpected notes %q (%v)", b, err)
+	}
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+// fragmentFile is a discovered fragment file in FS. Name is used in messages; External marks
+// fragments from manifest sources, which are rendered but never archived by merge.
+type fragmentFile struct {
+	FS       fs.FS
+	Path     string
+	Name     string
+	External bool
+}
+
+func (ff fragmentFile) read() ([]byte, error) {
+	return readFile(ff.FS, ff.Path)
+}
+
+// remoteSourcesUsage documents the --remote-sources flag of the commands that only read
+// fragments.
+const remoteSourcesUsage = "also fetch the git and url fragment sources of the manifest (merge and cut always do)"
+
+// discoverFragments lists fragments under dir in fsys, changesets (fragments.changesets), and
+// the manifest sources. Directory sources are read from fsys. Remote (git and url) sources
+// are skipped unless remote is set, as merge and cut set it; they are fetched into temporary
+// host directories, and the returned cleanup func removes them once the files have been read.
+func discoverFragments(ctx context.Context, fsys fs.FS, dir string, manifest releaseManifest, remote bool) ([]fragmentFile, func(), error) {
+	var tmpDirs []string
+	cleanup := func() {
+		for _, d := range tmpDirs {
+			_ = os.RemoveAll(d)
+		}
+	}
+
+	local, err := papertrail.FragmentFiles(fsys, dir, manifest)
+	if err != nil {
+		return nil, cleanup, err
+	}
+	files := make([]fragmentFile, 0, len(local))
+	for _, p := range local {
+		files = append(files, fragmentFile{FS: fsys, Path: p, Name: p})
+	}
+	// Changesets are pending like local fragments, so merge archives them too.
+	changesets, err := papertrail.ChangesetFiles(fsys, manifest)
+	if err != nil {
+		return nil, cleanup, err
+	}
+	for _, p := range changesets {
+		files = append(files, fragmentFile{FS: fsys, Path: p, Name: p})
+	}
+
+	for i, src := range manifest.Fragments.Sources {
+		// label prefixes fragment names from remote sources so messages don't show temp paths.
+		var root, label string
+		var srcFS fs.FS = hostFS{}
+		switch {
+		case strings.TrimSpace(src.Dir) != "":
+			root = strings.TrimSpace(src.Dir)
+			srcFS = fsys
+		case !remote:
+			continue
+		case strings.TrimSpace(src.Git) != "":
+			tmp, err := os.MkdirTemp("", "papertrail-source-")
+			if err != nil {
+				return nil, cleanup, err
+			}
+			tmpDirs = append(tmpDirs, tmp)
+			if err := cloneSource(ctx, src, tmp); err != nil {
+				return nil, cleanup, fmt.Errorf("fragments.sources[%d]: %w", i, err)
+			}
+			sub := src.Path
+			if sub == "" {
+				sub = "changelog.d"
+			}
+			root = filepath.Join(tmp, filepath.FromSlash(sub))
+			label = strings.TrimSpace(src.Git)
+			if src.Ref != "" {
+				label += "@" + src.Ref
+			}
+			label += ":" + sub
+		default:
+			tmp, err := os.MkdirTemp("", "papertrail-source-")
+			if err != nil {
+				return nil, cleanup, err
+			}
+			tmpDirs = append(tmpDirs, tmp)
+			if err := fetchTarball(ctx, strings.TrimSpace(src.URL), tmp); err != nil {
+				return nil, cleanup, fmt.Errorf("fragments.sources[%d]: %w", i, err)
+			}
+			root = filepath.Join(tmp, filepath.FromSlash(src.Path))
+			label = strings.TrimSpace(src.URL)
+			if src.Path != "" {
+				label += ":" + src.Path
+			}
+		}
+
+		paths, err := papertrail.FragmentFiles(srcFS, root, manifest)
+		if err != nil {
+			return nil, cleanup, fmt.Errorf("fragments.sources[%d]: %w", i, err)
+		}
+		for _, p := range paths {
+			name := p
+			if label != "" {
+				rel, err := filepath.Rel(root, p)
+				if err != nil {
+					rel = filepath.Base(p)
+				}
+				name = label + "/" + filepath.ToSlash(rel)
+			}
+			files = append(files, fragmentFile{FS: srcFS, Path: p, Name: name, External: true})
+		}
+	}
+	return files, cleanup, nil
+}
+
+// sourcesLedger is the file merge writes next to a release's archived fragments, listing the
+// source fragments it released as "<sha256>  <name>" lines, like sha256sum output. Source
+// files stay where they are, so discovery skips the ones a ledger lists.
+const sourcesLedger = "sources.sha256"
+
+func sourceLine(b []byte, name string) string {
+	return sha256Hex(b) + "  " + name
+}
+
+// releasedSourceLines returns the ledger of the External items, one line per file, sorted.
+func releasedSourceLines(items []item) []byte {
+	var lines []string
+	for _, it := range items {
+		if it.External && it.Source != "" && !slices.Contains(lines, it.Source) {
+			lines = append(lines, it.Source)
+		}
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	sort.Strings(lines)
+	return []byte(strings.Join(lines, "\n") + "\n")
+}
+
+// releasedSources returns the source fragments recorded by every ledger under archiveDir,
+// keyed by ledger line: digest and name, so a source file with the same contents as a
+// released one is still pending. A missing archive has none.
+func releasedSources(fsys fs.FS, archiveDir string) (map[string]bool, error) {
+	released := map[string]bool{}
+	err := fs.WalkDir(fsys, archiveDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == archiveDir && errors.Is(err, fs.ErrNotExist) {
+				return fs.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() || path.Base(p) != sourcesLedger {
+			return nil
+		}
+		b, err := readFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		for i, line := range strings.Split(strings.TrimRight(string(b), "\n"), "\n") {
+			digest, _, ok := strings.Cut(line, "  ")
+			if !ok || len(digest) != 64 {
+				return fmt.Errorf("%s:%d: invalid line %q (expected \"<sha256>  <name>\")", p, i+1, line)
+			}
+			released[line] = true
+		}
+		return nil
+	})
+	return released, err
+}
+
+// skipReleasedSources drops the External files that a ledger under archiveDir records as
+// released, by name and contents.
+func skipReleasedSources(fsys fs.FS, archiveDir string, files []fragmentFile) ([]fragmentFile, error) {
+	released, err := releasedSources(fsys, archiveDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(released) == 0 {
+		return files, nil
+	}
+	pending := files[:0:0]
+	for _, ff := range files {
+		if ff.External {
+			b, err := ff.read()
+			if err != nil {
+				return nil, err
+			}
+			if released[sourceLine(b, ff.Name)] {
+				continue
+			}
+		}
+		pending = append(pending, ff)
+	}
+	return pending, nil
+}
+
+func cloneSource(ctx context.Context, src papertrail.FragmentSource, dst string) error {
+	// Offline, only repositories on the local file system can be cloned.
+	if _, err := os.Stat(strings.TrimSpace(src.Git)); err != nil && isOffline(ctx) {
+		return fmt.Errorf("cloning %s: %w", strings.TrimSpace(src.Git), errOffline)
+	}
+	args := []string{"clone", "--quiet", "--depth", "1"}
+	if src.Ref != "" {
+		args = append(args, "--branch", src.Ref)
+	}
+	args = append(args, strings.TrimSpace(src.Git), dst)
+	_, err := runGit(ctx, args...)
+	return err
+}
+
+// fetchTarball downloads a (optionally gzip-compressed) tarball and extracts its regular
+// files into dst.
+func fetchTarball(ctx context.Context, url, dst string) error {
+	if isOffline(ctx) {
+		return fmt.Errorf("GET %s: %w", url, errOffline)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: 60 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return extractTarball(resp.Body, dst)
+}
+
+func extractTarball(r io.Reader, dst string) error {
+	br := bufio.NewReader(r)
+	var src io.Reader = br
+	if magic, err := br.Peek(2); err == nil && bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return fmt.Errorf("invalid gzip stream: %w", err)
+		}
+		defer gz.Close()
+		src = gz
+	}
+
+	tr := tar.NewReader(src)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid tarball: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := filepath.FromSlash(hdr.Name)
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("invalid tarball: unsafe path %q", hdr.Name)
+		}
+		target := filepath.Join(dst, name)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		f, err := os.Create(target)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(f, tr); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+}
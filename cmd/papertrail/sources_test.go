@@ -0,0 +1,198 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+func TestDiscoverFragments_Sources(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	local := filepath.Join(root, "changelog.d")
+	sub := filepath.Join(root, "sub", "changelog.d")
+	for _, p := range []string{
+		filepath.Join(local, "a.yml"),
+		filepath.Join(local, "archived", "v0.1.0", "old.yml"),
+		filepath.Join(sub, "b.yaml"),
+	} {
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte("component: CLI\ntype: fix\nsummary: x\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var tarball bytes.Buffer
+	gz := gzip.NewWriter(&tarball)
+	tw := tar.NewWriter(gz)
+	body := []byte("component: API\ntype: fix\nsummary: y\n")
+	if err := tw.WriteHeader(&tar.Header{Name: "repo-abc/changelog.d/c.yml", Mode: 0644, Size: int64(len(body)), Typeflag: tar.TypeReg}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write(body); err != nil {
+		t.Fatal(err)
+	}
+	_ = tw.Close()
+	_ = gz.Close()
+	var fetched atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetched.Store(true)
+		_, _ = w.Write(tarball.Bytes())
+	}))
+	defer srv.Close()
+
+	var m releaseManifest
+	m.Fragments.Sources = []papertrail.FragmentSource{
+		{Dir: sub},
+		{URL: srv.URL + "/frags.tar.gz", Path: "repo-abc/changelog.d"},
+	}
+	// Without remote, only the directory source is read and the URL is never fetched.
+	files, cleanup, err := discoverFragments(t.Context(), hostFS{}, local, m, false)
+	defer cleanup()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(files) != 2 || fetched.Load() {
+		t.Fatalf("got %d files (fetched: %v): %+v", len(files), fetched.Load(), files)
+	}
+
+	files, cleanup, err = discoverFragments(t.Context(), hostFS{}, local, m, true)
+	defer cleanup()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("got %d files: %+v", len(files), files)
+	}
+	if files[0].External || files[0].Path != filepath.Join(local, "a.yml") {
+		t.Fatalf("unexpected local file: %+v", files[0])
+	}
+	if !files[1].External || files[1].Name != filepath.Join(sub, "b.yaml") {
+		t.Fatalf("unexpected dir source file: %+v", files[1])
+	}
+	if !files[2].External || files[2].Name != srv.URL+"/frags.tar.gz:repo-abc/changelog.d/c.yml" {
+		t.Fatalf("unexpected url source file: %+v", files[2])
+	}
+	if _, err := papertrail.ReadFragment(files[2].FS, files[2].Path, m); err != nil {
+		t.Fatalf("reading fetched fragment: %v", err)
+	}
+}
+
+func TestSkipReleasedSources_ByName(t *testing.T) {
+	t.Parallel()
+
+	body := "component: CLI\ntype: fix\nsummary: Fix a typo\n"
+	fsys := newMemFS(map[string]string{
+		"a/changelog.d/typo.yml":                       body,
+		"b/changelog.d/typo.yml":                       body,
+		"changelog.d/archived/v1.0.0/" + sourcesLedger: sourceLine([]byte(body), "a/changelog.d/typo.yml") + "\n",
+	})
+	files := []fragmentFile{
+		{FS: fsys, Path: "a/changelog.d/typo.yml", Name: "a/changelog.d/typo.yml", External: true},
+		{FS: fsys, Path: "b/changelog.d/typo.yml", Name: "b/changelog.d/typo.yml", External: true},
+	}
+	pending, err := skipReleasedSources(fsys, "changelog.d/archived", files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].Name != "b/changelog.d/typo.yml" {
+		t.Fatalf("a source with the contents of a released one must stay pending: %+v", pending)
+	}
+}
+
+func TestCmdMerge_Changesets(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{
+		".papertrail.config.yml":  "versioning:\n  rules:\n    feature: minor\nfragments:\n  changesets:\n    dir: .changeset\n    components:\n      \"@acme/web\": Web\n",
+		"CHANGELOG.md":            "# Changelog\n\n## v1.2.0 (2026-01-01)\n\n- old\n",
+		"changelog.d/cli.yml":     "component: CLI\ntype: fix\nsummary: Fix the CLI\n",
+		".changeset/config.json":  "{}\n",
+		".changeset/README.md":    "# Changesets\n",
+		".changeset/brave-fox.md": "---\n\"@acme/web\": minor\n---\n\nAdd dark mode\n",
+	})
+	gitIn(t, dir, "tag", "v1.2.0")
+
+	out, err := captureStdout(t, func() error { return cmdBump(t.Context(), nil) })
+	if err != nil || out != "v1.3.0\n" {
+		t.Fatalf("bump = %q, %v; want v1.3.0", out, err)
+	}
+	if err := cmdMerge(t.Context(), []string{"--version", "v1.3.0", "--date", "2026-02-01"}); err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	changelog, _ := os.ReadFile(filepath.Join(dir, "CHANGELOG.md"))
+	if !strings.Contains(string(changelog), "Add dark mode.") || !strings.Contains(string(changelog), "Fix the CLI.") {
+		t.Fatalf("changelog:\n%s", changelog)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "changelog.d", "archived", "v1.3.0", "brave-fox.md")); err != nil {
+		t.Fatalf("changeset not archived: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".changeset", "config.json")); err != nil {
+		t.Fatalf("changesets config removed: %v", err)
+	}
+}
+
+func TestCmdMerge_SourcesReleasedOnce(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{
+		".papertrail.config.yml":         "fragments:\n  sources:\n    - dir: sub/changelog.d\n",
+		"CHANGELOG.md":                   "# Changelog\n",
+		"changelog.d/20260101_a.yml":     "component: CLI\ntype: fix\nsummary: Fix a\n",
+		"sub/changelog.d/20260101_b.yml": "component: Sub\ntype: fix\nsummary: Fix the submodule\n",
+	})
+	if err := cmdMerge(t.Context(), []string{"--version", "v1.0.0", "--date", "2026-01-02", "--skip-version-check"}); err != nil {
+		t.Fatalf("first merge: %v", err)
+	}
+	ledger, err := os.ReadFile(filepath.Join(dir, "changelog.d", "archived", "v1.0.0", sourcesLedger))
+	if err != nil || !strings.HasSuffix(string(ledger), "  sub/changelog.d/20260101_b.yml\n") {
+		t.Fatalf("ledger: %q, %v", ledger, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "sub", "changelog.d", "20260101_b.yml")); err != nil {
+		t.Fatalf("source fragment must stay in place: %v", err)
+	}
+
+	// Only the released source fragment is left: nothing is pending.
+	err = cmdMerge(t.Context(), []string{"--version", "v1.0.1", "--date", "2026-01-03", "--skip-version-check"})
+	if err == nil || !strings.Contains(err.Error(), "no fragments found") {
+		t.Fatalf("expected no pending fragments, got %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "changelog.d", "20260104_c.yml"), []byte("component: CLI\ntype: fix\nsummary: Fix c\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmdMerge(t.Context(), []string{"--version", "v1.0.1", "--date", "2026-01-04", "--skip-version-check"}); err != nil {
+		t.Fatalf("second merge: %v", err)
+	}
+	changelog, _ := os.ReadFile(filepath.Join(dir, "CHANGELOG.md"))
+	if n := strings.Count(string(changelog), "Fix the submodule."); n != 1 {
+		t.Fatalf("source fragment released %d times:\n%s", n, changelog)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "changelog.d", "archived", "v1.0.1", sourcesLedger)); !os.IsNotExist(err) {
+		t.Fatalf("v1.0.1 released no source fragments, yet has a ledger: %v", err)
+	}
+
+	// Unmerging a release makes its source fragments pending again.
+	for _, v := range []string{"v1.0.1", "v1.0.0"} {
+		if err := cmdUnmerge(t.Context(), []string{"--version", v}); err != nil {
+			t.Fatalf("unmerge %s: %v", v, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "changelog.d", sourcesLedger)); !os.IsNotExist(err) {
+		t.Fatalf("unmerge restored the ledger as a fragment: %v", err)
+	}
+	if err := cmdMerge(t.Context(), []string{"--version", "v1.0.0", "--date", "2026-01-05", "--skip-version-check"}); err != nil {
+		t.Fatalf("merge after unmerge: %v", err)
+	}
+	if changelog, _ := os.ReadFile(filepath.Join(dir, "CHANGELOG.md")); !strings.Contains(string(changelog), "Fix the submodule.") {
+		t.Fatalf("source fragment not released again:\n%s", changelog)
+	}
+}
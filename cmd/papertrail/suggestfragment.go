@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+// suggestFragment drafts the fragment a pull request is missing from its title and changed
+// files, as for a commit (see commitFragment): the type and summary from the title, the
+// component from its scope, the changed files, or else the first configured component, and
+// the pull request as a ref. p is where the fragment would go under fragmentsDir. reason
+// explains why no fragment can be suggested.
+func suggestFragment(pr pullRequest, changed []string, fragmentsDir string, manifest releaseManifest) (p string, content []byte, reason string) {
+	var component string
+	if order := manifest.ComponentOrder(); len(order) > 0 {
+		component = order[0]
+	}
+	types, err := commitTypes(manifest, nil)
+	if err != nil {
+		return "", nil, err.Error()
+	}
+	f, reason := commitFragment(gitCommit{Subject: pr.Title, Files: changed}, types, component, manifest)
+	if reason != "" {
+		return "", nil, reason
+	}
+	if len(f.Refs) == 0 && pr.Number > 0 {
+		f.Refs = []string{"#" + strconv.Itoa(pr.Number)}
+	}
+	content, ext, err := importedFragmentContent(f, manifest)
+	if err != nil {
+		return "", nil, err.Error()
+	}
+	now, err := releaseTime(manifest)
+	if err != nil {
+		return "", nil, err.Error()
+	}
+	p = path.Join(fragmentsDir, now.Format("20060102")+"_"+fragmentSlug(f.Summary)+ext)
+	if _, err := papertrail.ParseFragmentFile(p, content, manifest); err != nil {
+		return "", nil, err.Error()
+	}
+	return p, content, ""
+}
+
+// fragmentSuggestion formats a suggested fragment for the pr-fragment failure message.
+func fragmentSuggestion(p string, content []byte) string {
+	return fmt.Sprintf("💡 Suggested fragment (%s):\n```yaml\n%s```", p, content)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+func TestSuggestFragment(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "1790000000") // 2026-09-21
+	manifest, err := papertrail.ParseManifest([]byte("types:\n  order: [feature, fix]\nchangelog:\n  components: [CLI, Docs]\n  component_paths:\n    Docs: [docs/]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, content, reason := suggestFragment(pullRequest{Number: 42, Title: "feat: add a guide"}, []string{"docs/guide.md"}, "changelog.d", manifest)
+	if reason != "" || p != "changelog.d/20260921_add_a_guide.yml" || string(content) != "component: Docs\ntype: FEATURE\nsummary: Add a guide\nrefs:\n  - '#42'\n" {
+		t.Fatalf("got %s %q (%s)", p, content, reason)
+	}
+	// Without a matching path the first configured component is used.
+	if _, content, _ := suggestFragment(pullRequest{Title: "Fix the crash"}, []string{"main.go"}, "changelog.d", manifest); !strings.HasPrefix(string(content), "component: CLI\ntype: FIX\n") {
+		t.Fatalf("unexpected suggestion %q", content)
+	}
+	if _, _, reason := suggestFragment(pullRequest{Title: "Refresh dependencies"}, nil, "changelog.d", manifest); reason != "not a conventional commit" {
+		t.Fatalf("unexpected reason %q", reason)
+	}
+
+	// The breaking marker suggests a breaking fragment where the type is allowed.
+	manifest.Types.Order = nil
+	if _, content, reason := suggestFragment(pullRequest{Title: "feat(cli)!: drop the v1 API"}, nil, "changelog.d", manifest); reason != "" || string(content) != "component: CLI\ntype: BREAKING\nsummary: Drop the v1 API\n" {
+		t.Fatalf("breaking title: %q (%s)", content, reason)
+	}
+}
+
+func TestCmdPRFragment_WriteSuggestion(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{".papertrail.config.yml": "changelog:\n  components: [CLI]\n"})
+	base := gitOutput(t, dir, "rev-parse", "HEAD")
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitIn(t, dir, "add", "-A")
+	gitIn(t, dir, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "main")
+	event := filepath.Join(t.TempDir(), "event.json")
+	if err := os.WriteFile(event, []byte(`{"pull_request":{"number":7,"title":"fix: handle empty input","base":{"sha":"`+base+`"}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GITHUB_EVENT_PATH", event)
+	t.Setenv("SOURCE_DATE_EPOCH", "1790000000")
+
+	err := cmdPRFragment(t.Context(), []string{"--write-suggestion"})
+	if err == nil || !strings.Contains(err.Error(), "Suggested fragment (changelog.d/20260921_handle_empty_input.yml)") || !strings.Contains(err.Error(), "```yaml\ncomponent: CLI\ntype: FIX\nsummary: Handle empty input\nrefs:\n  - '#7'\n```") {
+		t.Fatalf("expected a suggestion, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "changelog.d/20260921_handle_empty_input.yml")); err != nil {
+		t.Fatalf("suggestion not written: %v", err)
+	}
+}
+
+func TestCmdPRFragment_JSON(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{".papertrail.config.yml": "changelog:\n  components: [CLI]\npr_policy:\n  title_validation:\n    subject_case: lower\n"})
+	base := gitOutput(t, dir, "rev-parse", "HEAD")
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitIn(t, dir, "add", "-A")
+	gitIn(t, dir, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "main")
+	t.Setenv("GITHUB_OUTPUT", filepath.Join(t.TempDir(), "output"))
+	t.Setenv("SOURCE_DATE_EPOCH", "1790000000")
+
+	run := func(title string) prFragmentReport {
+		t.Helper()
+		event := filepath.Join(t.TempDir(), "event.json")
+		if err := os.WriteFile(event, []byte(`{"pull_request":{"number":7,"title":"`+title+`","base":{"sha":"`+base+`"}}}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv("GITHUB_EVENT_PATH", event)
+		out, err := captureStdout(t, func() error { return cmdPRFragment(t.Context(), []string{"--format", "json"}) })
+		if err == nil {
+			t.Fatalf("%s: expected pr-fragment to fail", title)
+		}
+		var report prFragmentReport
+		if err := json.Unmarshal([]byte(out), &report); err != nil {
+			t.Fatalf("%s: invalid JSON %q: %v", title, out, err)
+		}
+		return report
+	}
+
+	report := run("Fix: Handle empty input")
+	if report.OK || report.SuggestedTitle != "fix: handle empty input" || !strings.Contains(report.Message, "description must start with a lowercase letter") {
+		t.Fatalf("title failure: %+v", report)
+	}
+	report = run("fix: handle empty input")
+	want := suggestedFragment{Path: "changelog.d/20260921_handle_empty_input.yml", Content: "component: CLI\ntype: FIX\nsummary: Handle empty input\nrefs:\n  - '#7'\n"}
+	if report.OK || report.SuggestedTitle != "" || report.SuggestedFragment == nil || *report.SuggestedFragment != want {
+		t.Fatalf("missing fragment: %+v", report)
+	}
+	if err := cmdPRFragment(t.Context(), []string{"--format", "yaml"}); err == nil || !strings.Contains(err.Error(), "invalid --format") {
+		t.Fatalf("expected an invalid format error, got %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffOp is one line of a line diff: ' ' (unchanged), '-' (only in a), or '+' (only in b).
+type diffOp struct {
+	Kind byte
+	Text string
+}
+
+// maxDiffCells bounds the LCS table; beyond it the changed middle is shown as replaced
+// wholesale rather than spending quadratic memory on a minimal diff.
+const maxDiffCells = 16 << 20
+
+// diffLines diffs two line slices by longest common subsequence after trimming the common
+// prefix and suffix, which keeps the table small for the local edits repairs make.
+func diffLines(a, b []string) []diffOp {
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	var ops []diffOp
+	for _, l := range a[:pre] {
+		ops = append(ops, diffOp{' ', l})
+	}
+	x, y := a[pre:len(a)-suf], b[pre:len(b)-suf]
+	if (len(x)+1)*(len(y)+1) > maxDiffCells {
+		for _, l := range x {
+			ops = append(ops, diffOp{'-', l})
+		}
+		for _, l := range y {
+			ops = append(ops, diffOp{'+', l})
+		}
+	} else {
+		// lcs[i][j] is the LCS length of x[i:] and y[j:].
+		lcs := make([][]int, len(x)+1)
+		for i := range lcs {
+			lcs[i] = make([]int, len(y)+1)
+		}
+		for i := len(x) - 1; i >= 0; i-- {
+			for j := len(y) - 1; j >= 0; j-- {
+				if x[i] == y[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else {
+					lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < len(x) || j < len(y) {
+			switch {
+			case i < len(x) && j < len(y) && x[i] == y[j]:
+				ops = append(ops, diffOp{' ', x[i]})
+				i++
+				j++
+			case i < len(x) && (j == len(y) || lcs[i+1][j] >= lcs[i][j+1]):
+				ops = append(ops, diffOp{'-', x[i]})
+				i++
+			default:
+				ops = append(ops, diffOp{'+', y[j]})
+				j++
+			}
+		}
+	}
+	for _, l := range a[len(a)-suf:] {
+		ops = append(ops, diffOp{' ', l})
+	}
+	return ops
+}
+
+// unifiedDiff returns a unified diff of a and b with three lines of context, or "" when
+// they are equal.
+func unifiedDiff(name, a, b string) string {
+	if a == b {
+		return ""
+	}
+	const context = 3
+	ops := diffLines(splitLines(a), splitLines(b))
+	// aLine[i] and bLine[i] are the 0-based lines of a and b at ops[i].
+	aLine, bLine := make([]int, len(ops)+1), make([]int, len(ops)+1)
+	for i, op := range ops {
+		aLine[i+1], bLine[i+1] = aLine[i], bLine[i]
+		if op.Kind != '+' {
+			aLine[i+1]++
+		}
+		if op.Kind != '-' {
+			bLine[i+1]++
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- a/%s\n+++ b/%s\n", name, name)
+	for i := 0; i < len(ops); {
+		if ops[i].Kind == ' ' {
+			i++
+			continue
+		}
+		start, end := max(i-context, 0), i
+		for j := i; j < len(ops); j++ {
+			if ops[j].Kind != ' ' {
+				end = j
+			} else if j-end > 2*context {
+				break
+			}
+		}
+		stop := min(end+context+1, len(ops))
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", aLine[start]+1, aLine[stop]-aLine[start], bLine[start]+1, bLine[stop]-bLine[start])
+		for _, op := range ops[start:stop] {
+			out.WriteByte(op.Kind)
+			out.WriteString(op.Text)
+			out.WriteByte('\n')
+		}
+		i = stop
+	}
+	return out.String()
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+// checkTitleTypes verifies that the fragments a pull request adds have types its title's
+// conventional commit type allows (pr_policy.title_types), unless the PR has the override
+// label. A title with the breaking marker ("feat!: ...") also allows fragments that bump the
+// major version. Titles that are not conventional commits, or whose type is not mapped, pass.
+func checkTitleTypes(pr pullRequest, changed []string, fragmentsDir string, manifest releaseManifest) error {
+	rules := manifest.PRPolicy.TitleTypes
+	if len(rules.Types) == 0 || (rules.OverrideLabel != "" && contains(pr.Labels, rules.OverrideLabel)) {
+		return nil
+	}
+	cc, ok := parseConventionalCommit(pr.Title, "")
+	if !ok {
+		return nil
+	}
+	allowed, ok := rules.Allowed(cc.Type)
+	if !ok {
+		return nil
+	}
+	var mismatches []string
+	for _, p := range changed {
+		if !isFragmentPath(p, fragmentsDir, manifest) || strings.Contains(p, "/archived/") {
+			continue
+		}
+		fragments, err := papertrail.ReadFragments(hostFS{}, p, manifest)
+		if err != nil {
+			// Deleted in the PR, or already reported by check.
+			continue
+		}
+		for _, f := range fragments {
+			if cc.Breaking && commitBump(f, manifest) == bumpMajor {
+				continue
+			}
+			if !contains(allowed, manifest.CanonicalType(f.Type)) {
+				mismatches = append(mismatches, fmt.Sprintf("%s has type %s", p, f.Type))
+			}
+		}
+	}
+	if len(mismatches) == 0 {
+		return nil
+	}
+	msg := fmt.Sprintf("❌ The PR title type %q allows fragment types %s, but:\n  - %s", cc.Type, strings.Join(allowed, ", "), strings.Join(mismatches, "\n  - "))
+	if rules.OverrideLabel != "" {
+		msg += "\n💡 Retitle the PR or change the fragment type; if the mismatch is intended, add the PR label: " + rules.OverrideLabel
+	}
+	return errors.New(msg)
+}
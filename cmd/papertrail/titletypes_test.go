@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+func TestCheckTitleTypes(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.MkdirAll("changelog.d", 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, body := range map[string]string{
+		"changelog.d/a.yml": "component: CLI\ntype: fix\nsummary: Fix a.\n",
+		"changelog.d/b.yml": "component: CLI\ntype: NEW FEATURE\nsummary: Add b.\n",
+	} {
+		if err := os.WriteFile(filepath.FromSlash(name), []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	manifest, err := papertrail.ParseManifest([]byte(`types:
+  aliases:
+    NEW FEATURE: feature
+pr_policy:
+  title_types:
+    types:
+      Fix: [fix, bugfix]
+      feat: [new feature, fix]
+    override_label: type-mismatch-ok
+versioning:
+  rules:
+    breaking: major
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed := []string{"main.go", "changelog.d/a.yml", "changelog.d/b.yml"}
+
+	err = checkTitleTypes(pullRequest{Title: "fix(cli): handle x"}, changed, "changelog.d", manifest)
+	if err == nil || !strings.Contains(err.Error(), `title type "fix" allows fragment types FIX, BUGFIX`) || !strings.Contains(err.Error(), "changelog.d/b.yml has type FEATURE") || strings.Contains(err.Error(), "a.yml") {
+		t.Fatalf("expected a mismatch for b.yml, got %v", err)
+	}
+	// A breaking title also allows fragments that bump the major version.
+	if err := os.WriteFile(filepath.FromSlash("changelog.d/c.yml"), []byte("component: CLI\ntype: breaking\nsummary: Drop c.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err = checkTitleTypes(pullRequest{Title: "fix: drop c"}, []string{"changelog.d/a.yml", "changelog.d/c.yml"}, "changelog.d", manifest)
+	if err == nil || !strings.Contains(err.Error(), "changelog.d/c.yml has type BREAKING") {
+		t.Fatalf("expected a mismatch for c.yml, got %v", err)
+	}
+	if err := checkTitleTypes(pullRequest{Title: "fix(cli)!: drop c"}, []string{"changelog.d/a.yml", "changelog.d/c.yml"}, "changelog.d", manifest); err != nil {
+		t.Fatalf("breaking title: %v", err)
+	}
+
+	for _, pr := range []pullRequest{
+		{Title: "feat: add b"},
+		{Title: "fix: handle x", Labels: []string{"type-mismatch-ok"}},
+		{Title: "docs: unmapped type"},
+		{Title: "Not conventional"},
+	} {
+		if err := checkTitleTypes(pr, changed, "changelog.d", manifest); err != nil {
+			t.Fatalf("%+v: %v", pr, err)
+		}
+	}
+}
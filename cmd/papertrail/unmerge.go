@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+// cmdUnmerge rolls back `merge --version`: it removes the version's section from the
+// changelog and moves its archived fragments back into the fragments directory, then checks
+// that every fragment arrived intact. Only the newest release can be rolled back, and git
+// tags are left alone.
+func cmdUnmerge(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("unmerge", flag.ContinueOnError)
+	fs.SetOutput(ioDiscard{})
+	version := fs.String("version", "", "the merged version to roll back, like v1.2.3 (required)")
+	fragmentsDir := fs.String("fragments", "changelog.d", "fragments directory to restore fragments to")
+	changelogPath := fs.String("changelog", "CHANGELOG.md", "changelog path")
+	archiveDir := fs.String("archive", "changelog.d/archived", "archive directory")
+	manifestPath := fs.String("manifest", "", "optional release config YAML path")
+	wait := fs.Duration("wait", 0, "wait this long (e.g. 2m) for another merge, unmerge, or cut of the changelog to finish instead of failing")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *version == "" {
+		return fmt.Errorf("--version is required (e.g. v0.1.0)")
+	}
+	manifest, err := loadManifestDefault(*manifestPath)
+	if err != nil {
+		return err
+	}
+	unlock, err := acquireChangelogLock(ctx, *changelogPath, *wait)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return unmerge(hostFS{}, *version, *fragmentsDir, *changelogPath, *archiveDir, changelogCompare(manifest, "v"))
+}
+
+// archivedFragment is a fragment file unmerge moves back, with the digest it must keep.
+type archivedFragment struct {
+	From, To string
+	Digest   [sha256.Size]byte
+}
+
+func unmerge(fsys writableFS, version, fragmentsDir, changelogPath, archiveDir string, compare func(from, to string) string) error {
+	orig, err := readFile(fsys, changelogPath)
+	if err != nil {
+		return err
+	}
+	if top, _, ok := firstReleaseHeading(string(orig)); !ok || top != version {
+		if _, found := papertrail.ExtractSection(string(orig), version); !found {
+			return fmt.Errorf("%s has no section for %s", changelogPath, version)
+		}
+		return fmt.Errorf("%s is not the newest release in %s (%s is); only the newest release can be unmerged", version, changelogPath, top)
+	}
+	// Plan every move before writing anything, so a conflict leaves the tree untouched.
+	archivePath := path.Join(archiveDir, version)
+	entries, err := fs.ReadDir(fsys, archivePath)
+	if err != nil {
+		return fmt.Errorf("no archived fragments for %s: %w", version, err)
+	}
+	var moves []archivedFragment
+	var ledger, undo string
+	for _, e := range entries {
+		if e.IsDir() {
+			return fmt.Errorf("unexpected directory %s in the archive", path.Join(archivePath, e.Name()))
+		}
+		from := path.Join(archivePath, e.Name())
+		if e.Name() == sourcesLedger {
+			// Dropping the ledger makes the release's source fragments pending again.
+			ledger = from
+			continue
+		}
+		if e.Name() == changelogUndo {
+			undo = from
+			continue
+		}
+		to := path.Join(fragmentsDir, e.Name())
+		if _, err := fs.Stat(fsys, to); err == nil {
+			return fmt.Errorf("cannot restore %s: %s already exists", from, to)
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		b, err := readFile(fsys, from)
+		if err != nil {
+			return err
+		}
+		moves = append(moves, archivedFragment{From: from, To: to, Digest: sha256.Sum256(b)})
+	}
+	updated, restored := orig, false
+	if undo != "" {
+		record, err := readFile(fsys, undo)
+		if err != nil {
+			return err
+		}
+		updated, restored = undoChangelogEdits(orig, record)
+	}
+	if !restored {
+		// Without a record that still applies, as when the changelog was edited after the
+		// merge, cut the section out instead.
+		updated, _ = papertrail.RemoveSection(orig, version, compare)
+	}
+
+	if err := fsys.WriteFile(changelogPath, updated, 0644); err != nil {
+		return err
+	}
+	for _, m := range moves {
+		if err := fsys.Rename(m.From, m.To); err != nil {
+			return err
+		}
+	}
+	for _, m := range moves {
+		b, err := readFile(fsys, m.To)
+		if err != nil {
+			return fmt.Errorf("restored fragment %s is missing: %w", m.To, err)
+		}
+		if sha256.Sum256(b) != m.Digest {
+			return fmt.Errorf("restored fragment %s does not match its archived copy", m.To)
+		}
+	}
+	for _, p := range []string{ledger, undo} {
+		if p == "" {
+			continue
+		}
+		if err := fsys.Remove(p); err != nil {
+			return err
+		}
+	}
+	if err := fsys.Remove(archivePath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "%s: removed the %s section and restored %d fragment file(s) to %s\n", version, changelogPath, len(moves), fragmentsDir)
+	return nil
+}
+
+// changelogUndo is the file merge writes next to a release's archived fragments, recording
+// the edits it made to the changelog so unmerge can restore it byte for byte.
+const changelogUndo = "changelog.undo"
+
+// changelogEdit replaces Inserted, found at offset At of the merged changelog, with Removed.
+type changelogEdit struct {
+	At       int    `json:"at"`
+	Inserted string `json:"inserted"`
+	Removed  string `json:"removed,omitempty"`
+}
+
+// changelogEdits returns the record of the edits that turn orig into updated, as JSON: one
+// edit per run of changed lines in their diff.
+func changelogEdits(orig, updated []byte) []byte {
+	var edits []changelogEdit
+	at, open := 0, false
+	for _, op := range diffLines(strings.SplitAfter(string(orig), "\n"), strings.SplitAfter(string(updated), "\n")) {
+		if op.Kind == ' ' {
+			at += len(op.Text)
+			open = false
+			continue
+		}
+		if !open {
+			edits = append(edits, changelogEdit{At: at})
+			open = true
+		}
+		e := &edits[len(edits)-1]
+		if op.Kind == '-' {
+			e.Removed += op.Text
+		} else {
+			e.Inserted += op.Text
+			at += len(op.Text)
+		}
+	}
+	b, _ := json.Marshal(edits)
+	return append(b, '\n')
+}
+
+// undoChangelogEdits reverts the recorded edits, reporting false when the record is invalid
+// or the changelog no longer has the text merge inserted.
+func undoChangelogEdits(changelog, record []byte) ([]byte, bool) {
+	var edits []changelogEdit
+	if err := json.Unmarshal(record, &edits); err != nil {
+		return nil, false
+	}
+	s := string(changelog)
+	for i := len(edits) - 1; i >= 0; i-- {
+		e := edits[i]
+		end := e.At + len(e.Inserted)
+		if e.At < 0 || end > len(s) || s[e.At:end] != e.Inserted {
+			return nil, false
+		}
+		s = s[:e.At] + e.Removed + s[end:]
+	}
+	return []byte(s), true
+}
+
+// firstReleaseHeading returns the version and date of the changelog's first release heading.
+func firstReleaseHeading(changelog string) (version, date string, ok bool) {
+	for _, line := range strings.Split(changelog, "\n") {
+		if version, date, ok = papertrail.ParseReleaseHeading(line); ok {
+			return version, date, true
+		}
+	}
+	return "", "", false
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+func TestUnmerge_MemFS(t *testing.T) {
+	const (
+		changelog = "# Changelog\n\n## v0.1.0 (2025-01-01)\n\n- old\n\n[unreleased]: https://example.com/compare/v0.1.0...HEAD\n"
+		fragA     = "component: CLI\ntype: fix\nsummary: Fix a\n"
+		fragB     = "- component: CLI\n  type: feature\n  summary: Add b\n"
+	)
+	fsys := newMemFS(map[string]string{
+		"CHANGELOG.md":                        changelog,
+		"changelog.d/a.yml":                   fragA,
+		"changelog.d/b.yml":                   fragB,
+		"changelog.d/archived/v0.1.0/old.yml": "component: CLI\ntype: fix\nsummary: old\n",
+	})
+	compare := func(from, to string) string { return "https://example.com/compare/" + from + "..." + to }
+	items := []item{
+		{Path: "changelog.d/a.yml", Frag: fragment{Component: "CLI", Type: "FIX", Summary: "Fix a"}},
+		{Path: "changelog.d/b.yml", Frag: fragment{Component: "CLI", Type: "FEATURE", Summary: "Add b"}},
+	}
+	section, _, _ := renderReleaseSection("v0.2.0", "2025-02-01", items, releaseManifest{})
+	if err := writeRelease(fsys, releaseOutput{Version: "v0.2.0", ChangelogPath: "CHANGELOG.md", ArchiveDir: "changelog.d/archived", Section: section, Items: items}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := unmerge(fsys, "v0.1.0", "changelog.d", "CHANGELOG.md", "changelog.d/archived", compare); err == nil || !strings.Contains(err.Error(), "newest") {
+		t.Fatalf("expected only the newest release to be unmergeable, got %v", err)
+	}
+	if err := unmerge(fsys, "v0.3.0", "changelog.d", "CHANGELOG.md", "changelog.d/archived", compare); err == nil || !strings.Contains(err.Error(), "no section") {
+		t.Fatalf("expected a missing section error, got %v", err)
+	}
+
+	if _, err := captureStdout(t, func() error {
+		return unmerge(fsys, "v0.2.0", "changelog.d", "CHANGELOG.md", "changelog.d/archived", compare)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := fs.ReadFile(fsys, "CHANGELOG.md"); string(b) != changelog {
+		t.Fatalf("CHANGELOG.md not restored:\n%s", b)
+	}
+	for name, want := range map[string]string{"changelog.d/a.yml": fragA, "changelog.d/b.yml": fragB} {
+		if b, err := fs.ReadFile(fsys, name); err != nil || string(b) != want {
+			t.Fatalf("%s not restored: %q, %v", name, b, err)
+		}
+	}
+	if _, err := fs.Stat(fsys, "changelog.d/archived/v0.2.0"); err == nil {
+		t.Fatal("archive directory should be gone")
+	}
+}
+
+func TestUnmerge_RestoresChangelogBytes(t *testing.T) {
+	t.Parallel()
+
+	kac, err := papertrail.ParseManifest([]byte("changelog:\n  compare_url: https://example.com/compare/{from}...{to}\n  style:\n    profile: keepachangelog\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, tc := range map[string]struct {
+		changelog string
+		manifest  releaseManifest
+	}{
+		"title only":           {changelog: "# Changelog\n"},
+		"no final newline":     {changelog: "# Changelog"},
+		"trailing blank lines": {changelog: "# Changelog\n\n## v0.1.0 (2025-01-01)\n\n- old\n\n\n"},
+		"no blank separator":   {changelog: "# Changelog\n## v0.1.0 (2025-01-01)\n\n- old\n"},
+		"anchor and footer":    {changelog: "# Changelog\n\n" + papertrail.ReleaseAnchor + "\n\nFooter\n"},
+		"keepachangelog links": {
+			changelog: "# Changelog\n\n## [Unreleased]\n\n## [v0.1.0] - 2025-01-01\n\n- old\n\n[unreleased]: https://example.com/compare/v0.1.0...HEAD\n",
+			manifest:  kac,
+		},
+		"keepachangelog without links": {
+			changelog: "# Changelog\n\n## [Unreleased]\n\n## [v0.1.0] - 2025-01-01\n\n- old\n",
+			manifest:  kac,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			fsys := newMemFS(map[string]string{
+				"CHANGELOG.md":      tc.changelog,
+				"changelog.d/a.yml": "component: CLI\ntype: fix\nsummary: Fix a\n",
+			})
+			items := []item{{Path: "changelog.d/a.yml", Frag: fragment{Component: "CLI", Type: "FIX", Summary: "Fix a"}}}
+			section, _, _ := renderReleaseSection("v0.2.0", "2025-02-01", items, tc.manifest)
+			compare := changelogCompare(tc.manifest, "v")
+			if err := writeRelease(fsys, releaseOutput{Version: "v0.2.0", ChangelogPath: "CHANGELOG.md", ArchiveDir: "changelog.d/archived", Section: section, Items: items, Style: tc.manifest.Changelog.Style, Compare: compare}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := captureStdout(t, func() error {
+				return unmerge(fsys, "v0.2.0", "changelog.d", "CHANGELOG.md", "changelog.d/archived", compare)
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if b, _ := fs.ReadFile(fsys, "CHANGELOG.md"); string(b) != tc.changelog {
+				t.Fatalf("CHANGELOG.md = %q, want %q", b, tc.changelog)
+			}
+		})
+	}
+}
+
+func TestUnmerge_EditedAfterMerge(t *testing.T) {
+	t.Parallel()
+
+	fsys := newMemFS(map[string]string{
+		"CHANGELOG.md":      "# Changelog\n\n## v0.1.0 (2025-01-01)\n\n- old\n",
+		"changelog.d/a.yml": "component: CLI\ntype: fix\nsummary: Fix a\n",
+	})
+	items := []item{{Path: "changelog.d/a.yml", Frag: fragment{Component: "CLI", Type: "FIX", Summary: "Fix a"}}}
+	section, _, _ := renderReleaseSection("v0.2.0", "2025-02-01", items, releaseManifest{})
+	if err := writeRelease(fsys, releaseOutput{Version: "v0.2.0", ChangelogPath: "CHANGELOG.md", ArchiveDir: "changelog.d/archived", Section: section, Items: items}); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := fs.ReadFile(fsys, "CHANGELOG.md")
+	if err := fsys.WriteFile("CHANGELOG.md", []byte(strings.Replace(string(b), "Fix a.", "Fix a typo.", 1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := captureStdout(t, func() error {
+		return unmerge(fsys, "v0.2.0", "changelog.d", "CHANGELOG.md", "changelog.d/archived", nil)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := fs.ReadFile(fsys, "CHANGELOG.md"); string(b) != "# Changelog\n\n## v0.1.0 (2025-01-01)\n\n- old\n" {
+		t.Fatalf("the section should be cut out of an edited changelog, got %q", b)
+	}
+	if _, err := fs.Stat(fsys, "changelog.d/archived/v0.2.0"); err == nil {
+		t.Fatal("archive directory should be gone")
+	}
+}
+
+func TestUnmerge_RefusesToOverwrite(t *testing.T) {
+	t.Parallel()
+
+	fsys := newMemFS(map[string]string{
+		"CHANGELOG.md":                      "# Changelog\n\n## v0.2.0 (2025-02-01)\n\n- new\n",
+		"changelog.d/a.yml":                 "component: CLI\ntype: fix\nsummary: Newer a\n",
+		"changelog.d/archived/v0.2.0/a.yml": "component: CLI\ntype: fix\nsummary: Fix a\n",
+	})
+	if err := unmerge(fsys, "v0.2.0", "changelog.d", "CHANGELOG.md", "changelog.d/archived", nil); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("expected a conflict error, got %v", err)
+	}
+	if b, _ := fs.ReadFile(fsys, "CHANGELOG.md"); !strings.Contains(string(b), "## v0.2.0") {
+		t.Fatal("a conflict must leave the changelog untouched")
+	}
+}
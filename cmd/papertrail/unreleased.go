@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+// cmdUnreleased regenerates the marker-delimited Unreleased block of the changelog from the
+// pending fragments, so they are visible between releases. The block is removed when none
+// are pending, and `merge` replaces it with the release section.
+func cmdUnreleased(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("unreleased", flag.ContinueOnError)
+	fs.SetOutput(ioDiscard{})
+	fragmentsDir := fs.String("fragments", "changelog.d", "fragments directory")
+	changelogPath := fs.String("changelog", "CHANGELOG.md", "changelog path")
+	manifestPath := fs.String("manifest", "", "optional release config YAML path")
+	check := fs.Bool("check", false, "print a diff and fail if the Unreleased block is out of date, without writing it")
+	remoteSources := fs.Bool("remote-sources", false, remoteSourcesUsage)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	manifest, err := loadManifestDefault(*manifestPath)
+	if err != nil {
+		return err
+	}
+	files, cleanup, err := discoverFragments(ctx, hostFS{}, *fragmentsDir, manifest, *remoteSources)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+	var items []item
+	for _, ff := range files {
+		its, err := readItems(ff, manifest)
+		if err != nil {
+			return err
+		}
+		items = append(items, its...)
+	}
+
+	fsys := hostFS{}
+	b, err := readFile(fsys, *changelogPath)
+	if err != nil {
+		return err
+	}
+	var block []byte
+	if len(items) > 0 {
+		block = papertrail.RenderUnreleased(libraryGroups(groupItems(items, manifest)), manifest.Changelog.Style)
+	}
+	updated := papertrail.SetUnreleased(b, block, manifest.Changelog.Style)
+	if string(updated) == string(b) {
+		return nil
+	}
+	if *check {
+		fmt.Fprint(os.Stdout, unifiedDiff(*changelogPath, string(b), string(updated)))
+		return fmt.Errorf("the Unreleased block of %s is out of date; run papertrail unreleased", *changelogPath)
+	}
+	return fsys.WriteFile(*changelogPath, updated, 0644)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+func TestCmdUnreleased(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	if err := os.MkdirAll("changelog.d", 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, body := range map[string]string{
+		"CHANGELOG.md":               "# Changelog\n\n## v1.0.0 (2026-01-01)\n\n### CLI\n\n- **fix**: Fix z.\n",
+		"changelog.d/20260102_a.yml": "component: CLI\ntype: fix\nsummary: Fix a\n",
+	} {
+		if err := os.WriteFile(name, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := cmdUnreleased(t.Context(), []string{"--check"}); err == nil || !strings.Contains(err.Error(), "out of date") {
+		t.Fatalf("expected --check to fail, got %v", err)
+	}
+	if err := cmdUnreleased(t.Context(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmdUnreleased(t.Context(), []string{"--check"}); err != nil {
+		t.Fatalf("block should be up to date: %v", err)
+	}
+	b, _ := os.ReadFile("CHANGELOG.md")
+	if !strings.Contains(string(b), papertrail.UnreleasedStart+"\n## Unreleased\n\n### CLI\n\n- **fix**: Fix a.\n") {
+		t.Fatalf("CHANGELOG.md:\n%s", b)
+	}
+
+	if err := cmdMerge(t.Context(), []string{"--version", "v1.1.0", "--date", "2026-01-03", "--skip-version-check"}); err != nil {
+		t.Fatal(err)
+	}
+	b, _ = os.ReadFile("CHANGELOG.md")
+	want := "# Changelog\n\n## v1.1.0 (2026-01-03)\n\n### CLI\n\n- **fix**: Fix a.\n\n## v1.0.0 (2026-01-01)\n"
+	if !strings.HasPrefix(string(b), want) {
+		t.Fatalf("merge should replace the Unreleased block:\n%s", b)
+	}
+	if err := cmdUnreleased(t.Context(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if b2, _ := os.ReadFile("CHANGELOG.md"); string(b2) != string(b) {
+		t.Fatalf("no pending fragments should leave no block:\n%s", b2)
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+// generatedSubsections are the "###" headings papertrail adds to release sections besides
+// component (or category) groups.
+var generatedSubsections = []string{papertrail.HighlightsHeading, papertrail.DeprecationsHeading, "Dependency changes", "Artifacts", "Contributors"}
+
+// invalidVersionHeadingRE matches release headings whose "v" version is not SemVer, which
+// ParseReleaseHeading does not read as releases.
+var invalidVersionHeadingRE = regexp.MustCompile(`^## \[?(v[^\s\]]*)`)
+
+// cmdVerifyChangelog checks the structure of the whole changelog, so drift from manual edits
+// is caught in CI: release headings are valid and strictly descending, dates parse and do not
+// increase, no version appears twice, and group headings and type labels match the manifest.
+func cmdVerifyChangelog(_ context.Context, args []string) error {
+	fs := flag.NewFlagSet("verify-changelog", flag.ContinueOnError)
+	fs.SetOutput(ioDiscard{})
+	changelogPath := fs.String("changelog", "CHANGELOG.md", "changelog path")
+	manifestPath := fs.String("manifest", "", "optional release config YAML path")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	manifest, err := loadManifestDefault(*manifestPath)
+	if err != nil {
+		return err
+	}
+	b, err := readFile(hostFS{}, *changelogPath)
+	if err != nil {
+		return err
+	}
+	if problems := changelogProblems(string(b), manifest); len(problems) > 0 {
+		return fmt.Errorf("%s has %d problem(s):\n  %s", *changelogPath, len(problems), strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// changelogProblems lists the changelog's structural problems by line number. Date-based
+// versions are not compared with each other. In release sections, "###" headings must be
+// categories in the keepachangelog profile, and configured components when
+// changelog.strict_components is set; bold type labels must be configured types unless
+// types.allow_unknown is set. Sections grouped by type are not checked below the heading.
+func changelogProblems(doc string, manifest releaseManifest) []string {
+	style := manifest.Changelog.Style
+	components := manifest.ComponentOrder()
+	checkComponents := manifest.Changelog.StrictComponents && len(components) > 0
+	var headings []string
+	for _, c := range components {
+		// A nested component's parent heads its subheading.
+		parent, _, _ := papertrail.SplitComponent(c)
+		headings = append(headings, parent)
+	}
+	checkTypes := len(manifest.TypeOrder()) > 0 && !manifest.Types.AllowUnknown
+
+	var (
+		problems  []string
+		seen      = map[string]int{}
+		prev      semver
+		prevKey   string
+		havePrev  bool
+		prevDate  string
+		inSection bool
+		inGroup   bool
+		inFence   bool
+	)
+	for i, line := range strings.Split(doc, "\n") {
+		n := i + 1
+		line = strings.TrimRight(line, "\r")
+		if isFence(line) {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		if strings.HasPrefix(line, "## ") || strings.HasPrefix(line, "##v") {
+			inSection, inGroup = false, false
+			version, date, ok := papertrail.ParseReleaseHeading(line)
+			if !ok {
+				switch m := invalidVersionHeadingRE.FindStringSubmatch(line); {
+				case brokenReleaseHeadingRE.MatchString(line):
+					problems = append(problems, fmt.Sprintf("line %d: invalid release heading %q (run papertrail fix-changelog)", n, line))
+				case m != nil:
+					_, err := parseSemver(m[1])
+					problems = append(problems, fmt.Sprintf("line %d: invalid version %q: %v", n, m[1], err))
+				}
+				continue
+			}
+			inSection = true
+			if first, dup := seen[version]; dup {
+				problems = append(problems, fmt.Sprintf("line %d: duplicate section for %s (first at line %d)", n, version, first))
+			} else {
+				seen[version] = n
+			}
+			if v, err := parseSemver(version); err == nil {
+				// Date-based versions are not compared.
+				switch {
+				case !havePrev:
+					prev, prevKey, havePrev = v, version, true
+				case version != prevKey && v.Compare(prev) >= 0:
+					problems = append(problems, fmt.Sprintf("line %d: %s appears after older %s", n, version, prevKey))
+				default:
+					prev, prevKey = v, version
+				}
+			}
+			if date == "" {
+				continue
+			}
+			if _, err := time.Parse("2006-01-02", date); err != nil {
+				problems = append(problems, fmt.Sprintf("line %d: invalid date %q for %s (expected YYYY-MM-DD)", n, date, version))
+				continue
+			}
+			if prevDate != "" && date > prevDate {
+				problems = append(problems, fmt.Sprintf("line %d: %s is dated %s, after the release above it (%s)", n, version, date, prevDate))
+			}
+			prevDate = date
+			continue
+		}
+		if !inSection {
+			continue
+		}
+		if name, ok := strings.CutPrefix(line, "### "); ok {
+			name = strings.TrimSpace(name)
+			inGroup = false
+			switch {
+			case contains(generatedSubsections, name):
+			case manifest.GroupByType():
+				// Type headings, with component labels that are not types.
+			case style.KeepAChangelog():
+				if !contains(papertrail.KeepAChangelogCategories, name) {
+					problems = append(problems, fmt.Sprintf("line %d: unknown category %q (expected one of %s)", n, name, strings.Join(papertrail.KeepAChangelogCategories, ", ")))
+				}
+			default:
+				inGroup = true
+				if checkComponents && !containsFold(headings, name) {
+					problems = append(problems, fmt.Sprintf("line %d: unknown component %q (changelog.components: %s)", n, name, strings.Join(components, ", ")))
+				}
+			}
+			continue
+		}
+		if !inGroup || !checkTypes || !(strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* ")) {
+			continue
+		}
+		if m := boldEntryRE.FindStringSubmatch(strings.TrimSpace(line[2:])); m != nil && !manifest.TypeAllowed(manifest.LabelType(m[1])) {
+			problems = append(problems, fmt.Sprintf("line %d: unknown type %q", n, m[1]))
+		}
+	}
+	return problems
+}
+
+func containsFold(xs []string, x string) bool {
+	for _, v := range xs {
+		if strings.EqualFold(v, x) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+func TestChangelogProblems(t *testing.T) {
+	t.Parallel()
+
+	manifest, err := papertrail.ParseManifest([]byte("types:\n  order: [feature, fix]\nchangelog:\n  components: [CLI]\n  strict_components: true\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid := "# Changelog\n\n## Unreleased\n\n## v1.2.0 (2026-01-03)\n\n### CLI\n\n- **fix**: Fix a.\n\n### Dependency changes\n\n- **Updated** x\n\n## v1.1.0 (2026-01-03)\n\n```md\n## v9.0.0 (1999-01-01)\n```\n\n## v1.0.0\n"
+	if problems := changelogProblems(valid, manifest); len(problems) > 0 {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+
+	broken := strings.Join([]string{
+		"# Changelog",
+		"",
+		"## v1.1.0 (2026-01-01)",
+		"### Server",
+		"- **chore**: Tidy.",
+		"## v1.2.0 (2026-02-30)",
+		"## v1.0.0 (2026-01-02)",
+		"## v1.0.0 (2025-12-01)",
+		"## 0.9.0",
+		"## v0.8 (2025-01-01)",
+	}, "\n")
+	want := []string{
+		`line 4: unknown component "Server"`,
+		`line 5: unknown type "chore"`,
+		"line 6: v1.2.0 appears after older v1.1.0",
+		`line 6: invalid date "2026-02-30"`,
+		"line 7: v1.0.0 is dated 2026-01-02, after the release above it (2026-01-01)",
+		"line 8: duplicate section for v1.0.0 (first at line 7)",
+		`line 9: invalid release heading "## 0.9.0"`,
+		`line 10: invalid version "v0.8"`,
+	}
+	got := strings.Join(changelogProblems(broken, manifest), "\n")
+	for _, w := range want {
+		if !strings.Contains(got, w) {
+			t.Errorf("missing %q in:\n%s", w, got)
+		}
+	}
+}
+
+func TestChangelogProblems_KeepAChangelog(t *testing.T) {
+	t.Parallel()
+
+	manifest, err := papertrail.ParseManifest([]byte("changelog:\n  style:\n    profile: keepachangelog\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := "# Changelog\n\n## [Unreleased]\n\n## [v1.0.0] - 2026-01-02\n\n### Added\n\n- Add a.\n\n### Improvements\n\n- Improve b.\n"
+	got := changelogProblems(doc, manifest)
+	if len(got) != 1 || !strings.Contains(got[0], `line 11: unknown category "Improvements"`) {
+		t.Fatalf("unexpected problems: %v", got)
+	}
+}
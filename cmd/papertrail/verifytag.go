@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+// releaseNotesDigestKey labels the release notes checksum cut writes into tag messages.
+const releaseNotesDigestKey = "release-notes-sha256:"
+
+// cmdVerifyTag is a release-integrity gate: the tag must exist and be annotated, the
+// changelog at the tagged commit must have a section for the version, and the tag message
+// must contain the release notes generated from that section or their SHA-256 checksum.
+func cmdVerifyTag(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("verify-tag", flag.ContinueOnError)
+	fs.SetOutput(ioDiscard{})
+	changelogPath := fs.String("changelog", "CHANGELOG.md", "changelog path")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("verify-tag requires exactly one tag (e.g. v1.2.3)")
+	}
+	tag := fs.Arg(0)
+	if _, err := parseSemver(tag); err != nil {
+		return fmt.Errorf("invalid tag %q: %v (expected vMAJOR.MINOR.PATCH)", tag, err)
+	}
+
+	ref := "refs/tags/" + tag
+	typ, err := runGit(ctx, "cat-file", "-t", ref)
+	if err != nil {
+		return fmt.Errorf("tag %s does not exist", tag)
+	}
+	if typ != "tag" {
+		return fmt.Errorf("tag %s is a lightweight tag; release tags must be annotated", tag)
+	}
+
+	notes, err := sectionReleaseNotes(gitFS{ctx: ctx, ref: ref}, gitPath(*changelogPath), tag)
+	if err != nil {
+		return fmt.Errorf("tag %s: %w", tag, err)
+	}
+
+	raw, err := runGit(ctx, "cat-file", "tag", ref)
+	if err != nil {
+		return err
+	}
+	msg := tagMessage(raw)
+	digest := sha256Hex(notes)
+	switch {
+	case strings.Contains(normalizeNotes(msg), normalizeNotes(string(notes))):
+	case withoutCommentLines(string(notes)) != "" && strings.Contains(withoutCommentLines(msg), withoutCommentLines(string(notes))):
+		// git tag -m/-F strips "#" lines (the markdown headings) unless --cleanup=verbatim.
+	case strings.Contains(msg, digest):
+	default:
+		return fmt.Errorf("tag %s message does not match the release notes for its %s section (expected the notes or %s %s)",
+			tag, *changelogPath, releaseNotesDigestKey, digest)
+	}
+	fmt.Fprintf(os.Stdout, "%s: tag, release notes, and %s section verified\n", tag, *changelogPath)
+	return nil
+}
+
+// sectionReleaseNotes reads the changelog at changelogPath and builds the release notes for
+// version from its section, as verify-tag checks them. Subsections that only release notes
+// carry, such as Contributors, are not part of them.
+func sectionReleaseNotes(fsys fs.FS, changelogPath, version string) ([]byte, error) {
+	changelog, err := readFile(fsys, changelogPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", changelogPath, err)
+	}
+	body, ok := papertrail.ExtractSection(string(changelog), version)
+	if !ok {
+		return nil, fmt.Errorf("%s has no section for %s", changelogPath, version)
+	}
+	return tagReleaseNotes(version, body), nil
+}
+
+// tagReleaseNotes builds release notes from a changelog section body the way notes and
+// merge --release-notes-out write them.
+func tagReleaseNotes(version, body string) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "## %s\n\n", version)
+	buf.WriteString(body)
+	return trimTrailingNewlines(buf.Bytes())
+}
+
+// tagMessage returns the message of a "git cat-file tag" object without its header or any
+// signature block.
+func tagMessage(raw string) string {
+	_, msg, _ := strings.Cut(raw, "\n\n")
+	for _, marker := range []string{"-----BEGIN PGP SIGNATURE-----", "-----BEGIN SSH SIGNATURE-----", "-----BEGIN SIGNED MESSAGE-----"} {
+		if i := strings.Index(msg, marker); i >= 0 {
+			msg = msg[:i]
+		}
+	}
+	return msg
+}
+
+func normalizeNotes(s string) string {
+	return strings.TrimSpace(strings.ReplaceAll(s, "\r\n", "\n"))
+}
+
+// withoutCommentLines drops blank and "#" lines, as git's default tag message cleanup does
+// to the latter.
+func withoutCommentLines(s string) string {
+	var kept []string
+	for _, l := range strings.Split(normalizeNotes(s), "\n") {
+		if t := strings.TrimSpace(l); t != "" && !strings.HasPrefix(t, "#") {
+			kept = append(kept, strings.TrimRight(l, " \t"))
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// releaseTagMessage is the annotated tag message cut writes: a subject plus the checksum of
+// the section-derived release notes (sectionReleaseNotes) verify-tag checks.
+func releaseTagMessage(tag string, releaseNotes []byte) string {
+	return fmt.Sprintf("Release %s\n\n%s %s\n", tag, releaseNotesDigestKey, sha256Hex(releaseNotes))
+}
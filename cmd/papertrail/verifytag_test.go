@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCmdVerifyTag(t *testing.T) {
+	dir := setupCutRepo(t)
+	if err := cmdCut(t.Context(), []string{"--date", "2026-02-01"}); err != nil {
+		t.Fatalf("cut: %v", err)
+	}
+	// cut tags with the release notes checksum.
+	if err := cmdVerifyTag(t.Context(), []string{"v1.3.0"}); err != nil {
+		t.Fatalf("verify cut tag: %v", err)
+	}
+
+	// A tag whose message is the notes themselves also verifies.
+	notes, err := os.ReadFile(filepath.Join(dir, ".papertrail", "release-notes.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gitIn(t, dir, "tag", "-f", "-a", "v1.3.0", "-m", string(notes))
+	if err := cmdVerifyTag(t.Context(), []string{"v1.3.0"}); err != nil {
+		t.Fatalf("verify notes tag: %v", err)
+	}
+
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-f", "-a", "v1.3.0", "-m", "Release v1.3.0"}, "does not match"},
+		{[]string{"-f", "v1.3.0"}, "lightweight"},
+	} {
+		gitIn(t, dir, append([]string{"tag"}, tc.args...)...)
+		if err := cmdVerifyTag(t.Context(), []string{"v1.3.0"}); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("tag %v: expected %q error, got %v", tc.args, tc.want, err)
+		}
+	}
+
+	// v1.2.0's commit has a section for it, but v1.1.0 was never tagged.
+	gitIn(t, dir, "tag", "-f", "-a", "v1.2.0", "v1.2.0^{}", "-m", "## v1.2.0\n\n- old\n")
+	if err := cmdVerifyTag(t.Context(), []string{"v1.2.0"}); err != nil {
+		t.Fatalf("verify v1.2.0: %v", err)
+	}
+	if err := cmdVerifyTag(t.Context(), []string{"v1.1.0"}); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Fatalf("expected missing tag error, got %v", err)
+	}
+}
+
+func TestCmdVerifyTag_CutWithContributors(t *testing.T) {
+	dir := setupCutRepo(t)
+	if err := os.WriteFile(filepath.Join(dir, "changelog.d", "20260202.yml"), []byte("component: CLI\ntype: fix\nsummary: Fix cut\nauthors: [\"@octocat\"]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitIn(t, dir, "add", "-A")
+	gitIn(t, dir, "commit", "-q", "-m", "fix")
+
+	if err := cmdCut(t.Context(), []string{"--date", "2026-02-01"}); err != nil {
+		t.Fatalf("cut: %v", err)
+	}
+	notes, err := os.ReadFile(filepath.Join(dir, ".papertrail", "release-notes.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(notes), "### Contributors") {
+		t.Fatalf("release notes have no contributors:\n%s", notes)
+	}
+	if err := cmdVerifyTag(t.Context(), []string{"v1.3.0"}); err != nil {
+		t.Fatalf("verify-tag after cut: %v", err)
+	}
+}
+
+func TestCmdVerifyTag_MergeWithGitContributors(t *testing.T) {
+	dir := setupCutRepo(t)
+	if err := cmdMerge(t.Context(), []string{"--version", "v1.3.0", "--date", "2026-02-01", "--contributors-from-git", "--release-notes-out", "notes.md"}); err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	notes, err := os.ReadFile(filepath.Join(dir, "notes.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(notes), "### Contributors") {
+		t.Fatalf("release notes have no contributors:\n%s", notes)
+	}
+	gitIn(t, dir, "add", "-A")
+	gitIn(t, dir, "commit", "-q", "-m", "chore(release): v1.3.0")
+	gitIn(t, dir, "tag", "-a", "v1.3.0", "--cleanup=verbatim", "-F", "notes.md")
+	if err := cmdVerifyTag(t.Context(), []string{"v1.3.0"}); err != nil {
+		t.Fatalf("verify-tag after merge: %v", err)
+	}
+}
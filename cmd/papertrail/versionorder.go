@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// cmdVersionOrder verifies that release sections appear in strictly descending version
+// order, which manual hotfix edits often break. With --fix it re-sorts them, newest first.
+func cmdVersionOrder(_ context.Context, args []string) error {
+	fs := flag.NewFlagSet("version-order", flag.ContinueOnError)
+	fs.SetOutput(ioDiscard{})
+	changelogPath := fs.String("changelog", "CHANGELOG.md", "changelog path")
+	fix := fs.Bool("fix", false, "re-sort out-of-order release sections in place")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	fsys := hostFS{}
+	b, err := readFile(fsys, *changelogPath)
+	if err != nil {
+		return err
+	}
+	preamble, sections := splitChangelog(string(b))
+	problems, duplicates := versionOrderProblems(preamble, sections)
+	if len(problems) == 0 {
+		return nil
+	}
+	if !*fix {
+		return fmt.Errorf("%s: release sections are not in descending version order (run with --fix to re-sort):\n  %s",
+			*changelogPath, strings.Join(problems, "\n  "))
+	}
+	if duplicates {
+		return fmt.Errorf("%s: has duplicate release sections; run papertrail fix-changelog to merge them first:\n  %s",
+			*changelogPath, strings.Join(problems, "\n  "))
+	}
+	sortSectionsNewestFirst(sections)
+	if err := fsys.WriteFile(*changelogPath, []byte(joinChangelog(preamble, sections)), 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "%s: re-sorted release sections (%d out of order)\n", *changelogPath, len(problems))
+	return nil
+}
+
+// versionOrderProblems reports each versioned section that is not older than the versioned
+// section before it, by line number. Dated sections are not compared.
+func versionOrderProblems(preamble string, sections []changelogSection) (problems []string, duplicates bool) {
+	line := strings.Count(preamble, "\n") + 1
+	var prev semver
+	havePrev := false
+	for _, s := range sections {
+		v, err := parseSemver(s.Key)
+		if err == nil {
+			switch c := v.Compare(prev); {
+			case !havePrev:
+			case c == 0:
+				duplicates = true
+				problems = append(problems, fmt.Sprintf("line %d: duplicate section for %s", line, v))
+			case c > 0:
+				problems = append(problems, fmt.Sprintf("line %d: %s appears after older %s", line, v, prev))
+			}
+			prev, havePrev = v, true
+		}
+		line += strings.Count(s.Body, "\n")
+	}
+	return problems, duplicates
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCmdVersionOrder(t *testing.T) {
+	t.Chdir(t.TempDir())
+	doc := "# Changelog\n\n" +
+		"## v1.2.0 (2026-03-01)\n\n- c\n\n" +
+		"## v1.1.1 (2026-02-15)\n\n- hotfix\n\n" +
+		"## v1.0.0 (2026-01-01)\n\n- a\n\n" +
+		"## v1.1.0 (2026-02-01)\n\n- b\n\n" +
+		"## 2025-12-01\n\n- dated\n"
+	if err := os.WriteFile("CHANGELOG.md", []byte(doc), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := cmdVersionOrder(t.Context(), nil)
+	if err == nil || !strings.Contains(err.Error(), "line 15: v1.1.0 appears after older v1.0.0") {
+		t.Fatalf("expected an out-of-order error, got %v", err)
+	}
+	if err := cmdVersionOrder(t.Context(), []string{"--fix"}); err != nil {
+		t.Fatal(err)
+	}
+	want := "# Changelog\n\n" +
+		"## v1.2.0 (2026-03-01)\n\n- c\n\n" +
+		"## v1.1.1 (2026-02-15)\n\n- hotfix\n\n" +
+		"## v1.1.0 (2026-02-01)\n\n- b\n\n" +
+		"## v1.0.0 (2026-01-01)\n\n- a\n\n" +
+		"## 2025-12-01\n\n- dated\n"
+	if b, _ := os.ReadFile("CHANGELOG.md"); string(b) != want {
+		t.Fatalf("re-sorted changelog:\n%s", b)
+	}
+	if err := cmdVersionOrder(t.Context(), nil); err != nil {
+		t.Fatalf("sorted changelog: %v", err)
+	}
+
+	dup := "# Changelog\n\n## v1.0.0\n\n- a\n\n## v1.0.0\n\n- b\n"
+	if err := os.WriteFile("CHANGELOG.md", []byte(dup), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmdVersionOrder(t.Context(), []string{"--fix"}); err == nil || !strings.Contains(err.Error(), "fix-changelog") {
+		t.Fatalf("expected duplicates to point at fix-changelog, got %v", err)
+	}
+}
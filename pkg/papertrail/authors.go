@@ -0,0 +1,60 @@
+package papertrail
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// githubHandleRE matches a GitHub @-handle: alphanumerics and single inner dashes, at most
+// 39 characters.
+var githubHandleRE = regexp.MustCompile(`^@[A-Za-z0-9](?:-?[A-Za-z0-9]){0,38}$`)
+
+// IsGitHubHandle reports whether author is a GitHub @-handle such as "@octocat".
+func IsGitHubHandle(author string) bool {
+	return githubHandleRE.MatchString(author)
+}
+
+// normalizeAuthors folds the author field into authors, trims and de-duplicates them, and
+// returns a message per invalid author. Authors are @-handles or free-form names on one line.
+func normalizeAuthors(f *Fragment) []string {
+	all := f.Authors
+	if a := strings.TrimSpace(f.Author); a != "" {
+		all = append([]string{a}, all...)
+	}
+	f.Author = ""
+	f.Authors = nil
+	var msgs []string
+	for _, a := range all {
+		a = strings.TrimSpace(a)
+		switch {
+		case a == "":
+			msgs = append(msgs, "authors must not be empty")
+		case strings.ContainsAny(a, "\r\n"):
+			msgs = append(msgs, fmt.Sprintf("author %q must be on one line", a))
+		case strings.HasPrefix(a, "@") && !IsGitHubHandle(a):
+			msgs = append(msgs, fmt.Sprintf("author %q is not a valid GitHub @-handle", a))
+		case !slices.Contains(f.Authors, a):
+			f.Authors = append(f.Authors, a)
+		}
+	}
+	return msgs
+}
+
+// Contributors returns the distinct authors of fragments, sorted case-insensitively with
+// @-handles ordered by name.
+func Contributors(fragments []Fragment) []string {
+	var out []string
+	for _, f := range fragments {
+		for _, a := range f.Authors {
+			if !slices.Contains(out, a) {
+				out = append(out, a)
+			}
+		}
+	}
+	slices.SortStableFunc(out, func(a, b string) int {
+		return strings.Compare(strings.ToLower(strings.TrimPrefix(a, "@")), strings.ToLower(strings.TrimPrefix(b, "@")))
+	})
+	return out
+}
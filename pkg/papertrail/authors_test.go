@@ -0,0 +1,40 @@
+package papertrail
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestValidateFragment_Authors(t *testing.T) {
+	t.Parallel()
+
+	const base = "component: CLI\ntype: fix\nsummary: Fix it.\n"
+	f, err := ParseFragment([]byte(base+"author: '@octocat'\nauthors: [Jane Doe, '@octocat', ' @hubot ']\n"), Manifest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(f.Authors, []string{"@octocat", "Jane Doe", "@hubot"}) || f.Author != "" {
+		t.Fatalf("authors: %q (author %q)", f.Authors, f.Author)
+	}
+
+	for _, bad := range []string{"author: '@-bad'", "authors: ['@a_b']", "authors: ['']", "authors: [\"Jane\\nDoe\"]"} {
+		_, issues := ValidateFragment([]byte(base+bad+"\n"), Manifest{})
+		if len(issues) != 1 || issues[0].Rule != RuleInvalidAuthor || issues[0].Severity != SeverityError {
+			t.Fatalf("%s: issues %+v", bad, issues)
+		}
+	}
+}
+
+func TestContributors(t *testing.T) {
+	t.Parallel()
+
+	got := Contributors([]Fragment{
+		{Authors: []string{"@zed", "bob"}},
+		{},
+		{Authors: []string{"@Alice", "bob"}},
+	})
+	if strings.Join(got, ",") != "@Alice,bob,@zed" {
+		t.Fatalf("Contributors = %q", got)
+	}
+}
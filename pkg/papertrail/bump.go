@@ -0,0 +1,147 @@
+package papertrail
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Bump is a SemVer version bump, ordered so a larger Bump is a bigger change.
+type Bump int
+
+const (
+	Patch Bump = iota
+	Minor
+	Major
+)
+
+func (b Bump) String() string {
+	switch b {
+	case Major:
+		return "major"
+	case Minor:
+		return "minor"
+	default:
+		return "patch"
+	}
+}
+
+// ComputeBump returns the highest bump the fragments call for under
+// versioning.component_rules and versioning.rules (see BumpForFragment). Types without a
+// rule (and no "*" rule) bump patch.
+func ComputeBump(fragments []Fragment, m Manifest) Bump {
+	bump := Patch
+	for _, f := range fragments {
+		// No mapping (no manifest, or no explicit rule and no '*') falls back to patch to
+		// avoid surprising "semantic" hard-codes.
+		if bt, ok := m.BumpForFragment(f); ok && bt > bump {
+			bump = bt
+		}
+	}
+	return bump
+}
+
+// ZeroMajorBump returns b as it applies to a version whose major version is baseMajor. Under
+// versioning.zero_major_policy, a 0.x version degrades major to minor and minor to patch, so
+// breaking changes before 1.0 release v0.(N+1).0 rather than v1.0.0.
+func (m Manifest) ZeroMajorBump(b Bump, baseMajor uint64) Bump {
+	if !m.Versioning.ZeroMajorPolicy || baseMajor != 0 || b == Patch {
+		return b
+	}
+	return b - 1
+}
+
+// BumpForFragment returns the bump for a fragment: its component's
+// versioning.component_rules when they have a rule for its type (or "*"), else BumpFor.
+func (m Manifest) BumpForFragment(f Fragment) (b Bump, ok bool) {
+	b, _, ok = m.BumpRule(f)
+	return b, ok
+}
+
+// BumpRule is BumpForFragment that also names the rule deciding the bump, e.g.
+// versioning.rules["FEATURE"] or versioning.component_rules["API"]["*"].
+func (m Manifest) BumpRule(f Fragment) (b Bump, rule string, ok bool) {
+	component := strings.TrimSpace(f.Component)
+	if rules, found := m.Versioning.ComponentRules[component]; found {
+		if b, key, ok := bumpFromRules(rules, f.Type); ok {
+			return b, fmt.Sprintf("versioning.component_rules[%q][%q]", component, key), true
+		}
+	}
+	b, key, ok := bumpFromRules(m.Versioning.Rules, f.Type)
+	if !ok {
+		return Patch, "", false
+	}
+	return b, fmt.Sprintf("versioning.rules[%q]", key), true
+}
+
+// BumpFor returns the versioning.rules bump for a fragment type, falling back to the "*"
+// rule. ok is false when neither applies.
+func (m Manifest) BumpFor(fragmentType string) (b Bump, ok bool) {
+	b, _, ok = bumpFromRules(m.Versioning.Rules, fragmentType)
+	return b, ok
+}
+
+// bumpFromRules looks fragmentType up in rules, falling back to "*"; key is the rule used.
+func bumpFromRules(rules map[string]string, fragmentType string) (b Bump, key string, ok bool) {
+	if len(rules) == 0 {
+		return Patch, "", false
+	}
+	key = strings.ToUpper(strings.TrimSpace(fragmentType))
+	v, ok := rules[key]
+	if !ok {
+		key = "*"
+		v, ok = rules[key]
+		if !ok {
+			return Patch, "", false
+		}
+	}
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "major":
+		return Major, key, true
+	case "minor":
+		return Minor, key, true
+	case "patch":
+		return Patch, key, true
+	default:
+		return Patch, "", false
+	}
+}
+
+func validateBumpRules(rules map[string]string, path string) error {
+	for k, v := range rules {
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "major", "minor", "patch":
+		default:
+			return fmt.Errorf("invalid %s[%q]=%q (expected major|minor|patch)", path, k, v)
+		}
+	}
+	return nil
+}
+
+func validateComponentRules(rules map[string]map[string]string) error {
+	names := make([]string, 0, len(rules))
+	for name := range rules {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("invalid versioning.component_rules: empty component name")
+		}
+		if err := validateBumpRules(rules[name], fmt.Sprintf("versioning.component_rules[%q]", name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func normalizeComponentRules(rules map[string]map[string]string, typeAliases map[string]string) map[string]map[string]string {
+	if len(rules) == 0 {
+		return nil
+	}
+	out := make(map[string]map[string]string, len(rules))
+	for name, r := range rules {
+		out[strings.TrimSpace(name)] = normalizeBumpRuleKeys(r, typeAliases)
+	}
+	return out
+}
@@ -0,0 +1,109 @@
+package papertrail
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestComputeBump(t *testing.T) {
+	t.Parallel()
+
+	m, err := ParseManifest([]byte("versioning:\n  rules:\n    breaking: major\n    feature: minor\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		types []string
+		want  Bump
+	}{
+		{nil, Patch},
+		{[]string{"FIX"}, Patch},
+		{[]string{"FIX", "FEATURE"}, Minor},
+		{[]string{"FEATURE", "BREAKING", "FIX"}, Major},
+	} {
+		var fragments []Fragment
+		for _, typ := range tc.types {
+			fragments = append(fragments, Fragment{Type: typ})
+		}
+		if got := ComputeBump(fragments, m); got != tc.want {
+			t.Fatalf("%q: got %s, want %s", tc.types, got, tc.want)
+		}
+	}
+
+	m.Versioning.Rules["*"] = "minor"
+	if got := ComputeBump([]Fragment{{Type: "CHORE"}}, m); got != Minor {
+		t.Fatalf(`"*" rule: got %s, want minor`, got)
+	}
+	if got := ComputeBump([]Fragment{{Type: "BREAKING"}}, Manifest{}); got != Patch {
+		t.Fatalf("no rules: got %s, want patch", got)
+	}
+}
+
+func TestComputeBump_ComponentRules(t *testing.T) {
+	t.Parallel()
+
+	m, err := ParseManifest([]byte(`types:
+  aliases:
+    BREAKING CHANGE: breaking
+versioning:
+  rules:
+    breaking: major
+    feature: minor
+  component_rules:
+    GitHub Actions:
+      breaking change: minor
+    Docs:
+      "*": patch
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		fragments []Fragment
+		want      Bump
+	}{
+		{[]Fragment{{Component: "GitHub Actions", Type: "BREAKING"}}, Minor},
+		{[]Fragment{{Component: "GitHub Actions", Type: "FEATURE"}}, Minor},
+		{[]Fragment{{Component: "Docs", Type: "BREAKING"}}, Patch},
+		{[]Fragment{{Component: "Docs", Type: "FEATURE"}, {Component: "CLI", Type: "BREAKING"}}, Major},
+	} {
+		if got := ComputeBump(tc.fragments, m); got != tc.want {
+			t.Errorf("%+v: got %s, want %s", tc.fragments, got, tc.want)
+		}
+	}
+	if b, rule, ok := m.BumpRule(Fragment{Component: "Docs", Type: "BREAKING"}); !ok || b != Patch || rule != `versioning.component_rules["Docs"]["*"]` {
+		t.Fatalf("BumpRule: got %s, %q, %v", b, rule, ok)
+	}
+	if _, _, ok := m.BumpRule(Fragment{Component: "CLI", Type: "FIX"}); ok {
+		t.Fatal("BumpRule: expected no rule for FIX")
+	}
+
+	if _, err := ParseManifest([]byte("versioning:\n  component_rules:\n    CLI:\n      fix: huge\n")); err == nil || !strings.Contains(err.Error(), `versioning.component_rules["CLI"]`) {
+		t.Fatalf("expected an invalid rule error, got %v", err)
+	}
+}
+
+func TestZeroMajorBump(t *testing.T) {
+	t.Parallel()
+
+	var m Manifest
+	if got := m.ZeroMajorBump(Major, 0); got != Major {
+		t.Fatalf("policy off: got %s, want major", got)
+	}
+	m.Versioning.ZeroMajorPolicy = true
+	for _, tc := range []struct {
+		bump      Bump
+		baseMajor uint64
+		want      Bump
+	}{
+		{Major, 0, Minor},
+		{Minor, 0, Patch},
+		{Patch, 0, Patch},
+		{Major, 1, Major},
+		{Minor, 2, Minor},
+	} {
+		if got := m.ZeroMajorBump(tc.bump, tc.baseMajor); got != tc.want {
+			t.Fatalf("%s from v%d: got %s, want %s", tc.bump, tc.baseMajor, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package papertrail
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Changesets configures reading the Markdown files of the changesets tool (.changeset/*.md)
+// as pending fragments, declared under `fragments.changesets` in the manifest.
+type Changesets struct {
+	// Dir is the changesets directory (usually .changeset); reading is off when it is empty.
+	Dir string `yaml:"dir"`
+	// Components maps package names to components (default: the package name).
+	Components map[string]string `yaml:"components"`
+	// Types maps changeset bumps (major, minor, patch) to fragment types
+	// (default: DefaultChangesetTypes).
+	Types map[string]string `yaml:"types"`
+}
+
+// DefaultChangesetTypes are the fragment types of changeset bumps unless
+// fragments.changesets.types overrides them.
+var DefaultChangesetTypes = map[string]string{
+	"major": "BREAKING",
+	"minor": "FEATURE",
+	"patch": "FIX",
+}
+
+// IsChangesetFile reports whether name is a changeset in fragments.changesets.dir: a
+// Markdown file directly in it, other than README.md.
+func (m Manifest) IsChangesetFile(name string) bool {
+	dir := m.changesetDir()
+	if dir == "" || FragmentFormat(name) != FormatMarkdown {
+		return false
+	}
+	return path.Dir(path.Clean(strings.ReplaceAll(name, "\\", "/"))) == dir
+}
+
+// changesetDir is fragments.changesets.dir as a clean slash-separated path, or "".
+func (m Manifest) changesetDir() string {
+	dir := strings.TrimSpace(m.Fragments.Changesets.Dir)
+	if dir == "" {
+		return ""
+	}
+	return path.Clean(strings.ReplaceAll(dir, "\\", "/"))
+}
+
+// ChangesetFiles lists the changesets in fragments.changesets.dir, sorted. It returns none
+// when changesets are not configured or the directory does not exist.
+func ChangesetFiles(fsys fs.FS, m Manifest) ([]string, error) {
+	dir := m.changesetDir()
+	if dir == "" {
+		return nil, nil
+	}
+	des, err := fs.ReadDir(fsys, dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, de := range des {
+		p := path.Join(dir, de.Name())
+		if !de.IsDir() && m.IsChangesetFile(p) {
+			files = append(files, p)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// ValidateChangeset parses and validates a changeset: YAML front matter mapping package
+// names to bumps, then a Markdown summary. Each package becomes one fragment, in front
+// matter order, whose component and type come from fragments.changesets; the summary's
+// first paragraph is the fragment summary and the rest its Details. Packages mapping to the
+// same component and type yield one fragment. An empty changeset yields none.
+func ValidateChangeset(b []byte, m Manifest) ([]Fragment, []Issue) {
+	invalid := func(format string, args ...any) ([]Fragment, []Issue) {
+		return nil, []Issue{{Rule: RuleInvalidYAML, Severity: SeverityError, Message: fmt.Sprintf(format, args...)}}
+	}
+	front, body, err := SplitFrontMatter(b)
+	if err != nil {
+		return invalid("%s", strings.Replace(err.Error(), "a Markdown fragment", "a changeset", 1))
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(front, &doc); err != nil {
+		return invalid("invalid YAML front matter: %v", err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	packages := doc.Content[0]
+	if packages.Kind != yaml.MappingNode {
+		return invalid("invalid changeset front matter: expected a map of package names to bumps")
+	}
+
+	summary, details := splitChangesetBody(string(body))
+	cfg := m.Fragments.Changesets
+	var fragments []Fragment
+	var issues []Issue
+	seen := map[[2]string]bool{}
+	for i := 0; i+1 < len(packages.Content); i += 2 {
+		pkg, bump := packages.Content[i].Value, strings.ToLower(strings.TrimSpace(packages.Content[i+1].Value))
+		typ, ok := cfg.Types[bump]
+		if !ok {
+			typ, ok = DefaultChangesetTypes[bump]
+		}
+		if !ok {
+			return invalid("invalid bump %q for package %s (expected major|minor|patch)", packages.Content[i+1].Value, pkg)
+		}
+		component := pkg
+		if c, ok := cfg.Components[pkg]; ok {
+			component = c
+		}
+		key := [2]string{component, strings.ToUpper(typ)}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		f, fIssues := validateFragment(Fragment{Component: component, Type: typ, Summary: summary}, m)
+		f.Details = details
+		for _, is := range fIssues {
+			is.Message = fmt.Sprintf("package %s: %s", pkg, is.Message)
+			issues = append(issues, is)
+		}
+		fragments = append(fragments, f)
+	}
+	return fragments, issues
+}
+
+// splitChangesetBody splits a changeset's Markdown into a one-line summary (the first
+// paragraph) and details (the rest).
+func splitChangesetBody(body string) (summary, details string) {
+	body = strings.TrimSpace(strings.ReplaceAll(body, "\r\n", "\n"))
+	first, rest, _ := strings.Cut(body, "\n\n")
+	return strings.Join(strings.Fields(first), " "), normalizeDetails(rest)
+}
+
+func normalizeChangesets(c Changesets) Changesets {
+	c.Dir = strings.TrimSpace(c.Dir)
+	if len(c.Types) > 0 {
+		types := make(map[string]string, len(c.Types))
+		for k, v := range c.Types {
+			types[strings.ToLower(strings.TrimSpace(k))] = strings.TrimSpace(v)
+		}
+		c.Types = types
+	}
+	return c
+}
+
+func validateChangesets(c Changesets) error {
+	for k, v := range c.Types {
+		if _, ok := DefaultChangesetTypes[k]; !ok {
+			return fmt.Errorf("invalid fragments.changesets.types key %q (expected major|minor|patch)", k)
+		}
+		if v == "" {
+			return fmt.Errorf("fragments.changesets.types.%s is empty", k)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package papertrail
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestValidateChangeset(t *testing.T) {
+	t.Parallel()
+
+	m, err := ParseManifest([]byte("fragments:\n  changesets:\n    dir: .changeset\n    components:\n      \"@acme/web\": Web\n      \"@acme/web-ui\": Web\n    types:\n      major: breaking change\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := []byte("---\n\"@acme/web\": minor\n\"@acme/web-ui\": minor\n\"@acme/sdk\": Major\n---\n\nAdd dark\nmode.\n\nThe toggle lives in *Settings*.\n")
+	got, issues := ValidateChangeset(b, m)
+	if len(issues) != 0 {
+		t.Fatalf("issues: %+v", issues)
+	}
+	want := []Fragment{
+		{Component: "Web", Type: "FEATURE", Summary: "Add dark mode.", Details: "The toggle lives in *Settings*."},
+		{Component: "@acme/sdk", Type: "BREAKING CHANGE", Summary: "Add dark mode.", Details: "The toggle lives in *Settings*."},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+
+	if got, issues := ValidateChangeset([]byte("---\n---\n"), m); len(got) != 0 || len(issues) != 0 {
+		t.Fatalf("empty changeset: %+v %+v", got, issues)
+	}
+	for in, want := range map[string]string{
+		"no front matter\n":                 "missing YAML front matter (a changeset",
+		"---\npkg: huge\n---\n\nx\n":        `invalid bump "huge" for package pkg`,
+		"---\n- pkg\n---\n\nx\n":            "expected a map of package names to bumps",
+		"---\npkg: patch\n---\n":            "package pkg: missing required field: summary",
+		"---\n\"@acme/web\": patch\n---\nx": "",
+	} {
+		_, err := ParseFragmentFile(".changeset/x.md", []byte(in), m)
+		if (want == "" && err != nil) || (want != "" && (err == nil || !strings.Contains(err.Error(), want))) {
+			t.Fatalf("%q: got %v, want %q", in, err, want)
+		}
+	}
+	if _, err := ParseManifest([]byte("fragments:\n  changesets:\n    types:\n      none: fix\n")); err == nil {
+		t.Fatalf("expected an error for an unknown bump key")
+	}
+}
+
+func TestChangesetFiles(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		".changeset/a.md":        {},
+		".changeset/README.md":   {},
+		".changeset/config.json": {},
+		".changeset/sub/b.md":    {},
+	}
+	var m Manifest
+	if got, err := ChangesetFiles(fsys, m); err != nil || got != nil {
+		t.Fatalf("unconfigured: %v, %v", got, err)
+	}
+	m.Fragments.Changesets.Dir = ".changeset/"
+	if got, err := ChangesetFiles(fsys, m); err != nil || !reflect.DeepEqual(got, []string{".changeset/a.md"}) {
+		t.Fatalf("got %v, %v", got, err)
+	}
+	if m.IsChangesetFile("changelog.d/a.md") || !m.IsChangesetFile("./.changeset/a.md") {
+		t.Fatalf("IsChangesetFile")
+	}
+	m.Fragments.Changesets.Dir = "missing"
+	if got, err := ChangesetFiles(fsys, m); err != nil || got != nil {
+		t.Fatalf("missing dir: %v, %v", got, err)
+	}
+}
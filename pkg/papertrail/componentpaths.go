@@ -0,0 +1,61 @@
+package papertrail
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// ComponentForPaths infers the component of a change from the files it touches, through
+// changelog.component_paths: the component owning the most files wins, ties going to the
+// earlier one in ComponentOrder (then lexicographically). A pattern owns a file it matches
+// with path.Match, or any file under it when it names a directory ("cmd/" or "cmd"). ok is
+// false when no file is owned.
+func (m Manifest) ComponentForPaths(files []string) (component string, ok bool) {
+	best := 0
+	order := m.ComponentOrder()
+	for c, patterns := range m.Changelog.ComponentPaths {
+		n := 0
+		for _, f := range files {
+			if slices.ContainsFunc(patterns, func(p string) bool { return pathOwns(p, f) }) {
+				n++
+			}
+		}
+		if n > best || (n == best && n > 0 && compareByOrderOrLex(c, component, order) < 0) {
+			component, best = c, n
+		}
+	}
+	return component, best > 0
+}
+
+func pathOwns(pattern, file string) bool {
+	pattern = strings.TrimPrefix(pattern, "./")
+	if ok, _ := path.Match(pattern, file); ok {
+		return true
+	}
+	dir := strings.TrimSuffix(pattern, "/")
+	return dir != "" && strings.HasPrefix(file, dir+"/")
+}
+
+func validateComponentPaths(paths map[string][]string) error {
+	names := make([]string, 0, len(paths))
+	for name := range paths {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("invalid changelog.component_paths: empty component name")
+		}
+		for _, p := range paths[name] {
+			if strings.TrimSpace(p) == "" {
+				return fmt.Errorf("invalid changelog.component_paths[%q]: empty path", name)
+			}
+			if _, err := path.Match(p, ""); err != nil {
+				return fmt.Errorf("invalid changelog.component_paths[%q]: pattern %q: %w", name, p, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package papertrail
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestManifest_ComponentForPaths(t *testing.T) {
+	t.Parallel()
+
+	m, err := ParseManifest([]byte(`changelog:
+  components: [CLI, Docs]
+  component_paths:
+    CLI: [cmd/, pkg]
+    Docs: ['*.md', docs/]
+    Actions: [.github/actions/]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		files []string
+		want  string
+	}{
+		{[]string{"cmd/papertrail/main.go", "README.md"}, "CLI"},
+		{[]string{"README.md", "docs/a.md", "pkg/x.go"}, "Docs"},
+		{[]string{".github/actions/x/action.yml"}, "Actions"},
+		{[]string{"docs/a.md", ".github/actions/x/action.yml"}, "Docs"},
+		{[]string{"go.mod", "pkgs/x.go"}, ""},
+	} {
+		got, ok := m.ComponentForPaths(tc.files)
+		if got != tc.want || ok != (tc.want != "") {
+			t.Errorf("%v: got %q (%v), want %q", tc.files, got, ok, tc.want)
+		}
+	}
+
+	if _, err := ParseManifest([]byte("changelog:\n  component_paths:\n    CLI: ['cmd/[']\n")); err == nil || !strings.Contains(err.Error(), `component_paths["CLI"]`) {
+		t.Fatalf("expected an invalid pattern error, got %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+package papertrail
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ComponentSeparator nests a component under a parent ("Server/Auth"). Nested components
+// are rendered under a parent heading, each with its own subheading.
+const ComponentSeparator = "/"
+
+// SplitComponent splits a nested component into its parent and child. For a top-level
+// component, parent is the component itself and nested is false.
+func SplitComponent(component string) (parent, child string, nested bool) {
+	parent, child, nested = strings.Cut(component, ComponentSeparator)
+	return parent, child, nested
+}
+
+// normalizeComponent trims the component and the space around its separator, so
+// "Server / Auth" is "Server/Auth".
+func normalizeComponent(component string) string {
+	parts := strings.Split(strings.TrimSpace(component), ComponentSeparator)
+	for i, p := range parts {
+		parts[i] = strings.TrimSpace(p)
+	}
+	return strings.Join(parts, ComponentSeparator)
+}
+
+// componentProblem explains why a (normalized) component cannot be rendered, or returns "".
+func componentProblem(component string) string {
+	parts := strings.Split(component, ComponentSeparator)
+	switch {
+	case len(parts) > 2:
+		return fmt.Sprintf("component %q is nested more than one level (expected Parent%sChild)", component, ComponentSeparator)
+	case len(parts) == 2 && (parts[0] == "" || parts[1] == ""):
+		return fmt.Sprintf("component %q has an empty parent or child (expected Parent%sChild)", component, ComponentSeparator)
+	}
+	return ""
+}
+
+func validateComponents(components []string) error {
+	for _, c := range components {
+		if p := componentProblem(c); p != "" {
+			return fmt.Errorf("invalid changelog.components: %s", p)
+		}
+	}
+	return nil
+}
+
+// compareComponents orders components level by level: parents in the order they first
+// appear in order (a nested entry places its parent), then, under the same parent, the
+// parent's own entries before its children, which follow the order of their entries.
+// Components missing from order sort after the known ones, lexicographically.
+func compareComponents(a, b string, order []string) int {
+	if a == b {
+		return 0
+	}
+	pa, ca, _ := SplitComponent(a)
+	pb, cb, _ := SplitComponent(b)
+	if pa != pb {
+		return compareByOrderOrLex(pa, pb, componentLevel(order, "", false))
+	}
+	switch {
+	case ca == "":
+		return -1
+	case cb == "":
+		return 1
+	}
+	return compareByOrderOrLex(ca, cb, componentLevel(order, pa, true))
+}
+
+// componentLevel lists the parents in order (children is false), or the children of parent,
+// without duplicates.
+func componentLevel(order []string, parent string, children bool) []string {
+	var level []string
+	for _, c := range order {
+		p, child, nested := SplitComponent(c)
+		name := p
+		if children {
+			if !nested || p != parent {
+				continue
+			}
+			name = child
+		}
+		if !slices.Contains(level, name) {
+			level = append(level, name)
+		}
+	}
+	return level
+}
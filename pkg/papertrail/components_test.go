@@ -0,0 +1,53 @@
+package papertrail
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderRelease_NestedComponents(t *testing.T) {
+	t.Parallel()
+
+	m, err := ParseManifest([]byte("changelog:\n  components: [CLI, Server/Storage, Server / Auth]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(m.ComponentOrder(), ","); got != "CLI,Server/Storage,Server/Auth" {
+		t.Fatalf("ComponentOrder: got %s", got)
+	}
+	fragments := []Fragment{
+		{Path: "1.yml", Component: "Server/Auth", Type: "FIX", Summary: "Fix login"},
+		{Path: "2.yml", Component: "Server", Type: "FIX", Summary: "Fix startup"},
+		{Path: "3.yml", Component: "Server/Storage", Type: "FIX", Summary: "Fix quota"},
+		{Path: "4.yml", Component: "CLI", Type: "FIX", Summary: "Fix flag"},
+		{Path: "5.yml", Component: "Server/Cache", Type: "FIX", Summary: "Fix eviction"},
+	}
+	want := "## v1.0.0\n\n" +
+		"### CLI\n\n- **fix**: Fix flag.\n\n" +
+		"### Server\n\n- **fix**: Fix startup.\n\n" +
+		"#### Storage\n\n- **fix**: Fix quota.\n\n" +
+		"#### Auth\n\n- **fix**: Fix login.\n\n" +
+		"#### Cache\n\n- **fix**: Fix eviction.\n\n"
+	if got := string(RenderRelease(NewReleaseSection("v1.0.0", "", fragments, m), m.Changelog.Style)); got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestValidateFragment_NestedComponents(t *testing.T) {
+	t.Parallel()
+
+	var m Manifest
+	f, issues := ValidateFragment([]byte("component: Server / Auth\ntype: fix\nsummary: Fix login\n"), m)
+	if len(issues) != 0 || f.Component != "Server/Auth" {
+		t.Fatalf("got %+v, issues %+v", f, issues)
+	}
+	for _, c := range []string{"Server/Auth/Tokens", "/Auth", "Server/"} {
+		_, issues := ValidateFragment([]byte("component: "+c+"\ntype: fix\nsummary: Fix login\n"), m)
+		if len(issues) != 1 || issues[0].Rule != RuleInvalidComponent {
+			t.Errorf("%s: got issues %+v", c, issues)
+		}
+	}
+	if _, err := ParseManifest([]byte("changelog:\n  components: [a/b/c]\n")); err == nil || !strings.Contains(err.Error(), "invalid changelog.components") {
+		t.Fatalf("expected an invalid components error, got %v", err)
+	}
+}
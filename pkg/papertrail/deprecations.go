@@ -0,0 +1,72 @@
+package papertrail
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DeprecationType is the fragment type for deprecation notices.
+const DeprecationType = "DEPRECATION"
+
+// DeprecationsHeading heads the deprecation notices at the end of a release section.
+const DeprecationsHeading = "Deprecations"
+
+// removalVersionRE matches a release version without build metadata, like v2.0.0 or
+// v2.0.0-rc.1.
+var removalVersionRE = regexp.MustCompile(`^v(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)(-[0-9A-Za-z.-]+)?$`)
+
+// Deprecation reports whether the fragment is a deprecation notice: its type is
+// DeprecationType or it declares a RemovalVersion.
+func (f Fragment) Deprecation() bool {
+	return strings.EqualFold(f.Type, DeprecationType) || f.RemovalVersion != ""
+}
+
+// DeprecationSummary returns the summary a deprecation notice is listed with under
+// Deprecations: the fragment summary with its removal version, if any.
+func DeprecationSummary(f Fragment) string {
+	if f.RemovalVersion == "" {
+		return f.Summary
+	}
+	return strings.TrimRight(strings.TrimSpace(f.Summary), ".") + " (removal in " + f.RemovalVersion + ")"
+}
+
+// ParseDeprecationSummary splits a summary listed under Deprecations into the fragment
+// summary (with its period) and removal version, undoing DeprecationSummary.
+func ParseDeprecationSummary(s string) (summary, removalVersion string) {
+	if m := deprecationSummaryRE.FindStringSubmatch(s); m != nil {
+		return m[1] + ".", m[2]
+	}
+	return s, ""
+}
+
+var deprecationSummaryRE = regexp.MustCompile(`^(.*) \(removal in (v\S+)\)\.?$`)
+
+// renderDeprecations lists the deprecation notices of groups under DeprecationsHeading,
+// labeled with their component and followed by their removal version, or returns nothing
+// when there are none. The output starts with the heading and ends with the last entry's
+// line. The keepachangelog profile has a Deprecated category instead.
+func renderDeprecations(groups []ComponentGroup, heading string, style Style) []byte {
+	if style.KeepAChangelog() {
+		return nil
+	}
+	var buf bytes.Buffer
+	for _, g := range groups {
+		for _, f := range g.Fragments {
+			if !f.Deprecation() {
+				continue
+			}
+			if buf.Len() == 0 {
+				fmt.Fprintf(&buf, "%s %s\n\n", heading, DeprecationsHeading)
+			}
+			f.Summary = DeprecationSummary(f)
+			f.Type, f.Details = "", ""
+			if style.layout == nil || !style.layout.flat {
+				f.Summary = style.componentLabel(g.Name) + f.Summary
+			}
+			buf.WriteString(style.listItem(f) + "\n")
+		}
+	}
+	return buf.Bytes()
+}
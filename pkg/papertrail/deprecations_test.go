@@ -0,0 +1,42 @@
+package papertrail
+
+import "testing"
+
+func TestRenderRelease_Deprecations(t *testing.T) {
+	t.Parallel()
+
+	m, err := ParseManifest([]byte("changelog:\n  components: [CLI, API]\ntypes:\n  order: [feature, deprecation, fix]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fragments := []Fragment{
+		{Path: "1.yml", Component: "API", Type: "DEPRECATION", Summary: "Deprecate v1 endpoints.", RemovalVersion: "v2.0.0", Details: "Use v2."},
+		{Path: "2.yml", Component: "CLI", Type: "FIX", Summary: "Fix flag"},
+		{Path: "3.yml", Component: "CLI", Type: "DEPRECATION", Summary: "Deprecate --legacy"},
+	}
+	want := "## v1.0.0\n\n" +
+		"### CLI\n\n- **deprecation**: Deprecate --legacy.\n- **fix**: Fix flag.\n\n" +
+		"### API\n\n- **deprecation**: Deprecate v1 endpoints.\n\n  Use v2.\n\n" +
+		"### Deprecations\n\n- **CLI**: Deprecate --legacy.\n- **API**: Deprecate v1 endpoints (removal in v2.0.0).\n\n"
+	if got := string(RenderRelease(NewReleaseSection("v1.0.0", "", fragments, m), m.Changelog.Style)); got != want {
+		t.Fatalf("got:\n%q\nwant:\n%q", got, want)
+	}
+
+	if summary, removal := ParseDeprecationSummary("Deprecate v1 endpoints (removal in v2.0.0)."); summary != "Deprecate v1 endpoints." || removal != "v2.0.0" {
+		t.Fatalf("ParseDeprecationSummary = %q, %q", summary, removal)
+	}
+}
+
+func TestValidateFragment_RemovalVersion(t *testing.T) {
+	t.Parallel()
+
+	var m Manifest
+	f, issues := ValidateFragment([]byte("component: CLI\ntype: deprecation\nsummary: Deprecate it\nremoval_version: v2.0.0\n"), m)
+	if len(issues) != 0 || f.RemovalVersion != "v2.0.0" || !f.Deprecation() {
+		t.Fatalf("got %+v, issues %+v", f, issues)
+	}
+	_, issues = ValidateFragment([]byte("component: CLI\ntype: deprecation\nsummary: Deprecate it\nremoval_version: \"2.0\"\n"), m)
+	if len(issues) != 1 || issues[0].Rule != RuleInvalidRemovalVersion || issues[0].Severity != SeverityError {
+		t.Fatalf("issues = %+v", issues)
+	}
+}
@@ -0,0 +1,11 @@
+// Package papertrail is the core of the papertrail changelog tool as a library: loading
+// and validating changelog fragments against a release manifest, computing the SemVer bump
+// they call for, and rendering and inserting release sections into CHANGELOG.md.
+//
+// The papertrail CLI (cmd/papertrail) is built on this package, so a program using it
+// produces byte-for-byte the same changelog the CLI would. Output is deterministic: it
+// never depends on map iteration or discovery order.
+//
+// Output formats are Renderers registered by name (markdown, json, and html are built in);
+// Register adds a format, such as asciidoc, that Lookup then returns.
+package papertrail
@@ -0,0 +1,47 @@
+package papertrail_test
+
+import (
+	"fmt"
+	"testing/fstest"
+
+	"github.com/bnprtr/papertrail/pkg/papertrail"
+)
+
+func Example() {
+	fsys := fstest.MapFS{
+		".papertrail.config.yml":     {Data: []byte("versioning:\n  rules:\n    feature: minor\n")},
+		"CHANGELOG.md":               {Data: []byte("# Changelog\n\n## v1.2.0\n\n- Earlier release.\n")},
+		"changelog.d/20260101_a.yml": {Data: []byte("component: CLI\ntype: feature\nsummary: Add a flag\n")},
+		"changelog.d/20260102_b.yml": {Data: []byte("component: CLI\ntype: fix\nsummary: Fix a crash\n")},
+	}
+	m, err := papertrail.LoadManifest(fsys, "")
+	if err != nil {
+		panic(err)
+	}
+	fragments, err := papertrail.LoadFragments(fsys, "changelog.d", m)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("bump:", papertrail.ComputeBump(fragments, m))
+
+	section := papertrail.RenderRelease(papertrail.NewReleaseSection("v1.3.0", "2026-01-02", fragments, m), m.Changelog.Style)
+	changelog, err := papertrail.InsertSection(fsys["CHANGELOG.md"].Data, section)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Print(string(changelog))
+	// Output:
+	// bump: minor
+	// # Changelog
+	//
+	// ## v1.3.0 (2026-01-02)
+	//
+	// ### CLI
+	//
+	// - **feature**: Add a flag.
+	// - **fix**: Fix a crash.
+	//
+	// ## v1.2.0
+	//
+	// - Earlier release.
+}
@@ -0,0 +1,133 @@
+package papertrail
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Fragment file formats, as listed in fragments.formats.
+const (
+	FormatYAML     = "yaml"
+	FormatMarkdown = "markdown"
+	FormatJSON     = "json"
+	FormatTOML     = "toml"
+)
+
+// FragmentFormatNames are the valid fragments.formats values.
+var FragmentFormatNames = []string{FormatYAML, FormatMarkdown, FormatJSON, FormatTOML}
+
+// DefaultFragmentFormats are the formats discovered when fragments.formats is not set. Other
+// formats are opt-in, so existing .md, .json, or .toml files in a fragments directory are
+// not mistaken for fragments.
+var DefaultFragmentFormats = []string{FormatYAML}
+
+// FragmentFormat returns the format of a fragment file by its extension (.yml and .yaml,
+// .md, .json, .toml), or "" for other files. README.md documents the fragments directory
+// and is never a fragment.
+func FragmentFormat(name string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".yml", ".yaml":
+		return FormatYAML
+	case ".md":
+		if strings.EqualFold(path.Base(name), "README.md") {
+			return ""
+		}
+		return FormatMarkdown
+	case ".json":
+		return FormatJSON
+	case ".toml":
+		return FormatTOML
+	}
+	return ""
+}
+
+// FragmentFormats returns the fragment formats to discover: fragments.formats, or
+// DefaultFragmentFormats when it is not set.
+func (m Manifest) FragmentFormats() []string {
+	if len(m.Fragments.Formats) == 0 {
+		return DefaultFragmentFormats
+	}
+	return m.Fragments.Formats
+}
+
+// IsFragmentFile reports whether name is a fragment file in one of the manifest's formats.
+func (m Manifest) IsFragmentFile(name string) bool {
+	f := FragmentFormat(name)
+	return f != "" && slices.Contains(m.FragmentFormats(), f)
+}
+
+// ValidateJSONFragments parses and validates a JSON fragment file: one fragment object or
+// an array of them, with the same fields and rules as YAML fragments.
+func ValidateJSONFragments(b []byte, m Manifest) ([]Fragment, []Issue) {
+	entries, err := jsonFragmentEntries(b)
+	if err != nil {
+		return nil, []Issue{{Rule: RuleInvalidYAML, Severity: SeverityError, Message: fmt.Sprintf("invalid JSON: %v", err)}}
+	}
+	return validateEntries(len(entries), "JSON", func(i int, f *Fragment) error { return json.Unmarshal(entries[i], f) }, m)
+}
+
+func jsonFragmentEntries(b []byte) ([]json.RawMessage, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	var v json.RawMessage
+	if err := dec.Decode(&v); errors.Is(err, io.EOF) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, errors.New("unexpected data after the top-level value")
+	}
+	if trimmed := bytes.TrimSpace(v); len(trimmed) > 0 && trimmed[0] == '[' {
+		var entries []json.RawMessage
+		if err := json.Unmarshal(v, &entries); err != nil {
+			return nil, err
+		}
+		return entries, nil
+	}
+	return []json.RawMessage{v}, nil
+}
+
+// ValidateTOMLFragments parses and validates a TOML fragment file: top-level keys for one
+// fragment, or a [[fragments]] table per fragment, with the same fields and rules as YAML
+// fragments. Only the TOML that fragments need is supported: strings, booleans, integers,
+// and arrays.
+func ValidateTOMLFragments(b []byte, m Manifest) ([]Fragment, []Issue) {
+	entries, err := parseTOMLFragments(b)
+	if err != nil {
+		return nil, []Issue{{Rule: RuleInvalidYAML, Severity: SeverityError, Message: fmt.Sprintf("invalid TOML: %v", err)}}
+	}
+	return validateEntries(len(entries), "TOML", func(i int, f *Fragment) error {
+		// Decoding through JSON applies the same field types as JSON fragments.
+		jb, err := json.Marshal(entries[i])
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(jb, f)
+	}, m)
+}
+
+func validateFragmentFormats(formats []string) error {
+	for _, f := range formats {
+		if !slices.Contains(FragmentFormatNames, f) {
+			return fmt.Errorf("invalid fragments.formats %q (expected %s)", f, strings.Join(FragmentFormatNames, "|"))
+		}
+	}
+	return nil
+}
+
+func normalizeFragmentFormats(formats []string) []string {
+	var out []string
+	for _, f := range formats {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if f != "" && !slices.Contains(out, f) {
+			out = append(out, f)
+		}
+	}
+	return out
+}
@@ -0,0 +1,144 @@
+package papertrail
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFragmentFormat(t *testing.T) {
+	t.Parallel()
+
+	for name, want := range map[string]string{
+		"changelog.d/a.yml":     FormatYAML,
+		"changelog.d/a.YAML":    FormatYAML,
+		"changelog.d/a.md":      FormatMarkdown,
+		"changelog.d/README.md": "",
+		"changelog.d/readme.md": "",
+		"changelog.d/a.json":    FormatJSON,
+		"changelog.d/a.toml":    FormatTOML,
+		"changelog.d/a.txt":     "",
+		"changelog.d/.gitkeep":  "",
+	} {
+		if got := FragmentFormat(name); got != want {
+			t.Fatalf("FragmentFormat(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestFragmentFiles_Formats(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"changelog.d/a.yml":  {Data: []byte("component: CLI\ntype: fix\nsummary: a\n")},
+		"changelog.d/b.md":   {Data: []byte("---\ncomponent: CLI\ntype: fix\nsummary: b\n---\n")},
+		"changelog.d/c.json": {Data: []byte(`{"component": "CLI", "type": "fix", "summary": "c"}`)},
+		"changelog.d/d.toml": {Data: []byte("component = \"CLI\"\ntype = \"fix\"\nsummary = \"d\"\n")},
+	}
+	var m Manifest
+	if got, _ := FragmentFiles(fsys, "changelog.d", m); !reflect.DeepEqual(got, []string{"changelog.d/a.yml"}) {
+		t.Fatalf("default formats: %v", got)
+	}
+	m, err := ParseManifest([]byte("fragments:\n  formats: [JSON, toml, yaml]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadFragments(fsys, "changelog.d", m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var summaries []string
+	for _, f := range got {
+		summaries = append(summaries, f.Summary)
+	}
+	if !reflect.DeepEqual(summaries, []string{"a", "c", "d"}) {
+		t.Fatalf("summaries: %v", summaries)
+	}
+	if _, err := ParseManifest([]byte("fragments:\n  formats: [xml]\n")); err == nil {
+		t.Fatalf("expected an error for an unknown format")
+	}
+}
+
+func TestValidateJSONFragments(t *testing.T) {
+	t.Parallel()
+
+	var m Manifest
+	m.Types.Order = []string{"FIX"}
+	fragments, issues := ValidateJSONFragments([]byte(`[
+		{"component": "CLI", "type": "fix", "summary": "One", "refs": ["#1"]},
+		{"component": "API", "type": "chore", "summary": "Two"}
+	]`), m)
+	if len(fragments) != 2 || fragments[0].Refs[0] != "#1" {
+		t.Fatalf("fragments: %+v", fragments)
+	}
+	if len(issues) != 1 || issues[0].Rule != RuleUnknownType || !strings.HasPrefix(issues[0].Message, "entry 2: unknown type") {
+		t.Fatalf("issues: %+v", issues)
+	}
+
+	for in, want := range map[string]string{
+		`{"component": "CLI",}`: "invalid JSON",
+		`{"summary": 3}`:        "invalid JSON",
+		`{"summary": "x"} {}`:   "unexpected data",
+		`{"summary": "x"}`:      "missing required field: component",
+		`{"summary": "x", "path": "y", "component": "A", "type": "fix"}`: "",
+	} {
+		_, err := ParseFragmentFile("x.json", []byte(in), m)
+		if (want == "" && err != nil) || (want != "" && (err == nil || !strings.Contains(err.Error(), want))) {
+			t.Fatalf("%s: got %v, want %q", in, err, want)
+		}
+	}
+}
+
+func TestValidateTOMLFragments(t *testing.T) {
+	t.Parallel()
+
+	var m Manifest
+	b := []byte(`# Two changes from one PR.
+[[fragments]]
+component = "CLI"   # trailing comment
+type = 'fix'
+summary = "Fix \"quoting\" é"
+refs = [
+  "#1",
+  '#2', # the second
+]
+
+[[fragments]]
+component = "API"
+type = "feature"
+summary = """
+Add a \
+  multi-line summary"""
+`)
+	fragments, issues := ValidateTOMLFragments(b, m)
+	if len(issues) != 0 {
+		t.Fatalf("issues: %+v", issues)
+	}
+	want := []Fragment{
+		{Component: "CLI", Type: "FIX", Summary: `Fix "quoting" é`, Refs: []string{"#1", "#2"}},
+		{Component: "API", Type: "FEATURE", Summary: "Add a multi-line summary"},
+	}
+	if !reflect.DeepEqual(fragments, want) {
+		t.Fatalf("got %+v, want %+v", fragments, want)
+	}
+
+	for in, want := range map[string]string{
+		"component = \"CLI\"\ntype = \"fix\"\nsummary = \"ok\"\n": "",
+		"component = \"CLI\"\n":                                   "missing required field: type",
+		"summary = 3\n":                                           "invalid TOML",
+		"summary = \"x\nsummary2 = 1\n":                           "line 1: unterminated string",
+		"summary = \"x\"\nsummary = \"y\"\n":                      `line 2: duplicate key "summary"`,
+		"[tool]\n":                                                "unsupported table",
+		"summary = \"x\"\n[[fragments]]\n":                        "cannot follow top-level keys",
+		"a.b = \"x\"\n":                                           "dotted keys",
+		"summary = 1.5\n":                                         "after value",
+		"summary = \"x\" y\n":                                     "after value",
+		"refs = [\"a\" \"b\"]\n":                                  "expected , or ]",
+	} {
+		_, err := ParseFragmentFile("x.toml", []byte(in), m)
+		if (want == "" && err != nil) || (want != "" && (err == nil || !strings.Contains(err.Error(), want))) {
+			t.Fatalf("%q: got %v, want %q", in, err, want)
+		}
+	}
+}
@@ -0,0 +1,396 @@
+package papertrail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Fragment is one pending changelog entry, as stored in a changelog.d fragment file.
+type Fragment struct {
+	Component string   `yaml:"component" json:"component"`
+	Type      string   `yaml:"type" json:"type"`
+	Summary   string   `yaml:"summary" json:"summary"`
+	Refs      []string `yaml:"refs,omitempty" json:"refs,omitempty"`
+
+	// Author and Authors credit the change, as GitHub @-handles or names. Validation folds
+	// Author into Authors, so parsed fragments only use Authors.
+	Author  string   `yaml:"author,omitempty" json:"author,omitempty"`
+	Authors []string `yaml:"authors,omitempty" json:"authors,omitempty"`
+
+	// Details is optional Markdown rendered under the entry's bullet, for changes a
+	// one-line summary cannot explain: the details field, or the body of a Markdown fragment
+	// (see ValidateFragmentFile).
+	Details string `yaml:"details,omitempty" json:"details,omitempty"`
+
+	// Highlight lists the entry under Highlights at the top of its release section as well.
+	Highlight bool `yaml:"highlight,omitempty" json:"highlight,omitempty"`
+
+	// RemovalVersion is the release (vMAJOR.MINOR.PATCH) that removes what a deprecation
+	// deprecates; see Deprecation.
+	RemovalVersion string `yaml:"removal_version,omitempty" json:"removal_version,omitempty"`
+
+	// Path is the file the fragment was loaded from. It only breaks ordering ties and is
+	// empty for fragments parsed from bytes.
+	Path string `yaml:"-" json:"-"`
+}
+
+// Severities of validation issues.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityOff     = "off"
+)
+
+// Validation rule IDs. Schema rules (invalid_yaml, missing_field, invalid_author,
+// invalid_component, invalid_removal_version) are always errors; the others can be re-leveled via `validation.severity` in the manifest.
+const (
+	RuleInvalidYAML           = "invalid_yaml"
+	RuleMissingField          = "missing_field"
+	RuleInvalidAuthor         = "invalid_author"
+	RuleInvalidComponent      = "invalid_component"
+	RuleInvalidRemovalVersion = "invalid_removal_version"
+	RuleUnknownComponent      = "unknown_component"
+	RuleUnknownType           = "unknown_type"
+	RuleSummaryFormat         = "summary_format"
+	RuleMarkdownSyntax        = "markdown_syntax"
+	RuleRefFormat             = "ref_format"
+)
+
+// ConfigurableRules are the rule IDs validation.severity may override.
+var ConfigurableRules = []string{
+	RuleUnknownComponent,
+	RuleUnknownType,
+	RuleSummaryFormat,
+	RuleMarkdownSyntax,
+	RuleRefFormat,
+}
+
+// Issue is a single rule violation found in a fragment.
+type Issue struct {
+	Rule     string
+	Severity string
+	Message  string
+	// Field is the fragment key the issue is about, if any.
+	Field string
+}
+
+func validateSeverityOverrides(overrides map[string]string) error {
+	keys := make([]string, 0, len(overrides))
+	for k := range overrides {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if !slices.Contains(ConfigurableRules, k) {
+			return fmt.Errorf("invalid validation.severity[%q]: unknown or non-configurable rule (expected one of %s)", k, strings.Join(ConfigurableRules, ", "))
+		}
+		switch strings.ToLower(strings.TrimSpace(overrides[k])) {
+		case SeverityError, SeverityWarning, SeverityOff:
+		default:
+			return fmt.Errorf("invalid validation.severity[%q]=%q (expected error|warning|off)", k, overrides[k])
+		}
+	}
+	return nil
+}
+
+// RuleSeverity returns the configured severity for rule, or def when not overridden.
+func (m Manifest) RuleSeverity(rule, def string) string {
+	if v, ok := m.Validation.Severity[rule]; ok {
+		return strings.ToLower(strings.TrimSpace(v))
+	}
+	return def
+}
+
+// ValidateFragment parses and normalizes a fragment, returning every issue found. Issues
+// whose severity resolves to "off" are dropped.
+func ValidateFragment(b []byte, m Manifest) (Fragment, []Issue) {
+	var f Fragment
+	if err := yaml.Unmarshal(b, &f); err != nil {
+		return Fragment{}, []Issue{{Rule: RuleInvalidYAML, Severity: SeverityError, Message: fmt.Sprintf("invalid YAML: %v", err)}}
+	}
+	return validateFragment(f, m)
+}
+
+// ValidateFragments parses a fragment file and validates each fragment in it. A file holds
+// one fragment, a top-level list of fragments, or several YAML documents separated by "---"
+// (each a fragment or a list). Fragments keep their order in the file, and in files with
+// more than one, issue messages name the entry ("entry 2: ...").
+func ValidateFragments(b []byte, m Manifest) ([]Fragment, []Issue) {
+	nodes, err := fragmentNodes(b)
+	if err != nil {
+		return nil, []Issue{{Rule: RuleInvalidYAML, Severity: SeverityError, Message: fmt.Sprintf("invalid YAML: %v", err)}}
+	}
+	return validateEntries(len(nodes), "YAML", func(i int, f *Fragment) error { return nodes[i].Decode(f) }, m)
+}
+
+// validateEntries decodes and validates the n fragments of a file in order. An empty file
+// (n == 0) is one fragment with every field missing. In files with more than one fragment,
+// issue messages name the entry. format names the syntax in decoding errors.
+func validateEntries(n int, format string, decode func(i int, f *Fragment) error, m Manifest) ([]Fragment, []Issue) {
+	if n == 0 {
+		f, issues := validateFragment(Fragment{}, m)
+		return []Fragment{f}, issues
+	}
+	var fragments []Fragment
+	var issues []Issue
+	for i := range n {
+		var f Fragment
+		var is []Issue
+		if err := decode(i, &f); err != nil {
+			is = []Issue{{Rule: RuleInvalidYAML, Severity: SeverityError, Message: fmt.Sprintf("invalid %s: %v", format, err)}}
+		} else {
+			f, is = validateFragment(f, m)
+		}
+		if n > 1 {
+			for j := range is {
+				is[j].Message = fmt.Sprintf("entry %d: %s", i+1, is[j].Message)
+			}
+		}
+		fragments = append(fragments, f)
+		issues = append(issues, is...)
+	}
+	return fragments, issues
+}
+
+// fragmentNodes splits a fragment file into one node per fragment: each document's mapping,
+// or the entries of a document's top-level list. Empty documents are skipped.
+func fragmentNodes(b []byte) ([]*yaml.Node, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	var nodes []*yaml.Node
+	for {
+		var doc yaml.Node
+		if err := dec.Decode(&doc); errors.Is(err, io.EOF) {
+			return nodes, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if len(doc.Content) == 0 {
+			continue
+		}
+		root := doc.Content[0]
+		switch {
+		case root.Kind == yaml.SequenceNode:
+			nodes = append(nodes, root.Content...)
+		case root.Kind == yaml.ScalarNode && root.Tag == "!!null":
+			// A bare "---" or a comment-only document.
+		default:
+			nodes = append(nodes, root)
+		}
+	}
+}
+
+func validateFragment(f Fragment, m Manifest) (Fragment, []Issue) {
+	f.Component = normalizeComponent(f.Component)
+	f.Type = strings.TrimSpace(strings.ToUpper(f.Type))
+	f.Summary = strings.TrimSpace(f.Summary)
+	f.Details = normalizeDetails(f.Details)
+	f.RemovalVersion = strings.TrimSpace(f.RemovalVersion)
+	for i := range f.Refs {
+		f.Refs[i] = strings.TrimSpace(f.Refs[i])
+	}
+	authorIssues := normalizeAuthors(&f)
+
+	var issues []Issue
+	report := func(rule, field, severity, msg string) {
+		if severity == SeverityOff {
+			return
+		}
+		issues = append(issues, Issue{Rule: rule, Severity: severity, Message: msg, Field: field})
+	}
+
+	if f.Component == "" {
+		report(RuleMissingField, "component", SeverityError, "missing required field: component")
+	}
+	if f.Type == "" {
+		report(RuleMissingField, "type", SeverityError, "missing required field: type")
+	}
+	if f.Summary == "" {
+		report(RuleMissingField, "summary", SeverityError, "missing required field: summary")
+	} else {
+		for _, msg := range m.Changelog.Summary.summaryIssues(f.Summary) {
+			report(RuleSummaryFormat, "summary", m.RuleSeverity(RuleSummaryFormat, SeverityError), msg)
+		}
+		// Broken inline Markdown is a warning by default: changelog.style.escape_markdown
+		// renders it literally.
+		for _, p := range markdownProblems(f.Summary) {
+			report(RuleMarkdownSyntax, "summary", m.RuleSeverity(RuleMarkdownSyntax, SeverityWarning), p.Message)
+		}
+	}
+
+	if p := componentProblem(f.Component); f.Component != "" && p != "" {
+		report(RuleInvalidComponent, "component", SeverityError, p)
+	} else if f.Component != "" {
+		// strict_components makes unknown components errors; otherwise the rule is off unless
+		// explicitly enabled (and only meaningful when a component order is configured).
+		def := SeverityOff
+		if m.Changelog.StrictComponents {
+			def = SeverityError
+		}
+		order := m.ComponentOrder()
+		if (m.Changelog.StrictComponents || len(order) > 0) && !slices.Contains(order, f.Component) {
+			report(RuleUnknownComponent, "component", m.RuleSeverity(RuleUnknownComponent, def),
+				fmt.Sprintf("unknown component %q (expected one of %s)", f.Component, strings.Join(order, ", ")))
+		}
+	}
+
+	if f.Type != "" {
+		f.Type = m.CanonicalType(f.Type)
+		// A configured type order is an allowlist unless types.allow_unknown is set.
+		if !m.TypeAllowed(f.Type) {
+			report(RuleUnknownType, "type", m.RuleSeverity(RuleUnknownType, SeverityError),
+				fmt.Sprintf("unknown type %q (expected one of %s)", f.Type, strings.Join(m.TypeOrder(), ", ")))
+		}
+	}
+
+	if f.RemovalVersion != "" && !removalVersionRE.MatchString(f.RemovalVersion) {
+		report(RuleInvalidRemovalVersion, "removal_version", SeverityError,
+			fmt.Sprintf("invalid removal_version %q (expected a release like v2.0.0)", f.RemovalVersion))
+	}
+
+	for _, msg := range authorIssues {
+		report(RuleInvalidAuthor, "authors", SeverityError, msg)
+	}
+	for _, msg := range m.Refs.refIssues(f.Type, f.Refs) {
+		report(RuleRefFormat, "refs", m.RuleSeverity(RuleRefFormat, SeverityError), msg)
+	}
+	return f, issues
+}
+
+// ParseFragment parses a fragment and fails on the first error-severity issue. Warnings are
+// ignored; use ValidateFragment to see them.
+func ParseFragment(b []byte, m Manifest) (Fragment, error) {
+	f, issues := ValidateFragment(b, m)
+	for _, is := range issues {
+		if is.Severity == SeverityError {
+			return Fragment{}, errors.New(is.Message)
+		}
+	}
+	return f, nil
+}
+
+// ParseFragments parses every fragment in a fragment file (see ValidateFragments) and fails
+// on the first error-severity issue.
+func ParseFragments(b []byte, m Manifest) ([]Fragment, error) {
+	return firstError(ValidateFragments(b, m))
+}
+
+// ValidateFragmentFile validates the fragment file name by its format (see FragmentFormat):
+// Markdown (ValidateMarkdownFragment), JSON (ValidateJSONFragments), TOML
+// (ValidateTOMLFragments), or YAML (ValidateFragments) for any other name. Files in
+// fragments.changesets.dir are changesets (ValidateChangeset).
+func ValidateFragmentFile(name string, b []byte, m Manifest) ([]Fragment, []Issue) {
+	if m.IsChangesetFile(name) {
+		return ValidateChangeset(b, m)
+	}
+	switch FragmentFormat(name) {
+	case FormatMarkdown:
+		f, issues := ValidateMarkdownFragment(b, m)
+		return []Fragment{f}, issues
+	case FormatJSON:
+		return ValidateJSONFragments(b, m)
+	case FormatTOML:
+		return ValidateTOMLFragments(b, m)
+	}
+	return ValidateFragments(b, m)
+}
+
+// ParseFragmentFile parses the fragment file name by its format (see ValidateFragmentFile)
+// and fails on the first error-severity issue.
+func ParseFragmentFile(name string, b []byte, m Manifest) ([]Fragment, error) {
+	return firstError(ValidateFragmentFile(name, b, m))
+}
+
+func firstError(fragments []Fragment, issues []Issue) ([]Fragment, error) {
+	for _, is := range issues {
+		if is.Severity == SeverityError {
+			return nil, errors.New(is.Message)
+		}
+	}
+	return fragments, nil
+}
+
+// ReadFragment reads and parses the fragment at p in fsys, setting its Path.
+func ReadFragment(fsys fs.FS, p string, m Manifest) (Fragment, error) {
+	b, err := fs.ReadFile(fsys, p)
+	if err != nil {
+		return Fragment{}, err
+	}
+	f, err := ParseFragment(b, m)
+	if err != nil {
+		return Fragment{}, err
+	}
+	f.Path = p
+	return f, nil
+}
+
+// ReadFragments reads and parses every fragment in the file at p in fsys (see
+// ParseFragmentFile), setting their Path.
+func ReadFragments(fsys fs.FS, p string, m Manifest) ([]Fragment, error) {
+	b, err := fs.ReadFile(fsys, p)
+	if err != nil {
+		return nil, err
+	}
+	fragments, err := ParseFragmentFile(p, b, m)
+	if err != nil {
+		return nil, err
+	}
+	for i := range fragments {
+		fragments[i].Path = p
+	}
+	return fragments, nil
+}
+
+// FragmentFiles lists the fragment files under dir in the manifest's formats (see
+// Manifest.IsFragmentFile), sorted. Archived fragments (any "archived" subdirectory) are
+// skipped.
+func FragmentFiles(fsys fs.FS, dir string, m Manifest) ([]string, error) {
+	var files []string
+	err := fs.WalkDir(fsys, dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != dir && path.Base(p) == "archived" {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if m.IsFragmentFile(p) {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// LoadFragments reads and validates every fragment under dir in fsys, in output order
+// (fragments from one file keep their order in it). It fails on the first invalid fragment,
+// naming its path.
+func LoadFragments(fsys fs.FS, dir string, m Manifest) ([]Fragment, error) {
+	files, err := FragmentFiles(fsys, dir, m)
+	if err != nil {
+		return nil, err
+	}
+	fragments := make([]Fragment, 0, len(files))
+	for _, p := range files {
+		fromFile, err := ReadFragments(fsys, p, m)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fragment %s: %w", p, err)
+		}
+		fragments = append(fragments, fromFile...)
+	}
+	return SortFragments(fragments, m), nil
+}
@@ -0,0 +1,160 @@
+package papertrail
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestValidateFragment_Severity(t *testing.T) {
+	t.Parallel()
+
+	var m Manifest
+	m.Changelog.Components = []string{"CLI"}
+	m.Types.Order = []string{"FIX"}
+
+	b := []byte("component: Web\ntype: chore\nsummary: x\n")
+
+	// Defaults: unknown components are accepted (not strict), unknown types are errors.
+	_, issues := ValidateFragment(b, m)
+	if len(issues) != 1 || issues[0].Rule != RuleUnknownType || issues[0].Severity != SeverityError {
+		t.Fatalf("unexpected issues: %+v", issues)
+	}
+
+	m.Validation.Severity = map[string]string{
+		RuleUnknownType:      "warning",
+		RuleUnknownComponent: "warning",
+	}
+	_, issues = ValidateFragment(b, m)
+	if len(issues) != 2 {
+		t.Fatalf("unexpected issues: %+v", issues)
+	}
+	for _, is := range issues {
+		if is.Severity != SeverityWarning {
+			t.Fatalf("expected warning, got %+v", is)
+		}
+	}
+	if _, err := ParseFragment(b, m); err != nil {
+		t.Fatalf("warnings must not fail parsing: %v", err)
+	}
+
+	m.Changelog.StrictComponents = true
+	m.Validation.Severity = map[string]string{RuleUnknownType: "off"}
+	_, issues = ValidateFragment(b, m)
+	if len(issues) != 1 || issues[0].Rule != RuleUnknownComponent || issues[0].Severity != SeverityError {
+		t.Fatalf("unexpected issues: %+v", issues)
+	}
+}
+
+func TestValidateFragment_AllowUnknownTypes(t *testing.T) {
+	t.Parallel()
+
+	m, err := ParseManifest([]byte("types:\n  order: [feature, fix]\n  allow_unknown: true\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, issues := ValidateFragment([]byte("component: CLI\ntype: Chore\nsummary: Bump deps\n"), m)
+	if len(issues) != 0 || f.Type != "CHORE" {
+		t.Fatalf("got %+v, issues %+v", f, issues)
+	}
+
+	fragments := []Fragment{
+		{Path: "a.yml", Component: "CLI", Type: "CHORE", Summary: "c"},
+		{Path: "b.yml", Component: "CLI", Type: "AUDIT", Summary: "a"},
+		{Path: "c.yml", Component: "CLI", Type: "FIX", Summary: "f"},
+	}
+	want := "## v1.0.0\n\n### CLI\n\n- **fix**: f.\n- **audit**: a.\n- **chore**: c.\n\n"
+	if got := string(RenderRelease(NewReleaseSection("v1.0.0", "", fragments, m), m.Changelog.Style)); got != want {
+		t.Fatalf("got:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestValidateSeverityOverrides(t *testing.T) {
+	t.Parallel()
+
+	if err := validateSeverityOverrides(map[string]string{RuleUnknownType: "Warning"}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := validateSeverityOverrides(map[string]string{RuleMissingField: "warning"}); err == nil {
+		t.Fatalf("expected error for non-configurable rule")
+	}
+	if err := validateSeverityOverrides(map[string]string{RuleUnknownType: "fatal"}); err == nil {
+		t.Fatalf("expected error for invalid severity")
+	}
+}
+
+func TestLoadFragments(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"changelog.d/20260102_b.yml":          {Data: []byte("component: CLI\ntype: fix\nsummary: Fix b\n")},
+		"changelog.d/20260101_a.yaml":         {Data: []byte("component: CLI\ntype: bugfix\nsummary: Fix a\nrefs: [\" #1 \"]\n")},
+		"changelog.d/README.md":               {Data: []byte("not a fragment")},
+		"changelog.d/archived/v1.0.0/old.yml": {Data: []byte("component: CLI\ntype: fix\nsummary: Old\n")},
+	}
+	m, err := ParseManifest([]byte("types:\n  order: [fix]\n  aliases:\n    bugfix: fix\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadFragments(fsys, "changelog.d", m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Fragment{
+		{Component: "CLI", Type: "FIX", Summary: "Fix a", Refs: []string{"#1"}, Path: "changelog.d/20260101_a.yaml"},
+		{Component: "CLI", Type: "FIX", Summary: "Fix b", Path: "changelog.d/20260102_b.yml"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+
+	fsys["changelog.d/20260103_c.yml"] = &fstest.MapFile{Data: []byte("component: CLI\ntype: chore\nsummary: c\n")}
+	if _, err := LoadFragments(fsys, "changelog.d", m); err == nil || !strings.Contains(err.Error(), "changelog.d/20260103_c.yml") {
+		t.Fatalf("expected an error naming the invalid fragment, got %v", err)
+	}
+}
+
+func TestValidateFragments_MultipleEntries(t *testing.T) {
+	t.Parallel()
+
+	var m Manifest
+	for name, tc := range map[string]struct {
+		in       string
+		want     []string
+		wantErrs []string
+	}{
+		"single": {in: "component: CLI\ntype: fix\nsummary: One\n", want: []string{"One"}},
+		"list": {
+			in:   "- component: CLI\n  type: fix\n  summary: One\n- component: API\n  type: feature\n  summary: Two\n",
+			want: []string{"One", "Two"},
+		},
+		"documents": {
+			in:   "---\ncomponent: CLI\ntype: fix\nsummary: One\n---\n- component: API\n  type: fix\n  summary: Two\n---\n# empty\n",
+			want: []string{"One", "Two"},
+		},
+		"entry errors": {
+			in:       "- component: CLI\n  type: fix\n  summary: One\n- component: API\n  type: fix\n",
+			want:     []string{"One", ""},
+			wantErrs: []string{"entry 2: missing required field: summary"},
+		},
+		"empty": {in: "", want: []string{""}, wantErrs: []string{
+			"missing required field: component", "missing required field: type", "missing required field: summary",
+		}},
+	} {
+		fragments, issues := ValidateFragments([]byte(tc.in), m)
+		var summaries, msgs []string
+		for _, f := range fragments {
+			summaries = append(summaries, f.Summary)
+		}
+		for _, is := range issues {
+			msgs = append(msgs, is.Message)
+		}
+		if !reflect.DeepEqual(summaries, tc.want) || !reflect.DeepEqual(msgs, tc.wantErrs) {
+			t.Fatalf("%s: got %q, issues %q; want %q, %q", name, summaries, msgs, tc.want, tc.wantErrs)
+		}
+	}
+	if _, err := ParseFragments([]byte("- [not, a, fragment]\n"), m); err == nil || !strings.Contains(err.Error(), "invalid YAML") {
+		t.Fatalf("expected invalid YAML, got %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+package papertrail
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// HighlightsHeading heads the highlighted entries at the top of a release section.
+const HighlightsHeading = "Highlights"
+
+// renderHighlights lists the highlighted fragments under HighlightsHeading, labeled with
+// their component (as in the keepachangelog profile) rather than their type and without
+// details, so the headline changes read at a glance. The output ends with the last entry's
+// line, or is empty when nothing is highlighted.
+func renderHighlights(groups []ComponentGroup, heading string, style Style) []byte {
+	var buf bytes.Buffer
+	for _, g := range groups {
+		for _, f := range g.Fragments {
+			if !f.Highlight {
+				continue
+			}
+			if buf.Len() == 0 {
+				fmt.Fprintf(&buf, "%s %s\n\n", heading, HighlightsHeading)
+			}
+			f.Type, f.Details = "", ""
+			if style.layout == nil || !style.layout.flat {
+				f.Summary = style.componentLabel(g.Name) + f.Summary
+			}
+			buf.WriteString(style.listItem(f) + "\n")
+		}
+	}
+	return buf.Bytes()
+}
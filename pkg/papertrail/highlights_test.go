@@ -0,0 +1,29 @@
+package papertrail
+
+import "testing"
+
+func TestRenderRelease_Highlights(t *testing.T) {
+	t.Parallel()
+
+	m, err := ParseManifest([]byte("changelog:\n  components: [CLI, API]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fragments := []Fragment{
+		{Path: "1.yml", Component: "API", Type: "FEATURE", Summary: "Add streaming", Highlight: true, Details: "Long story."},
+		{Path: "2.yml", Component: "CLI", Type: "FIX", Summary: "Fix flag"},
+		{Path: "3.yml", Component: "CLI", Type: "FEATURE", Summary: "Add themes", Highlight: true},
+	}
+	want := "## v1.0.0\n\n" +
+		"### Highlights\n\n- **CLI**: Add themes.\n- **API**: Add streaming.\n\n" +
+		"### CLI\n\n- **feature**: Add themes.\n- **fix**: Fix flag.\n\n" +
+		"### API\n\n- **feature**: Add streaming.\n\n  Long story.\n\n"
+	if got := string(RenderRelease(NewReleaseSection("v1.0.0", "", fragments, m), m.Changelog.Style)); got != want {
+		t.Fatalf("got:\n%q\nwant:\n%q", got, want)
+	}
+
+	f, issues := ValidateFragment([]byte("component: CLI\ntype: fix\nsummary: Fix it\nhighlight: true\n"), m)
+	if len(issues) != 0 || !f.Highlight {
+		t.Fatalf("got %+v, issues %+v", f, issues)
+	}
+}
@@ -0,0 +1,174 @@
+package papertrail
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// UnreleasedHeading heads the Keep a Changelog section for changes not released yet. The
+// keepachangelog profile keeps it above the newest release.
+const UnreleasedHeading = "## [Unreleased]"
+
+// linkReferenceRE matches a Markdown link reference definition ("[v1.2.3]: https://...").
+var linkReferenceRE = regexp.MustCompile(`^\[([^\]]+)\]:\s+\S+\s*$`)
+
+// CompareURL expands changelog.compare_url for the changes from one version to another, or
+// returns "" when it is not set.
+func (m Manifest) CompareURL(from, to string) string {
+	u := strings.TrimSpace(m.Changelog.CompareURL)
+	if u == "" {
+		return ""
+	}
+	return strings.NewReplacer("{from}", from, "{to}", to).Replace(u)
+}
+
+// renderCategories renders fragments under Keep a Changelog category headings, in
+// KeepAChangelogCategories order. Types become categories, so entries carry no type label;
+// when several components are released together, entries are labeled with their component
+// instead (as style.TypeLabel would label a type).
+func renderCategories(groups []ComponentGroup, heading string, style Style) []byte {
+	byCategory := map[string][]Fragment{}
+	for _, g := range groups {
+		for _, f := range g.Fragments {
+			c := style.category(f.Type)
+			f.Type = ""
+			if len(groups) > 1 {
+				f.Summary = style.componentLabel(g.Name) + f.Summary
+			}
+			byCategory[c] = append(byCategory[c], f)
+		}
+	}
+	var buf bytes.Buffer
+	for _, c := range KeepAChangelogCategories {
+		fragments := byCategory[c]
+		if len(fragments) == 0 {
+			continue
+		}
+		if buf.Len() > 0 {
+			buf.WriteString(strings.Repeat("\n", style.groupSpacing()))
+		}
+		fmt.Fprintf(&buf, "%s %s\n\n", heading, c)
+		for _, f := range fragments {
+			buf.WriteString(style.listItem(f) + "\n")
+		}
+	}
+	if buf.Len() > 0 {
+		buf.WriteString("\n")
+	}
+	return buf.Bytes()
+}
+
+// InsertKeepAChangelogSection inserts a rendered release section into a Keep a Changelog
+// document: right after the Unreleased section, which is added first if missing. When
+// compare is non-nil, the link references at the end of the document are updated as well:
+// "[unreleased]" then compares version to HEAD, and "[<version>]" compares the previous
+// release (the next release section down, if any) to version.
+func InsertKeepAChangelogSection(changelog, section []byte, version string, compare func(from, to string) string) ([]byte, error) {
+	s := string(changelog)
+	u := unreleasedIndex(s)
+	if u < 0 {
+		idx := min(releaseInsertionIndex(s), linkReferencesIndex(s))
+		s = insertAt(s, idx, UnreleasedHeading+"\n\n")
+		if u = unreleasedIndex(s); u < 0 {
+			return nil, fmt.Errorf("could not add an Unreleased section to the CHANGELOG")
+		}
+	}
+
+	// The release goes before the heading after Unreleased, or before the link references.
+	idx := linkReferencesIndex(s)
+	bodyStart := u + strings.IndexByte(s[u:], '\n') + 1
+	if bodyStart <= u {
+		bodyStart = len(s)
+	}
+	if i := strings.Index(s[bodyStart:], "\n## "); i >= 0 && bodyStart+i+1 < idx {
+		idx = bodyStart + i + 1
+	} else if strings.HasPrefix(s[bodyStart:], "## ") {
+		idx = bodyStart
+	}
+	s = insertAt(s, idx, string(section))
+	if compare == nil {
+		return []byte(s), nil
+	}
+
+	var prev string
+	for _, l := range strings.Split(s[idx+len(section):], "\n") {
+		if v, _, ok := ParseReleaseHeading(l); ok {
+			prev = v
+			break
+		}
+	}
+	links := []string{"[unreleased]: " + compare(version, "HEAD")}
+	if prev != "" {
+		links = append(links, "["+version+"]: "+compare(prev, version))
+	}
+	refs := linkReferencesIndex(s)
+	for _, l := range strings.Split(strings.TrimRight(s[refs:], "\n"), "\n") {
+		m := linkReferenceRE.FindStringSubmatch(l)
+		if m == nil || strings.EqualFold(m[1], "unreleased") || m[1] == version {
+			continue
+		}
+		links = append(links, strings.TrimRight(l, " \t\r"))
+	}
+	var out bytes.Buffer
+	out.WriteString(strings.TrimRight(s[:refs], "\n") + "\n\n")
+	out.WriteString(strings.Join(links, "\n") + "\n")
+	return out.Bytes(), nil
+}
+
+// LinkReference is a Markdown link reference definition, "[Label]: URL"; Keep a Changelog
+// documents end with one per release.
+type LinkReference struct {
+	Label, URL string
+}
+
+// String renders the definition.
+func (r LinkReference) String() string {
+	return "[" + r.Label + "]: " + r.URL
+}
+
+// SplitLinkReferences splits a changelog into its body and the link reference definitions
+// that end it, in document order. The body keeps its text up to the first definition.
+func SplitLinkReferences(changelog string) (body string, refs []LinkReference) {
+	i := linkReferencesIndex(changelog)
+	for _, l := range strings.Split(changelog[i:], "\n") {
+		if m := linkReferenceRE.FindStringSubmatch(l); m != nil {
+			_, url, _ := strings.Cut(l, "]:")
+			refs = append(refs, LinkReference{Label: m[1], URL: strings.TrimSpace(url)})
+		}
+	}
+	return changelog[:i], refs
+}
+
+// unreleasedIndex returns the offset of the Unreleased heading line, or -1.
+func unreleasedIndex(s string) int {
+	off := 0
+	for _, l := range strings.SplitAfter(s, "\n") {
+		if strings.EqualFold(strings.TrimSpace(l), UnreleasedHeading) {
+			return off
+		}
+		off += len(l)
+	}
+	return -1
+}
+
+// linkReferencesIndex returns the offset of the link reference definitions that end the
+// document, or len(s) when it does not end with any.
+func linkReferencesIndex(s string) int {
+	lines := strings.SplitAfter(s, "\n")
+	idx := len(s)
+	off := len(s)
+	for i := len(lines) - 1; i >= 0; i-- {
+		off -= len(lines[i])
+		l := strings.TrimSpace(lines[i])
+		switch {
+		case l == "":
+		case linkReferenceRE.MatchString(l):
+			idx = off
+		default:
+			return idx
+		}
+	}
+	return idx
+}
@@ -0,0 +1,142 @@
+package papertrail
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRenderRelease_KeepAChangelog(t *testing.T) {
+	t.Parallel()
+
+	m, err := ParseManifest([]byte("changelog:\n  style: keepachangelog\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fragments := []Fragment{
+		{Component: "CLI", Type: "FIX", Summary: "Fix a crash"},
+		{Component: "CLI", Type: "FEATURE", Summary: "Add a flag"},
+		{Component: "CLI", Type: "CHORE", Summary: "Tidy up"},
+	}
+	want := "## [v1.1.0] - 2026-01-02\n\n" +
+		"### Added\n\n- Add a flag.\n\n" +
+		"### Changed\n\n- Tidy up.\n\n" +
+		"### Fixed\n\n- Fix a crash.\n\n"
+	if got := string(RenderRelease(NewReleaseSection("v1.1.0", "2026-01-02", fragments, m), m.Changelog.Style)); got != want {
+		t.Fatalf("got:\n%q\nwant:\n%q", got, want)
+	}
+
+	// Several components label entries with the component; categories are configurable.
+	m, err = ParseManifest([]byte("changelog:\n  style:\n    profile: keepachangelog\n    type_label: plain\n    categories:\n      chore: removed\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fragments = append(fragments, Fragment{Component: "API", Type: "FIX", Summary: "Fix the API"})
+	want = "## [v1.1.0]\n\n" +
+		"### Added\n\n- CLI: Add a flag.\n\n" +
+		"### Removed\n\n- CLI: Tidy up.\n\n" +
+		"### Fixed\n\n- API: Fix the API.\n- CLI: Fix a crash.\n\n"
+	if got := string(RenderRelease(NewReleaseSection("v1.1.0", "", fragments, m), m.Changelog.Style)); got != want {
+		t.Fatalf("got:\n%q\nwant:\n%q", got, want)
+	}
+
+	for _, in := range []string{
+		"changelog:\n  style: fancy\n",
+		"changelog:\n  style:\n    categories:\n      fix: Repaired\n",
+	} {
+		if _, err := ParseManifest([]byte(in)); err == nil {
+			t.Fatalf("expected an error for %q", in)
+		}
+	}
+}
+
+func TestInsertKeepAChangelogSection(t *testing.T) {
+	t.Parallel()
+
+	compare := func(from, to string) string { return "https://example.com/compare/" + from + "..." + to }
+	section := []byte("## [v1.1.0] - 2026-01-02\n\n### Fixed\n\n- New.\n\n")
+	for _, tc := range []struct {
+		name, changelog, want string
+		compare               func(from, to string) string
+	}{
+		{
+			name: "after Unreleased, updating links",
+			changelog: "# Changelog\n\n## [Unreleased]\n\n## [v1.0.0] - 2026-01-01\n\n### Added\n\n- Old.\n\n" +
+				"[unreleased]: https://example.com/compare/v1.0.0...HEAD\n[v1.0.0]: https://example.com/releases/v1.0.0\n",
+			want: "# Changelog\n\n## [Unreleased]\n\n## [v1.1.0] - 2026-01-02\n\n### Fixed\n\n- New.\n\n## [v1.0.0] - 2026-01-01\n\n### Added\n\n- Old.\n\n" +
+				"[unreleased]: https://example.com/compare/v1.1.0...HEAD\n" +
+				"[v1.1.0]: https://example.com/compare/v1.0.0...v1.1.0\n" +
+				"[v1.0.0]: https://example.com/releases/v1.0.0\n",
+			compare: compare,
+		},
+		{
+			name:      "adds Unreleased to a new changelog",
+			changelog: "# Changelog\n",
+			want: "# Changelog\n\n## [Unreleased]\n\n## [v1.1.0] - 2026-01-02\n\n### Fixed\n\n- New.\n\n" +
+				"[unreleased]: https://example.com/compare/v1.1.0...HEAD\n",
+			compare: compare,
+		},
+		{
+			name:      "without links",
+			changelog: "# Changelog\n\n## v1.0.0 (2026-01-01)\n\n- Old.\n",
+			want:      "# Changelog\n\n## [Unreleased]\n\n## [v1.1.0] - 2026-01-02\n\n### Fixed\n\n- New.\n\n## v1.0.0 (2026-01-01)\n\n- Old.\n",
+		},
+	} {
+		got, err := InsertKeepAChangelogSection([]byte(tc.changelog), section, "v1.1.0", tc.compare)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if string(got) != tc.want {
+			t.Fatalf("%s: got:\n%q\nwant:\n%q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestParseReleaseHeading(t *testing.T) {
+	t.Parallel()
+
+	for line, want := range map[string][2]string{
+		"## v1.2.3 (2026-01-02)":   {"v1.2.3", "2026-01-02"},
+		"## v1.2.3":                {"v1.2.3", ""},
+		"## [v1.2.3] - 2026-01-02": {"v1.2.3", "2026-01-02"},
+		"## [v1.2.3]\r\n":          {"v1.2.3", ""},
+		"## 2025-12-01":            {"2025-12-01", ""},
+		"## v1.2 (2026-01-02)":     {},
+		"## [v1.2.3.4] - 2026":     {},
+		"## vNext":                 {},
+		"## [Unreleased]":          {},
+		"## Notes":                 {},
+		"### v1.2.3":               {},
+	} {
+		v, d, ok := ParseReleaseHeading(line)
+		if ok != (want[0] != "") || v != want[0] || d != want[1] {
+			t.Fatalf("ParseReleaseHeading(%q) = %q, %q, %v; want %q", line, v, d, ok, want)
+		}
+	}
+	body, ok := ExtractSection("## [v1.0.0] - 2026-01-01\n\n- Old.\n\n[v1.0.0]: https://example.com\n", "v1.0.0")
+	if !ok || body != "- Old.\n" {
+		t.Fatalf("ExtractSection = %q, %v", body, ok)
+	}
+}
+
+func TestSplitLinkReferences(t *testing.T) {
+	t.Parallel()
+
+	body, refs := SplitLinkReferences("# Changelog\n\n## [v1.0.0] - 2026-01-01\n\n- See [the docs][docs].\n\n" +
+		"[unreleased]: https://example.com/compare/v1.0.0...HEAD\n\n[v1.0.0]:  https://example.com/releases/v1.0.0 \n")
+	if body != "# Changelog\n\n## [v1.0.0] - 2026-01-01\n\n- See [the docs][docs].\n\n" {
+		t.Fatalf("body %q", body)
+	}
+	want := []LinkReference{
+		{Label: "unreleased", URL: "https://example.com/compare/v1.0.0...HEAD"},
+		{Label: "v1.0.0", URL: "https://example.com/releases/v1.0.0"},
+	}
+	if !slices.Equal(refs, want) {
+		t.Fatalf("refs %v, want %v", refs, want)
+	}
+	if got := want[0].String(); got != "[unreleased]: https://example.com/compare/v1.0.0...HEAD" {
+		t.Fatalf("String() = %q", got)
+	}
+	if body, refs := SplitLinkReferences("# Changelog\n"); body != "# Changelog\n" || refs != nil {
+		t.Fatalf("no references: %q, %v", body, refs)
+	}
+}
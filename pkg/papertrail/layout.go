@@ -0,0 +1,146 @@
+package papertrail
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// changelog.group_by values.
+const (
+	GroupByComponent = "component"
+	GroupByType      = "type"
+)
+
+// changelog.layout values.
+const (
+	LayoutGrouped = "grouped"
+	LayoutFlat    = "flat"
+)
+
+// sectionLayout is what RenderGroups needs for layouts other than component groups: the
+// type order and the changelog.type_headings.
+type sectionLayout struct {
+	byType   bool
+	flat     bool
+	order    []string
+	headings map[string]string
+}
+
+// GroupByType reports whether release sections are grouped by type (changelog.group_by:
+// type) rather than by component.
+func (m Manifest) GroupByType() bool {
+	return m.Changelog.GroupBy == GroupByType
+}
+
+// Flat reports whether release sections are one list without group headings
+// (changelog.layout: flat).
+func (m Manifest) Flat() bool {
+	return m.Changelog.Layout == LayoutFlat
+}
+
+// sectionLayout returns the layout for the manifest's style, or nil for component groups.
+func (m Manifest) sectionLayout() *sectionLayout {
+	if !m.GroupByType() && !m.Flat() {
+		return nil
+	}
+	return &sectionLayout{byType: m.GroupByType(), flat: m.Flat(), order: m.Types.Order, headings: m.Changelog.TypeHeadings}
+}
+
+// heading returns the heading of a type's group: its changelog.type_headings entry, else the
+// type capitalized ("Breaking change").
+func (l sectionLayout) heading(t string) string {
+	if h := strings.TrimSpace(l.headings[t]); h != "" {
+		return h
+	}
+	if t == "" {
+		return "Other"
+	}
+	return upperFirst(displayType(t))
+}
+
+func validateLayout(groupBy, layout string, style Style) error {
+	switch groupBy {
+	case "", GroupByComponent:
+	case GroupByType:
+		if style.KeepAChangelog() {
+			return fmt.Errorf("changelog.group_by: type cannot be combined with the keepachangelog profile, which groups by category")
+		}
+	default:
+		return fmt.Errorf("invalid changelog.group_by %q (expected %s|%s)", groupBy, GroupByComponent, GroupByType)
+	}
+	switch layout {
+	case "", LayoutGrouped:
+	case LayoutFlat:
+		if style.KeepAChangelog() || groupBy == GroupByType {
+			return fmt.Errorf("changelog.layout: flat has no group headings; it cannot be combined with the keepachangelog profile or changelog.group_by: type")
+		}
+	default:
+		return fmt.Errorf("invalid changelog.layout %q (expected %s|%s)", layout, LayoutGrouped, LayoutFlat)
+	}
+	return nil
+}
+
+// renderTypeGroups renders fragments under type headings, in type order with unconfigured
+// types last. Entries carry their component in place of a type label, in component order.
+func renderTypeGroups(groups []ComponentGroup, heading string, style Style) []byte {
+	l := style.layout
+	byType := map[string][]Fragment{}
+	var types []string
+	for _, cg := range groups {
+		for _, f := range cg.Fragments {
+			t := f.Type
+			if _, seen := byType[t]; !seen {
+				types = append(types, t)
+			}
+			f.Type = ""
+			f.Summary = style.componentLabel(cg.Name) + f.Summary
+			byType[t] = append(byType[t], f)
+		}
+	}
+	slices.SortFunc(types, func(a, b string) int { return compareByOrderOrLex(a, b, l.order) })
+
+	var buf bytes.Buffer
+	for i, t := range types {
+		if i > 0 {
+			buf.WriteString(strings.Repeat("\n", style.groupSpacing()))
+		}
+		fmt.Fprintf(&buf, "%s %s\n\n", heading, l.heading(t))
+		for _, f := range byType[t] {
+			buf.WriteString(style.listItem(f) + "\n")
+		}
+	}
+	if len(types) > 0 {
+		buf.WriteString("\n")
+	}
+	return buf.Bytes()
+}
+
+// renderFlat renders every fragment in one list without headings, ordered by type and then
+// file name as within a component group.
+func renderFlat(groups []ComponentGroup, style Style) []byte {
+	var fragments []Fragment
+	for _, g := range groups {
+		fragments = append(fragments, g.Fragments...)
+	}
+	if len(fragments) == 0 {
+		return nil
+	}
+	order := style.layout.order
+	slices.SortStableFunc(fragments, func(a, b Fragment) int {
+		if c := compareByOrderOrLex(a.Type, b.Type, order); c != 0 {
+			return c
+		}
+		if ba, bb := baseName(a.Path), baseName(b.Path); ba != bb {
+			return strings.Compare(ba, bb)
+		}
+		return strings.Compare(a.Path, b.Path)
+	})
+	var buf bytes.Buffer
+	for _, f := range fragments {
+		buf.WriteString(style.listItem(f) + "\n")
+	}
+	buf.WriteString("\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,78 @@
+package papertrail
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderRelease_GroupByType(t *testing.T) {
+	t.Parallel()
+
+	m, err := ParseManifest([]byte(`types:
+  order: [breaking, feature, fix]
+  allow_unknown: true
+changelog:
+  components: [CLI, API]
+  group_by: type
+  type_headings:
+    feature: Added
+    fix: Fixed
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fragments := []Fragment{
+		{Path: "changelog.d/1.yml", Component: "API", Type: "FIX", Summary: "Fix api"},
+		{Path: "changelog.d/2.yml", Component: "CLI", Type: "FIX", Summary: "Fix cli"},
+		{Path: "changelog.d/3.yml", Component: "API", Type: "FEATURE", Summary: "Add api"},
+		{Path: "changelog.d/4.yml", Component: "CLI", Type: "BREAKING", Summary: "Drop flag"},
+		{Path: "changelog.d/5.yml", Component: "CLI", Type: "CHORE", Summary: "Bump deps"},
+	}
+	want := "## v1.0.0 (2026-01-01)\n\n" +
+		"### Breaking\n\n- **CLI**: Drop flag.\n\n" +
+		"### Added\n\n- **API**: Add api.\n\n" +
+		"### Fixed\n\n- **CLI**: Fix cli.\n- **API**: Fix api.\n\n" +
+		"### Chore\n\n- **CLI**: Bump deps.\n\n"
+	if got := string(RenderRelease(NewReleaseSection("v1.0.0", "2026-01-01", fragments, m), m.Changelog.Style)); got != want {
+		t.Fatalf("got:\n%q\nwant:\n%q", got, want)
+	}
+
+	for _, tc := range []struct{ config, want string }{
+		{"changelog:\n  group_by: kind\n", `invalid changelog.group_by "kind"`},
+		{"changelog:\n  group_by: type\n  style: keepachangelog\n", "keepachangelog"},
+	} {
+		if _, err := ParseManifest([]byte(tc.config)); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%q: expected %q error, got %v", tc.config, tc.want, err)
+		}
+	}
+}
+
+func TestRenderRelease_Flat(t *testing.T) {
+	t.Parallel()
+
+	m, err := ParseManifest([]byte("types:\n  order: [feature, fix]\nchangelog:\n  components: [CLI]\n  layout: flat\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fragments := []Fragment{
+		{Path: "changelog.d/20260102_b.yml", Component: "CLI", Type: "FIX", Summary: "Fix b"},
+		{Path: "changelog.d/20260101_a.yml", Component: "CLI", Type: "FIX", Summary: "Fix a"},
+		{Path: "changelog.d/20260103_c.yml", Component: "CLI", Type: "FEATURE", Summary: "Add c"},
+	}
+	want := "## v1.0.0 (2026-01-01)\n\n- **feature**: Add c.\n- **fix**: Fix a.\n- **fix**: Fix b.\n\n"
+	if got := string(RenderRelease(NewReleaseSection("v1.0.0", "2026-01-01", fragments, m), m.Changelog.Style)); got != want {
+		t.Fatalf("got:\n%q\nwant:\n%q", got, want)
+	}
+	if got := string(RenderRelease(NewReleaseSection("v1.0.0", "", nil, m), m.Changelog.Style)); got != "## v1.0.0\n\n" {
+		t.Fatalf("empty release: got %q", got)
+	}
+
+	for _, tc := range []struct{ config, want string }{
+		{"changelog:\n  layout: list\n", `invalid changelog.layout "list"`},
+		{"changelog:\n  layout: flat\n  group_by: type\n", "cannot be combined"},
+	} {
+		if _, err := ParseManifest([]byte(tc.config)); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%q: expected %q error, got %v", tc.config, tc.want, err)
+		}
+	}
+}
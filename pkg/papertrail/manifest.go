@@ -0,0 +1,502 @@
+package papertrail
+
+import (
+	"fmt"
+	"io/fs"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultManifestPaths are the config files LoadManifest tries, in order, when no path is
+// given.
+var DefaultManifestPaths = []string{".papertrail.config.yml", "papertrail.config.yml"}
+
+// Manifest is the release configuration read from .papertrail.config.yml. The zero value is
+// a valid manifest: any component and type are accepted, ordered lexicographically, and
+// every type bumps patch.
+type Manifest struct {
+	Versioning struct {
+		// Rules maps fragment types to major|minor|patch; "*" matches any other type.
+		Rules map[string]string `yaml:"rules"`
+		// ComponentRules overrides Rules for fragments of a component: component name, then
+		// fragment type (or "*") to major|minor|patch.
+		ComponentRules map[string]map[string]string `yaml:"component_rules"`
+		// ZeroMajorPolicy degrades bumps by one level while the major version is 0 (see
+		// ZeroMajorBump).
+		ZeroMajorPolicy bool `yaml:"zero_major_policy"`
+		// Components versions these components independently of the rest of the repository
+		// (monorepo mode), each with its own tags and changelog.
+		Components map[string]ComponentVersioning `yaml:"components"`
+	} `yaml:"versioning"`
+
+	Changelog struct {
+		// Components defines the preferred order for component headings.
+		// Unknown components are appended deterministically.
+		Components []string `yaml:"components"`
+
+		// ComponentsOrder is a legacy alias for Components (kept for backward compatibility).
+		ComponentsOrder []string `yaml:"components_order"`
+
+		StrictComponents bool `yaml:"strict_components"`
+
+		// ComponentPaths maps components to the repository paths they own (see
+		// ComponentForPaths), for tools that infer a fragment's component from changed files.
+		ComponentPaths map[string][]string `yaml:"component_paths"`
+
+		// Timezone is the IANA zone (e.g. America/New_York) for default release dates.
+		Timezone string `yaml:"timezone"`
+
+		// Style adjusts the generated markdown (see Style).
+		Style Style `yaml:"style"`
+
+		// CompareURL links two releases, with {from} and {to} replaced by their versions
+		// (e.g. https://github.com/org/repo/compare/{from}...{to}). The keepachangelog profile
+		// uses it for the link references below the releases.
+		CompareURL string `yaml:"compare_url"`
+
+		// Templates replace the built-in markdown with Go templates (see ReleaseTemplates).
+		Templates ReleaseTemplates `yaml:"templates"`
+
+		// Summary constrains fragment summaries (see SummaryRules).
+		Summary SummaryRules `yaml:"summary"`
+
+		// GroupBy selects the "###" headings of release sections: component (default), or
+		// type for type headings with component-labeled entries (see GroupByType).
+		GroupBy string `yaml:"group_by"`
+
+		// TypeHeadings maps fragment types to their headings when grouping by type; other
+		// types are headed by their name, capitalized.
+		TypeHeadings map[string]string `yaml:"type_headings"`
+
+		// Layout is grouped (default: "###" group headings) or flat, one list of entries
+		// ordered by type and file name, for single-component projects.
+		Layout string `yaml:"layout"`
+	} `yaml:"changelog"`
+
+	Types struct {
+		// Order defines the allowed fragment types and the preferred ordering in output.
+		// Values are treated case-insensitively and normalized internally.
+		Order []string `yaml:"order"`
+		// Aliases maps alternate type spellings to canonical types.
+		Aliases map[string]string `yaml:"aliases"`
+		// AllowUnknown accepts types missing from Order; they sort after the known types.
+		AllowUnknown bool `yaml:"allow_unknown"`
+		// Display maps types to the labels rendered in their place (see Style.DisplayType),
+		// e.g. "✨ Feature"; other types are shown in lowercase.
+		Display map[string]string `yaml:"display"`
+	} `yaml:"types"`
+
+	Fragments struct {
+		// Sources declares extra fragment locations merged into discovery (see FragmentSource).
+		Sources []FragmentSource `yaml:"sources"`
+		// Formats lists the fragment file formats to discover: yaml, markdown, json, toml
+		// (default: yaml and markdown).
+		Formats []string `yaml:"formats"`
+		// Changesets reads .changeset files as pending fragments (see Changesets).
+		Changesets Changesets `yaml:"changesets"`
+	} `yaml:"fragments"`
+
+	CommitMessage struct {
+		// Types maps fragment types to conventional-commit types for `commit-message`.
+		Types map[string]string `yaml:"types"`
+	} `yaml:"commit_message"`
+
+	Import struct {
+		// Types maps the entry types of other changelog tools (towncrier types, changie kinds) to
+		// fragment types for `import`; an empty value skips entries of that type.
+		Types map[string]string `yaml:"types"`
+	} `yaml:"import"`
+
+	// Refs constrains fragment refs (see RefRules).
+	Refs RefRules `yaml:"refs"`
+
+	// Templates maps fragment types to text/template skeletons used by `new`.
+	Templates map[string]string `yaml:"templates"`
+
+	Validation struct {
+		// Severity overrides the severity of configurable rules (error|warning|off).
+		Severity map[string]string `yaml:"severity"`
+	} `yaml:"validation"`
+
+	PRPolicy struct {
+		FragmentRequirement struct {
+			OptOutLabel string `yaml:"opt_out_label"`
+		} `yaml:"fragment_requirement"`
+		// Body constrains pull request descriptions (see PRBodyRules).
+		Body PRBodyRules `yaml:"body"`
+		// TitleTypes checks fragment types against the PR title's type (see PRTitleTypes).
+		TitleTypes PRTitleTypes `yaml:"title_types"`
+		// TitleValidation constrains pull request titles (see PRTitleRules).
+		TitleValidation PRTitleRules `yaml:"title_validation"`
+	} `yaml:"pr_policy"`
+}
+
+// FragmentSource is an extra location fragments are discovered from, declared under
+// `fragments.sources` in the manifest. Exactly one of Dir, Git, or URL must be set. Merge
+// leaves source files in place and records their digests with the release, so a file that
+// was already released is not pending again.
+type FragmentSource struct {
+	// Dir is a local directory (e.g. a submodule's changelog.d).
+	Dir string `yaml:"dir"`
+	// Git is a clonable repository URL; Ref optionally selects a branch or tag.
+	Git string `yaml:"git"`
+	Ref string `yaml:"ref"`
+	// URL points at a tarball (optionally gzip-compressed).
+	URL string `yaml:"url"`
+	// Path is the fragments directory inside a Git checkout (default: changelog.d) or
+	// tarball (default: archive root).
+	Path string `yaml:"path"`
+}
+
+// ComponentVersioning configures an independently versioned component
+// (versioning.components).
+type ComponentVersioning struct {
+	// Changelog is the component's own changelog (required).
+	Changelog string `yaml:"changelog"`
+	// TagPrefix prefixes the component's release tags. The default is the component name in
+	// lowercase with spaces as dashes, then "/v" (API tags are api/v1.2.0).
+	TagPrefix string `yaml:"tag_prefix"`
+}
+
+// LoadManifest loads the manifest at path from fsys. When path is empty, DefaultManifestPaths
+// are tried and a missing manifest yields the zero value.
+func LoadManifest(fsys fs.FS, path string) (Manifest, error) {
+	mp := strings.TrimSpace(path)
+	if mp == "" {
+		for _, cand := range DefaultManifestPaths {
+			if _, err := fs.Stat(fsys, cand); err == nil {
+				mp = cand
+				break
+			}
+		}
+	}
+	if mp == "" {
+		return Manifest{}, nil
+	}
+	b, err := fs.ReadFile(fsys, mp)
+	if err != nil {
+		return Manifest{}, err
+	}
+	return ParseManifest(b)
+}
+
+// ParseManifest parses and validates a manifest, normalizing every type name (uppercase,
+// aliases resolved) so lookups need no further canonicalization. Validating
+// changelog.timezone needs a zoneinfo database; programs that run on hosts without one
+// should import time/tzdata.
+func ParseManifest(b []byte) (Manifest, error) {
+	var m Manifest
+	if err := yaml.Unmarshal(b, &m); err != nil {
+		return Manifest{}, fmt.Errorf("invalid manifest YAML: %w", err)
+	}
+	if err := validateBumpRules(m.Versioning.Rules, "versioning.rules"); err != nil {
+		return Manifest{}, err
+	}
+	if err := validateComponentRules(m.Versioning.ComponentRules); err != nil {
+		return Manifest{}, err
+	}
+	if err := validateComponentVersioning(m.Versioning.Components); err != nil {
+		return Manifest{}, err
+	}
+	if err := validateFragmentSources(m.Fragments.Sources); err != nil {
+		return Manifest{}, err
+	}
+	if err := validateComponents(m.ComponentOrder()); err != nil {
+		return Manifest{}, err
+	}
+	if err := validateComponentPaths(m.Changelog.ComponentPaths); err != nil {
+		return Manifest{}, err
+	}
+	m.Fragments.Formats = normalizeFragmentFormats(m.Fragments.Formats)
+	if err := validateFragmentFormats(m.Fragments.Formats); err != nil {
+		return Manifest{}, err
+	}
+	m.Fragments.Changesets = normalizeChangesets(m.Fragments.Changesets)
+	if err := validateChangesets(m.Fragments.Changesets); err != nil {
+		return Manifest{}, err
+	}
+	if err := validateSeverityOverrides(m.Validation.Severity); err != nil {
+		return Manifest{}, err
+	}
+	if err := validateSummaryRules(m.Changelog.Summary); err != nil {
+		return Manifest{}, err
+	}
+	if err := validatePRBodyRules(m.PRPolicy.Body); err != nil {
+		return Manifest{}, err
+	}
+	if tz := strings.TrimSpace(m.Changelog.Timezone); tz != "" {
+		if _, err := time.LoadLocation(tz); err != nil {
+			return Manifest{}, fmt.Errorf("invalid changelog.timezone %q: %w", tz, err)
+		}
+	}
+	m.Types.Aliases = normalizeTypeAliases(m.Types.Aliases)
+	m.Types.Order = normalizeTypeOrder(m.Types.Order, m.Types.Aliases)
+	m.Versioning.Rules = normalizeBumpRuleKeys(m.Versioning.Rules, m.Types.Aliases)
+	m.Versioning.ComponentRules = normalizeComponentRules(m.Versioning.ComponentRules, m.Types.Aliases)
+	m.CommitMessage.Types = normalizeTypeKeys(m.CommitMessage.Types, m.Types.Aliases)
+	m.Templates = normalizeTypeKeys(m.Templates, m.Types.Aliases)
+	m.Changelog.Style = normalizeStyle(m.Changelog.Style, m.Types.Aliases)
+	titleTypes, err := normalizePRTitleTypes(m.PRPolicy.TitleTypes, m.Types.Aliases)
+	if err != nil {
+		return Manifest{}, err
+	}
+	m.PRPolicy.TitleTypes = titleTypes
+	titleRules, err := normalizePRTitleRules(m.PRPolicy.TitleValidation, m.ComponentOrder())
+	if err != nil {
+		return Manifest{}, err
+	}
+	m.PRPolicy.TitleValidation = titleRules
+	refs, err := normalizeRefRules(m.Refs, m.Types.Aliases)
+	if err != nil {
+		return Manifest{}, err
+	}
+	m.Refs = refs
+	m.Changelog.Style.refPatterns = refs.Patterns
+	m.Types.Display = normalizeTypeKeys(m.Types.Display, m.Types.Aliases)
+	m.Changelog.Style.typeDisplay = m.Types.Display
+	m.Changelog.GroupBy = strings.ToLower(strings.TrimSpace(m.Changelog.GroupBy))
+	m.Changelog.TypeHeadings = normalizeTypeKeys(m.Changelog.TypeHeadings, m.Types.Aliases)
+	m.Changelog.Layout = strings.ToLower(strings.TrimSpace(m.Changelog.Layout))
+	if err := validateLayout(m.Changelog.GroupBy, m.Changelog.Layout, m.Changelog.Style); err != nil {
+		return Manifest{}, err
+	}
+	m.Changelog.Style.layout = m.sectionLayout()
+	if err := validateStyle(m.Changelog.Style); err != nil {
+		return Manifest{}, err
+	}
+	return m, nil
+}
+
+// VersionedComponent returns the versioning of an independently versioned component, with
+// defaults applied. ok is false for components released with the rest of the repository.
+func (m Manifest) VersionedComponent(component string) (v ComponentVersioning, ok bool) {
+	v, ok = m.Versioning.Components[component]
+	if !ok {
+		return ComponentVersioning{}, false
+	}
+	if strings.TrimSpace(v.TagPrefix) == "" {
+		v.TagPrefix = strings.ReplaceAll(strings.ToLower(component), " ", "-") + "/v"
+	}
+	return v, true
+}
+
+// ComponentOrder returns the configured component order without blanks or duplicates, or
+// nil when none is configured.
+func (m Manifest) ComponentOrder() []string {
+	components := m.Changelog.Components
+	if len(components) == 0 {
+		components = m.Changelog.ComponentsOrder
+	}
+	seen := map[string]bool{}
+	var out []string
+	for _, c := range components {
+		c = normalizeComponent(c)
+		if c == "" || seen[c] {
+			continue
+		}
+		seen[c] = true
+		out = append(out, c)
+	}
+	return out
+}
+
+// TypeOrder returns the configured (normalized) type order, or nil when any type is
+// accepted.
+func (m Manifest) TypeOrder() []string {
+	return m.Types.Order
+}
+
+// TypeAllowed reports whether a canonical type is accepted: any type when types.order is
+// empty or types.allow_unknown is set, else only the types in types.order.
+func (m Manifest) TypeAllowed(t string) bool {
+	return len(m.Types.Order) == 0 || m.Types.AllowUnknown || slices.Contains(m.Types.Order, t)
+}
+
+// LabelType returns the type a rendered label stands for: the type whose types.display
+// label it is, else the label's CanonicalType.
+func (m Manifest) LabelType(label string) string {
+	label = strings.TrimSpace(label)
+	for _, t := range slices.Sorted(maps.Keys(m.Types.Display)) {
+		if strings.TrimSpace(m.Types.Display[t]) == label {
+			return t
+		}
+	}
+	return m.CanonicalType(label)
+}
+
+// CanonicalType uppercases t and resolves it through types.aliases.
+func (m Manifest) CanonicalType(t string) string {
+	tt := strings.ToUpper(strings.TrimSpace(t))
+	if tt == "" {
+		return tt
+	}
+	if canon, ok := m.Types.Aliases[tt]; ok {
+		return canon
+	}
+	return tt
+}
+
+// CompareFragments orders fragments deterministically: component order (level by level for
+// nested components, see compareComponents), then type order, then file name, then full
+// path, so discovery order never affects output.
+func (m Manifest) CompareFragments(a, b Fragment) int {
+	if c := compareComponents(a.Component, b.Component, m.ComponentOrder()); c != 0 {
+		return c
+	}
+	if c := compareByOrderOrLex(a.Type, b.Type, m.TypeOrder()); c != 0 {
+		return c
+	}
+	if ba, bb := baseName(a.Path), baseName(b.Path); ba != bb {
+		return strings.Compare(ba, bb)
+	}
+	return strings.Compare(a.Path, b.Path)
+}
+
+// baseName is filepath.Base for both separators, so host and fs.FS paths sort alike.
+func baseName(p string) string {
+	if i := strings.LastIndexAny(p, `/\`); i >= 0 {
+		return p[i+1:]
+	}
+	return p
+}
+
+func compareByOrderOrLex(a, b string, order []string) int {
+	if a == b {
+		return 0
+	}
+	if len(order) > 0 {
+		ai := indexIn(order, a)
+		bi := indexIn(order, b)
+		if ai != bi {
+			return ai - bi
+		}
+		// Both unknown: stable lexicographic tiebreaker.
+	}
+	return strings.Compare(a, b)
+}
+
+func indexIn(order []string, v string) int {
+	if i := slices.Index(order, v); i >= 0 {
+		return i
+	}
+	return len(order) + 1
+}
+
+func validateComponentVersioning(components map[string]ComponentVersioning) error {
+	names := make([]string, 0, len(components))
+	for name := range components {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("invalid versioning.components: empty component name")
+		}
+		if strings.TrimSpace(components[name].Changelog) == "" {
+			return fmt.Errorf("invalid versioning.components[%q]: changelog is required", name)
+		}
+	}
+	return nil
+}
+
+func validateFragmentSources(sources []FragmentSource) error {
+	for i, s := range sources {
+		set := 0
+		for _, v := range []string{s.Dir, s.Git, s.URL} {
+			if strings.TrimSpace(v) != "" {
+				set++
+			}
+		}
+		if set != 1 {
+			return fmt.Errorf("invalid fragments.sources[%d]: exactly one of dir, git, url must be set", i)
+		}
+		if s.Ref != "" && strings.TrimSpace(s.Git) == "" {
+			return fmt.Errorf("invalid fragments.sources[%d]: ref is only supported for git sources", i)
+		}
+	}
+	return nil
+}
+
+func normalizeTypeAliases(in map[string]string) map[string]string {
+	if len(in) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(in))
+	for k, v := range in {
+		kk := strings.ToUpper(strings.TrimSpace(k))
+		vv := strings.ToUpper(strings.TrimSpace(v))
+		if kk == "" || vv == "" {
+			continue
+		}
+		out[kk] = vv
+	}
+	return out
+}
+
+func normalizeTypeOrder(order []string, aliases map[string]string) []string {
+	if len(order) == 0 {
+		return nil
+	}
+	seen := map[string]bool{}
+	var out []string
+	for _, t := range order {
+		tt := strings.ToUpper(strings.TrimSpace(t))
+		if tt == "" {
+			continue
+		}
+		if canon, ok := aliases[tt]; ok {
+			tt = canon
+		}
+		if seen[tt] {
+			continue
+		}
+		seen[tt] = true
+		out = append(out, tt)
+	}
+	return out
+}
+
+func normalizeBumpRuleKeys(rules map[string]string, typeAliases map[string]string) map[string]string {
+	if len(rules) == 0 {
+		return rules
+	}
+	out := make(map[string]string, len(rules))
+	for k, v := range rules {
+		kk := strings.TrimSpace(k)
+		if kk == "" {
+			continue
+		}
+		if kk != "*" {
+			kk = strings.ToUpper(kk)
+			if canon, ok := typeAliases[kk]; ok {
+				kk = canon
+			}
+		}
+		out[kk] = v
+	}
+	return out
+}
+
+// normalizeTypeKeys canonicalizes the keys of a map keyed by fragment type (uppercase,
+// alias-resolved), dropping empty keys and values.
+func normalizeTypeKeys(in map[string]string, aliases map[string]string) map[string]string {
+	if len(in) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(in))
+	for k, v := range in {
+		kk := strings.ToUpper(strings.TrimSpace(k))
+		if kk == "" || strings.TrimSpace(v) == "" {
+			continue
+		}
+		if canon, ok := aliases[kk]; ok {
+			kk = canon
+		}
+		out[kk] = v
+	}
+	return out
+}
@@ -0,0 +1,98 @@
+package papertrail
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseManifest_Normalizes(t *testing.T) {
+	t.Parallel()
+
+	m, err := ParseManifest([]byte("versioning:\n  rules:\n    bugfix: minor\n    '*': patch\n" +
+		"changelog:\n  components_order: [CLI, ' ', Docs, CLI]\n" +
+		"types:\n  order: [feature, Fix, bugfix]\n  aliases:\n    bugfix: fix\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.TypeOrder(); !slices.Equal(got, []string{"FEATURE", "FIX"}) {
+		t.Fatalf("type order: got %q", got)
+	}
+	if got := m.ComponentOrder(); !slices.Equal(got, []string{"CLI", "Docs"}) {
+		t.Fatalf("component order: got %q", got)
+	}
+	if got := m.CanonicalType(" BugFix "); got != "FIX" {
+		t.Fatalf("canonical type: got %q", got)
+	}
+	if b, ok := m.BumpFor("fix"); !ok || b != Minor {
+		t.Fatalf("bump for fix: got %v, %v", b, ok)
+	}
+
+	for _, tc := range []struct{ yaml, want string }{
+		{"versioning:\n  rules:\n    fix: tiny\n", "versioning.rules"},
+		{"fragments:\n  sources:\n    - dir: a\n      url: b\n", "fragments.sources[0]"},
+		{"validation:\n  severity:\n    missing_field: off\n", "validation.severity"},
+		{"changelog: [", "invalid manifest YAML"},
+	} {
+		if _, err := ParseManifest([]byte(tc.yaml)); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("%q: expected %s error, got %v", tc.yaml, tc.want, err)
+		}
+	}
+}
+
+func TestLoadManifest_Default(t *testing.T) {
+	t.Parallel()
+
+	m, err := LoadManifest(fstest.MapFS{}, "")
+	if err != nil || m.TypeOrder() != nil {
+		t.Fatalf("missing manifest: got %+v, %v", m, err)
+	}
+	m, err = LoadManifest(fstest.MapFS{"papertrail.config.yml": {Data: []byte("types:\n  order: [fix]\n")}}, "")
+	if err != nil || !slices.Equal(m.TypeOrder(), []string{"FIX"}) {
+		t.Fatalf("default path: got %+v, %v", m, err)
+	}
+	if _, err := LoadManifest(fstest.MapFS{}, "missing.yml"); err == nil {
+		t.Fatal("expected an error for an explicit missing path")
+	}
+}
+
+func TestValidateFragmentSources(t *testing.T) {
+	t.Parallel()
+
+	bad := [][]FragmentSource{
+		{{}},
+		{{Dir: "a", URL: "http://x"}},
+		{{Dir: "a", Ref: "main"}},
+	}
+	for _, s := range bad {
+		if err := validateFragmentSources(s); err == nil {
+			t.Fatalf("expected error for %+v", s)
+		}
+	}
+	if err := validateFragmentSources([]FragmentSource{{Git: "https://example.com/r.git", Ref: "main"}}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestVersionedComponent(t *testing.T) {
+	t.Parallel()
+
+	m, err := ParseManifest([]byte("versioning:\n  components:\n    API:\n      changelog: services/api/CHANGELOG.md\n" +
+		"    Web UI:\n      changelog: web/CHANGELOG.md\n      tag_prefix: web-\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct{ component, prefix string }{{"API", "api/v"}, {"Web UI", "web-"}} {
+		v, ok := m.VersionedComponent(tc.component)
+		if !ok || v.TagPrefix != tc.prefix {
+			t.Fatalf("%s: got %+v, %v", tc.component, v, ok)
+		}
+	}
+	if _, ok := m.VersionedComponent("CLI"); ok {
+		t.Fatal("CLI is not independently versioned")
+	}
+	if _, err := ParseManifest([]byte("versioning:\n  components:\n    API: {}\n")); err == nil || !strings.Contains(err.Error(), `versioning.components["API"]: changelog is required`) {
+		t.Fatalf("expected a missing changelog error, got %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package papertrail
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// frontMatterDelim opens and closes the YAML front matter of a Markdown fragment.
+const frontMatterDelim = "---"
+
+// ValidateMarkdownFragment parses and validates a Markdown fragment: YAML front matter with
+// the usual fields (component, type, summary, refs) between "---" lines, then a Markdown
+// body that becomes the fragment's Details (in place of a details field).
+func ValidateMarkdownFragment(b []byte, m Manifest) (Fragment, []Issue) {
+	front, body, err := SplitFrontMatter(b)
+	if err != nil {
+		return Fragment{}, []Issue{{Rule: RuleInvalidYAML, Severity: SeverityError, Message: err.Error()}}
+	}
+	var f Fragment
+	if err := yaml.Unmarshal(front, &f); err != nil {
+		return Fragment{}, []Issue{{Rule: RuleInvalidYAML, Severity: SeverityError, Message: fmt.Sprintf("invalid YAML front matter: %v", err)}}
+	}
+	f, issues := validateFragment(f, m)
+	if body := normalizeDetails(string(body)); body != "" {
+		if f.Details != "" {
+			issues = append(issues, Issue{Rule: RuleInvalidYAML, Severity: SeverityError, Field: "details",
+				Message: "details are set in both the front matter and the body (use one)"})
+		}
+		f.Details = body
+	}
+	return f, issues
+}
+
+// SplitFrontMatter splits a Markdown fragment into its YAML front matter and body.
+func SplitFrontMatter(b []byte) (front, body []byte, err error) {
+	b = bytes.TrimPrefix(b, []byte("\ufeff"))
+	lines := strings.SplitAfter(string(b), "\n")
+	if len(lines) == 0 || strings.TrimRight(lines[0], " \t\r\n") != frontMatterDelim {
+		return nil, nil, fmt.Errorf("missing YAML front matter (a Markdown fragment starts with a %s line)", frontMatterDelim)
+	}
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimRight(lines[i], " \t\r\n") == frontMatterDelim {
+			return []byte(strings.Join(lines[1:i], "")), []byte(strings.Join(lines[i+1:], "")), nil
+		}
+	}
+	return nil, nil, fmt.Errorf("unterminated YAML front matter (no closing %s line)", frontMatterDelim)
+}
+
+// normalizeDetails trims surrounding blank lines and trailing spaces, and collapses runs of
+// blank lines, so details render without markdownlint blank-line issues (MD012).
+func normalizeDetails(s string) string {
+	var out []string
+	blank := false
+	for _, l := range strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n") {
+		l = strings.TrimRight(l, " \t")
+		if l == "" {
+			blank = len(out) > 0
+			continue
+		}
+		if blank {
+			out = append(out, "")
+			blank = false
+		}
+		out = append(out, l)
+	}
+	return strings.Join(out, "\n")
+}
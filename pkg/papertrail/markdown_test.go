@@ -0,0 +1,73 @@
+package papertrail
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateMarkdownFragment(t *testing.T) {
+	t.Parallel()
+
+	var m Manifest
+	b := []byte("---\ncomponent: CLI\ntype: feature\nsummary: Add templates\nrefs: [\"#12\"]\n---\n\nTemplates live in `templates/`.\n\n\n- one\n- two   \n")
+	f, issues := ValidateMarkdownFragment(b, m)
+	if len(issues) != 0 {
+		t.Fatalf("issues: %+v", issues)
+	}
+	if f.Component != "CLI" || f.Type != "FEATURE" || f.Summary != "Add templates" || len(f.Refs) != 1 {
+		t.Fatalf("fragment: %+v", f)
+	}
+	if want := "Templates live in `templates/`.\n\n- one\n- two"; f.Details != want {
+		t.Fatalf("details %q, want %q", f.Details, want)
+	}
+
+	for in, want := range map[string]string{
+		"# Notes\n":                  "missing YAML front matter",
+		"---\ncomponent: CLI\n":      "unterminated YAML front matter",
+		"---\ncomponent: [\n---\nx":  "invalid YAML front matter",
+		"---\ncomponent: CLI\n---\n": "missing required field: type",
+	} {
+		if _, err := ParseFragmentFile("changelog.d/x.md", []byte(in), m); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("%q: got %v, want %q", in, err, want)
+		}
+	}
+}
+
+func TestValidateFragment_Details(t *testing.T) {
+	t.Parallel()
+
+	var m Manifest
+	f, issues := ValidateFragment([]byte("component: CLI\ntype: feature\nsummary: Add templates\ndetails: |\n  Templates live in `templates/`.\n\n\n  - one   \n"), m)
+	if len(issues) != 0 {
+		t.Fatalf("issues: %+v", issues)
+	}
+	if want := "Templates live in `templates/`.\n\n- one"; f.Details != want {
+		t.Fatalf("details %q, want %q", f.Details, want)
+	}
+
+	_, issues = ValidateMarkdownFragment([]byte("---\ncomponent: CLI\ntype: fix\nsummary: Fix it\ndetails: Why.\n---\n\nHow.\n"), m)
+	if len(issues) != 1 || issues[0].Field != "details" {
+		t.Fatalf("expected a details conflict, got %+v", issues)
+	}
+	f, issues = ValidateMarkdownFragment([]byte("---\ncomponent: CLI\ntype: fix\nsummary: Fix it\ndetails: Why.\n---\n"), m)
+	if len(issues) != 0 || f.Details != "Why." {
+		t.Fatalf("front matter details: got %+v, issues %+v", f, issues)
+	}
+}
+
+func TestRenderRelease_Details(t *testing.T) {
+	t.Parallel()
+
+	var m Manifest
+	m.Changelog.Style.Bullet = "*"
+	fragments := []Fragment{
+		{Path: "a.md", Component: "CLI", Type: "FEATURE", Summary: "Add templates", Details: "First paragraph.\n\n- nested"},
+		{Path: "b.yml", Component: "CLI", Type: "FIX", Summary: "Fix it"},
+	}
+	want := "## v1.0.0\n\n### CLI\n\n" +
+		"* **feature**: Add templates.\n\n  First paragraph.\n\n  - nested\n" +
+		"* **fix**: Fix it.\n\n"
+	if got := string(RenderRelease(NewReleaseSection("v1.0.0", "", fragments, m), m.Changelog.Style)); got != want {
+		t.Fatalf("got:\n%q\nwant:\n%q", got, want)
+	}
+}
@@ -0,0 +1,171 @@
+package papertrail
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// rawHTMLRE matches an inline HTML tag or comment opening. Autolinks (<https://...>) do not
+// match: a tag name is followed by whitespace, "/", or ">".
+var rawHTMLRE = regexp.MustCompile(`<(?:/?[A-Za-z][A-Za-z0-9-]*(?:\s[^<>]*)?/?>|!--)`)
+
+// markdownProblem is one way a summary would render broken as inline Markdown, with the byte
+// offsets of the characters that escaping it backslash-escapes.
+type markdownProblem struct {
+	Message string
+	escape  []int
+}
+
+// markdownProblems parses s as inline Markdown and reports unclosed code spans, unbalanced
+// emphasis delimiters (*, **, _, __), and raw HTML. Text inside code spans and
+// backslash-escaped characters are ignored.
+func markdownProblems(s string) []markdownProblem {
+	var problems []markdownProblem
+	outside, unclosed := codeSpanText(s)
+	if unclosed >= 0 {
+		n := backtickRun(s, unclosed)
+		p := markdownProblem{Message: "summary has an unclosed code span (unmatched " + strings.Repeat("`", n) + ")"}
+		for i := range n {
+			p.escape = append(p.escape, unclosed+i)
+		}
+		problems = append(problems, p)
+	}
+
+	// Delimiter runs outside code spans, keyed by delimiter ("*", "**", "_", ...).
+	runs := map[string][]int{}
+	for i := 0; i < len(outside); {
+		c := outside[i]
+		if c != '*' && c != '_' {
+			i++
+			continue
+		}
+		j := i
+		for j < len(outside) && outside[j] == c {
+			j++
+		}
+		before, _ := utf8.DecodeLastRuneInString(outside[:i])
+		after, _ := utf8.DecodeRuneInString(outside[j:])
+		leftFlanking := j < len(outside) && !unicode.IsSpace(after)
+		rightFlanking := i > 0 && !unicode.IsSpace(before)
+		intraword := isWordRune(before) && isWordRune(after) && i > 0 && j < len(outside)
+		if (leftFlanking || rightFlanking) && !(c == '_' && intraword) {
+			key := outside[i:j]
+			runs[key] = append(runs[key], i)
+		}
+		i = j
+	}
+	keys := make([]string, 0, len(runs))
+	for k := range runs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if len(runs[k])%2 == 0 {
+			continue
+		}
+		p := markdownProblem{Message: fmt.Sprintf("summary has unbalanced %s emphasis", k)}
+		for _, start := range runs[k] {
+			for i := range len(k) {
+				p.escape = append(p.escape, start+i)
+			}
+		}
+		problems = append(problems, p)
+	}
+
+	for _, loc := range rawHTMLRE.FindAllStringIndex(outside, -1) {
+		problems = append(problems, markdownProblem{
+			Message: fmt.Sprintf("summary contains raw HTML %s", s[loc[0]:loc[1]]),
+			escape:  []int{loc[0]},
+		})
+	}
+	return problems
+}
+
+// codeSpanText returns s with code spans and backslash-escaped characters blanked out (as
+// NUL bytes, keeping offsets), and the offset of the first backtick run that opens no code
+// span, or -1.
+func codeSpanText(s string) (outside string, unclosed int) {
+	b := []byte(s)
+	unclosed = -1
+	for i := 0; i < len(b); {
+		switch {
+		case b[i] == '\\' && i+1 < len(b):
+			b[i], b[i+1] = 0, 0
+			i += 2
+		case b[i] == '`':
+			n := backtickRun(s, i)
+			end := closingBacktickRun(s, i+n, n)
+			if end < 0 {
+				if unclosed < 0 {
+					unclosed = i
+				}
+				i += n
+				continue
+			}
+			for k := i; k < end; k++ {
+				b[k] = 0
+			}
+			i = end
+		default:
+			i++
+		}
+	}
+	return string(b), unclosed
+}
+
+// backtickRun returns the length of the run of backticks at s[i:].
+func backtickRun(s string, i int) int {
+	n := 0
+	for i+n < len(s) && s[i+n] == '`' {
+		n++
+	}
+	return n
+}
+
+// closingBacktickRun returns the offset just past the first run of exactly n backticks in
+// s[from:], or -1.
+func closingBacktickRun(s string, from, n int) int {
+	for i := from; i < len(s); {
+		if s[i] != '`' {
+			i++
+			continue
+		}
+		m := backtickRun(s, i)
+		if m == n {
+			return i + m
+		}
+		i += m
+	}
+	return -1
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// EscapeInlineMarkdown backslash-escapes the characters that would make s render broken as
+// inline Markdown (see markdownProblems): unmatched backticks, the delimiters of unbalanced
+// emphasis, and the "<" of raw HTML. Well-formed Markdown is returned unchanged.
+func EscapeInlineMarkdown(s string) string {
+	escape := map[int]bool{}
+	for _, p := range markdownProblems(s) {
+		for _, i := range p.escape {
+			escape[i] = true
+		}
+	}
+	if len(escape) == 0 {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if escape[i] {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,62 @@
+package papertrail
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkdownProblems(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		summary string
+		want    string // substring of the only problem; empty for none
+		escaped string
+	}{
+		{summary: "Fix **bold** and *italic* and `code`.", escaped: "Fix **bold** and *italic* and `code`."},
+		{summary: "Support snake_case_names and __init__.", escaped: "Support snake_case_names and __init__."},
+		{summary: "Match `a*b` and `` `x` `` literally.", escaped: "Match `a*b` and `` `x` `` literally."},
+		{summary: `Accept \*literal\* stars.`, escaped: `Accept \*literal\* stars.`},
+		{summary: "Link <https://example.com> and 2 * 3.", escaped: "Link <https://example.com> and 2 * 3."},
+		{summary: "Fix **bold text.", want: "unbalanced ** emphasis", escaped: `Fix \*\*bold text.`},
+		{summary: "Fix *a and *b*.", want: "unbalanced * emphasis", escaped: `Fix \*a and \*b\*.`},
+		{summary: "Run `go vet on save.", want: "unclosed code span", escaped: "Run \\`go vet on save."},
+		{summary: "Drop the <br> tag.", want: "raw HTML <br>", escaped: `Drop the \<br> tag.`},
+	} {
+		problems := markdownProblems(tc.summary)
+		switch {
+		case tc.want == "" && len(problems) > 0:
+			t.Fatalf("%q: unexpected problems %+v", tc.summary, problems)
+		case tc.want != "" && (len(problems) != 1 || !strings.Contains(problems[0].Message, tc.want)):
+			t.Fatalf("%q: problems %+v, want one %q", tc.summary, problems, tc.want)
+		}
+		if got := EscapeInlineMarkdown(tc.summary); got != tc.escaped {
+			t.Fatalf("EscapeInlineMarkdown(%q) = %q, want %q", tc.summary, got, tc.escaped)
+		}
+		if tc.want != "" && len(markdownProblems(tc.escaped)) > 0 {
+			t.Fatalf("%q: escaped summary still has problems", tc.escaped)
+		}
+	}
+}
+
+func TestValidateFragment_MarkdownSyntax(t *testing.T) {
+	t.Parallel()
+
+	m, err := ParseManifest(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, issues := ValidateFragment([]byte("component: CLI\ntype: fix\nsummary: Fix **bold text.\n"), m)
+	if len(issues) != 1 || issues[0].Rule != RuleMarkdownSyntax || issues[0].Severity != SeverityWarning || issues[0].Field != "summary" {
+		t.Fatalf("issues %+v, want one markdown_syntax warning", issues)
+	}
+
+	style := Style{EscapeMarkdown: true}
+	out := string(RenderGroups([]ComponentGroup{{Name: "CLI", Fragments: []Fragment{f}}}, "###", style))
+	if !strings.Contains(out, `Fix \*\*bold text.`) {
+		t.Fatalf("escape_markdown output:\n%s", out)
+	}
+	if f.Summary != "Fix **bold text." {
+		t.Fatalf("rendering changed the fragment: %q", f.Summary)
+	}
+}
@@ -0,0 +1,106 @@
+package papertrail
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// PRBodyRules constrain pull request descriptions, declared under `pr_policy.body` in the
+// manifest and enforced by `pr-body`. The zero value allows any description.
+type PRBodyRules struct {
+	// RequiredSections are headings (e.g. "## Testing") the description must have, each with
+	// some text under it. The heading level is ignored and case does not matter.
+	RequiredSections []string `yaml:"required_sections"`
+	// MinLength is the fewest characters the description may have (0: no limit).
+	MinLength int `yaml:"min_length"`
+	// Forbidden is text the description must not contain, such as "TODO" or template
+	// boilerplate; matching ignores case.
+	Forbidden []string `yaml:"forbidden"`
+}
+
+// Enabled reports whether any rule is set.
+func (r PRBodyRules) Enabled() bool {
+	return len(r.RequiredSections) > 0 || r.MinLength > 0 || len(r.Forbidden) > 0
+}
+
+// htmlCommentRE matches HTML comments, which pull request templates use for instructions.
+var htmlCommentRE = regexp.MustCompile(`(?s)<!--.*?-->`)
+
+// Check returns a message per rule the description breaks. HTML comments do not count as
+// content.
+func (r PRBodyRules) Check(body string) []string {
+	body = strings.TrimSpace(htmlCommentRE.ReplaceAllString(strings.ReplaceAll(body, "\r\n", "\n"), ""))
+	var msgs []string
+	if n := utf8.RuneCountInString(body); r.MinLength > 0 && n < r.MinLength {
+		msgs = append(msgs, fmt.Sprintf("description is %d characters long (at least %d required)", n, r.MinLength))
+	}
+	for _, want := range r.RequiredSections {
+		content, found := bodySection(body, want)
+		switch {
+		case !found:
+			msgs = append(msgs, fmt.Sprintf("missing section %q", want))
+		case content == "":
+			msgs = append(msgs, fmt.Sprintf("section %q is empty", want))
+		}
+	}
+	lower := strings.ToLower(body)
+	for _, f := range r.Forbidden {
+		if strings.Contains(lower, strings.ToLower(f)) {
+			msgs = append(msgs, fmt.Sprintf("description contains %q", f))
+		}
+	}
+	return msgs
+}
+
+// bodySection finds the heading named like heading and returns the text under it, up to the
+// next heading of the same or a higher level.
+func bodySection(body, heading string) (content string, found bool) {
+	name := headingText(heading)
+	lines := strings.Split(body, "\n")
+	for i, line := range lines {
+		level := markdownHeadingLevel(line)
+		if level == 0 || !strings.EqualFold(headingText(line), name) {
+			continue
+		}
+		end := len(lines)
+		for j := i + 1; j < len(lines); j++ {
+			if l := markdownHeadingLevel(lines[j]); l > 0 && l <= level {
+				end = j
+				break
+			}
+		}
+		return strings.TrimSpace(strings.Join(lines[i+1:end], "\n")), true
+	}
+	return "", false
+}
+
+func markdownHeadingLevel(line string) int {
+	n := len(line) - len(strings.TrimLeft(line, "#"))
+	if n == 0 || n > 6 || (len(line) > n && line[n] != ' ' && line[n] != '\t') {
+		return 0
+	}
+	return n
+}
+
+func headingText(h string) string {
+	return strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(h), "#"))
+}
+
+func validatePRBodyRules(r PRBodyRules) error {
+	if r.MinLength < 0 {
+		return fmt.Errorf("invalid pr_policy.body.min_length %d (must not be negative)", r.MinLength)
+	}
+	for _, s := range r.RequiredSections {
+		if headingText(s) == "" {
+			return fmt.Errorf("invalid pr_policy.body.required_sections: empty heading %q", s)
+		}
+	}
+	for _, f := range r.Forbidden {
+		if strings.TrimSpace(f) == "" {
+			return fmt.Errorf("invalid pr_policy.body.forbidden: empty text")
+		}
+	}
+	return nil
+}
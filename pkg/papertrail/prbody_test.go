@@ -0,0 +1,42 @@
+package papertrail
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPRBodyRules_Check(t *testing.T) {
+	t.Parallel()
+
+	m, err := ParseManifest([]byte(`pr_policy:
+  body:
+    required_sections: ["## Testing", Summary]
+    min_length: 30
+    forbidden: [TODO, Describe your change here]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := m.PRPolicy.Body
+	if got := r.Check("## Summary\n\nFixes the crash on empty input.\n\n### Testing\n\nAdded a unit test.\n"); len(got) > 0 {
+		t.Fatalf("unexpected problems: %v", got)
+	}
+	got := strings.Join(r.Check("## Summary\n<!-- Describe your change here -->\n\ndescribe your change here\n## Testing\n<!-- how? -->\n## Notes\nTODO\n"), "\n")
+	for _, want := range []string{
+		`section "## Testing" is empty`,
+		`description contains "TODO"`,
+		`description contains "Describe your change here"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+	got = strings.Join(r.Check("Short."), "\n")
+	if !strings.Contains(got, "description is 6 characters long (at least 30 required)") || !strings.Contains(got, `missing section "Summary"`) {
+		t.Fatalf("unexpected problems:\n%s", got)
+	}
+
+	if _, err := ParseManifest([]byte("pr_policy:\n  body:\n    required_sections: ['##']\n")); err == nil {
+		t.Fatal("expected an empty heading error")
+	}
+}
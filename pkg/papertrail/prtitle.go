@@ -0,0 +1,159 @@
+package papertrail
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// PRTitleTypes maps the conventional commit type of a pull request title to the fragment
+// types the pull request may add, declared under `pr_policy.title_types` and checked by
+// `pr-fragment`: a PR titled "fix: ..." that adds a feature fragment is suspicious.
+type PRTitleTypes struct {
+	// Types maps title types (e.g. fix) to fragment types; titles with other types, or that
+	// are not conventional commits, are not checked.
+	Types map[string][]string `yaml:"types"`
+	// OverrideLabel is the PR label that skips the check.
+	OverrideLabel string `yaml:"override_label"`
+}
+
+// Allowed returns the fragment types (canonical) a PR whose title has titleType may add; ok
+// is false when titleType is not mapped.
+func (t PRTitleTypes) Allowed(titleType string) (types []string, ok bool) {
+	types, ok = t.Types[strings.ToLower(strings.TrimSpace(titleType))]
+	return types, ok
+}
+
+// normalizePRTitleTypes lowercases the title types and canonicalizes the fragment types.
+func normalizePRTitleTypes(t PRTitleTypes, aliases map[string]string) (PRTitleTypes, error) {
+	if len(t.Types) == 0 {
+		return t, nil
+	}
+	keys := make([]string, 0, len(t.Types))
+	for k := range t.Types {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	out := make(map[string][]string, len(t.Types))
+	for _, k := range keys {
+		kk := strings.ToLower(strings.TrimSpace(k))
+		if kk == "" {
+			return PRTitleTypes{}, fmt.Errorf("invalid pr_policy.title_types.types: empty title type")
+		}
+		types := normalizeTypeOrder(t.Types[k], aliases)
+		if len(types) == 0 {
+			return PRTitleTypes{}, fmt.Errorf("invalid pr_policy.title_types.types[%q]: no fragment types", k)
+		}
+		out[kk] = types
+	}
+	t.Types = out
+	t.OverrideLabel = strings.TrimSpace(t.OverrideLabel)
+	return t, nil
+}
+
+// PRTitleRules constrain pull request titles, declared under `pr_policy.title_validation`
+// in the manifest and enforced by `pr-fragment`. Only titles that are conventional commits
+// are checked. The zero value allows any title.
+type PRTitleRules struct {
+	// AllowedScopes are the scopes a title may have, such as api in "feat(api): ...".
+	// Matching ignores case; titles without a scope pass.
+	AllowedScopes []string `yaml:"allowed_scopes"`
+	// ComponentScopes also allows the changelog components as scopes.
+	ComponentScopes bool `yaml:"component_scopes"`
+	// ScopeAliases maps other spellings of a scope (e.g. apis) to an allowed scope, which
+	// titles may use in its place. Matching ignores case.
+	ScopeAliases map[string]string `yaml:"scope_aliases"`
+	// BreakingFragment requires a title with the breaking marker ("feat!: ...") to come with
+	// a fragment whose type bumps the major version.
+	BreakingFragment bool `yaml:"breaking_fragment"`
+	// MaxLength is the most characters the title may have (0: no limit).
+	MaxLength int `yaml:"max_length"`
+	// NoTrailingPeriod rejects a description that ends with a period.
+	NoTrailingPeriod bool `yaml:"no_trailing_period"`
+	// SubjectCase is how the description after the colon starts: "lower" for a lowercase
+	// letter, "sentence" for an uppercase one (default: either). Descriptions that start with
+	// something other than a letter, such as `--flag`, pass.
+	SubjectCase string `yaml:"subject_case"`
+}
+
+// Scopes returns the scopes titles may have: AllowedScopes, then components when
+// ComponentScopes is set. It returns nil when any scope is allowed.
+func (r PRTitleRules) Scopes(components []string) []string {
+	scopes := slices.Clone(r.AllowedScopes)
+	if r.ComponentScopes {
+		for _, c := range components {
+			if !slices.ContainsFunc(scopes, func(s string) bool { return strings.EqualFold(s, c) }) {
+				scopes = append(scopes, c)
+			}
+		}
+	}
+	return scopes
+}
+
+// ResolveScope returns the allowed scope a title scope names, directly or through
+// ScopeAliases, spelled as configured. ok is false when scopes are restricted (see Scopes)
+// and scope is not one of them; when any scope is allowed, scope is returned as is unless it
+// is an alias.
+func (r PRTitleRules) ResolveScope(scope string, components []string) (resolved string, ok bool) {
+	if target, ok := r.ScopeAliases[strings.ToLower(strings.TrimSpace(scope))]; ok {
+		scope = target
+	}
+	scopes := r.Scopes(components)
+	if len(scopes) == 0 {
+		return scope, true
+	}
+	for _, s := range scopes {
+		if strings.EqualFold(s, scope) {
+			return s, true
+		}
+	}
+	return scope, false
+}
+
+// normalizePRTitleRules trims the allowed scopes, lowercases the scope aliases, and
+// validates the rules. Alias targets must be allowed scopes, and take their spelling.
+func normalizePRTitleRules(r PRTitleRules, components []string) (PRTitleRules, error) {
+	scopes := make([]string, 0, len(r.AllowedScopes))
+	for _, s := range r.AllowedScopes {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return PRTitleRules{}, fmt.Errorf("invalid pr_policy.title_validation.allowed_scopes: empty scope")
+		}
+		scopes = append(scopes, s)
+	}
+	r.AllowedScopes = scopes
+	if len(r.ScopeAliases) > 0 {
+		keys := make([]string, 0, len(r.ScopeAliases))
+		for k := range r.ScopeAliases {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		allowed := r.Scopes(components)
+		aliases := make(map[string]string, len(keys))
+		for _, k := range keys {
+			alias, target := strings.ToLower(strings.TrimSpace(k)), strings.TrimSpace(r.ScopeAliases[k])
+			if alias == "" || target == "" {
+				return PRTitleRules{}, fmt.Errorf("invalid pr_policy.title_validation.scope_aliases[%q]: empty scope", k)
+			}
+			if len(allowed) > 0 {
+				i := slices.IndexFunc(allowed, func(s string) bool { return strings.EqualFold(s, target) })
+				if i < 0 {
+					return PRTitleRules{}, fmt.Errorf("invalid pr_policy.title_validation.scope_aliases[%q]: %q is not an allowed scope", k, target)
+				}
+				target = allowed[i]
+			}
+			aliases[alias] = target
+		}
+		r.ScopeAliases = aliases
+	}
+	if r.MaxLength < 0 {
+		return PRTitleRules{}, fmt.Errorf("invalid pr_policy.title_validation.max_length %d (must not be negative)", r.MaxLength)
+	}
+	r.SubjectCase = strings.ToLower(strings.TrimSpace(r.SubjectCase))
+	switch r.SubjectCase {
+	case "", "lower", "sentence":
+	default:
+		return PRTitleRules{}, fmt.Errorf("invalid pr_policy.title_validation.subject_case %q (expected lower or sentence)", r.SubjectCase)
+	}
+	return r, nil
+}
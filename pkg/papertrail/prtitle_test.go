@@ -0,0 +1,119 @@
+package papertrail
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPRTitleRules_Scopes(t *testing.T) {
+	t.Parallel()
+
+	components := []string{"CLI", "API"}
+	if got := (PRTitleRules{}).Scopes(components); len(got) != 0 {
+		t.Fatalf("zero rules should allow any scope, got %v", got)
+	}
+	if got := (PRTitleRules{ComponentScopes: true}).Scopes(components); !slices.Equal(got, []string{"CLI", "API"}) {
+		t.Fatalf("component scopes: got %v", got)
+	}
+	// Components already allowed (ignoring case) are not repeated.
+	r := PRTitleRules{AllowedScopes: []string{"deps", "cli"}, ComponentScopes: true}
+	if got := r.Scopes(components); !slices.Equal(got, []string{"deps", "cli", "API"}) {
+		t.Fatalf("allowed and component scopes: got %v", got)
+	}
+	if r.AllowedScopes[1] != "cli" || len(r.AllowedScopes) != 2 {
+		t.Fatalf("Scopes must not modify AllowedScopes: %v", r.AllowedScopes)
+	}
+}
+
+func TestPRTitleRules_ResolveScope(t *testing.T) {
+	t.Parallel()
+
+	m, err := ParseManifest([]byte("changelog:\n  components: [CLI]\npr_policy:\n  title_validation:\n" +
+		"    allowed_scopes: [API, deps]\n    component_scopes: true\n    scope_aliases:\n      APIs: api\n      Dependencies: deps\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules := m.PRPolicy.TitleValidation
+	for _, tc := range []struct {
+		scope, want string
+		ok          bool
+	}{
+		{"API", "API", true},
+		{"api", "API", true},
+		{"cli", "CLI", true},
+		{"apis", "API", true},            // alias, spelled as the allowed scope
+		{" Dependencies ", "deps", true}, // alias keys ignore case and spaces
+		{"web", "web", false},
+	} {
+		got, ok := rules.ResolveScope(tc.scope, m.ComponentOrder())
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("ResolveScope(%q) = %q, %v; want %q, %v", tc.scope, got, ok, tc.want, tc.ok)
+		}
+	}
+
+	// Without restricted scopes any scope passes, and aliases still apply.
+	open := PRTitleRules{ScopeAliases: map[string]string{"apis": "api"}}
+	if got, ok := open.ResolveScope("web", nil); got != "web" || !ok {
+		t.Fatalf("unrestricted scope: %q, %v", got, ok)
+	}
+	if got, ok := open.ResolveScope("APIs", nil); got != "api" || !ok {
+		t.Fatalf("unrestricted alias: %q, %v", got, ok)
+	}
+}
+
+func TestParseManifest_PRTitleRulesInvalid(t *testing.T) {
+	t.Parallel()
+
+	for in, want := range map[string]string{
+		"allowed_scopes: [api, '']":                                  "empty scope",
+		"allowed_scopes: [api]\n    scope_aliases:\n      apis: web": `"web" is not an allowed scope`,
+		"scope_aliases:\n      apis: ''":                             "empty scope",
+		"max_length: -1":                                             "must not be negative",
+		"subject_case: title":                                        "expected lower or sentence",
+	} {
+		_, err := ParseManifest([]byte("pr_policy:\n  title_validation:\n    " + in + "\n"))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: got %v, want an error containing %q", in, err, want)
+		}
+	}
+}
+
+func TestPRTitleTypes_Allowed(t *testing.T) {
+	t.Parallel()
+
+	var zero PRTitleTypes
+	if types, ok := zero.Allowed("fix"); ok || types != nil {
+		t.Fatalf("zero value maps no title type, got %v, %v", types, ok)
+	}
+
+	m, err := ParseManifest([]byte("pr_policy:\n  title_types:\n    override_label: ' type-mismatch '\n    types:\n      FIX: [fix, security]\n      feat: [feature]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tt := m.PRPolicy.TitleTypes
+	if tt.OverrideLabel != "type-mismatch" {
+		t.Fatalf("override label %q", tt.OverrideLabel)
+	}
+	for title, want := range map[string][]string{
+		"fix":    {"FIX", "SECURITY"}, // title types are lowercased and fragment types canonical
+		" Feat ": {"FEATURE"},
+	} {
+		if got, ok := tt.Allowed(title); !ok || !slices.Equal(got, want) {
+			t.Errorf("Allowed(%q) = %v, %v; want %v", title, got, ok, want)
+		}
+	}
+	if _, ok := tt.Allowed("docs"); ok {
+		t.Fatal("an unmapped title type should not be checked")
+	}
+
+	for in, want := range map[string]string{
+		"fix: []":       "no fragment types",
+		"'': [feature]": "empty title type",
+	} {
+		_, err := ParseManifest([]byte("pr_policy:\n  title_types:\n    types:\n      " + in + "\n"))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: got %v, want an error containing %q", in, err, want)
+		}
+	}
+}
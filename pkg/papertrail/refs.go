@@ -0,0 +1,156 @@
+package papertrail
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// RefRules constrain fragment refs, declared under `refs` in the manifest. The zero value
+// accepts any refs, including none.
+type RefRules struct {
+	// Patterns are the accepted ref formats; every ref must match one of them in full. No
+	// patterns accept any ref.
+	Patterns []RefPattern `yaml:"patterns"`
+	// Required lists the fragment types that need at least one ref ("*" for every type).
+	Required []string `yaml:"required"`
+}
+
+// RefPattern is an accepted ref format: a regular expression (RE2 syntax) matched against
+// the whole ref, e.g. `#\d+`, `https://\S+`, or `JIRA-\d+`.
+type RefPattern struct {
+	Pattern string `yaml:"pattern"`
+	// URL links matching refs in rendered output, with {ref} replaced by the ref and {1},
+	// {2}, ... by its submatches (e.g. https://github.com/org/repo/pull/{1} for `#(\d+)`).
+	// Refs matching no pattern with a URL are not rendered.
+	URL string `yaml:"url"`
+
+	re *regexp.Regexp
+}
+
+// regexp returns the compiled pattern, compiling it when p did not come from ParseManifest.
+func (p RefPattern) regexp() (*regexp.Regexp, error) {
+	if p.re != nil {
+		return p.re, nil
+	}
+	return compileRefPattern(p.Pattern)
+}
+
+// match reports whether ref matches p in full.
+func (p RefPattern) match(ref string) bool {
+	re, err := p.regexp()
+	return err == nil && re.MatchString(ref)
+}
+
+// link returns the Markdown link for ref, or false when ref does not match p or p has no
+// URL.
+func (p RefPattern) link(ref string) (string, bool) {
+	if p.URL == "" {
+		return "", false
+	}
+	re, err := p.regexp()
+	if err != nil {
+		return "", false
+	}
+	m := re.FindStringSubmatch(ref)
+	if m == nil {
+		return "", false
+	}
+	url := strings.ReplaceAll(p.URL, "{ref}", ref)
+	for i := len(m) - 1; i > 0; i-- {
+		url = strings.ReplaceAll(url, "{"+strconv.Itoa(i)+"}", m[i])
+	}
+	return "[" + ref + "](" + url + ")", true
+}
+
+// refLinks renders the refs that match a pattern with a URL as trailing Markdown links, e.g.
+// " ([#123](https://github.com/org/repo/pull/123))", or "" when none do. Each ref uses the
+// first pattern it matches.
+func refLinks(patterns []RefPattern, refs []string) string {
+	var links []string
+	for _, ref := range refs {
+		for _, p := range patterns {
+			if !p.match(ref) {
+				continue
+			}
+			if l, ok := p.link(ref); ok {
+				links = append(links, l)
+			}
+			break
+		}
+	}
+	if len(links) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(links, ", ") + ")"
+}
+
+var (
+	refLinksSuffixRE = regexp.MustCompile(` \((\[[^\]]+\]\([^()\s]+\)(?:, \[[^\]]+\]\([^()\s]+\))*)\)$`)
+	refLinkRE        = regexp.MustCompile(`\[([^\]]+)\]\([^()\s]+\)`)
+)
+
+// SplitRefLinks undoes the trailing ref links of a rendered entry: it returns line without
+// them and the refs they link. Links the patterns would not render the same way are left in
+// line, so rendering summary and refs again gives back line.
+func SplitRefLinks(line string, patterns []RefPattern) (summary string, refs []string) {
+	m := refLinksSuffixRE.FindStringSubmatchIndex(line)
+	if m == nil {
+		return line, nil
+	}
+	for _, l := range refLinkRE.FindAllStringSubmatch(line[m[2]:m[3]], -1) {
+		refs = append(refs, l[1])
+	}
+	if refLinks(patterns, refs) != line[m[0]:] {
+		return line, nil
+	}
+	return line[:m[0]], refs
+}
+
+func compileRefPattern(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile(`^(?:` + pattern + `)$`)
+}
+
+// refIssues returns a message per refs rule the fragment's refs break; typ is the canonical
+// fragment type.
+func (r RefRules) refIssues(typ string, refs []string) []string {
+	var msgs []string
+	if len(refs) == 0 && (slices.Contains(r.Required, "*") || (typ != "" && slices.Contains(r.Required, typ))) {
+		msgs = append(msgs, fmt.Sprintf("type %q requires at least one ref", typ))
+	}
+	if len(r.Patterns) == 0 {
+		return msgs
+	}
+	for _, ref := range refs {
+		if !slices.ContainsFunc(r.Patterns, func(p RefPattern) bool { return p.match(ref) }) {
+			msgs = append(msgs, fmt.Sprintf("ref %q matches none of the refs.patterns (%s)", ref, r.patternList()))
+		}
+	}
+	return msgs
+}
+
+func (r RefRules) patternList() string {
+	patterns := make([]string, len(r.Patterns))
+	for i, p := range r.Patterns {
+		patterns[i] = p.Pattern
+	}
+	return strings.Join(patterns, ", ")
+}
+
+// normalizeRefRules compiles the patterns and canonicalizes the required types.
+func normalizeRefRules(r RefRules, aliases map[string]string) (RefRules, error) {
+	for i, p := range r.Patterns {
+		if strings.TrimSpace(p.Pattern) == "" {
+			return RefRules{}, fmt.Errorf("refs.patterns[%d]: pattern is required", i)
+		}
+		re, err := compileRefPattern(p.Pattern)
+		if err != nil {
+			return RefRules{}, fmt.Errorf("invalid refs.patterns[%d] %q: %w", i, p.Pattern, err)
+		}
+		r.Patterns[i].re = re
+	}
+	r.Required = normalizeTypeOrder(r.Required, aliases)
+	return r, nil
+}
@@ -0,0 +1,109 @@
+package papertrail
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateFragment_RefRules(t *testing.T) {
+	t.Parallel()
+
+	m, err := ParseManifest([]byte(`types:
+  aliases:
+    BUGFIX: fix
+refs:
+  patterns:
+    - pattern: '#\d+'
+    - pattern: 'https://\S+'
+    - pattern: 'JIRA-\d+'
+  required: [bugfix]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		fragment string
+		want     string // substring of the only issue; empty for none
+	}{
+		{fragment: "type: fix\nrefs: ['#12', JIRA-7, 'https://example.com/x']", want: ""},
+		{fragment: "type: feature", want: ""},
+		{fragment: "type: fix", want: `type "FIX" requires at least one ref`},
+		{fragment: "type: feature\nrefs: ['#12x']", want: `ref "#12x" matches none`},
+		{fragment: "type: feature\nrefs: [PROJ-1]", want: `#\d+, https://\S+, JIRA-\d+`},
+	} {
+		_, issues := ValidateFragment([]byte("component: CLI\nsummary: Fix it.\n"+tc.fragment+"\n"), m)
+		switch {
+		case tc.want == "" && len(issues) > 0:
+			t.Fatalf("%q: unexpected issues %+v", tc.fragment, issues)
+		case tc.want != "" && (len(issues) != 1 || issues[0].Rule != RuleRefFormat || issues[0].Field != "refs" || !strings.Contains(issues[0].Message, tc.want)):
+			t.Fatalf("%q: issues %+v, want one %q", tc.fragment, issues, tc.want)
+		}
+	}
+
+	all, err := ParseManifest([]byte("refs:\n  required: ['*']\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseFragment([]byte("component: CLI\ntype: docs\nsummary: Fix it.\nrefs: [anything]\n"), all); err != nil {
+		t.Fatalf("without patterns any ref is accepted: %v", err)
+	}
+	if _, err := ParseFragment([]byte("component: CLI\ntype: docs\nsummary: Fix it.\n"), all); err == nil {
+		t.Fatal(`expected "*" to require refs for every type`)
+	}
+
+	for _, bad := range []string{"- pattern: ''", "- pattern: '(['"} {
+		if _, err := ParseManifest([]byte("refs:\n  patterns:\n    " + bad + "\n")); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestRenderGroups_RefLinks(t *testing.T) {
+	t.Parallel()
+
+	m, err := ParseManifest([]byte(`refs:
+  patterns:
+    - pattern: '#(\d+)'
+      url: https://github.com/org/repo/pull/{1}
+    - pattern: 'https://\S+'
+      url: '{ref}'
+    - pattern: 'JIRA-\d+'
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := ParseFragment([]byte("component: CLI\ntype: fix\nsummary: Fix it\nrefs: ['#123', JIRA-7, 'https://example.com/x']\n"), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := ParseFragment([]byte("component: CLI\ntype: fix\nsummary: Tidy up\nrefs: [JIRA-8]\n"), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(RenderGroups([]ComponentGroup{{Name: "CLI", Fragments: []Fragment{f, plain}}}, "###", m.Changelog.Style))
+	want := "### CLI\n\n" +
+		"- **fix**: Fix it. ([#123](https://github.com/org/repo/pull/123), [https://example.com/x](https://example.com/x))\n" +
+		"- **fix**: Tidy up.\n\n"
+	if out != want {
+		t.Fatalf("output:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestSplitRefLinks(t *testing.T) {
+	t.Parallel()
+
+	m, err := ParseManifest([]byte("refs:\n  patterns:\n    - pattern: '#(\\d+)'\n      url: https://github.com/org/repo/pull/{1}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary, refs := SplitRefLinks("Fix it. ([#1](https://github.com/org/repo/pull/1), [#2](https://github.com/org/repo/pull/2))", m.Refs.Patterns)
+	if summary != "Fix it." || strings.Join(refs, ",") != "#1,#2" {
+		t.Fatalf("got %q %q", summary, refs)
+	}
+	// Links the patterns would render differently are part of the summary.
+	for _, line := range []string{"See ([docs](https://example.com/docs)).", "Fix it ([#1](https://example.com/1))"} {
+		if summary, refs := SplitRefLinks(line, m.Refs.Patterns); summary != line || refs != nil {
+			t.Fatalf("%q: got %q %q", line, summary, refs)
+		}
+	}
+}
@@ -0,0 +1,325 @@
+package papertrail
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ReleaseAnchor marks where InsertSection puts new release sections (newest first), for
+// changelogs with no release yet or with content after the releases.
+const ReleaseAnchor = "<!-- papertrail: new releases are inserted below this line -->"
+
+// ReleaseSection is one release: its fragments grouped by component, in output order.
+type ReleaseSection struct {
+	// Version is the release version, e.g. v1.2.3.
+	Version string
+	// Date is optional (YYYY-MM-DD); release notes omit it.
+	Date   string
+	Groups []ComponentGroup
+}
+
+// ComponentGroup is a component heading with its fragments in output order.
+type ComponentGroup struct {
+	Name      string
+	Fragments []Fragment
+}
+
+// NewReleaseSection groups fragments into a release section.
+func NewReleaseSection(version, date string, fragments []Fragment, m Manifest) ReleaseSection {
+	return ReleaseSection{Version: version, Date: date, Groups: GroupFragments(fragments, m)}
+}
+
+// SortFragments returns a copy of fragments in output order (see Manifest.CompareFragments).
+func SortFragments(fragments []Fragment, m Manifest) []Fragment {
+	out := slices.Clone(fragments)
+	slices.SortStableFunc(out, m.CompareFragments)
+	return out
+}
+
+// GroupFragments groups fragments by component in output order; empty groups are omitted.
+func GroupFragments(fragments []Fragment, m Manifest) []ComponentGroup {
+	var groups []ComponentGroup
+	for _, f := range SortFragments(fragments, m) {
+		if n := len(groups); n > 0 && groups[n-1].Name == f.Component {
+			groups[n-1].Fragments = append(groups[n-1].Fragments, f)
+			continue
+		}
+		groups = append(groups, ComponentGroup{Name: f.Component, Fragments: []Fragment{f}})
+	}
+	return groups
+}
+
+// RenderRelease renders s as a CHANGELOG.md section in the given style (usually
+// m.Changelog.Style): a release heading (see ReleaseHeading), then "###" groups (see
+// RenderGroups). The output ends with one blank line.
+func RenderRelease(s ReleaseSection, style Style) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(ReleaseHeading(s.Version, s.Date, style) + "\n\n")
+	buf.Write(RenderGroups(s.Groups, "###", style))
+	return buf.Bytes()
+}
+
+// ReleaseHeading returns the heading line of a release section: "## <version> (<date>)", or
+// "## [<version>] - <date>" in the keepachangelog profile. The date is left out when empty.
+func ReleaseHeading(version, date string, style Style) string {
+	switch {
+	case style.KeepAChangelog() && date == "":
+		return "## [" + version + "]"
+	case style.KeepAChangelog():
+		return "## [" + version + "] - " + date
+	case date == "":
+		return "## " + version
+	}
+	return "## " + version + " (" + date + ")"
+}
+
+// ParseReleaseHeading reads a release heading line in either profile's shape (see
+// ReleaseHeading). ok is false for other lines, including "## [Unreleased]"; a release
+// version is a SemVer version (see ParseVersion) or a date-based one (2025-12-01).
+func ParseReleaseHeading(line string) (version, date string, ok bool) {
+	rest, found := strings.CutPrefix(strings.TrimRight(line, " \t\r\n"), "## ")
+	if !found {
+		return "", "", false
+	}
+	if inner, after, bracketed := strings.Cut(strings.TrimPrefix(rest, "["), "]"); strings.HasPrefix(rest, "[") && bracketed {
+		version = inner
+		date = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(after), "-"))
+	} else {
+		version, date, _ = strings.Cut(rest, " ")
+		date = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(date), "("), ")")
+	}
+	if _, err := ParseVersion(version); err != nil && !isDateVersion(version) {
+		return "", "", false
+	}
+	return version, date, true
+}
+
+// isDateVersion reports whether a release is versioned by its date (YYYY-MM-DD).
+func isDateVersion(version string) bool {
+	_, err := time.Parse("2006-01-02", version)
+	return err == nil
+}
+
+// RenderGroups renders groups under component headings using the given heading prefix
+// (e.g. "###") and style. In the keepachangelog profile the headings are categories instead
+// (see renderCategories), under changelog.group_by: type they are types (see
+// renderTypeGroups), and changelog.layout: flat leaves them out (see renderFlat). Nested
+// components ("Server/Auth") get a subheading one level down under their parent's heading.
+// Highlighted entries are listed first under their own heading (see renderHighlights), and
+// deprecation notices last (see renderDeprecations). Non-empty output always ends with one blank line.
+func RenderGroups(groups []ComponentGroup, heading string, style Style) []byte {
+	if style.EscapeMarkdown {
+		groups = escapeSummaries(groups)
+	}
+	body := renderGroups(groups, heading, style)
+	if deprecations := renderDeprecations(groups, heading, style); len(deprecations) > 0 {
+		// body ends with a blank line, which stands for the first line of spacing.
+		body = append(append(body, strings.Repeat("\n", style.groupSpacing()-1)...), deprecations...)
+		body = append(body, '\n')
+	}
+	highlights := renderHighlights(groups, heading, style)
+	if len(highlights) == 0 {
+		return body
+	}
+	return append(append(highlights, strings.Repeat("\n", style.groupSpacing())...), body...)
+}
+
+func renderGroups(groups []ComponentGroup, heading string, style Style) []byte {
+	if style.KeepAChangelog() {
+		return renderCategories(groups, heading, style)
+	}
+	switch {
+	case style.layout != nil && style.layout.flat:
+		return renderFlat(groups, style)
+	case style.layout != nil && style.layout.byType:
+		return renderTypeGroups(groups, heading, style)
+	}
+	var buf bytes.Buffer
+	parent := ""
+	for i, g := range groups {
+		if i > 0 {
+			buf.WriteString(strings.Repeat("\n", style.groupSpacing()))
+		}
+		p, child, nested := SplitComponent(g.Name)
+		if p != parent || i == 0 {
+			fmt.Fprintf(&buf, "%s %s\n\n", heading, style.heading(p))
+		}
+		parent = p
+		if nested {
+			// Nested components get a subheading under their parent's heading.
+			fmt.Fprintf(&buf, "%s# %s\n\n", heading, style.heading(child))
+		}
+		for _, f := range g.Fragments {
+			buf.WriteString(style.listItem(f) + "\n")
+		}
+	}
+	if len(groups) > 0 {
+		buf.WriteString("\n")
+	}
+	return buf.Bytes()
+}
+
+// escapeSummaries returns a copy of groups with every summary passed through
+// EscapeInlineMarkdown.
+func escapeSummaries(groups []ComponentGroup) []ComponentGroup {
+	out := make([]ComponentGroup, len(groups))
+	for i, g := range groups {
+		out[i] = ComponentGroup{Name: g.Name, Fragments: make([]Fragment, len(g.Fragments))}
+		for j, f := range g.Fragments {
+			f.Summary = EscapeInlineMarkdown(f.Summary)
+			out[i].Fragments[j] = f
+		}
+	}
+	return out
+}
+
+// InsertSection inserts a rendered release section into a changelog: below ReleaseAnchor
+// when present, else before the first release ("## v..." or "## 20..."), else at the end.
+func InsertSection(changelog, section []byte) ([]byte, error) {
+	s := string(changelog)
+	idx := releaseInsertionIndex(s)
+	if idx < 0 || idx > len(s) {
+		return nil, fmt.Errorf("could not find insertion point for release section in CHANGELOG")
+	}
+
+	return []byte(insertAt(s, idx, string(section))), nil
+}
+
+// insertAt inserts section at idx, after a blank line when it follows other text.
+func insertAt(s string, idx int, section string) string {
+	head := s[:idx]
+	tail := s[idx:]
+
+	var out strings.Builder
+	out.WriteString(head)
+	if len(head) > 0 && !strings.HasSuffix(head, "\n\n") {
+		if strings.HasSuffix(head, "\n") {
+			out.WriteString("\n")
+		} else {
+			out.WriteString("\n\n")
+		}
+	}
+	out.WriteString(section)
+	out.WriteString(tail)
+	return out.String()
+}
+
+func releaseInsertionIndex(changelog string) int {
+	if i := strings.Index(changelog, ReleaseAnchor); i >= 0 {
+		// Insert after the anchor line and any blank lines that follow it.
+		j := i + len(ReleaseAnchor)
+		if k := strings.IndexByte(changelog[j:], '\n'); k >= 0 {
+			j += k + 1
+		} else {
+			j = len(changelog)
+		}
+		for j < len(changelog) && (changelog[j] == '\n' || changelog[j] == '\r') {
+			j++
+		}
+		return j
+	}
+	best := -1
+	for _, c := range []int{
+		strings.Index(changelog, "\n## 20"),
+		strings.Index(changelog, "\n## v"),
+		strings.Index(changelog, "\n## [20"),
+		strings.Index(changelog, "\n## [v"),
+	} {
+		if c >= 0 && (best < 0 || c < best) {
+			best = c
+		}
+	}
+	if best < 0 {
+		return len(changelog)
+	}
+	return best + 1
+}
+
+// ExtractSection returns the body of the "## <version>" section (without its heading
+// line), or false if the changelog has no section for version. Keep a Changelog headings
+// ("## [<version>] - <date>") are recognized too.
+func ExtractSection(changelog, version string) (string, bool) {
+	lines := strings.SplitAfter(changelog, "\n")
+	start := -1
+	for i, l := range lines {
+		h := strings.TrimRight(l, "\r\n")
+		if v, _, ok := ParseReleaseHeading(h); (ok && v == version) || h == "## "+version || strings.HasPrefix(h, "## "+version+" (") {
+			start = i + 1
+			break
+		}
+	}
+	if start < 0 {
+		return "", false
+	}
+	end := len(lines)
+	for i := start; i < len(lines); i++ {
+		if strings.HasPrefix(lines[i], "## ") {
+			end = i
+			break
+		}
+	}
+	body := strings.Join(lines[start:end], "")
+	if end == len(lines) {
+		// The link references that end a Keep a Changelog document are not the last release's.
+		body = body[:linkReferencesIndex(body)]
+	}
+	body = strings.Trim(body, "\r\n")
+	if body == "" {
+		return "", true
+	}
+	return body + "\n", true
+}
+
+// RemoveSection removes the section for version (heading through the line before the next
+// "## " heading or the closing link references) and its "[<version>]" link reference. When
+// compare is non-nil, an "[unreleased]" link reference is pointed at the next release down
+// instead, or dropped when there is none. ok is false if the changelog has no section for
+// version.
+func RemoveSection(changelog []byte, version string, compare func(from, to string) string) (out []byte, ok bool) {
+	s := string(changelog)
+	refs := linkReferencesIndex(s)
+	body, tail := s[:refs], s[refs:]
+	lines := strings.SplitAfter(body, "\n")
+	start := -1
+	for i, l := range lines {
+		if v, _, found := ParseReleaseHeading(l); found && v == version {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return changelog, false
+	}
+	end := len(lines)
+	var prev string
+	for i := start + 1; i < len(lines); i++ {
+		if strings.HasPrefix(lines[i], "## ") {
+			end = i
+			prev, _, _ = ParseReleaseHeading(lines[i])
+			break
+		}
+	}
+	body = strings.Join(lines[:start], "") + strings.Join(lines[end:], "")
+
+	var links []string
+	for _, l := range strings.Split(strings.TrimRight(tail, "\n"), "\n") {
+		m := linkReferenceRE.FindStringSubmatch(l)
+		switch {
+		case m == nil || m[1] == version:
+			continue
+		case compare != nil && strings.EqualFold(m[1], "unreleased"):
+			if prev != "" {
+				links = append(links, "[unreleased]: "+compare(prev, "HEAD"))
+			}
+			continue
+		}
+		links = append(links, l)
+	}
+	if len(links) == 0 {
+		return []byte(strings.TrimRight(body, "\n") + "\n"), true
+	}
+	return []byte(strings.TrimRight(body, "\n") + "\n\n" + strings.Join(links, "\n") + "\n"), true
+}
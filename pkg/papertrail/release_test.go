@@ -0,0 +1,64 @@
+package papertrail
+
+import "testing"
+
+func TestRenderRelease_Ordering(t *testing.T) {
+	t.Parallel()
+
+	var m Manifest
+	m.Types.Order = []string{"BREAKING CHANGE", "PATCH"}
+	m.Changelog.Components = []string{"B"}
+
+	fragments := []Fragment{
+		{Path: "changelog.d/2.yml", Component: "A", Type: "PATCH", Summary: "a2"},
+		{Path: "changelog.d/1.yml", Component: "A", Type: "PATCH", Summary: "a1"},
+		{Path: "changelog.d/0.yml", Component: "A", Type: "BREAKING CHANGE", Summary: "z"},
+		{Path: "changelog.d/3.yml", Component: "B", Type: "PATCH", Summary: "b"},
+	}
+	want := "## v1.0.0 (2026-01-01)\n\n" +
+		"### B\n\n- **patch**: b.\n\n" +
+		"### A\n\n- **breaking change**: z.\n- **patch**: a1.\n- **patch**: a2.\n\n"
+	if got := string(RenderRelease(NewReleaseSection("v1.0.0", "2026-01-01", fragments, m), m.Changelog.Style)); got != want {
+		t.Fatalf("got:\n%q\nwant:\n%q", got, want)
+	}
+	reversed := []Fragment{fragments[3], fragments[2], fragments[1], fragments[0]}
+	if got := string(RenderRelease(NewReleaseSection("v1.0.0", "2026-01-01", reversed, m), m.Changelog.Style)); got != want {
+		t.Fatalf("output depends on input order:\n%q", got)
+	}
+}
+
+func TestInsertSection(t *testing.T) {
+	t.Parallel()
+
+	section := []byte("## v1.1.0\n\n### CLI\n\n- **fix**: New.\n\n")
+	for _, tc := range []struct{ name, changelog, want string }{
+		{"before the first release", "# Changelog\n\n## v1.0.0\n\nOld.\n",
+			"# Changelog\n\n## v1.1.0\n\n### CLI\n\n- **fix**: New.\n\n## v1.0.0\n\nOld.\n"},
+		{"below the anchor", "# Changelog\n" + ReleaseAnchor + "\n\n",
+			"# Changelog\n" + ReleaseAnchor + "\n\n## v1.1.0\n\n### CLI\n\n- **fix**: New.\n\n"},
+		{"at the end", "# Changelog", "# Changelog\n\n## v1.1.0\n\n### CLI\n\n- **fix**: New.\n\n"},
+	} {
+		got, err := InsertSection([]byte(tc.changelog), section)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tc.want {
+			t.Fatalf("%s: got:\n%q\nwant:\n%q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestExtractSection(t *testing.T) {
+	t.Parallel()
+
+	changelog := "# Changelog\n\n## v1.1.0 (2026-02-01)\n\n### CLI\n\n- **fix**: New.\n\n## v1.0.0\n\n## v0.9.0\n\nOld.\n"
+	if body, ok := ExtractSection(changelog, "v1.1.0"); !ok || body != "### CLI\n\n- **fix**: New.\n" {
+		t.Fatalf("v1.1.0: got %q, %v", body, ok)
+	}
+	if body, ok := ExtractSection(changelog, "v1.0.0"); !ok || body != "" {
+		t.Fatalf("empty section: got %q, %v", body, ok)
+	}
+	if _, ok := ExtractSection(changelog, "v1.0"); ok {
+		t.Fatal("v1.0 must not match v1.0.0")
+	}
+}
@@ -0,0 +1,198 @@
+package papertrail
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// PreviewMarker starts every rendered preview (a ReleaseModel without a Version), so a pull
+// request comment holding one can be found and updated.
+const PreviewMarker = "<!-- papertrail-preview -->"
+
+// Renderer turns a release model into output bytes in one format.
+type Renderer interface {
+	Render(m ReleaseModel) ([]byte, error)
+}
+
+// ReleaseModel is what renderers consume: grouped fragments plus the release they belong to.
+type ReleaseModel struct {
+	// Version is empty when previewing pending fragments.
+	Version string
+	// Date is optional; release notes omit it.
+	Date   string
+	Groups []ComponentGroup
+	// Style is changelog.style; only the markdown renderer applies it.
+	Style Style
+	// Template is a changelog template (see ParseReleaseTemplate) the markdown renderer
+	// executes instead of its built-in layout.
+	Template *template.Template
+}
+
+// NewReleaseModel groups fragments into a release model in the manifest's style.
+func NewReleaseModel(version, date string, fragments []Fragment, m Manifest) ReleaseModel {
+	return ReleaseModel{Version: version, Date: date, Groups: GroupFragments(fragments, m), Style: m.Changelog.Style}
+}
+
+// renderers is the format registry behind Register and Lookup. markdown is the default.
+var (
+	renderersMu sync.RWMutex
+	renderers   = map[string]Renderer{
+		"markdown": MarkdownRenderer{},
+		"json":     JSONRenderer{},
+		"html":     HTMLRenderer{},
+	}
+)
+
+// Register adds or replaces the renderer for a format name, e.g. to add asciidoc output.
+func Register(name string, r Renderer) {
+	renderersMu.Lock()
+	defer renderersMu.Unlock()
+	renderers[name] = r
+}
+
+// Lookup returns the renderer registered for a format name.
+func Lookup(name string) (Renderer, error) {
+	renderersMu.RLock()
+	r, ok := renderers[name]
+	renderersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown format %q (expected %s)", name, strings.Join(Formats(), "|"))
+	}
+	return r, nil
+}
+
+// Formats returns the registered format names, markdown first and the rest sorted.
+func Formats() []string {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+	names := make([]string, 0, len(renderers))
+	for n := range renderers {
+		if n != "markdown" {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	if _, ok := renderers["markdown"]; ok {
+		names = append([]string{"markdown"}, names...)
+	}
+	return names
+}
+
+// MarkdownRenderer renders CHANGELOG.md sections, release notes, and PR previews.
+type MarkdownRenderer struct{}
+
+func (MarkdownRenderer) Render(m ReleaseModel) ([]byte, error) {
+	if m.Template != nil {
+		return renderModelTemplate(m)
+	}
+	var buf bytes.Buffer
+	heading := "###"
+	if m.Version == "" {
+		buf.WriteString(PreviewMarker + "\n")
+		buf.WriteString("### Changelog preview\n\n")
+		heading = "####"
+	} else {
+		buf.WriteString(ReleaseHeading(m.Version, m.Date, m.Style) + "\n\n")
+	}
+	buf.Write(RenderGroups(m.Groups, heading, m.Style))
+	return buf.Bytes(), nil
+}
+
+// renderModelTemplate executes the model's template with the release as a ReleaseSection.
+// Previews still start with PreviewMarker.
+func renderModelTemplate(m ReleaseModel) ([]byte, error) {
+	out, err := RenderReleaseTemplate(m.Template, ReleaseSection{Version: m.Version, Date: m.Date, Groups: m.Groups})
+	if err != nil || m.Version != "" {
+		return out, err
+	}
+	return append([]byte(PreviewMarker+"\n"), out...), nil
+}
+
+type releaseJSON struct {
+	Version    string          `json:"version,omitempty"`
+	Date       string          `json:"date,omitempty"`
+	Components []componentJSON `json:"components"`
+}
+
+type componentJSON struct {
+	Name    string      `json:"name"`
+	Entries []entryJSON `json:"entries"`
+}
+
+type entryJSON struct {
+	Type      string   `json:"type"`
+	Summary   string   `json:"summary"`
+	Path      string   `json:"path"`
+	Refs      []string `json:"refs,omitempty"`
+	Details   string   `json:"details,omitempty"`
+	Highlight bool     `json:"highlight,omitempty"`
+	// RemovalVersion is set for deprecations that name the release removing what they deprecate.
+	RemovalVersion string `json:"removal_version,omitempty"`
+}
+
+// JSONRenderer renders the grouped entries as JSON for automation.
+type JSONRenderer struct{}
+
+func (JSONRenderer) Render(m ReleaseModel) ([]byte, error) {
+	doc := releaseJSON{Version: m.Version, Date: m.Date, Components: []componentJSON{}}
+	for _, g := range m.Groups {
+		c := componentJSON{Name: g.Name}
+		for _, f := range g.Fragments {
+			c.Entries = append(c.Entries, entryJSON{
+				Type:           displayType(f.Type),
+				Summary:        ensurePeriod(f.Summary),
+				Path:           f.Path,
+				Refs:           f.Refs,
+				Details:        f.Details,
+				Highlight:      f.Highlight,
+				RemovalVersion: f.RemovalVersion,
+			})
+		}
+		doc.Components = append(doc.Components, c)
+	}
+	b, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// HTMLRenderer renders the grouped entries as an HTML fragment for dashboards.
+type HTMLRenderer struct{}
+
+func (HTMLRenderer) Render(m ReleaseModel) ([]byte, error) {
+	var buf bytes.Buffer
+	title, group := "h2", "h3"
+	switch {
+	case m.Version == "":
+		buf.WriteString(PreviewMarker + "\n")
+		buf.WriteString("<h3>Changelog preview</h3>\n")
+		group = "h4"
+	case m.Date == "":
+		fmt.Fprintf(&buf, "<%s>%s</%s>\n", title, html.EscapeString(m.Version), title)
+	default:
+		fmt.Fprintf(&buf, "<%s>%s (%s)</%s>\n", title, html.EscapeString(m.Version), html.EscapeString(m.Date), title)
+	}
+	for _, g := range m.Groups {
+		fmt.Fprintf(&buf, "<%s>%s</%s>\n<ul>\n", group, html.EscapeString(g.Name), group)
+		for _, f := range g.Fragments {
+			fmt.Fprintf(&buf, "<li><strong>%s</strong>: %s",
+				html.EscapeString(m.Style.DisplayType(f.Type)), html.EscapeString(ensurePeriod(f.Summary)))
+			// Details are Markdown; without a Markdown renderer each paragraph is shown as text.
+			for _, p := range strings.Split(f.Details, "\n\n") {
+				if p != "" {
+					fmt.Fprintf(&buf, "<p>%s</p>", html.EscapeString(p))
+				}
+			}
+			buf.WriteString("</li>\n")
+		}
+		buf.WriteString("</ul>\n")
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,79 @@
+package papertrail
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRenderFormats(t *testing.T) {
+	t.Parallel()
+
+	var m Manifest
+	m.Changelog.Components = []string{"B", "A"}
+	fragments := []Fragment{
+		{Path: "changelog.d/a.yml", Component: "A", Type: "FIX", Summary: "Fix <b>", Refs: []string{"#1"}},
+		{Path: "changelog.d/b.md", Component: "B", Type: "FEATURE", Summary: "Add b", Details: "Why <b>.\n\nHow."},
+	}
+
+	model := NewReleaseModel("", "", fragments, m)
+	b, err := JSONRenderer{}.Render(model)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var doc releaseJSON
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, b)
+	}
+	if len(doc.Components) != 2 || doc.Components[0].Name != "B" || doc.Components[1].Entries[0].Summary != "Fix <b>." ||
+		doc.Components[1].Entries[0].Path != "changelog.d/a.yml" || doc.Components[0].Entries[0].Details != "Why <b>.\n\nHow." {
+		t.Fatalf("unexpected doc: %+v", doc)
+	}
+
+	h, err := HTMLRenderer{}.Render(model)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	got := string(h)
+	want := PreviewMarker + "\n<h3>Changelog preview</h3>\n" +
+		"<h4>B</h4>\n<ul>\n<li><strong>feature</strong>: Add b.<p>Why &lt;b&gt;.</p><p>How.</p></li>\n</ul>\n" +
+		"<h4>A</h4>\n<ul>\n<li><strong>fix</strong>: Fix &lt;b&gt;.</li>\n</ul>\n"
+	if got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	md, err := MarkdownRenderer{}.Render(NewReleaseModel("v1.0.0", "2026-01-01", fragments, m))
+	if err != nil || !strings.HasPrefix(string(md), "## v1.0.0 (2026-01-01)\n\n### B\n\n- **feature**: Add b.\n") {
+		t.Fatalf("markdown = %q, %v", md, err)
+	}
+}
+
+type upperRenderer struct{}
+
+func (upperRenderer) Render(m ReleaseModel) ([]byte, error) {
+	return []byte(strings.ToUpper(m.Version)), nil
+}
+
+func TestRendererRegistry(t *testing.T) {
+	if got := strings.Join(Formats(), "|"); got != "markdown|html|json" {
+		t.Fatalf("names: %s", got)
+	}
+	if _, err := Lookup("asciidoc"); err == nil || !strings.Contains(err.Error(), "markdown|html|json") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	Register("upper", upperRenderer{})
+	t.Cleanup(func() {
+		renderersMu.Lock()
+		delete(renderers, "upper")
+		renderersMu.Unlock()
+	})
+	r, err := Lookup("upper")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	b, _ := r.Render(ReleaseModel{Version: "v1.0.0"})
+	if string(b) != "V1.0.0" {
+		t.Fatalf("got %q", b)
+	}
+}
@@ -0,0 +1,328 @@
+package papertrail
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Style holds the changelog.style knobs that make generated markdown match a
+// project's existing CHANGELOG conventions. The zero value is the default style.
+type Style struct {
+	// Bullet is the list marker: "-" (default) or "*".
+	Bullet string `yaml:"bullet"`
+	// TypeLabel is how an entry's type is shown: bold (default, "**fix**: ..."), plain
+	// ("fix: ..."), or none.
+	TypeLabel string `yaml:"type_label"`
+	// GroupSpacing is the number of blank lines between component groups (default 1).
+	GroupSpacing *int `yaml:"group_spacing"`
+	// HeadingCase is applied to component headings: as-is (default), title, sentence,
+	// lower, or upper.
+	HeadingCase string `yaml:"heading_case"`
+	// Wrap is the column to wrap entries at, with continuation lines indented under the
+	// bullet. 0 (default) never wraps.
+	Wrap int `yaml:"wrap"`
+	// Markdownlint keeps output markdownlint-clean: group_spacing must stay 1, and merge and
+	// cut lint what they generate before writing it (as with --lint-output).
+	Markdownlint bool `yaml:"markdownlint"`
+	// Profile switches the overall changelog structure: default, or keepachangelog for
+	// Keep a Changelog sections ("## [v1.2.3] - 2026-01-02", entries under Added, Changed,
+	// Deprecated, Removed, Fixed, and Security, an Unreleased section, and link references).
+	// `changelog.style: keepachangelog` is shorthand for setting only the profile.
+	Profile string `yaml:"profile"`
+	// Categories maps fragment types to Keep a Changelog categories, over
+	// DefaultKeepAChangelogCategories; other types are listed under Changed.
+	Categories map[string]string `yaml:"categories"`
+	// EscapeMarkdown backslash-escapes the characters that would break a summary's inline
+	// Markdown (see EscapeInlineMarkdown), so it renders as written.
+	EscapeMarkdown bool `yaml:"escape_markdown"`
+
+	// refPatterns are refs.patterns, set by ParseManifest; refs matching one with a URL are
+	// rendered as links after the summary.
+	refPatterns []RefPattern
+	// typeDisplay is types.display, set by ParseManifest.
+	typeDisplay map[string]string
+	// layout is set by ParseManifest under changelog.group_by: type or changelog.layout:
+	// flat.
+	layout *sectionLayout
+}
+
+// Changelog profiles (changelog.style.profile).
+const (
+	ProfileDefault        = "default"
+	ProfileKeepAChangelog = "keepachangelog"
+)
+
+// KeepAChangelogCategories are the Keep a Changelog categories, in output order.
+var KeepAChangelogCategories = []string{"Added", "Changed", "Deprecated", "Removed", "Fixed", "Security"}
+
+// DefaultKeepAChangelogCategories maps common fragment types to Keep a Changelog categories.
+var DefaultKeepAChangelogCategories = map[string]string{
+	"ADDED":           "Added",
+	"FEATURE":         "Added",
+	"FEAT":            "Added",
+	"CHANGED":         "Changed",
+	"BREAKING":        "Changed",
+	"BREAKING CHANGE": "Changed",
+	"DEPRECATED":      "Deprecated",
+	"DEPRECATION":     "Deprecated",
+	"REMOVED":         "Removed",
+	"REMOVAL":         "Removed",
+	"FIX":             "Fixed",
+	"FIXED":           "Fixed",
+	"BUGFIX":          "Fixed",
+	"SECURITY":        "Security",
+}
+
+// UnmarshalYAML accepts a profile name as shorthand for a style with only that profile.
+func (s *Style) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.ScalarNode {
+		*s = Style{Profile: n.Value}
+		return nil
+	}
+	type plain Style
+	return n.Decode((*plain)(s))
+}
+
+// KeepAChangelog reports whether the keepachangelog profile is selected.
+func (s Style) KeepAChangelog() bool {
+	return s.Profile == ProfileKeepAChangelog
+}
+
+// category returns the Keep a Changelog category of a fragment type.
+func (s Style) category(t string) string {
+	t = strings.ToUpper(strings.TrimSpace(t))
+	if c, ok := s.Categories[t]; ok {
+		return c
+	}
+	if c, ok := DefaultKeepAChangelogCategories[t]; ok {
+		return c
+	}
+	return "Changed"
+}
+
+var (
+	styleBullets      = []string{"-", "*"}
+	styleTypeLabels   = []string{"bold", "plain", "none"}
+	styleHeadingCases = []string{"as-is", "title", "sentence", "lower", "upper"}
+	styleProfiles     = []string{ProfileDefault, ProfileKeepAChangelog}
+)
+
+const (
+	maxGroupSpacing = 3
+	// minWrap keeps a wrap width from splitting entries into a column of single words.
+	minWrap = 40
+)
+
+func validateStyle(s Style) error {
+	check := func(key, v string, allowed []string) error {
+		if v != "" && !slices.Contains(allowed, v) {
+			return fmt.Errorf("invalid changelog.style.%s %q (expected %s)", key, v, strings.Join(allowed, "|"))
+		}
+		return nil
+	}
+	if err := check("bullet", s.Bullet, styleBullets); err != nil {
+		return err
+	}
+	if err := check("type_label", s.TypeLabel, styleTypeLabels); err != nil {
+		return err
+	}
+	if err := check("heading_case", s.HeadingCase, styleHeadingCases); err != nil {
+		return err
+	}
+	if s.GroupSpacing != nil && (*s.GroupSpacing < 0 || *s.GroupSpacing > maxGroupSpacing) {
+		return fmt.Errorf("invalid changelog.style.group_spacing %d (expected 0-%d)", *s.GroupSpacing, maxGroupSpacing)
+	}
+	if s.Markdownlint && s.groupSpacing() != 1 {
+		return fmt.Errorf("changelog.style.group_spacing %d breaks markdownlint (MD012/MD022); use 1 with changelog.style.markdownlint", s.groupSpacing())
+	}
+	if s.Wrap != 0 && s.Wrap < minWrap {
+		return fmt.Errorf("invalid changelog.style.wrap %d (expected 0 for no wrapping, or at least %d)", s.Wrap, minWrap)
+	}
+	if err := check("profile", s.Profile, styleProfiles); err != nil {
+		return err
+	}
+	for t, c := range s.Categories {
+		if !slices.Contains(KeepAChangelogCategories, c) {
+			return fmt.Errorf("invalid changelog.style.categories.%s %q (expected %s)", t, c, strings.Join(KeepAChangelogCategories, "|"))
+		}
+	}
+	return nil
+}
+
+// normalizeStyle canonicalizes the profile, category keys (as fragment types), and category
+// names, so "Keep a Changelog" headings are spelled one way.
+func normalizeStyle(s Style, typeAliases map[string]string) Style {
+	s.Profile = strings.ToLower(strings.TrimSpace(s.Profile))
+	if s.Profile == ProfileDefault {
+		s.Profile = ""
+	}
+	s.Categories = normalizeTypeKeys(s.Categories, typeAliases)
+	for t, c := range s.Categories {
+		for _, want := range KeepAChangelogCategories {
+			if strings.EqualFold(strings.TrimSpace(c), want) {
+				c = want
+			}
+		}
+		s.Categories[t] = c
+	}
+	return s
+}
+
+func (s Style) bullet() string {
+	if s.Bullet == "" {
+		return "-"
+	}
+	return s.Bullet
+}
+
+func (s Style) groupSpacing() int {
+	if s.GroupSpacing == nil {
+		return 1
+	}
+	return *s.GroupSpacing
+}
+
+// entry formats one list item (without the bullet) for a Fragment.
+func (s Style) entry(f Fragment) string {
+	summary := ensurePeriod(f.Summary)
+	label := s.DisplayType(f.Type)
+	if label == "" {
+		return summary
+	}
+	switch s.TypeLabel {
+	case "plain":
+		return label + ": " + summary
+	case "none":
+		return summary
+	default:
+		return "**" + label + "**: " + summary
+	}
+}
+
+// DisplayType returns the label of a type in rendered entries: its types.display entry (e.g.
+// "✨ Feature"), else the type in lowercase.
+func (s Style) DisplayType(t string) string {
+	if label, ok := s.typeDisplay[strings.ToUpper(strings.TrimSpace(t))]; ok {
+		return label
+	}
+	return displayType(t)
+}
+
+// componentLabel prefixes an entry with its component when a component, not a type, labels
+// entries (the keepachangelog profile), in the TypeLabel form.
+func (s Style) componentLabel(component string) string {
+	switch {
+	case component == "" || s.TypeLabel == "none":
+		return ""
+	case s.TypeLabel == "plain":
+		return component + ": "
+	}
+	return "**" + component + "**: "
+}
+
+// listItem formats a whole list item for a Fragment, wrapped at Wrap when set. Details
+// follow the entry line as an indented block, so they render inside the list item.
+func (s Style) listItem(f Fragment) string {
+	indent := strings.Repeat(" ", len(s.bullet())+1)
+	line := s.bullet() + " " + s.entry(f) + refLinks(s.refPatterns, f.Refs)
+	if s.Wrap > 0 {
+		line = wrapListItem(line, s.Wrap, indent)
+	}
+	if f.Details == "" {
+		return line
+	}
+	var b strings.Builder
+	b.WriteString(line + "\n")
+	for _, l := range strings.Split(f.Details, "\n") {
+		b.WriteString("\n")
+		if l != "" {
+			b.WriteString(indent + l)
+		}
+	}
+	return b.String()
+}
+
+// wrapListItem breaks line at spaces so lines fit in width columns where possible; words
+// longer than the width (e.g. URLs) are never split. A line is never started with a word
+// markdown would read as a new block (a list marker, heading, or quote), so wrapping
+// cannot change the rendered output.
+func wrapListItem(line string, width int, indent string) string {
+	words := strings.Fields(line)
+	if len(words) == 0 {
+		return line
+	}
+	var b strings.Builder
+	b.WriteString(words[0])
+	col := utf8.RuneCountInString(words[0])
+	for _, w := range words[1:] {
+		n := utf8.RuneCountInString(w)
+		if col+1+n > width && !startsMarkdownBlock(w) {
+			b.WriteString("\n" + indent + w)
+			col = len(indent) + n
+			continue
+		}
+		b.WriteString(" " + w)
+		col += 1 + n
+	}
+	return b.String()
+}
+
+func startsMarkdownBlock(w string) bool {
+	switch w[0] {
+	case '-', '*', '+', '#', '>', '=', '|':
+		return true
+	}
+	i := 0
+	for i < len(w) && isDigit(w[i]) {
+		i++
+	}
+	return i > 0 && i < len(w) && (w[i] == '.' || w[i] == ')')
+}
+
+// heading applies HeadingCase to a component heading. title and sentence only raise
+// letters, so acronyms like "CLI" survive.
+func (s Style) heading(name string) string {
+	switch s.HeadingCase {
+	case "lower":
+		return strings.ToLower(name)
+	case "upper":
+		return strings.ToUpper(name)
+	case "sentence":
+		return upperFirst(name)
+	case "title":
+		words := strings.Split(name, " ")
+		for i, w := range words {
+			words[i] = upperFirst(w)
+		}
+		return strings.Join(words, " ")
+	default:
+		return name
+	}
+}
+
+func upperFirst(s string) string {
+	r, n := utf8.DecodeRuneInString(s)
+	if n == 0 {
+		return s
+	}
+	return string(unicode.ToUpper(r)) + s[n:]
+}
+
+func ensurePeriod(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.HasSuffix(s, ".") || strings.HasSuffix(s, "!") || strings.HasSuffix(s, "?") {
+		return s
+	}
+	return s + "."
+}
+
+func displayType(t string) string {
+	return strings.ToLower(strings.TrimSpace(t))
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
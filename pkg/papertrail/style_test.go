@@ -0,0 +1,107 @@
+package papertrail
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChangelogStyle(t *testing.T) {
+	t.Parallel()
+
+	m, err := ParseManifest([]byte("changelog:\n  style:\n    bullet: \"*\"\n    type_label: plain\n    group_spacing: 2\n    heading_case: title\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fragments := []Fragment{
+		{Path: "a.yml", Component: "command line", Type: "fix", Summary: "Fix a"},
+		{Path: "b.yml", Component: "CLI tools", Type: "feature", Summary: "Add b"},
+	}
+	section := RenderRelease(NewReleaseSection("v1.0.0", "2026-01-01", fragments, m), m.Changelog.Style)
+	want := "## v1.0.0 (2026-01-01)\n\n" +
+		"### CLI Tools\n\n* feature: Add b.\n\n\n" +
+		"### Command Line\n\n* fix: Fix a.\n\n"
+	if string(section) != want {
+		t.Fatalf("got:\n%q\nwant:\n%q", section, want)
+	}
+
+	zero := 0
+	m.Changelog.Style = Style{TypeLabel: "none", HeadingCase: "upper", GroupSpacing: &zero}
+	section = RenderRelease(NewReleaseSection("v1.0.0", "", fragments, m), m.Changelog.Style)
+	want = "## v1.0.0\n\n### CLI TOOLS\n\n- Add b.\n### COMMAND LINE\n\n- Fix a.\n\n"
+	if string(section) != want {
+		t.Fatalf("got:\n%q\nwant:\n%q", section, want)
+	}
+}
+
+func TestChangelogStyle_TypeDisplay(t *testing.T) {
+	t.Parallel()
+
+	m, err := ParseManifest([]byte(`types:
+  aliases:
+    NEW FEATURE: feature
+  display:
+    NEW FEATURE: "✨ Feature"
+    BUGFIX: "🐛 Fix"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fragments := []Fragment{
+		{Path: "a.yml", Component: "CLI", Type: "FEATURE", Summary: "Add a"},
+		{Path: "b.yml", Component: "CLI", Type: "DOCS", Summary: "Document b"},
+	}
+	want := "## v1.0.0\n\n### CLI\n\n- **docs**: Document b.\n- **✨ Feature**: Add a.\n\n"
+	if got := string(RenderRelease(NewReleaseSection("v1.0.0", "", fragments, m), m.Changelog.Style)); got != want {
+		t.Fatalf("got:\n%q\nwant:\n%q", got, want)
+	}
+	for label, typ := range map[string]string{"✨ Feature": "FEATURE", "🐛 Fix": "BUGFIX", "docs": "DOCS", "new feature": "FEATURE"} {
+		if got := m.LabelType(label); got != typ {
+			t.Errorf("LabelType(%q) = %q, want %q", label, got, typ)
+		}
+	}
+}
+
+func TestChangelogStyle_Invalid(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct{ yaml, want string }{
+		{"bullet: +", "changelog.style.bullet"},
+		{"type_label: italic", "changelog.style.type_label"},
+		{"heading_case: camel", "changelog.style.heading_case"},
+		{"group_spacing: 9", "changelog.style.group_spacing"},
+	} {
+		_, err := ParseManifest([]byte("changelog:\n  style:\n    " + tc.yaml + "\n"))
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("%s: expected %s error, got %v", tc.yaml, tc.want, err)
+		}
+	}
+}
+
+func TestWrapListItem(t *testing.T) {
+	t.Parallel()
+
+	style := Style{Wrap: 40}
+	got := style.listItem(Fragment{Type: "fix", Summary: "Handle retries when the upstream returns - or 2. items; see https://example.com/a/very/long/link/that/does/not/fit"})
+	want := "- **fix**: Handle retries when the\n" +
+		"  upstream returns - or 2. items; see\n" +
+		"  https://example.com/a/very/long/link/that/does/not/fit."
+	if got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+	for _, line := range strings.Split(got, "\n")[:2] {
+		if len(line) > 40 {
+			t.Fatalf("line over the wrap width: %q", line)
+		}
+	}
+
+	// Words that would start a new markdown block stay on the previous line.
+	if got := wrapListItem("- aaaa - 1. # bbbb", 6, "  "); got != "- aaaa - 1. #\n  bbbb" {
+		t.Fatalf("block-starting words: %q", got)
+	}
+	if got := (Style{}).listItem(Fragment{Type: "fix", Summary: strings.Repeat("word ", 30)}); strings.Contains(got, "\n") {
+		t.Fatalf("wrapped without a wrap width: %q", got)
+	}
+	if _, err := ParseManifest([]byte("changelog:\n  style:\n    wrap: 10\n")); err == nil || !strings.Contains(err.Error(), "changelog.style.wrap") {
+		t.Fatalf("expected wrap error, got %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package papertrail
+
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SummaryRules constrain fragment summaries, declared under `changelog.summary` in the
+// manifest. The zero value allows any non-empty summary.
+type SummaryRules struct {
+	// MaxLength is the most characters a summary may have (0: no limit).
+	MaxLength int `yaml:"max_length"`
+	// MinLength is the fewest characters a summary may have (0: no limit).
+	MinLength int `yaml:"min_length"`
+	// RequireCapitalized rejects summaries starting with a lowercase letter.
+	RequireCapitalized bool `yaml:"require_capitalized"`
+}
+
+// summaryIssues returns a message per changelog.summary rule the summary breaks.
+func (r SummaryRules) summaryIssues(summary string) []string {
+	var msgs []string
+	n := utf8.RuneCountInString(summary)
+	if r.MaxLength > 0 && n > r.MaxLength {
+		msgs = append(msgs, fmt.Sprintf("summary is %d characters long (at most %d allowed)", n, r.MaxLength))
+	}
+	if r.MinLength > 0 && n < r.MinLength {
+		msgs = append(msgs, fmt.Sprintf("summary is %d characters long (at least %d required)", n, r.MinLength))
+	}
+	if first, _ := utf8.DecodeRuneInString(summary); r.RequireCapitalized && unicode.IsLower(first) {
+		msgs = append(msgs, "summary must start with a capital letter")
+	}
+	return msgs
+}
+
+func validateSummaryRules(r SummaryRules) error {
+	switch {
+	case r.MaxLength < 0:
+		return fmt.Errorf("invalid changelog.summary.max_length %d (must not be negative)", r.MaxLength)
+	case r.MinLength < 0:
+		return fmt.Errorf("invalid changelog.summary.min_length %d (must not be negative)", r.MinLength)
+	case r.MaxLength > 0 && r.MinLength > r.MaxLength:
+		return fmt.Errorf("changelog.summary.min_length %d is greater than max_length %d", r.MinLength, r.MaxLength)
+	}
+	return nil
+}
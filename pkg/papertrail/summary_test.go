@@ -0,0 +1,42 @@
+package papertrail
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateFragment_SummaryRules(t *testing.T) {
+	t.Parallel()
+
+	m, err := ParseManifest([]byte("changelog:\n  summary:\n    max_length: 30\n    min_length: 10\n    require_capitalized: true\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for summary, want := range map[string]string{
+		"Fix the crash on start.":               "",
+		"Ünïcode is counted in characters, ok.": "at most 30",
+		"Tidy.":                   "at least 10",
+		"fix the crash on start.": "capital letter",
+		"`go vet` passes on the repository again.": "at most 30",
+		"`go vet` passes again.":                   "",
+	} {
+		_, issues := ValidateFragment([]byte("component: CLI\ntype: fix\nsummary: '"+summary+"'\n"), m)
+		switch {
+		case want == "" && len(issues) > 0:
+			t.Fatalf("%q: unexpected issues %+v", summary, issues)
+		case want != "" && (len(issues) != 1 || issues[0].Rule != RuleSummaryFormat || issues[0].Field != "summary" || !strings.Contains(issues[0].Message, want)):
+			t.Fatalf("%q: issues %+v, want one %q", summary, issues, want)
+		}
+	}
+
+	m.Validation.Severity = map[string]string{RuleSummaryFormat: "warning"}
+	if _, err := ParseFragment([]byte("component: CLI\ntype: fix\nsummary: tidy\n"), m); err != nil {
+		t.Fatalf("warnings must not fail parsing: %v", err)
+	}
+
+	for _, bad := range []string{"max_length: -1", "min_length: 5\n    max_length: 4"} {
+		if _, err := ParseManifest([]byte("changelog:\n  summary:\n    " + bad + "\n")); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package papertrail
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// ReleaseTemplates are Go text/template files that replace the built-in markdown, declared
+// under `changelog.templates` in the manifest. Each is executed with a ReleaseSection.
+type ReleaseTemplates struct {
+	// Release renders CHANGELOG.md sections and, unless ReleaseNotes is set, release notes
+	// (with an empty Date).
+	Release string `yaml:"release"`
+	// ReleaseNotes renders release notes.
+	ReleaseNotes string `yaml:"release_notes"`
+	// Preview renders previews of pending fragments (with an empty Version and Date).
+	Preview string `yaml:"preview"`
+}
+
+// TemplateFuncs are the functions changelog templates can call besides text/template's
+// builtins: period ends a summary with a period as built-in entries do, type shows a type as
+// entries label it, and lower, upper, trim, and join are the strings functions.
+var TemplateFuncs = template.FuncMap{
+	"period": ensurePeriod,
+	"type":   displayType,
+	"lower":  strings.ToLower,
+	"upper":  strings.ToUpper,
+	"trim":   strings.TrimSpace,
+	"join":   func(sep string, xs []string) string { return strings.Join(xs, sep) },
+}
+
+// ParseReleaseTemplate parses a changelog template with TemplateFuncs. name is used in
+// error messages.
+func ParseReleaseTemplate(name, text string) (*template.Template, error) {
+	t, err := template.New(name).Option("missingkey=error").Funcs(TemplateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid changelog template %s: %w", name, err)
+	}
+	return t, nil
+}
+
+// RenderReleaseTemplate executes t with s. Like RenderRelease, non-empty output ends with
+// exactly one blank line, so sections insert cleanly whatever the template's trailing
+// whitespace.
+func RenderReleaseTemplate(t *template.Template, s ReleaseSection) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, s); err != nil {
+		return nil, fmt.Errorf("rendering changelog template %s: %w", t.Name(), err)
+	}
+	out := bytes.TrimRight(buf.Bytes(), " \t\r\n")
+	if len(out) == 0 {
+		return nil, nil
+	}
+	return append(out, '\n', '\n'), nil
+}
+
+// Fragments returns every fragment of the release, in output order.
+func (s ReleaseSection) Fragments() []Fragment {
+	var out []Fragment
+	for _, g := range s.Groups {
+		out = append(out, g.Fragments...)
+	}
+	return out
+}
@@ -0,0 +1,46 @@
+package papertrail
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderReleaseTemplate(t *testing.T) {
+	t.Parallel()
+
+	var m Manifest
+	s := NewReleaseSection("v1.2.0", "2026-01-02", []Fragment{
+		{Component: "CLI", Type: "FIX", Summary: "Fix a crash", Refs: []string{"#1", "#2"}},
+		{Component: "API", Type: "FEATURE", Summary: "Add an endpoint!"},
+	}, m)
+	tmpl, err := ParseReleaseTemplate("release.tmpl", `# {{ .Version }}{{ with .Date }} on {{ . }}{{ end }}
+{{ range .Groups }}
+{{ .Name | upper }}:
+{{- range .Fragments }}
+* [{{ type .Type }}] {{ period .Summary }}{{ with .Refs }} ({{ join ", " . }}){{ end }}
+{{- end }}
+{{ end }}
+{{ len .Fragments }} changes
+
+
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := RenderReleaseTemplate(tmpl, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# v1.2.0 on 2026-01-02\n\nAPI:\n* [feature] Add an endpoint!\n\nCLI:\n* [fix] Fix a crash. (#1, #2)\n\n2 changes\n\n"
+	if string(got) != want {
+		t.Fatalf("got:\n%q\nwant:\n%q", got, want)
+	}
+
+	if _, err := ParseReleaseTemplate("bad.tmpl", "{{ .Version "); err == nil || !strings.Contains(err.Error(), "bad.tmpl") {
+		t.Fatalf("expected a parse error naming the template, got %v", err)
+	}
+	tmpl, _ = ParseReleaseTemplate("missing.tmpl", "{{ .Nope }}")
+	if _, err := RenderReleaseTemplate(tmpl, s); err == nil || !strings.Contains(err.Error(), "missing.tmpl") {
+		t.Fatalf("expected an execution error, got %v", err)
+	}
+}
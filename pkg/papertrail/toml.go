@@ -0,0 +1,329 @@
+package papertrail
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// tomlFragmentsTable is the array-of-tables header of TOML files with several fragments.
+const tomlFragmentsTable = "fragments"
+
+// parseTOMLFragments parses the TOML subset fragment files need: key = value pairs whose
+// values are strings (all four kinds), booleans, integers, or arrays of those, plus
+// [[fragments]] tables for files with several fragments. It returns one key/value map per
+// fragment, in file order; a file without [[fragments]] is a single fragment.
+func parseTOMLFragments(b []byte) ([]map[string]any, error) {
+	p := &tomlParser{src: strings.ReplaceAll(string(b), "\r\n", "\n"), line: 1}
+	p.skip(tomlBOM)
+	top := map[string]any{}
+	var tables []map[string]any
+	cur := top
+	for {
+		p.skipBlankLines()
+		if p.eof() {
+			break
+		}
+		if p.peek() == '[' {
+			if !p.skip("[[" + tomlFragmentsTable + "]]") {
+				return nil, p.errorf("unsupported table (only [[%s]] is allowed)", tomlFragmentsTable)
+			}
+			if len(top) > 0 {
+				return nil, p.errorf("[[%s]] cannot follow top-level keys", tomlFragmentsTable)
+			}
+			cur = map[string]any{}
+			tables = append(tables, cur)
+		} else {
+			key, err := p.key()
+			if err != nil {
+				return nil, err
+			}
+			p.skipSpaces()
+			if !p.skip("=") {
+				return nil, p.errorf("expected = after key %q", key)
+			}
+			p.skipSpaces()
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			if _, dup := cur[key]; dup {
+				return nil, p.errorf("duplicate key %q", key)
+			}
+			cur[key] = v
+		}
+		if err := p.endOfLine(); err != nil {
+			return nil, err
+		}
+	}
+	if tables != nil {
+		return tables, nil
+	}
+	if len(top) == 0 {
+		return nil, nil
+	}
+	return []map[string]any{top}, nil
+}
+
+const tomlBOM = "\ufeff"
+
+type tomlParser struct {
+	src  string
+	pos  int
+	line int
+}
+
+func (p *tomlParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+func (p *tomlParser) eof() bool { return p.pos >= len(p.src) }
+
+func (p *tomlParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+// skip consumes s if the input continues with it.
+func (p *tomlParser) skip(s string) bool {
+	if !strings.HasPrefix(p.src[p.pos:], s) {
+		return false
+	}
+	p.line += strings.Count(s, "\n")
+	p.pos += len(s)
+	return true
+}
+
+func (p *tomlParser) skipSpaces() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+func (p *tomlParser) skipComment() {
+	if p.peek() == '#' {
+		for !p.eof() && p.peek() != '\n' {
+			p.pos++
+		}
+	}
+}
+
+// skipBlankLines skips whitespace, comments, and newlines.
+func (p *tomlParser) skipBlankLines() {
+	for {
+		p.skipSpaces()
+		p.skipComment()
+		if !p.skip("\n") {
+			return
+		}
+	}
+}
+
+// endOfLine requires the rest of the line to be blank or a comment.
+func (p *tomlParser) endOfLine() error {
+	p.skipSpaces()
+	p.skipComment()
+	if !p.eof() && !p.skip("\n") {
+		return p.errorf("unexpected %q after value", p.peek())
+	}
+	return nil
+}
+
+func (p *tomlParser) key() (string, error) {
+	switch p.peek() {
+	case '"':
+		return p.basicString()
+	case '\'':
+		return p.literalString()
+	}
+	start := p.pos
+	for !p.eof() && isBareKeyChar(p.peek()) {
+		p.pos++
+	}
+	if p.pos == start {
+		return "", p.errorf("expected a key")
+	}
+	if p.peek() == '.' {
+		return "", p.errorf("dotted keys are not supported")
+	}
+	return p.src[start:p.pos], nil
+}
+
+func isBareKeyChar(c byte) bool {
+	return c == '_' || c == '-' || isDigit(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func (p *tomlParser) value() (any, error) {
+	switch {
+	case p.skip(`"""`):
+		return p.multilineString(`"""`, true)
+	case p.skip(`'''`):
+		return p.multilineString(`'''`, false)
+	case p.peek() == '"':
+		return p.basicString()
+	case p.peek() == '\'':
+		return p.literalString()
+	case p.peek() == '[':
+		return p.array()
+	case p.skip("true"):
+		return true, nil
+	case p.skip("false"):
+		return false, nil
+	}
+	start := p.pos
+	for !p.eof() && (isDigit(p.peek()) || strings.IndexByte("+-_", p.peek()) >= 0) {
+		p.pos++
+	}
+	if p.pos == start {
+		return nil, p.errorf("unsupported value (expected a string, boolean, integer, or array)")
+	}
+	n, err := strconv.ParseInt(strings.ReplaceAll(p.src[start:p.pos], "_", ""), 10, 64)
+	if err != nil {
+		return nil, p.errorf("invalid integer %q", p.src[start:p.pos])
+	}
+	return n, nil
+}
+
+func (p *tomlParser) array() ([]any, error) {
+	p.pos++ // [
+	out := []any{}
+	for {
+		p.skipBlankLines()
+		if p.skip("]") {
+			return out, nil
+		}
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+		p.skipBlankLines()
+		if p.skip("]") {
+			return out, nil
+		}
+		if !p.skip(",") {
+			return nil, p.errorf("expected , or ] in array")
+		}
+	}
+}
+
+func (p *tomlParser) literalString() (string, error) {
+	p.pos++ // '
+	end := strings.IndexAny(p.src[p.pos:], "'\n")
+	if end < 0 || p.src[p.pos+end] != '\'' {
+		return "", p.errorf("unterminated string")
+	}
+	s := p.src[p.pos : p.pos+end]
+	p.pos += end + 1
+	return s, nil
+}
+
+func (p *tomlParser) basicString() (string, error) {
+	p.pos++ // "
+	var b strings.Builder
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", p.errorf("unterminated string")
+		}
+		c := p.peek()
+		switch c {
+		case '"':
+			p.pos++
+			return b.String(), nil
+		case '\\':
+			if err := p.escape(&b); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+// multilineString reads a multi-line string after its opening triple-quote delimiter. A
+// newline right after the delimiter is trimmed; in basic strings, a backslash at the end of
+// a line joins it with the next non-blank text.
+func (p *tomlParser) multilineString(delim string, basic bool) (string, error) {
+	p.skip("\n")
+	var b strings.Builder
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated multi-line string")
+		}
+		if p.skip(delim) {
+			// Up to two quotes right before the closing delimiter belong to the string.
+			for i := 0; i < 2 && p.skip(delim[:1]); i++ {
+				b.WriteByte(delim[0])
+			}
+			return b.String(), nil
+		}
+		c := p.peek()
+		switch {
+		case basic && c == '\\':
+			rest := strings.TrimLeft(p.src[p.pos+1:], " \t")
+			if strings.HasPrefix(rest, "\n") {
+				p.pos++
+				for !p.eof() && strings.IndexByte(" \t\n", p.peek()) >= 0 {
+					if p.peek() == '\n' {
+						p.line++
+					}
+					p.pos++
+				}
+				continue
+			}
+			if err := p.escape(&b); err != nil {
+				return "", err
+			}
+		default:
+			if c == '\n' {
+				p.line++
+			}
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+func (p *tomlParser) escape(b *strings.Builder) error {
+	p.pos++ // backslash
+	if p.eof() {
+		return p.errorf("unterminated escape")
+	}
+	c := p.peek()
+	p.pos++
+	switch c {
+	case 'b':
+		b.WriteByte('\b')
+	case 't':
+		b.WriteByte('\t')
+	case 'n':
+		b.WriteByte('\n')
+	case 'f':
+		b.WriteByte('\f')
+	case 'r':
+		b.WriteByte('\r')
+	case '"', '\\':
+		b.WriteByte(c)
+	case 'u', 'U':
+		n := 4
+		if c == 'U' {
+			n = 8
+		}
+		if p.pos+n > len(p.src) {
+			return p.errorf("invalid unicode escape")
+		}
+		r, err := strconv.ParseUint(p.src[p.pos:p.pos+n], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(r)) {
+			return p.errorf("invalid unicode escape %q", p.src[p.pos:p.pos+n])
+		}
+		b.WriteRune(rune(r))
+		p.pos += n
+	default:
+		return p.errorf("invalid escape \\%c", c)
+	}
+	return nil
+}
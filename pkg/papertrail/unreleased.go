@@ -0,0 +1,91 @@
+package papertrail
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Markers around the block of pending changes that `unreleased` keeps in the changelog.
+const (
+	UnreleasedStart = "<!-- papertrail: unreleased start -->"
+	UnreleasedEnd   = "<!-- papertrail: unreleased end -->"
+)
+
+// RenderUnreleased renders the unreleased block for the pending fragments' groups: the
+// markers around an Unreleased heading ("## Unreleased", or UnreleasedHeading in the
+// keepachangelog profile) and the groups (see RenderGroups). The output ends with one blank
+// line.
+func RenderUnreleased(groups []ComponentGroup, style Style) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(UnreleasedStart + "\n")
+	buf.WriteString(unreleasedTitle(style) + "\n\n")
+	buf.Write(RenderGroups(groups, "###", style))
+	buf.WriteString(UnreleasedEnd + "\n\n")
+	return buf.Bytes()
+}
+
+func unreleasedTitle(style Style) string {
+	if style.KeepAChangelog() {
+		return UnreleasedHeading
+	}
+	return "## Unreleased"
+}
+
+// SetUnreleased puts block (see RenderUnreleased) into the changelog: over the existing
+// unreleased block, else over the keepachangelog profile's Unreleased section, else where
+// the next release section would go (see InsertSection). An empty block removes the
+// existing one; the keepachangelog profile keeps a bare Unreleased heading in its place.
+func SetUnreleased(changelog, block []byte, style Style) []byte {
+	s := string(changelog)
+	if len(block) == 0 {
+		if style.KeepAChangelog() {
+			block = []byte(UnreleasedHeading + "\n\n")
+		}
+		out, _ := ReplaceUnreleased(changelog, block)
+		return out
+	}
+	if out, ok := ReplaceUnreleased(changelog, block); ok {
+		return out
+	}
+	if u := unreleasedIndex(s); style.KeepAChangelog() && u >= 0 {
+		end := linkReferencesIndex(s)
+		bodyStart := u + strings.IndexByte(s[u:], '\n') + 1
+		if bodyStart <= u {
+			bodyStart = len(s)
+		}
+		if i := strings.Index(s[bodyStart:], "\n## "); i >= 0 && bodyStart+i+1 < end {
+			end = bodyStart + i + 1
+		}
+		return []byte(s[:u] + string(block) + s[end:])
+	}
+	idx := releaseInsertionIndex(s)
+	if style.KeepAChangelog() {
+		idx = min(idx, linkReferencesIndex(s))
+	}
+	return []byte(insertAt(s, idx, string(block)))
+}
+
+// ReplaceUnreleased replaces the unreleased block, through the blank lines after its end
+// marker, with section; `merge` puts the release there. ok is false when the changelog has
+// no unreleased block.
+func ReplaceUnreleased(changelog, section []byte) (out []byte, ok bool) {
+	s := string(changelog)
+	start := strings.Index(s, UnreleasedStart)
+	if start < 0 {
+		return changelog, false
+	}
+	rel := strings.Index(s[start:], UnreleasedEnd)
+	if rel < 0 {
+		return changelog, false
+	}
+	end := start + rel + len(UnreleasedEnd)
+	if i := strings.IndexByte(s[end:], '\n'); i >= 0 {
+		end += i + 1
+	} else {
+		end = len(s)
+	}
+	for end < len(s) && (s[end] == '\n' || s[end] == '\r') {
+		end++
+	}
+	return []byte(s[:start] + string(section) + s[end:]), true
+}
@@ -0,0 +1,51 @@
+package papertrail
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSetUnreleased(t *testing.T) {
+	t.Parallel()
+
+	groups := []ComponentGroup{{Name: "CLI", Fragments: []Fragment{{Component: "CLI", Type: "FIX", Summary: "Fix a"}}}}
+	block := RenderUnreleased(groups, Style{})
+	const doc = "# Changelog\n\n## v1.0.0 (2026-01-01)\n\n### CLI\n\n- **fix**: Fix z.\n"
+
+	got := string(SetUnreleased([]byte(doc), block, Style{}))
+	want := "# Changelog\n\n" + UnreleasedStart + "\n## Unreleased\n\n### CLI\n\n- **fix**: Fix a.\n\n" + UnreleasedEnd + "\n\n## v1.0.0 (2026-01-01)\n\n### CLI\n\n- **fix**: Fix z.\n"
+	if got != want {
+		t.Fatalf("SetUnreleased:\n%s\nwant:\n%s", got, want)
+	}
+	if again := string(SetUnreleased([]byte(got), block, Style{})); again != got {
+		t.Fatalf("not idempotent:\n%s", again)
+	}
+	if removed := string(SetUnreleased([]byte(got), nil, Style{})); removed != doc {
+		t.Fatalf("removing the block:\n%s", removed)
+	}
+
+	section := RenderRelease(ReleaseSection{Version: "v1.1.0", Date: "2026-02-01", Groups: groups}, Style{})
+	released, ok := ReplaceUnreleased([]byte(got), section)
+	if !ok || !strings.HasPrefix(string(released), "# Changelog\n\n## v1.1.0 (2026-02-01)\n\n### CLI\n\n- **fix**: Fix a.\n\n## v1.0.0") {
+		t.Fatalf("ReplaceUnreleased (ok %v):\n%s", ok, released)
+	}
+	if _, ok := ReplaceUnreleased([]byte(doc), section); ok {
+		t.Fatal("expected no block to replace")
+	}
+}
+
+func TestSetUnreleased_KeepAChangelog(t *testing.T) {
+	t.Parallel()
+
+	style := Style{Profile: ProfileKeepAChangelog}
+	groups := []ComponentGroup{{Name: "CLI", Fragments: []Fragment{{Component: "CLI", Type: "FIX", Summary: "Fix a"}}}}
+	const doc = "# Changelog\n\n## [Unreleased]\n\n## [v1.0.0] - 2026-01-01\n\n### Fixed\n\n- Fix z.\n\n[unreleased]: https://example.com/compare/v1.0.0...HEAD\n"
+
+	got := string(SetUnreleased([]byte(doc), RenderUnreleased(groups, style), style))
+	if strings.Count(got, UnreleasedHeading) != 1 || !strings.Contains(got, UnreleasedStart+"\n"+UnreleasedHeading+"\n\n### Fixed\n\n- Fix a.\n\n"+UnreleasedEnd+"\n\n## [v1.0.0]") {
+		t.Fatalf("SetUnreleased:\n%s", got)
+	}
+	if removed := string(SetUnreleased([]byte(got), nil, style)); removed != doc {
+		t.Fatalf("removing the block:\n%s", removed)
+	}
+}
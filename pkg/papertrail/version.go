@@ -0,0 +1,230 @@
+package papertrail
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Version is a Semantic Versioning 2.0.0 version. Papertrail always writes versions with a
+// leading "v" (v1.2.3-rc.1+build.5), and ParseVersion requires it.
+type Version struct {
+	Major, Minor, Patch uint64
+	Prerelease          []string
+	Build               []string
+}
+
+// ParseVersion parses a "v"-prefixed SemVer 2.0.0 version. Numeric components and numeric
+// prerelease identifiers must not have leading zeros; identifiers are [0-9A-Za-z-]+.
+func ParseVersion(s string) (Version, error) {
+	rest, ok := strings.CutPrefix(s, "v")
+	if !ok {
+		return Version{}, errors.New(`missing leading "v"`)
+	}
+	var v Version
+	if core, build, ok := strings.Cut(rest, "+"); ok {
+		ids, err := semverIdentifiers(build, "build metadata", false)
+		if err != nil {
+			return Version{}, err
+		}
+		v.Build = ids
+		rest = core
+	}
+	if core, pre, ok := strings.Cut(rest, "-"); ok {
+		ids, err := semverIdentifiers(pre, "prerelease", true)
+		if err != nil {
+			return Version{}, err
+		}
+		v.Prerelease = ids
+		rest = core
+	}
+
+	parts := strings.Split(rest, ".")
+	if len(parts) != 3 {
+		return Version{}, errors.New("expected MAJOR.MINOR.PATCH")
+	}
+	nums := []*uint64{&v.Major, &v.Minor, &v.Patch}
+	for i, name := range []string{"major", "minor", "patch"} {
+		n, err := semverNumber(parts[i], name)
+		if err != nil {
+			return Version{}, err
+		}
+		*nums[i] = n
+	}
+	return v, nil
+}
+
+func semverNumber(s, name string) (uint64, error) {
+	if s == "" {
+		return 0, fmt.Errorf("empty %s version", name)
+	}
+	if len(s) > 1 && s[0] == '0' {
+		return 0, fmt.Errorf("%s version %q has a leading zero", name, s)
+	}
+	var n uint64
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return 0, fmt.Errorf("%s version %q is not a number", name, s)
+		}
+		d := uint64(r - '0')
+		if n > (^uint64(0)-d)/10 {
+			return 0, fmt.Errorf("%s version %q is too large", name, s)
+		}
+		n = n*10 + d
+	}
+	return n, nil
+}
+
+func semverIdentifiers(s, what string, noLeadingZeros bool) ([]string, error) {
+	ids := strings.Split(s, ".")
+	for _, id := range ids {
+		if id == "" {
+			return nil, fmt.Errorf("empty %s identifier", what)
+		}
+		for _, r := range id {
+			if !(r == '-' || (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')) {
+				return nil, fmt.Errorf("invalid character %q in %s identifier %q", r, what, id)
+			}
+		}
+		if noLeadingZeros && isNumericIdentifier(id) && len(id) > 1 && id[0] == '0' {
+			return nil, fmt.Errorf("numeric %s identifier %q has a leading zero", what, id)
+		}
+	}
+	return ids, nil
+}
+
+func isNumericIdentifier(id string) bool {
+	for _, r := range id {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return id != ""
+}
+
+func (v Version) String() string {
+	s := fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.Prerelease) > 0 {
+		s += "-" + strings.Join(v.Prerelease, ".")
+	}
+	if len(v.Build) > 0 {
+		s += "+" + strings.Join(v.Build, ".")
+	}
+	return s
+}
+
+// Compare orders versions by SemVer precedence, returning -1, 0, or 1. Build metadata is
+// ignored, so versions differing only in build compare equal.
+func (v Version) Compare(o Version) int {
+	for _, p := range [][2]uint64{{v.Major, o.Major}, {v.Minor, o.Minor}, {v.Patch, o.Patch}} {
+		if p[0] != p[1] {
+			if p[0] < p[1] {
+				return -1
+			}
+			return 1
+		}
+	}
+	// A version without prerelease identifiers has higher precedence than one with them.
+	switch {
+	case len(v.Prerelease) == 0 && len(o.Prerelease) == 0:
+		return 0
+	case len(v.Prerelease) == 0:
+		return 1
+	case len(o.Prerelease) == 0:
+		return -1
+	}
+	for i := 0; i < len(v.Prerelease) && i < len(o.Prerelease); i++ {
+		if c := compareIdentifiers(v.Prerelease[i], o.Prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(v.Prerelease) < len(o.Prerelease):
+		return -1
+	case len(v.Prerelease) > len(o.Prerelease):
+		return 1
+	}
+	return 0
+}
+
+// compareIdentifiers compares prerelease identifiers: numeric ones numerically, numeric
+// lower than alphanumeric, and alphanumeric ones in ASCII order.
+func compareIdentifiers(a, b string) int {
+	an, bn := isNumericIdentifier(a), isNumericIdentifier(b)
+	switch {
+	case an && bn:
+		// No leading zeros, so a longer number is larger; this avoids overflow.
+		if len(a) != len(b) {
+			if len(a) < len(b) {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a, b)
+	case an:
+		return -1
+	case bn:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// Bump returns the next release of kind. Prerelease and build identifiers are dropped; a
+// prerelease whose release already carries the bump is promoted to that release
+// (v1.3.0-rc.1 bumped minor is v1.3.0), so the result always has higher precedence.
+func (v Version) Bump(kind Bump) Version {
+	next := Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+	pre := len(v.Prerelease) > 0
+	switch kind {
+	case Major:
+		if !pre || v.Minor != 0 || v.Patch != 0 {
+			next.Major++
+		}
+		next.Minor, next.Patch = 0, 0
+	case Minor:
+		if !pre || v.Patch != 0 {
+			next.Minor++
+		}
+		next.Patch = 0
+	default:
+		if !pre {
+			next.Patch++
+		}
+	}
+	return next
+}
+
+// BumpPrerelease returns the next prerelease of kind with identifier id (e.g. "rc"): the
+// next number in the same series when v is already a prerelease of that release
+// (v1.3.0-rc.1 is followed by v1.3.0-rc.2), else the first one (v1.2.3 bumped minor is
+// v1.3.0-rc.1). It fails when the result would not have higher precedence than v, as when
+// switching from rc back to beta.
+func (v Version) BumpPrerelease(kind Bump, id string) (Version, error) {
+	if _, err := semverIdentifiers(id, "prerelease", true); err != nil {
+		return Version{}, fmt.Errorf("invalid prerelease identifier %q: %w", id, err)
+	}
+	if strings.Contains(id, ".") || isNumericIdentifier(id) {
+		return Version{}, fmt.Errorf("invalid prerelease identifier %q (expected a single non-numeric identifier such as rc)", id)
+	}
+	next := v.Bump(kind)
+	next.Prerelease = []string{id, "1"}
+	core := Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+	if len(v.Prerelease) == 2 && v.Prerelease[0] == id && isNumericIdentifier(v.Prerelease[1]) && next.Compare(core) < 0 {
+		n, err := semverNumber(v.Prerelease[1], "prerelease")
+		if err != nil {
+			return Version{}, err
+		}
+		next = core
+		next.Prerelease = []string{id, fmt.Sprint(n + 1)}
+	}
+	if next.Compare(v) <= 0 {
+		return Version{}, fmt.Errorf("prerelease %s would not be newer than %s", next, v)
+	}
+	return next, nil
+}
+
+// ParseBuildMetadata parses SemVer build metadata (the part after "+", e.g. "nightly.45")
+// into its dot-separated identifiers.
+func ParseBuildMetadata(s string) ([]string, error) {
+	return semverIdentifiers(s, "build metadata", false)
+}
@@ -0,0 +1,99 @@
+package papertrail
+
+import (
+	"strings"
+	"testing"
+)
+
+func mustVersion(t *testing.T, s string) Version {
+	t.Helper()
+	v, err := ParseVersion(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestParseVersion(t *testing.T) {
+	t.Parallel()
+
+	for _, s := range []string{"v0.0.0", "v1.2.3", "v1.0.0-alpha.1", "v1.0.0-0.3.7", "v1.0.0-x-y.z.--", "v1.0.0+20130313144700", "v1.0.0-beta+exp.sha.5114f85"} {
+		v, err := ParseVersion(s)
+		if err != nil {
+			t.Fatalf("%s: %v", s, err)
+		}
+		if v.String() != s {
+			t.Fatalf("round trip: got %s, want %s", v, s)
+		}
+	}
+	for _, s := range []string{"1.2.3", "v1.2", "v1.2.3.4", "v01.2.3", "v1.02.3", "v1.2.03", "v1.2.3-", "v1.2.3-01", "v1.2.3-a..b", "v1.2.3+", "v1.2.3-a_b", "v1.x.3", "v99999999999999999999.0.0"} {
+		if _, err := ParseVersion(s); err == nil {
+			t.Fatalf("%s: expected error", s)
+		}
+	}
+	// Leading zeros are allowed in build metadata.
+	if _, err := ParseVersion("v1.2.3+001"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	t.Parallel()
+
+	// Precedence example from the SemVer 2.0.0 specification, in ascending order.
+	ordered := []string{
+		"v1.0.0-alpha", "v1.0.0-alpha.1", "v1.0.0-alpha.beta", "v1.0.0-beta", "v1.0.0-beta.2",
+		"v1.0.0-beta.11", "v1.0.0-rc.1", "v1.0.0", "v1.0.1", "v1.1.0", "v2.0.0",
+	}
+	for i := 1; i < len(ordered); i++ {
+		a, _ := ParseVersion(ordered[i-1])
+		b, _ := ParseVersion(ordered[i])
+		if a.Compare(b) != -1 || b.Compare(a) != 1 {
+			t.Fatalf("expected %s < %s", a, b)
+		}
+	}
+	a, _ := ParseVersion("v1.0.0+a")
+	b, _ := ParseVersion("v1.0.0+b")
+	if a.Compare(b) != 0 {
+		t.Fatalf("build metadata must not affect precedence")
+	}
+}
+
+func TestVersionBumpPrerelease(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		base string
+		kind Bump
+		id   string
+		want string
+	}{
+		{"v1.2.3", Minor, "rc", "v1.3.0-rc.1"},
+		{"v1.3.0-rc.1", Minor, "rc", "v1.3.0-rc.2"},
+		{"v1.3.0-rc.9", Patch, "rc", "v1.3.0-rc.10"},
+		{"v1.3.0-rc.2", Major, "rc", "v2.0.0-rc.1"},
+		{"v1.3.0-beta.3", Minor, "rc", "v1.3.0-rc.1"},
+		{"v1.3.0-rc", Minor, "rc", "v1.3.0-rc.1"},
+		{"v1.2.3+build.7", Patch, "rc", "v1.2.4-rc.1"},
+	}
+	for _, c := range cases {
+		got, err := mustVersion(t, c.base).BumpPrerelease(c.kind, c.id)
+		if err != nil {
+			t.Fatalf("%s: %v", c.base, err)
+		}
+		if got.String() != c.want {
+			t.Fatalf("bump %s --prerelease %s: got %s, want %s", c.base, c.id, got, c.want)
+		}
+	}
+
+	for _, c := range []struct{ base, id, want string }{
+		{"v1.3.0-rc.2", "beta", "would not be newer"},
+		{"v1.2.3", "rc.1", "single non-numeric identifier"},
+		{"v1.2.3", "7", "single non-numeric identifier"},
+		{"v1.2.3", "r_c", "invalid character"},
+	} {
+		if _, err := mustVersion(t, c.base).BumpPrerelease(Minor, c.id); err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Fatalf("%s --prerelease %s: expected %q error, got %v", c.base, c.id, c.want, err)
+		}
+	}
+}